@@ -0,0 +1,168 @@
+// Package client provides a Go client for a catalog server that keeps the
+// server-side session across calls.
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"connectrpc.com/connect"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"github.com/opentdf/connectrpc-catalog/gen/catalog/v1/catalogv1connect"
+)
+
+// SessionHeader carries the session ID between the client and the server
+const SessionHeader = "X-Session-ID"
+
+// Catalog is a catalog server client. Loaded protos live in a server-side
+// session; Catalog sends the session ID the server assigned with every
+// later call so they all see the same catalog.
+type Catalog struct {
+	service catalogv1connect.CatalogServiceClient
+
+	// Guards sessionID
+	mu        sync.Mutex
+	sessionID string
+}
+
+// New creates a client for the catalog server at baseURL (e.g.,
+// "http://localhost:8080"). A nil httpClient uses http.DefaultClient.
+func New(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) *Catalog {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	c := &Catalog{}
+	opts = append(opts, connect.WithInterceptors(&sessionInterceptor{catalog: c}))
+	c.service = catalogv1connect.NewCatalogServiceClient(httpClient, baseURL, opts...)
+	return c
+}
+
+// Service returns the underlying generated client for RPCs without a
+// convenience method. Calls made with it share the session too.
+func (c *Catalog) Service() catalogv1connect.CatalogServiceClient {
+	return c.service
+}
+
+// SessionID returns the current session ID, or "" before the first call
+func (c *Catalog) SessionID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessionID
+}
+
+// SetSessionID resumes an existing session, e.g. one shared with the UI
+func (c *Catalog) SetSessionID(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessionID = sessionID
+}
+
+// sessionInterceptor sends the current session ID with each request and
+// records the one the server returns, which changes when a session expires
+type sessionInterceptor struct {
+	catalog *Catalog
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *sessionInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		i.sendSession(req.Header())
+		resp, err := next(ctx, req)
+		if err == nil {
+			i.recordSession(resp.Header())
+		}
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor. The server's session
+// ID is recorded from the response header once the first message arrives.
+func (i *sessionInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		i.sendSession(conn.RequestHeader())
+		return &sessionStreamingClientConn{StreamingClientConn: conn, interceptor: i}
+	}
+}
+
+// WrapStreamingHandler implements connect.Interceptor; handlers are not
+// wrapped
+func (i *sessionInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// sendSession sets the session header unless the caller already set one
+func (i *sessionInterceptor) sendSession(header http.Header) {
+	if sessionID := i.catalog.SessionID(); sessionID != "" && header.Get(SessionHeader) == "" {
+		header.Set(SessionHeader, sessionID)
+	}
+}
+
+// recordSession keeps the session ID from a response header, if any
+func (i *sessionInterceptor) recordSession(header http.Header) {
+	if sessionID := header.Get(SessionHeader); sessionID != "" {
+		i.catalog.SetSessionID(sessionID)
+	}
+}
+
+// sessionStreamingClientConn records the session ID of a streaming response
+type sessionStreamingClientConn struct {
+	connect.StreamingClientConn
+	interceptor *sessionInterceptor
+	recorded    sync.Once
+}
+
+// Receive implements connect.StreamingClientConn
+func (c *sessionStreamingClientConn) Receive(msg any) error {
+	err := c.StreamingClientConn.Receive(msg)
+	if err == nil {
+		c.recorded.Do(func() {
+			c.interceptor.recordSession(c.ResponseHeader())
+		})
+	}
+	return err
+}
+
+// LoadPath loads the protos under a path on the server's filesystem into
+// the session. A failed load returns the response along with an error
+// carrying its message.
+func (c *Catalog) LoadPath(ctx context.Context, path string) (*catalogv1.LoadProtosResponse, error) {
+	return c.Load(ctx, &catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_ProtoPath{ProtoPath: path},
+	})
+}
+
+// Load loads protos from any source into the session. A failed load returns
+// the response along with an error carrying its message.
+func (c *Catalog) Load(ctx context.Context, req *catalogv1.LoadProtosRequest) (*catalogv1.LoadProtosResponse, error) {
+	resp, err := c.service.LoadProtos(ctx, connect.NewRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Msg.Success {
+		return resp.Msg, errors.New(resp.Msg.Error)
+	}
+	return resp.Msg, nil
+}
+
+// ListServices returns the services loaded in the session
+func (c *Catalog) ListServices(ctx context.Context) ([]*catalogv1.ServiceInfo, error) {
+	resp, err := c.service.ListServices(ctx, connect.NewRequest(&catalogv1.ListServicesRequest{}))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg.Services, nil
+}
+
+// Invoke calls a method of a loaded service. The error is for requests the
+// catalog rejects; a call that reaches the target and fails is reported in
+// the response, with its status and error kind.
+func (c *Catalog) Invoke(ctx context.Context, req *catalogv1.InvokeGRPCRequest) (*catalogv1.InvokeGRPCResponse, error) {
+	resp, err := c.service.InvokeGRPC(ctx, connect.NewRequest(req))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg, nil
+}
@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"github.com/opentdf/connectrpc-catalog/gen/catalog/v1/catalogv1connect"
+	"github.com/opentdf/connectrpc-catalog/internal/server"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// newTestServer starts an in-process catalog server and returns its URL
+func newTestServer(t *testing.T) string {
+	t.Helper()
+
+	catalogServer := server.New()
+	t.Cleanup(func() { catalogServer.Close() })
+
+	mux := http.NewServeMux()
+	mux.Handle(catalogv1connect.NewCatalogServiceHandler(catalogServer))
+	testServer := httptest.NewServer(mux)
+	t.Cleanup(testServer.Close)
+
+	return testServer.URL
+}
+
+// testDescriptorSet returns a serialized descriptor set with one unary method
+func testDescriptorSet(t *testing.T) []byte {
+	t.Helper()
+
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("test/v1/test.proto"),
+		Package: proto.String("test.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("TestRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:     proto.String("name"),
+					JsonName: proto.String("name"),
+					Number:   proto.Int32(1),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				}},
+			},
+			{Name: proto.String("TestResponse")},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("TestService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("TestMethod"),
+				InputType:  proto.String(".test.v1.TestRequest"),
+				OutputType: proto.String(".test.v1.TestResponse"),
+			}},
+		}},
+	}}}
+	data, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+	return data
+}
+
+// TestCatalog_Session tests that loaded services stay visible to later calls
+func TestCatalog_Session(t *testing.T) {
+	ctx := context.Background()
+	baseURL := newTestServer(t)
+	catalog := New(nil, baseURL)
+
+	if catalog.SessionID() != "" {
+		t.Fatal("Expected no session before the first call")
+	}
+
+	resp, err := catalog.Load(ctx, &catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_DescriptorSet{DescriptorSet: testDescriptorSet(t)},
+	})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if resp.ServiceCount != 1 {
+		t.Errorf("Expected 1 service, got %d", resp.ServiceCount)
+	}
+	sessionID := catalog.SessionID()
+	if sessionID == "" {
+		t.Fatal("Expected the server to assign a session")
+	}
+
+	services, err := catalog.ListServices(ctx)
+	if err != nil {
+		t.Fatalf("ListServices failed: %v", err)
+	}
+	if len(services) != 1 || services[0].Name != "test.v1.TestService" {
+		t.Errorf("Expected the loaded service in the same session, got %v", services)
+	}
+	if catalog.SessionID() != sessionID {
+		t.Errorf("Expected session %q to be kept, got %q", sessionID, catalog.SessionID())
+	}
+
+	invokeResp, err := catalog.Invoke(ctx, &catalogv1.InvokeGRPCRequest{
+		Endpoint:    "localhost:1",
+		Service:     "test.v1.TestService",
+		Method:      "TestMethod",
+		RequestJson: `{"name":"catalog"}`,
+		DryRun:      true,
+	})
+	if err != nil {
+		t.Fatalf("Invoke failed: %v", err)
+	}
+	if invokeResp.DryRunRequest == nil {
+		t.Error("Expected a dry run description")
+	}
+
+	// A separate client starts its own session, unless it resumes this one
+	other := New(nil, baseURL)
+	if services, err := other.ListServices(ctx); err != nil || len(services) != 0 {
+		t.Errorf("Expected a new session to be empty, got %v, %v", services, err)
+	}
+	other.SetSessionID(sessionID)
+	if services, err := other.ListServices(ctx); err != nil || len(services) != 1 {
+		t.Errorf("Expected the resumed session to list the service, got %v, %v", services, err)
+	}
+}
+
+// TestCatalog_LoadPathError tests that a failed load is returned as an error
+func TestCatalog_LoadPathError(t *testing.T) {
+	catalog := New(nil, newTestServer(t))
+
+	resp, err := catalog.LoadPath(context.Background(), t.TempDir()+"/missing")
+	if err == nil {
+		t.Fatal("Expected an error for a missing path")
+	}
+	if resp == nil || resp.Success || resp.Error != err.Error() {
+		t.Errorf("Expected the failed response with the error, got %v", resp)
+	}
+}
+
+// TestCatalog_StreamSession tests that streaming calls send and record the
+// session like unary ones
+func TestCatalog_StreamSession(t *testing.T) {
+	ctx := context.Background()
+	baseURL := newTestServer(t)
+	catalog := New(nil, baseURL)
+
+	stream, err := catalog.Service().LoadProtosStream(ctx, connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_DescriptorSet{DescriptorSet: testDescriptorSet(t)},
+	}))
+	if err != nil {
+		t.Fatalf("LoadProtosStream failed: %v", err)
+	}
+	var result *catalogv1.LoadProtosResponse
+	for stream.Receive() {
+		if msg := stream.Msg(); msg.Result != nil {
+			result = msg.Result
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if result == nil || !result.Success {
+		t.Fatalf("Expected a successful load result, got %v", result)
+	}
+
+	sessionID := catalog.SessionID()
+	if sessionID == "" {
+		t.Fatal("Expected the session to be recorded from the stream")
+	}
+	services, err := catalog.ListServices(ctx)
+	if err != nil {
+		t.Fatalf("ListServices failed: %v", err)
+	}
+	if len(services) != 1 {
+		t.Errorf("Expected the streamed load in the same session, got %v", services)
+	}
+
+	// A later stream reuses the session rather than starting a new one
+	stream, err = catalog.Service().LoadProtosStream(ctx, connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_DescriptorSet{DescriptorSet: testDescriptorSet(t)},
+	}))
+	if err != nil {
+		t.Fatalf("LoadProtosStream failed: %v", err)
+	}
+	for stream.Receive() {
+	}
+	stream.Close()
+	if got := stream.ResponseHeader().Get(SessionHeader); got != sessionID {
+		t.Errorf("Expected the stream to continue session %q, got %q", sessionID, got)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/connect"
+	catalogv1connect "github.com/opentdf/connectrpc-catalog/gen/catalog/v1/catalogv1connect"
+)
+
+const (
+	// csrfCookieName holds the token issued with the UI
+	csrfCookieName = "catalog_csrf"
+	// csrfHeader carries the token on state-changing RPCs. It is left out of
+	// the CORS allowed headers, so cross-origin pages can't send it.
+	csrfHeader = "X-CSRF-Token"
+	// csrfMetaName names the meta tag the UI reads the token from
+	csrfMetaName = "csrf-token"
+)
+
+// csrfProtectedProcedures are the RPCs that load into or change a session, or
+// make the server call out, and so require a CSRF token
+var csrfProtectedProcedures = map[string]bool{
+	catalogv1connect.CatalogServiceLoadProtosProcedure:            true,
+	catalogv1connect.CatalogServiceLoadProtosBatchProcedure:       true,
+	catalogv1connect.CatalogServiceLoadProtosStreamProcedure:      true,
+	catalogv1connect.CatalogServiceInvokeGRPCProcedure:            true,
+	catalogv1connect.CatalogServiceInvokeReflectiveProcedure:      true,
+	catalogv1connect.CatalogServiceCompileProtoProcedure:          true,
+	catalogv1connect.CatalogServiceCompactSessionProcedure:        true,
+	catalogv1connect.CatalogServiceSetEndpointDefaultsProcedure:   true,
+	catalogv1connect.CatalogServiceSetCredentialProviderProcedure: true,
+	catalogv1connect.CatalogServiceSetOAuthCredentialsProcedure:   true,
+	catalogv1connect.CatalogServiceRefreshReflectionProcedure:     true,
+	catalogv1connect.CatalogServiceWarmEndpointsProcedure:         true,
+	catalogv1connect.CatalogServiceCheckEndpointProcedure:         true,
+	catalogv1connect.CatalogServiceProbeTransportsProcedure:       true,
+	catalogv1connect.CatalogServiceInspectTLSProcedure:            true,
+}
+
+// csrfProtector issues CSRF tokens with the UI and checks them on protected
+// RPCs. A token is a random nonce signed with a per-process secret; requests
+// must send it both in the cookie and in the X-CSRF-Token header, which only
+// same-origin scripts that read it from the page can do.
+type csrfProtector struct {
+	secret []byte
+}
+
+// newCSRFProtector creates a protector with a fresh secret, so tokens issued
+// before a restart stop being accepted
+func newCSRFProtector() (*csrfProtector, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate CSRF secret: %w", err)
+	}
+	return &csrfProtector{secret: secret}, nil
+}
+
+// issue creates a new token
+func (c *csrfProtector) issue() (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	encoded := hex.EncodeToString(nonce)
+	return encoded + "." + c.sign(encoded), nil
+}
+
+// sign returns the hex HMAC of a nonce
+func (c *csrfProtector) sign(nonce string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// valid reports whether a token was issued by this protector
+func (c *csrfProtector) valid(token string) bool {
+	nonce, signature, ok := strings.Cut(token, ".")
+	return ok && hmac.Equal([]byte(signature), []byte(c.sign(nonce)))
+}
+
+// token returns the request's token if its cookie holds a valid one, or
+// issues a new one and sets the cookie
+func (c *csrfProtector) token(w http.ResponseWriter, r *http.Request) (string, error) {
+	if cookie, err := r.Cookie(csrfCookieName); err == nil && c.valid(cookie.Value) {
+		return cookie.Value, nil
+	}
+
+	token, err := c.issue()
+	if err != nil {
+		return "", err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   r.TLS != nil,
+	})
+	return token, nil
+}
+
+// injectMeta adds the token to a page as <meta name="csrf-token">
+func (c *csrfProtector) injectMeta(page []byte, token string) []byte {
+	meta := fmt.Sprintf(`<meta name="%s" content="%s">`, csrfMetaName, html.EscapeString(token))
+	if i := bytes.Index(page, []byte("</head>")); i >= 0 {
+		return append(page[:i:i], append([]byte(meta), page[i:]...)...)
+	}
+	return append([]byte(meta), page...)
+}
+
+// check rejects calls to protected procedures whose header token is missing,
+// doesn't match the cookie, or wasn't issued by this protector
+func (c *csrfProtector) check(procedure string, header http.Header) error {
+	if !csrfProtectedProcedures[procedure] {
+		return nil
+	}
+
+	token := header.Get(csrfHeader)
+	cookie, err := (&http.Request{Header: header}).Cookie(csrfCookieName)
+	if token == "" || err != nil || cookie.Value != token || !c.valid(token) {
+		return connect.NewError(
+			connect.CodePermissionDenied,
+			errors.New("missing or invalid CSRF token; reload the UI"),
+		)
+	}
+	return nil
+}
+
+// WrapUnary implements connect.Interceptor
+func (c *csrfProtector) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if err := c.check(req.Spec().Procedure, req.Header()); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor; the server has no
+// streaming clients to protect
+func (c *csrfProtector) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (c *csrfProtector) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := c.check(conn.Spec().Procedure, conn.RequestHeader()); err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
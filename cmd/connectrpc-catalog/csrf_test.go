@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"connectrpc.com/connect"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	catalogv1connect "github.com/opentdf/connectrpc-catalog/gen/catalog/v1/catalogv1connect"
+	"github.com/opentdf/connectrpc-catalog/internal/server"
+)
+
+// TestCSRF_IssuesTokenWithUI tests that the UI page carries a token matching
+// its cookie, and that a visitor keeps their token across loads
+func TestCSRF_IssuesTokenWithUI(t *testing.T) {
+	csrf, err := newCSRFProtector()
+	if err != nil {
+		t.Fatalf("newCSRFProtector failed: %v", err)
+	}
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte("<!doctype html><html><head><title>x</title></head></html>")},
+	}
+	handler := spaHandler(fsys, csrf)
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/services/detail", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != csrfCookieName {
+		t.Fatalf("Expected the CSRF cookie, got %v", cookies)
+	}
+	token := cookies[0].Value
+	if !strings.Contains(rec.Body.String(), `<meta name="csrf-token" content="`+token+`"></head>`) {
+		t.Errorf("Expected the token in a meta tag, got %s", rec.Body.String())
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Expected Cache-Control no-store, got %q", got)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if len(rec.Result().Cookies()) != 0 || !strings.Contains(rec.Body.String(), token) {
+		t.Error("Expected the existing token to be reused")
+	}
+}
+
+// TestCSRF_Interceptor tests that protected RPCs, unary and streaming,
+// require a header token matching a valid cookie while others don't
+func TestCSRF_Interceptor(t *testing.T) {
+	csrf, err := newCSRFProtector()
+	if err != nil {
+		t.Fatalf("newCSRFProtector failed: %v", err)
+	}
+	catalogServer := server.New()
+	defer catalogServer.Close()
+
+	path, handler := catalogv1connect.NewCatalogServiceHandler(catalogServer, connect.WithInterceptors(csrf))
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	client := catalogv1connect.NewCatalogServiceClient(ts.Client(), ts.URL)
+
+	token, err := csrf.issue()
+	if err != nil {
+		t.Fatalf("issue failed: %v", err)
+	}
+	forged := strings.Repeat("0", 32) + "." + strings.Repeat("0", 64)
+
+	withToken := func(header http.Header, headerToken, cookieToken string) {
+		if headerToken != "" {
+			header.Set(csrfHeader, headerToken)
+		}
+		if cookieToken != "" {
+			header.Set("Cookie", csrfCookieName+"="+cookieToken)
+		}
+	}
+
+	// Reads are never checked
+	if _, err := client.ListServices(context.Background(), connect.NewRequest(&catalogv1.ListServicesRequest{})); err != nil {
+		t.Errorf("Expected ListServices without a token to succeed, got %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		headerToken  string
+		cookieToken  string
+		wantRejected bool
+	}{
+		{"no token", "", "", true},
+		{"header only", token, "", true},
+		{"cookie only", "", token, true},
+		{"mismatch", token, forged, true},
+		{"forged", forged, forged, true},
+		{"valid", token, token, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// An empty source fails validation once past the interceptor
+			req := connect.NewRequest(&catalogv1.LoadProtosRequest{})
+			withToken(req.Header(), tt.headerToken, tt.cookieToken)
+			_, err := client.LoadProtos(context.Background(), req)
+			if rejected := connect.CodeOf(err) == connect.CodePermissionDenied; rejected != tt.wantRejected {
+				t.Errorf("LoadProtos: expected rejected=%v, got %v", tt.wantRejected, err)
+			}
+
+			streamReq := connect.NewRequest(&catalogv1.LoadProtosRequest{})
+			withToken(streamReq.Header(), tt.headerToken, tt.cookieToken)
+			stream, err := client.LoadProtosStream(context.Background(), streamReq)
+			if err != nil {
+				t.Fatalf("LoadProtosStream failed: %v", err)
+			}
+			for stream.Receive() {
+			}
+			if rejected := connect.CodeOf(stream.Err()) == connect.CodePermissionDenied; rejected != tt.wantRejected {
+				t.Errorf("LoadProtosStream: expected rejected=%v, got %v", tt.wantRejected, stream.Err())
+			}
+		})
+	}
+}
+
+// TestCSRF_OutboundProcedures tests that the diagnostics RPCs, which make
+// the server connect to a caller-chosen endpoint, require a token
+func TestCSRF_OutboundProcedures(t *testing.T) {
+	csrf, err := newCSRFProtector()
+	if err != nil {
+		t.Fatalf("newCSRFProtector failed: %v", err)
+	}
+	catalogServer := server.New()
+	defer catalogServer.Close()
+
+	path, handler := catalogv1connect.NewCatalogServiceHandler(catalogServer, connect.WithInterceptors(csrf))
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	client := catalogv1connect.NewCatalogServiceClient(ts.Client(), ts.URL)
+
+	ctx := context.Background()
+	calls := map[string]func() error{
+		"CheckEndpoint": func() error {
+			_, err := client.CheckEndpoint(ctx, connect.NewRequest(&catalogv1.CheckEndpointRequest{Endpoint: "localhost:1"}))
+			return err
+		},
+		"ProbeTransports": func() error {
+			_, err := client.ProbeTransports(ctx, connect.NewRequest(&catalogv1.ProbeTransportsRequest{Endpoint: "localhost:1"}))
+			return err
+		},
+		"InspectTLS": func() error {
+			_, err := client.InspectTLS(ctx, connect.NewRequest(&catalogv1.InspectTLSRequest{Endpoint: "localhost:1"}))
+			return err
+		},
+	}
+	for name, call := range calls {
+		if err := call(); connect.CodeOf(err) != connect.CodePermissionDenied {
+			t.Errorf("%s: expected PermissionDenied without a token, got %v", name, err)
+		}
+	}
+}
@@ -20,7 +20,10 @@ import (
 	"connectrpc.com/connect"
 	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
 	catalogv1connect "github.com/opentdf/connectrpc-catalog/gen/catalog/v1/catalogv1connect"
+	"github.com/opentdf/connectrpc-catalog/internal/invoker"
+	"github.com/opentdf/connectrpc-catalog/internal/loader"
 	"github.com/opentdf/connectrpc-catalog/internal/server"
+	"github.com/opentdf/connectrpc-catalog/internal/session"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 )
@@ -31,6 +34,8 @@ var uiAssets embed.FS
 const (
 	defaultPort = "8080"
 	defaultHost = "localhost"
+	// sharedSessionID is the session clients share with -shared-session
+	sharedSessionID = "shared"
 )
 
 func main() {
@@ -41,12 +46,51 @@ func main() {
 		protoPath    = flag.String("proto-path", "", "Local directory path for proto files")
 		protoRepo    = flag.String("proto-repo", "", "GitHub repository (e.g., github.com/connectrpc/eliza)")
 		bufModule    = flag.String("buf-module", "", "Buf registry module (e.g., buf.build/connectrpc/eliza)")
+		selfCatalog  = flag.Bool("catalog-service", false, "Load the catalog's own CatalogService, so its methods can be listed and invoked like any other service")
 		endpoint     = flag.String("endpoint", "", "Default gRPC endpoint for invocations (optional)")
+		maxConns     = flag.Int("max-connections", invoker.DefaultMaxConnections, "Maximum cached gRPC connections per session")
+		connTTL      = flag.Duration("connection-ttl", invoker.DefaultConnectionTTL, "Time-to-live for cached gRPC connections")
+		connIdle     = flag.Duration("connection-idle-timeout", invoker.DefaultConnectionIdleTimeout, "Close cached gRPC connections unused for this long")
+		maxInflight  = flag.Int("max-concurrent-invocations", server.DefaultMaxConcurrentInvocations, "Maximum invocations in flight across all sessions")
+		checkBuf     = flag.Bool("check-buf", true, "Warn at startup if buf is not installed")
+		sessionTTL   = flag.Duration("session-ttl", session.DefaultSessionTTL, "Expire sessions unused for this long")
+		cleanupEvery = flag.Duration("session-cleanup-interval", session.CleanupInterval, "How often expired sessions are removed and idle ones compacted")
+		compactDir   = flag.String("compaction-dir", "", "Directory for spilling idle session registries (optional)")
+		compactIdle  = flag.Duration("compact-idle-after", 0, "Compact sessions idle this long (requires -compaction-dir)")
+		reflectTTL   = flag.Duration("reflection-cache-ttl", loader.DefaultReflectionCacheTTL, "Reuse reflected descriptors across sessions for this long (0 disables)")
+		gitSSHCmd    = flag.String("git-ssh-command", "", "SSH command for git remotes, as GIT_SSH_COMMAND (e.g., \"ssh -i /keys/deploy_key\")")
+		noUI         = flag.Bool("no-ui", false, "Serve only the API; other paths return 404 instead of the embedded UI")
+		csrfFlag     = flag.Bool("csrf", false, "Require a CSRF token, issued with the UI, on RPCs that load, invoke or change session state")
+		sharedSess   = flag.Bool("shared-session", false, "Load the --proto-* source into one read-only session shared by all clients that send no session ID")
+		connectVer   = flag.String("connect-protocol-version", invoker.DefaultConnectProtocolVersion, "Connect-Protocol-Version sent on Connect calls unless a request sets its own (empty omits it)")
+		invokeMD     = metadataFlag{}
+		redactMD     listFlag
+		redactOpts   listFlag
 	)
+	flag.Var(invokeMD, "invoke-metadata", "Metadata added to every invocation as key=value (repeatable, server-side only)")
+	flag.Var(&redactMD, "redact-metadata", "Metadata keys to redact from recorded invocations, besides authorization and cookies (comma-separated, repeatable)")
+	flag.Var(&redactOpts, "redact-field-option", "Custom bool field options marking request fields to redact, e.g. acme.v1.sensitive (comma-separated, repeatable)")
 	flag.Parse()
 
+	loader.SetReflectionCacheTTL(*reflectTTL)
+	loader.SetGitSSHCommand(*gitSSHCmd)
+
 	// Create catalog server
-	catalogServer := server.New()
+	serverOpts := []server.Option{
+		server.WithConnectionPool(*maxConns, *connTTL),
+		server.WithConnectionIdleTimeout(*connIdle),
+		server.WithMaxConcurrentInvocations(*maxInflight),
+		server.WithBufCheck(*checkBuf),
+		server.WithDefaultInvokeMetadata(invokeMD),
+		server.WithRedaction(redactMD, redactOpts),
+		server.WithSessions(*sessionTTL, *cleanupEvery),
+		server.WithSessionCompaction(*compactDir, *compactIdle),
+		server.WithConnectProtocolVersion(*connectVer),
+	}
+	if *sharedSess {
+		serverOpts = append(serverOpts, server.WithSharedSession(sharedSessionID))
+	}
+	catalogServer := server.New(serverOpts...)
 	defer func() {
 		if err := catalogServer.Close(); err != nil {
 			log.Printf("Error closing catalog server: %v", err)
@@ -54,38 +98,64 @@ func main() {
 	}()
 
 	// Validate server setup
-	if err := catalogServer.ValidateSetup(); err != nil {
+	warnings, err := catalogServer.ValidateSetup()
+	if err != nil {
 		log.Fatalf("Server setup validation failed: %v", err)
 	}
+	for _, warning := range warnings {
+		log.Printf("Warning: %s", warning)
+	}
 
 	// Auto-load protos if source flags are provided
-	if err := loadProtosFromFlags(catalogServer, *protoPath, *protoRepo, *bufModule, *endpoint); err != nil {
+	if err := loadProtosFromFlags(catalogServer, *protoPath, *protoRepo, *bufModule, *selfCatalog, *endpoint, catalogServer.GetConfig().SharedSessionID); err != nil {
 		log.Printf("Warning: Failed to auto-load protos: %v", err)
 		// Continue server startup even if proto loading fails
 	}
+	if *sharedSess {
+		// Sealed even if loading failed, so clients can't fill it themselves
+		catalogServer.SealSharedSession()
+		log.Printf("Clients without a session ID share the read-only session %q", sharedSessionID)
+	}
 
 	// Create HTTP mux
 	mux := http.NewServeMux()
 
+	// CSRF protection only makes sense with the UI, which hands out tokens
+	var csrf *csrfProtector
+	interceptors := []connect.Interceptor{corsInterceptor()}
+	if *csrfFlag {
+		if *noUI {
+			log.Printf("Warning: -csrf has no UI to issue tokens with -no-ui; protected RPCs will be rejected")
+		}
+		csrf, err = newCSRFProtector()
+		if err != nil {
+			log.Fatalf("Failed to enable CSRF protection: %v", err)
+		}
+		interceptors = append(interceptors, csrf)
+	}
+
 	// Register Connect handlers with CORS wrapper
 	path, handler := catalogv1connect.NewCatalogServiceHandler(
 		catalogServer,
-		connect.WithInterceptors(corsInterceptor()),
+		connect.WithInterceptors(interceptors...),
 	)
 	// Wrap handler with CORS middleware for preflight requests
 	mux.Handle(path, corsMiddleware(handler))
 
-	// Serve embedded UI assets
-	uiFS, err := fs.Sub(uiAssets, "dist")
-	if err != nil {
-		log.Fatalf("Failed to get UI filesystem: %v", err)
-	}
+	// Serve embedded UI assets unless running headless, where unknown paths
+	// should be plain 404s rather than the SPA fallback
+	if !*noUI {
+		uiFS, err := fs.Sub(uiAssets, "dist")
+		if err != nil {
+			log.Fatalf("Failed to get UI filesystem: %v", err)
+		}
 
-	// Register MIME types for common web assets
-	registerMIMETypes()
+		// Register MIME types for common web assets
+		registerMIMETypes()
 
-	// Serve static files with SPA fallback
-	mux.HandleFunc("/", spaHandler(uiFS))
+		// Serve static files with SPA fallback
+		mux.HandleFunc("/", spaHandler(uiFS, csrf))
+	}
 
 	// Create server with h2c support (HTTP/2 without TLS) for Connect
 	h2s := &http2.Server{}
@@ -101,7 +171,9 @@ func main() {
 	// Start server in goroutine
 	go func() {
 		log.Printf("ConnectRPC Catalog server starting on http://%s:%s", *host, *port)
-		log.Printf("UI available at: http://%s:%s", *host, *port)
+		if !*noUI {
+			log.Printf("UI available at: http://%s:%s", *host, *port)
+		}
 		log.Printf("API available at: http://%s:%s/catalog.v1.CatalogService/*", *host, *port)
 
 		if err := h1s.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -121,8 +193,41 @@ func main() {
 	log.Println("Server stopped")
 }
 
-// spaHandler serves static files and falls back to index.html for client-side routing
-func spaHandler(fsys fs.FS) http.HandlerFunc {
+// metadataFlag collects repeated key=value flags into a metadata map
+type metadataFlag map[string]string
+
+func (m metadataFlag) String() string {
+	return fmt.Sprintf("%d entries", len(m))
+}
+
+func (m metadataFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	m[strings.TrimSpace(key)] = val
+	return nil
+}
+
+// listFlag collects comma-separated values across repeated flags
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// spaHandler serves static files and falls back to index.html for client-side
+// routing. With csrf set, index.html carries a CSRF token.
+func spaHandler(fsys fs.FS, csrf *csrfProtector) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Don't handle API routes
 		if strings.HasPrefix(r.URL.Path, "/catalog.v1.CatalogService/") {
@@ -132,39 +237,85 @@ func spaHandler(fsys fs.FS) http.HandlerFunc {
 
 		// Clean the path
 		path := strings.TrimPrefix(r.URL.Path, "/")
-		if path == "" {
-			path = "index.html"
+		if path == "" || path == "index.html" {
+			serveIndex(w, r, fsys, csrf)
+			return
 		}
 
 		// Try to open the requested file
 		file, err := fsys.Open(path)
 		if err != nil {
 			// File not found, serve index.html for SPA routing
-			indexFile, indexErr := fsys.Open("index.html")
-			if indexErr != nil {
-				http.Error(w, "index.html not found", http.StatusInternalServerError)
-				return
-			}
-			defer indexFile.Close()
-
-			// Set correct content type for HTML
-			w.Header().Set("Content-Type", "text/html; charset=utf-8")
-			http.ServeContent(w, r, "index.html", getModTime(indexFile), indexFile.(io.ReadSeeker))
+			serveIndex(w, r, fsys, csrf)
 			return
 		}
 		defer file.Close()
 
-		// Determine content type based on file extension
-		ext := filepath.Ext(path)
-		if contentType := mime.TypeByExtension(ext); contentType != "" {
-			w.Header().Set("Content-Type", contentType)
+		content := file.(io.ReadSeeker)
+
+		// Determine content type based on file extension, sniffing the
+		// content of extensionless or unrecognized files
+		contentType := mime.TypeByExtension(filepath.Ext(path))
+		if contentType == "" {
+			contentType, err = sniffContentType(content)
+			if err != nil {
+				http.Error(w, "failed to read file", http.StatusInternalServerError)
+				return
+			}
 		}
+		w.Header().Set("Content-Type", contentType)
 
 		// Serve the file
-		http.ServeContent(w, r, path, getModTime(file), file.(io.ReadSeeker))
+		http.ServeContent(w, r, path, getModTime(file), content)
 	}
 }
 
+// serveIndex serves index.html, adding a CSRF token (and its cookie) to the
+// page when csrf is set
+func serveIndex(w http.ResponseWriter, r *http.Request, fsys fs.FS, csrf *csrfProtector) {
+	indexFile, err := fsys.Open("index.html")
+	if err != nil {
+		http.Error(w, "index.html not found", http.StatusInternalServerError)
+		return
+	}
+	defer indexFile.Close()
+
+	// Set correct content type for HTML
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if csrf == nil {
+		http.ServeContent(w, r, "index.html", getModTime(indexFile), indexFile.(io.ReadSeeker))
+		return
+	}
+
+	page, err := io.ReadAll(indexFile)
+	if err != nil {
+		http.Error(w, "failed to read index.html", http.StatusInternalServerError)
+		return
+	}
+	token, err := csrf.token(w, r)
+	if err != nil {
+		http.Error(w, "failed to issue CSRF token", http.StatusInternalServerError)
+		return
+	}
+	// The page is specific to the visitor's token
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(csrf.injectMeta(page, token))
+}
+
+// sniffContentType detects the content type from the first 512 bytes of
+// content and rewinds it
+func sniffContentType(content io.ReadSeeker) (string, error) {
+	var buf [512]byte
+	n, err := io.ReadFull(content, buf[:])
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
 // getModTime extracts modification time from file info
 func getModTime(file fs.File) time.Time {
 	if stat, err := file.Stat(); err == nil {
@@ -176,21 +327,23 @@ func getModTime(file fs.File) time.Time {
 // registerMIMETypes ensures proper MIME types for web assets
 func registerMIMETypes() {
 	mimeTypes := map[string]string{
-		".js":   "application/javascript",
-		".mjs":  "application/javascript",
-		".json": "application/json",
-		".css":  "text/css",
-		".html": "text/html; charset=utf-8",
-		".svg":  "image/svg+xml",
-		".png":  "image/png",
-		".jpg":  "image/jpeg",
-		".jpeg": "image/jpeg",
-		".gif":  "image/gif",
-		".woff": "font/woff",
-		".woff2": "font/woff2",
-		".ttf":  "font/ttf",
-		".eot":  "application/vnd.ms-fontobject",
-		".ico":  "image/x-icon",
+		".js":          "application/javascript",
+		".mjs":         "application/javascript",
+		".json":        "application/json",
+		".css":         "text/css",
+		".html":        "text/html; charset=utf-8",
+		".svg":         "image/svg+xml",
+		".png":         "image/png",
+		".jpg":         "image/jpeg",
+		".jpeg":        "image/jpeg",
+		".gif":         "image/gif",
+		".woff":        "font/woff",
+		".woff2":       "font/woff2",
+		".ttf":         "font/ttf",
+		".eot":         "application/vnd.ms-fontobject",
+		".ico":         "image/x-icon",
+		".map":         "application/json",
+		".webmanifest": "application/manifest+json",
 	}
 
 	for ext, mimeType := range mimeTypes {
@@ -221,7 +374,7 @@ func corsMiddleware(next http.Handler) http.Handler {
 func setCORSHeaders(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Connect-Protocol-Version, Connect-Timeout-Ms")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, "+invoker.ConnectProtocolVersionHeader+", Connect-Timeout-Ms")
 	w.Header().Set("Access-Control-Max-Age", "86400")
 }
 
@@ -235,8 +388,9 @@ func corsInterceptor() connect.UnaryInterceptorFunc {
 	}
 }
 
-// loadProtosFromFlags handles auto-loading protos from CLI flags
-func loadProtosFromFlags(catalogServer *server.CatalogServer, protoPath, protoRepo, bufModule, endpoint string) error {
+// loadProtosFromFlags handles auto-loading protos from CLI flags, into the
+// given session or, if empty, a new one
+func loadProtosFromFlags(catalogServer *server.CatalogServer, protoPath, protoRepo, bufModule string, selfCatalog bool, endpoint, sessionID string) error {
 	// Count how many proto sources are provided
 	sourcesProvided := 0
 	if protoPath != "" {
@@ -248,6 +402,9 @@ func loadProtosFromFlags(catalogServer *server.CatalogServer, protoPath, protoRe
 	if bufModule != "" {
 		sourcesProvided++
 	}
+	if selfCatalog {
+		sourcesProvided++
+	}
 
 	// No source provided - nothing to do
 	if sourcesProvided == 0 {
@@ -256,7 +413,7 @@ func loadProtosFromFlags(catalogServer *server.CatalogServer, protoPath, protoRe
 
 	// Validate that only ONE source is provided
 	if sourcesProvided > 1 {
-		return fmt.Errorf("only one proto source flag can be specified at a time (--proto-path, --proto-repo, or --buf-module)")
+		return fmt.Errorf("only one proto source flag can be specified at a time (--proto-path, --proto-repo, --buf-module, or --catalog-service)")
 	}
 
 	// Build the LoadProtos request based on which flag was provided
@@ -286,6 +443,18 @@ func loadProtosFromFlags(catalogServer *server.CatalogServer, protoPath, protoRe
 				BufModule: bufModule,
 			},
 		})
+
+	case selfCatalog:
+		log.Printf("Auto-loading the catalog's own CatalogService")
+		req = connect.NewRequest(&catalogv1.LoadProtosRequest{
+			Source: &catalogv1.LoadProtosRequest_CatalogService{
+				CatalogService: true,
+			},
+		})
+	}
+
+	if sessionID != "" {
+		req.Header().Set("X-Session-ID", sessionID)
 	}
 
 	// Call LoadProtos
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestSPAHandler_ContentType tests content types for registered extensions,
+// extensionless assets and the SPA fallback
+func TestSPAHandler_ContentType(t *testing.T) {
+	registerMIMETypes()
+
+	fsys := fstest.MapFS{
+		"index.html":                 {Data: []byte("<!doctype html><html></html>")},
+		"assets/index-3f2a9c.js.map": {Data: []byte(`{"version":3,"sources":[]}`)},
+		"site.webmanifest":           {Data: []byte(`{"name":"catalog"}`)},
+		"assets/LICENSE":             {Data: []byte("MIT License\n\nPermission is hereby granted")},
+		"assets/logo":                {Data: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")},
+	}
+	handler := spaHandler(fsys, nil)
+
+	tests := []struct {
+		path        string
+		contentType string
+		body        string
+	}{
+		{"/assets/index-3f2a9c.js.map", "application/json", `{"version":3`},
+		{"/site.webmanifest", "application/manifest+json", `{"name"`},
+		{"/assets/LICENSE", "text/plain; charset=utf-8", "MIT License"},
+		{"/assets/logo", "image/png", "\x89PNG"},
+		{"/services/detail", "text/html; charset=utf-8", "<!doctype html>"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", tt.path, rec.Code)
+		}
+		if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("%s: expected Content-Type %q, got %q", tt.path, tt.contentType, got)
+		}
+		// Sniffing must not consume the start of the body
+		if !strings.HasPrefix(rec.Body.String(), tt.body) {
+			t.Errorf("%s: expected body starting with %q, got %q", tt.path, tt.body, rec.Body.String())
+		}
+	}
+}
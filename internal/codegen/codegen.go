@@ -0,0 +1,62 @@
+// Package codegen renders client code snippets that invoke a method
+package codegen
+
+import (
+	"fmt"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+// Request describes the call a snippet should make
+type Request struct {
+	Method   *desc.MethodDescriptor
+	Endpoint string
+	UseTLS   bool
+}
+
+// generator renders a snippet for one language
+type generator func(Request) (string, error)
+
+// generators maps each supported language to its generator
+var generators = map[catalogv1.CodeLanguage]generator{
+	catalogv1.CodeLanguage_CODE_LANGUAGE_GO:         generateGo,
+	catalogv1.CodeLanguage_CODE_LANGUAGE_JAVASCRIPT: generateJavaScript,
+}
+
+// Generate renders a snippet invoking req.Method in the given language.
+// CODE_LANGUAGE_UNSPECIFIED selects Go.
+func Generate(language catalogv1.CodeLanguage, req Request) (string, error) {
+	if language == catalogv1.CodeLanguage_CODE_LANGUAGE_UNSPECIFIED {
+		language = catalogv1.CodeLanguage_CODE_LANGUAGE_GO
+	}
+
+	generate, ok := generators[language]
+	if !ok {
+		return "", fmt.Errorf("unsupported language: %v", language)
+	}
+	if req.Method.IsClientStreaming() || req.Method.IsServerStreaming() {
+		return "", fmt.Errorf("only unary methods are supported")
+	}
+	return generate(req)
+}
+
+// SupportsLanguage reports whether snippets can be generated for language
+func SupportsLanguage(language catalogv1.CodeLanguage) bool {
+	if language == catalogv1.CodeLanguage_CODE_LANGUAGE_UNSPECIFIED {
+		return true
+	}
+	_, ok := generators[language]
+	return ok
+}
+
+// methodURL returns the Connect URL of a method
+func methodURL(req Request) string {
+	scheme := "http"
+	if req.UseTLS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/%s/%s", scheme, req.Endpoint,
+		req.Method.GetService().GetFullyQualifiedName(), req.Method.GetName())
+}
@@ -0,0 +1,211 @@
+package codegen
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// createGreetFile builds a service whose request covers nested messages,
+// enums, repeated fields, and a oneof
+func createGreetFile(t *testing.T, goPackage string) *desc.FileDescriptor {
+	t.Helper()
+
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+
+	oneofField := field("nickname", 5, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, "")
+	oneofField.OneofIndex = proto.Int32(0)
+
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("greet/v1/greet.proto"),
+		Package: proto.String("greet.v1"),
+		Syntax:  proto.String("proto3"),
+		Options: &descriptorpb.FileOptions{},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Mood"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("MOOD_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("MOOD_HAPPY"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("GreetRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("user_name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("mood", 2, descriptorpb.FieldDescriptorProto_TYPE_ENUM, optional, ".greet.v1.Mood"),
+					field("address", 3, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional, ".greet.v1.GreetRequest.Address"),
+					field("tags", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, repeated, ""),
+					oneofField,
+				},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("Address"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						field("zip_code", 1, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, ""),
+					},
+				}},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("alias")}},
+			},
+			{Name: proto.String("GreetResponse")},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("GreetService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name:       proto.String("Greet"),
+					InputType:  proto.String(".greet.v1.GreetRequest"),
+					OutputType: proto.String(".greet.v1.GreetResponse"),
+				},
+				{
+					Name:            proto.String("GreetStream"),
+					InputType:       proto.String(".greet.v1.GreetRequest"),
+					OutputType:      proto.String(".greet.v1.GreetResponse"),
+					ServerStreaming: proto.Bool(true),
+				},
+			},
+		}},
+	}
+	if goPackage != "" {
+		fdp.Options.GoPackage = proto.String(goPackage)
+	}
+
+	fd, err := desc.CreateFileDescriptor(fdp)
+	if err != nil {
+		t.Fatalf("Failed to create file descriptor: %v", err)
+	}
+	return fd
+}
+
+// TestGenerate_Go tests rendering a connect-go snippet
+func TestGenerate_Go(t *testing.T) {
+	fd := createGreetFile(t, "example.com/gen/greet/v1;greetv1")
+	method := fd.FindService("greet.v1.GreetService").FindMethodByName("Greet")
+
+	code, err := Generate(catalogv1.CodeLanguage_CODE_LANGUAGE_UNSPECIFIED, Request{
+		Method:   method,
+		Endpoint: "api.example.com",
+		UseTLS:   true,
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "main.go", code, 0); err != nil {
+		t.Fatalf("Snippet does not parse: %v\n%s", err, code)
+	}
+
+	for _, want := range []string{
+		`greetv1 "example.com/gen/greet/v1"`,
+		`connect.NewClient[greetv1.GreetRequest, greetv1.GreetResponse](`,
+		`"https://api.example.com/greet.v1.GreetService/Greet"`,
+		`UserName: ""`,
+		`Mood:     greetv1.Mood_MOOD_UNSPECIFIED`,
+		`Address: &greetv1.GreetRequest_Address{`,
+		`ZipCode: 0`,
+		`Alias: &greetv1.GreetRequest_Nickname{Nickname: ""}`,
+		`client.CallUnary(context.Background(), req)`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Snippet missing %q:\n%s", want, code)
+		}
+	}
+}
+
+// TestGenerate_GoPlaceholderPackage tests the import used without go_package
+func TestGenerate_GoPlaceholderPackage(t *testing.T) {
+	fd := createGreetFile(t, "")
+	method := fd.FindService("greet.v1.GreetService").FindMethodByName("Greet")
+
+	code, err := Generate(catalogv1.CodeLanguage_CODE_LANGUAGE_GO, Request{Method: method, Endpoint: "localhost:8080"})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if !strings.Contains(code, `greetv1 "example.com/gen/greet/v1"`) {
+		t.Errorf("Unexpected placeholder import:\n%s", code)
+	}
+	if !strings.Contains(code, "// Replace with the Go package generated for greet/v1/greet.proto") {
+		t.Errorf("Missing placeholder comment:\n%s", code)
+	}
+	if !strings.Contains(code, `"http://localhost:8080/greet.v1.GreetService/Greet"`) {
+		t.Errorf("Unexpected URL:\n%s", code)
+	}
+}
+
+// TestGenerate_Errors tests unsupported languages and streaming methods
+func TestGenerate_Errors(t *testing.T) {
+	fd := createGreetFile(t, "")
+	svc := fd.FindService("greet.v1.GreetService")
+
+	if _, err := Generate(catalogv1.CodeLanguage(99), Request{Method: svc.FindMethodByName("Greet")}); err == nil {
+		t.Error("Expected error for unsupported language")
+	}
+	if _, err := Generate(catalogv1.CodeLanguage_CODE_LANGUAGE_GO, Request{Method: svc.FindMethodByName("GreetStream")}); err == nil {
+		t.Error("Expected error for streaming method")
+	}
+}
+
+// TestGoCamelCase tests Go identifier conversion
+func TestGoCamelCase(t *testing.T) {
+	tests := map[string]string{
+		"user_name":            "UserName",
+		"GreetRequest.Address": "GreetRequest_Address",
+		"_private":             "XPrivate",
+		"field2_value":         "Field2Value",
+	}
+	for in, want := range tests {
+		if got := goCamelCase(in); got != want {
+			t.Errorf("goCamelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestGenerate_JavaScript tests rendering a Connect fetch snippet
+func TestGenerate_JavaScript(t *testing.T) {
+	fd := createGreetFile(t, "")
+	method := fd.FindService("greet.v1.GreetService").FindMethodByName("Greet")
+
+	code, err := Generate(catalogv1.CodeLanguage_CODE_LANGUAGE_JAVASCRIPT, Request{
+		Method:   method,
+		Endpoint: "api.example.com",
+		UseTLS:   true,
+	})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`await fetch("https://api.example.com/greet.v1.GreetService/Greet", {`,
+		`method: "POST"`,
+		`"Content-Type": "application/json"`,
+		`"Connect-Protocol-Version": "1"`,
+		`"user_name": ""`,
+		`"mood": "MOOD_UNSPECIFIED"`,
+		`"zip_code": 0`,
+		`const data = await response.json();`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Snippet missing %q:\n%s", want, code)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/opentdf/connectrpc-catalog/internal/registry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// generateGo renders a runnable connect-go program calling the method with
+// its example request
+func generateGo(req Request) (string, error) {
+	imports := newGoImports()
+	input := req.Method.GetInputType()
+	output := req.Method.GetOutputType()
+
+	literal := imports.messageLiteral(registry.ExampleMessage(input), 1)
+	inputType := imports.messageType(input)
+	outputType := imports.messageType(output)
+
+	var b strings.Builder
+	b.WriteString("package main\n\n")
+	b.WriteString("import (\n")
+	b.WriteString("\t\"context\"\n\t\"log\"\n\t\"net/http\"\n\n")
+	b.WriteString("\t\"connectrpc.com/connect\"\n")
+	for _, imp := range imports.sorted() {
+		if imp.guessed {
+			fmt.Fprintf(&b, "\t// Replace with the Go package generated for %s\n", imp.protoFile)
+		}
+		fmt.Fprintf(&b, "\t%s %q\n", imp.alias, imp.path)
+	}
+	b.WriteString(")\n\n")
+	b.WriteString("func main() {\n")
+	fmt.Fprintf(&b, "\tclient := connect.NewClient[%s, %s](\n", inputType, outputType)
+	b.WriteString("\t\thttp.DefaultClient,\n")
+	fmt.Fprintf(&b, "\t\t%q,\n", methodURL(req))
+	b.WriteString("\t)\n\n")
+	fmt.Fprintf(&b, "\treq := connect.NewRequest(&%s)\n", literal)
+	b.WriteString("\tres, err := client.CallUnary(context.Background(), req)\n")
+	b.WriteString("\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n")
+	b.WriteString("\tlog.Println(res.Msg)\n")
+	b.WriteString("}\n")
+
+	formatted, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to format Go snippet: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// goImport is a Go package referenced by a snippet
+type goImport struct {
+	path      string
+	alias     string
+	protoFile string
+	guessed   bool // no go_package option, so the path is a placeholder
+}
+
+// goImports assigns unique aliases to the Go packages of referenced files
+type goImports struct {
+	byPath  map[string]*goImport
+	aliases map[string]bool
+}
+
+func newGoImports() *goImports {
+	return &goImports{
+		byPath:  make(map[string]*goImport),
+		aliases: map[string]bool{"context": true, "log": true, "http": true, "connect": true},
+	}
+}
+
+// sorted returns the imports ordered by path
+func (g *goImports) sorted() []*goImport {
+	imports := make([]*goImport, 0, len(g.byPath))
+	for _, imp := range g.byPath {
+		imports = append(imports, imp)
+	}
+	sort.Slice(imports, func(i, j int) bool { return imports[i].path < imports[j].path })
+	return imports
+}
+
+// qualifier returns the alias for the Go package of a proto file, adding the import
+func (g *goImports) qualifier(fd *desc.FileDescriptor) string {
+	importPath, name, guessed := goPackage(fd)
+	if imp, ok := g.byPath[importPath]; ok {
+		return imp.alias
+	}
+
+	alias := name
+	for i := 2; g.aliases[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", name, i)
+	}
+	g.aliases[alias] = true
+	g.byPath[importPath] = &goImport{path: importPath, alias: alias, protoFile: fd.GetName(), guessed: guessed}
+	return alias
+}
+
+// messageType returns the qualified Go type name of a message
+func (g *goImports) messageType(md *desc.MessageDescriptor) string {
+	return g.qualifier(md.GetFile()) + "." + goIdent(md.GetFullyQualifiedName(), md.GetFile().GetPackage())
+}
+
+// messageLiteral renders a composite literal for msg, without the leading &
+func (g *goImports) messageLiteral(msg *dynamic.Message, depth int) string {
+	md := msg.GetMessageDescriptor()
+	indent := strings.Repeat("\t", depth)
+
+	var fields []string
+	for _, field := range md.GetFields() {
+		// Maps stay empty and proto3 optional fields stay unset in examples
+		if field.IsMap() || field.IsProto3Optional() {
+			continue
+		}
+		if oneOf := field.GetOneOf(); oneOf != nil {
+			if set, value := msg.GetOneOfField(oneOf); set == field {
+				wrapper := g.qualifier(md.GetFile()) + "." + goIdent(md.GetFullyQualifiedName(), md.GetFile().GetPackage()) + "_" + goCamelCase(field.GetName())
+				fields = append(fields, fmt.Sprintf("%s: &%s{%s: %s}",
+					goCamelCase(oneOf.GetName()), wrapper, goCamelCase(field.GetName()), g.value(field, value, depth+1)))
+			}
+			continue
+		}
+
+		if field.IsRepeated() {
+			values, _ := msg.GetField(field).([]interface{})
+			if len(values) == 0 {
+				continue
+			}
+			elements := make([]string, len(values))
+			for i, value := range values {
+				elements[i] = g.value(field, value, depth+2)
+			}
+			fields = append(fields, fmt.Sprintf("%s: []%s{\n%s\t\t%s,\n%s\t}",
+				goCamelCase(field.GetName()), g.fieldType(field),
+				indent, strings.Join(elements, ",\n"+indent+"\t\t"), indent))
+			continue
+		}
+
+		if field.GetMessageType() != nil && !msg.HasField(field) {
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%s: %s", goCamelCase(field.GetName()), g.value(field, msg.GetField(field), depth+1)))
+	}
+
+	typeName := g.messageType(md)
+	if len(fields) == 0 {
+		return typeName + "{}"
+	}
+	return fmt.Sprintf("%s{\n%s\t%s,\n%s}", typeName, indent, strings.Join(fields, ",\n"+indent+"\t"), indent)
+}
+
+// fieldType returns the Go element type of a field
+func (g *goImports) fieldType(field *desc.FieldDescriptor) string {
+	if md := field.GetMessageType(); md != nil {
+		return "*" + g.messageType(md)
+	}
+	if ed := field.GetEnumType(); ed != nil {
+		return g.qualifier(ed.GetFile()) + "." + goIdent(ed.GetFullyQualifiedName(), ed.GetFile().GetPackage())
+	}
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return "bool"
+	case descriptorpb.FieldDescriptorProto_TYPE_STRING:
+		return "string"
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		return "[]byte"
+	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		return "float64"
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		return "float32"
+	case descriptorpb.FieldDescriptorProto_TYPE_INT64, descriptorpb.FieldDescriptorProto_TYPE_SINT64, descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
+		return "int64"
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_FIXED64:
+		return "uint64"
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT32, descriptorpb.FieldDescriptorProto_TYPE_FIXED32:
+		return "uint32"
+	default:
+		return "int32"
+	}
+}
+
+// value renders a single field value as a Go expression
+func (g *goImports) value(field *desc.FieldDescriptor, value interface{}, depth int) string {
+	if md := field.GetMessageType(); md != nil {
+		if msg, ok := value.(*dynamic.Message); ok {
+			return "&" + g.messageLiteral(msg, depth)
+		}
+		return "&" + g.messageType(md) + "{}"
+	}
+
+	if ed := field.GetEnumType(); ed != nil {
+		number, _ := value.(int32)
+		enumValue := ed.FindValueByNumber(number)
+		if enumValue == nil {
+			enumValue = ed.GetValues()[0]
+		}
+		return g.qualifier(ed.GetFile()) + "." + enumValueIdent(ed, enumValue)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []byte:
+		return "[]byte{}"
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// goIdent returns the Go identifier protoc-gen-go uses for a message or enum
+func goIdent(fullName, pkg string) string {
+	name := fullName
+	if pkg != "" {
+		name = strings.TrimPrefix(fullName, pkg+".")
+	}
+	return goCamelCase(name)
+}
+
+// enumValueIdent returns the Go identifier protoc-gen-go uses for an enum
+// value: prefixed by the parent message for nested enums, or by the enum itself
+func enumValueIdent(ed *desc.EnumDescriptor, value *desc.EnumValueDescriptor) string {
+	prefix := goIdent(ed.GetFullyQualifiedName(), ed.GetFile().GetPackage())
+	if parent, ok := ed.GetParent().(*desc.MessageDescriptor); ok {
+		prefix = goIdent(parent.GetFullyQualifiedName(), ed.GetFile().GetPackage())
+	}
+	return prefix + "_" + value.GetName()
+}
+
+// goPackage returns the import path and package name of a file's generated
+// Go code, guessing from the proto package when go_package is not set
+func goPackage(fd *desc.FileDescriptor) (string, string, bool) {
+	goPkg := fd.GetFileOptions().GetGoPackage()
+	if goPkg == "" {
+		pkg := fd.GetPackage()
+		return "example.com/gen/" + strings.ReplaceAll(pkg, ".", "/"), sanitizeGoName(strings.ReplaceAll(pkg, ".", "")), true
+	}
+
+	if importPath, name, ok := strings.Cut(goPkg, ";"); ok {
+		return importPath, sanitizeGoName(name), false
+	}
+	return goPkg, sanitizeGoName(path.Base(goPkg)), false
+}
+
+// sanitizeGoName turns s into a valid Go package name
+func sanitizeGoName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if b.Len() == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "pb"
+	}
+	return b.String()
+}
+
+// goCamelCase converts a proto name to the Go identifier protoc-gen-go uses
+func goCamelCase(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// Skip over '.' in ".{{lowercase}}"
+		case c == '.':
+			b = append(b, '_') // convert '.' to '_'
+		case c == '_' && (i == 0 || s[i-1] == '.'):
+			// Convert initial '_' to ensure we start with a capital letter
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isASCIILower(s[i+1]):
+			// Skip over '_' in "_{{lowercase}}"
+		case isASCIIDigit(c):
+			b = append(b, c)
+		default:
+			// Assume we have a letter now - if not, it's a bogus identifier
+			if isASCIILower(c) {
+				c -= 'a' - 'A' // convert lowercase to uppercase
+			}
+			b = append(b, c)
+
+			// Accept lower case sequence that follows
+			for ; i+1 < len(s) && isASCIILower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isASCIILower(c byte) bool { return 'a' <= c && c <= 'z' }
+func isASCIIDigit(c byte) bool { return '0' <= c && c <= '9' }
@@ -0,0 +1,45 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/opentdf/connectrpc-catalog/internal/registry"
+)
+
+// generateJavaScript renders a fetch call that POSTs the example request to
+// the method using the Connect protocol's JSON encoding
+func generateJavaScript(req Request) (string, error) {
+	body, err := registry.ExampleJSON(req.Method.GetInputType())
+	if err != nil {
+		return "", fmt.Errorf("failed to build example request: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "const response = await fetch(%s, {\n", strconv.Quote(methodURL(req)))
+	b.WriteString("  method: \"POST\",\n")
+	b.WriteString("  headers: {\n")
+	b.WriteString("    \"Content-Type\": \"application/json\",\n")
+	b.WriteString("    \"Connect-Protocol-Version\": \"1\",\n")
+	b.WriteString("  },\n")
+	// Indent the body to sit inside the options object, blanking the
+	// whitespace-only lines jsonpb emits for empty arrays
+	lines := strings.Split(body, "\n")
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			lines[i] = ""
+		} else {
+			lines[i] = "  " + lines[i]
+		}
+	}
+	fmt.Fprintf(&b, "  body: JSON.stringify(%s),\n", strings.Join(lines, "\n"))
+	b.WriteString("});\n\n")
+	b.WriteString("if (!response.ok) {\n")
+	b.WriteString("  const error = await response.json();\n")
+	b.WriteString("  throw new Error(`${error.code}: ${error.message}`);\n")
+	b.WriteString("}\n\n")
+	b.WriteString("const data = await response.json();\n")
+	b.WriteString("console.log(data);\n")
+	return b.String(), nil
+}
@@ -6,12 +6,36 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"connectrpc.com/connect"
 	elizav1 "github.com/opentdf/connectrpc-catalog/gen/connectrpc/eliza/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 )
 
+// EchoHeaderPrefix marks request headers that Say echoes back, unchanged,
+// as both response headers and trailers. This lets tests check that
+// metadata is forwarded to the service and surfaced from its response.
+const EchoHeaderPrefix = "X-Echo-"
+
+// Sentences starting with these prefixes make Say inject a fault instead of
+// answering, so tests can exercise error and timeout handling:
+//
+//	error:<code>[:<message>]  fails with a Connect code such as "not_found",
+//	                          with an ErrorInfo detail whose reason is FaultReason
+//	sleep:<ms>[:<sentence>]   waits before answering the rest of the sentence,
+//	                          failing early if the call is canceled
+const (
+	ErrorPrefix = "error:"
+	SleepPrefix = "sleep:"
+)
+
+// FaultReason is the ErrorInfo reason of errors injected with ErrorPrefix.
+const FaultReason = "INJECTED_FAULT"
+
 // Handler implements the ElizaServiceHandler interface.
 type Handler struct{}
 
@@ -30,10 +54,32 @@ func (h *Handler) Say(
 		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("sentence is required"))
 	}
 
+	sentence, err := injectFault(ctx, sentence)
+	if err != nil {
+		return nil, err
+	}
+
 	response := generateResponse(sentence)
-	return connect.NewResponse(&elizav1.SayResponse{
+	resp := connect.NewResponse(&elizav1.SayResponse{
 		Sentence: response,
-	}), nil
+	})
+	echoHeaders(req.Header(), resp.Header(), resp.Trailer())
+	return resp, nil
+}
+
+// echoHeaders copies the request headers prefixed with EchoHeaderPrefix
+// into each of the given response headers.
+func echoHeaders(from http.Header, to ...http.Header) {
+	for name, values := range from {
+		if !strings.HasPrefix(http.CanonicalHeaderKey(name), EchoHeaderPrefix) {
+			continue
+		}
+		for _, header := range to {
+			for _, value := range values {
+				header.Add(name, value)
+			}
+		}
+	}
 }
 
 // Converse handles the bidirectional streaming Converse RPC.
@@ -88,6 +134,52 @@ func (h *Handler) Introduce(
 	return nil
 }
 
+// injectFault applies the fault a sentence asks for, returning the error to
+// fail with or the sentence left to answer.
+func injectFault(ctx context.Context, sentence string) (string, error) {
+	switch {
+	case strings.HasPrefix(sentence, ErrorPrefix):
+		name, message, _ := strings.Cut(strings.TrimPrefix(sentence, ErrorPrefix), ":")
+		var code connect.Code
+		if err := code.UnmarshalText([]byte(name)); err != nil {
+			return "", connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("unknown error code %q", name))
+		}
+		if message == "" {
+			message = fmt.Sprintf("injected %s error", code)
+		}
+		connectErr := connect.NewError(code, errors.New(message))
+		if detail, err := connect.NewErrorDetail(&errdetails.ErrorInfo{Reason: FaultReason, Domain: "eliza"}); err == nil {
+			connectErr.AddDetail(detail)
+		}
+		return "", connectErr
+
+	case strings.HasPrefix(sentence, SleepPrefix):
+		spec, rest, _ := strings.Cut(strings.TrimPrefix(sentence, SleepPrefix), ":")
+		ms, err := strconv.Atoi(spec)
+		if err != nil || ms < 0 {
+			return "", connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid sleep duration %q, expected milliseconds", spec))
+		}
+		timer := time.NewTimer(time.Duration(ms) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			code := connect.CodeCanceled
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				code = connect.CodeDeadlineExceeded
+			}
+			return "", connect.NewError(code, ctx.Err())
+		}
+		if rest == "" {
+			rest = fmt.Sprintf("I waited %dms", ms)
+		}
+		return rest, nil
+
+	default:
+		return sentence, nil
+	}
+}
+
 // generateResponse creates a response based on the input.
 func generateResponse(input string) string {
 	input = strings.ToLower(input)
@@ -0,0 +1,95 @@
+package elizaservice_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	elizav1 "github.com/opentdf/connectrpc-catalog/gen/connectrpc/eliza/v1"
+	"github.com/opentdf/connectrpc-catalog/internal/elizaservice"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// TestHandler_FaultInjection tests that magic sentences make Say fail or
+// wait, and that other sentences are answered as before
+func TestHandler_FaultInjection(t *testing.T) {
+	handler := elizaservice.NewHandler()
+	say := func(ctx context.Context, sentence string) (string, error) {
+		resp, err := handler.Say(ctx, connect.NewRequest(&elizav1.SayRequest{Sentence: sentence}))
+		if err != nil {
+			return "", err
+		}
+		return resp.Msg.GetSentence(), nil
+	}
+
+	t.Run("error", func(t *testing.T) {
+		_, err := say(context.Background(), "error:not_found:no such patient")
+		var connectErr *connect.Error
+		if !errors.As(err, &connectErr) {
+			t.Fatalf("Expected a Connect error, got %v", err)
+		}
+		if connectErr.Code() != connect.CodeNotFound || connectErr.Message() != "no such patient" {
+			t.Errorf("Unexpected error: %v", connectErr)
+		}
+		if len(connectErr.Details()) != 1 {
+			t.Fatalf("Expected one detail, got %d", len(connectErr.Details()))
+		}
+		detail, err := connectErr.Details()[0].Value()
+		if err != nil {
+			t.Fatalf("Failed to decode detail: %v", err)
+		}
+		if info, ok := detail.(*errdetails.ErrorInfo); !ok || info.GetReason() != elizaservice.FaultReason {
+			t.Errorf("Expected an ErrorInfo detail, got %v", detail)
+		}
+	})
+
+	t.Run("error without message", func(t *testing.T) {
+		_, err := say(context.Background(), "error:unavailable")
+		if connect.CodeOf(err) != connect.CodeUnavailable {
+			t.Errorf("Expected Unavailable, got %v", err)
+		}
+	})
+
+	t.Run("unknown code", func(t *testing.T) {
+		_, err := say(context.Background(), "error:broken")
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("Expected InvalidArgument, got %v", err)
+		}
+	})
+
+	t.Run("sleep", func(t *testing.T) {
+		start := time.Now()
+		sentence, err := say(context.Background(), "sleep:50:hello")
+		if err != nil {
+			t.Fatalf("Say failed: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+			t.Errorf("Expected a 50ms delay, took %v", elapsed)
+		}
+		if sentence != "Hello! How can I help you today?" {
+			t.Errorf("Expected the rest of the sentence to be answered, got %q", sentence)
+		}
+	})
+
+	t.Run("sleep past deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := say(ctx, "sleep:5000"); connect.CodeOf(err) != connect.CodeDeadlineExceeded {
+			t.Errorf("Expected DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("invalid sleep", func(t *testing.T) {
+		if _, err := say(context.Background(), "sleep:soon"); connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("Expected InvalidArgument, got %v", err)
+		}
+	})
+
+	t.Run("normal sentence", func(t *testing.T) {
+		if sentence, err := say(context.Background(), "I feel fine"); err != nil || sentence != "Tell me more about how you're feeling." {
+			t.Errorf("Unexpected answer %q, %v", sentence, err)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package elizaservice
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"connectrpc.com/connect"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Health implements the grpc.health.v1.Health service over Connect, gRPC and
+// gRPC-Web. Statuses can be changed at any time, e.g. to test how clients
+// react to a service going NOT_SERVING.
+type Health struct {
+	mu       sync.RWMutex
+	statuses map[string]healthpb.HealthCheckResponse_ServingStatus
+	// changed is closed and replaced whenever a status changes, waking watchers
+	changed chan struct{}
+}
+
+// NewHealth creates a health service that reports SERVING for the server as
+// a whole ("") and for each of the given services.
+func NewHealth(services ...string) *Health {
+	h := &Health{
+		statuses: map[string]healthpb.HealthCheckResponse_ServingStatus{"": healthpb.HealthCheckResponse_SERVING},
+		changed:  make(chan struct{}),
+	}
+	for _, service := range services {
+		h.statuses[service] = healthpb.HealthCheckResponse_SERVING
+	}
+	return h
+}
+
+// SetServingStatus sets the status reported for a service, "" being the
+// server as a whole.
+func (h *Health) SetServingStatus(service string, status healthpb.HealthCheckResponse_ServingStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.statuses[service] = status
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
+
+// status returns the status of a service, whether it is known, and a channel
+// closed on the next change.
+func (h *Health) status(service string) (healthpb.HealthCheckResponse_ServingStatus, bool, <-chan struct{}) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status, ok := h.statuses[service]
+	return status, ok, h.changed
+}
+
+// Handler returns the path and HTTP handler serving the health service.
+func (h *Health) Handler() (string, http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle("/grpc.health.v1.Health/Check", connect.NewUnaryHandler("/grpc.health.v1.Health/Check", h.Check))
+	mux.Handle("/grpc.health.v1.Health/Watch", connect.NewServerStreamHandler("/grpc.health.v1.Health/Watch", h.Watch))
+	return "/grpc.health.v1.Health/", mux
+}
+
+// Check handles the Check RPC. Unknown services fail with NOT_FOUND, as in
+// the gRPC health checking protocol.
+func (h *Health) Check(
+	ctx context.Context,
+	req *connect.Request[healthpb.HealthCheckRequest],
+) (*connect.Response[healthpb.HealthCheckResponse], error) {
+	status, ok, _ := h.status(req.Msg.GetService())
+	if !ok {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("unknown service %q", req.Msg.GetService()))
+	}
+	return connect.NewResponse(&healthpb.HealthCheckResponse{Status: status}), nil
+}
+
+// Watch handles the server streaming Watch RPC, sending the current status
+// and then every change until the client goes away. Unknown services are
+// reported as SERVICE_UNKNOWN.
+func (h *Health) Watch(
+	ctx context.Context,
+	req *connect.Request[healthpb.HealthCheckRequest],
+	stream *connect.ServerStream[healthpb.HealthCheckResponse],
+) error {
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		status, ok, changed := h.status(req.Msg.GetService())
+		if !ok {
+			status = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if status != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: status}); err != nil {
+				return err
+			}
+			last = status
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
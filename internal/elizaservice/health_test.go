@@ -0,0 +1,90 @@
+package elizaservice_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"github.com/opentdf/connectrpc-catalog/gen/connectrpc/eliza/v1/elizav1connect"
+	"github.com/opentdf/connectrpc-catalog/internal/elizaservice"
+	"github.com/opentdf/connectrpc-catalog/internal/invoker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// TestElizaService_Health tests health checks and watches against the
+// embedded server, including a switch to NOT_SERVING
+func TestElizaService_Health(t *testing.T) {
+	server := elizaservice.NewServer("50093")
+	go func() {
+		if err := server.Start(); err != nil && err.Error() != "http: Server closed" {
+			t.Logf("Server error: %v", err)
+		}
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Stop(ctx)
+	}()
+
+	// Wait for server to start
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := grpc.NewClient("localhost:50093", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	check := func(service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		return resp.GetStatus(), err
+	}
+
+	for _, service := range []string{"", elizav1connect.ElizaServiceName} {
+		if got, err := check(service); err != nil || got != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Check(%q): expected SERVING, got %v, %v", service, got, err)
+		}
+	}
+	if _, err := check("unknown.v1.Service"); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for an unknown service, got %v", err)
+	}
+
+	// Both transports find the health service when probing
+	probe := invoker.ProbeTransports(ctx, "localhost:50093", false, "")
+	for _, result := range probe.Results {
+		if !result.Supported {
+			t.Errorf("Expected %v to be supported, got %s", result.Transport, result.Detail)
+		}
+	}
+	if probe.Recommended != catalogv1.Transport_TRANSPORT_CONNECT {
+		t.Errorf("Expected Connect to be recommended, got %v", probe.Recommended)
+	}
+
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: elizav1connect.ElizaServiceName})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if resp, err := watch.Recv(); err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("Expected an initial SERVING, got %v, %v", resp, err)
+	}
+
+	server.Health().SetServingStatus(elizav1connect.ElizaServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	if resp, err := watch.Recv(); err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("Expected the watch to report NOT_SERVING, got %v, %v", resp, err)
+	}
+	if got, err := check(elizav1connect.ElizaServiceName); err != nil || got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("Expected NOT_SERVING, got %v, %v", got, err)
+	}
+	if got, _ := check(""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected the overall status to stay SERVING, got %v", got)
+	}
+}
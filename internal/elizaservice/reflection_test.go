@@ -0,0 +1,71 @@
+package elizaservice_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/opentdf/connectrpc-catalog/gen/connectrpc/eliza/v1/elizav1connect"
+	"github.com/opentdf/connectrpc-catalog/internal/elizaservice"
+	"github.com/opentdf/connectrpc-catalog/internal/loader"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+)
+
+// TestElizaService_Reflection tests that the reflection loader discovers
+// Eliza and that the v1 reflection service is served alongside v1alpha
+func TestElizaService_Reflection(t *testing.T) {
+	server := elizaservice.NewServer("50094")
+	go func() {
+		if err := server.Start(); err != nil && err.Error() != "http: Server closed" {
+			t.Logf("Server error: %v", err)
+		}
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Stop(ctx)
+	}()
+
+	// Wait for server to start
+	time.Sleep(100 * time.Millisecond)
+
+	// The loader speaks v1alpha
+	result, err := loader.LoadFromReflectionWithWarnings("localhost:50094", loader.ReflectionOptions{TimeoutSeconds: 5})
+	if err != nil {
+		t.Fatalf("LoadFromReflection failed: %v", err)
+	}
+	if len(result.Services) != 1 || result.Services[0] != elizav1connect.ElizaServiceName {
+		t.Errorf("Expected only %s, got %v", elizav1connect.ElizaServiceName, result.Services)
+	}
+	if len(result.Descriptors.GetFile()) == 0 {
+		t.Error("Expected descriptors for the discovered service")
+	}
+
+	conn, err := grpc.NewClient("localhost:50094", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := reflectionv1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		t.Fatalf("Failed to open v1 reflection stream: %v", err)
+	}
+	if err := stream.Send(&reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		t.Fatalf("Failed to send v1 request: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("v1 reflection failed: %v", err)
+	}
+	services := resp.GetListServicesResponse().GetService()
+	if len(services) != 1 || services[0].GetName() != elizav1connect.ElizaServiceName {
+		t.Errorf("Expected v1 to list %s, got %v", elizav1connect.ElizaServiceName, services)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 
+	"connectrpc.com/grpcreflect"
 	"github.com/opentdf/connectrpc-catalog/gen/connectrpc/eliza/v1/elizav1connect"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
@@ -14,6 +15,7 @@ import (
 type Server struct {
 	httpServer *http.Server
 	port       string
+	health     *Health
 }
 
 // NewServer creates a new Eliza server on the specified port.
@@ -26,6 +28,16 @@ func NewServer(port string) *Server {
 	path, elizaHandler := elizav1connect.NewElizaServiceHandler(handler)
 	mux.Handle(path, elizaHandler)
 
+	// Register gRPC server reflection, both v1 and the older v1alpha, so
+	// reflection clients can discover the service without its protos
+	reflector := grpcreflect.NewStaticReflector(elizav1connect.ElizaServiceName)
+	mux.Handle(grpcreflect.NewHandlerV1(reflector))
+	mux.Handle(grpcreflect.NewHandlerV1Alpha(reflector))
+
+	// Register gRPC health checking, reporting SERVING until changed
+	health := NewHealth(elizav1connect.ElizaServiceName)
+	mux.Handle(health.Handler())
+
 	// Add health check endpoint
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -36,7 +48,8 @@ func NewServer(port string) *Server {
 	h2cHandler := h2c.NewHandler(mux, &http2.Server{})
 
 	return &Server{
-		port: port,
+		port:   port,
+		health: health,
 		httpServer: &http.Server{
 			Addr:    ":" + port,
 			Handler: h2cHandler,
@@ -48,6 +61,8 @@ func NewServer(port string) *Server {
 func (s *Server) Start() error {
 	log.Printf("Eliza service listening on port %s", s.port)
 	log.Printf("Supported protocols: Connect (HTTP/JSON), gRPC (HTTP/2), gRPC-Web")
+	log.Printf("gRPC reflection: v1 and v1alpha")
+	log.Printf("gRPC health: grpc.health.v1.Health")
 	log.Printf("Health check: http://localhost:%s/health", s.port)
 	return s.httpServer.ListenAndServe()
 }
@@ -57,6 +72,12 @@ func (s *Server) Stop(ctx context.Context) error {
 	return s.httpServer.Shutdown(ctx)
 }
 
+// Health returns the server's health service, whose statuses tests can
+// change.
+func (s *Server) Health() *Health {
+	return s.health
+}
+
 // Addr returns the server address.
 func (s *Server) Addr() string {
 	return s.httpServer.Addr
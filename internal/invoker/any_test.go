@@ -0,0 +1,144 @@
+package invoker
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// anyTestProtos defines a service whose response embeds an Any, and a payload
+// type in a file the service file does not import
+var anyTestProtos = map[string]string{
+	"wrap/v1/wrap.proto": `syntax = "proto3";
+package wrap.v1;
+import "google/protobuf/any.proto";
+service WrapService { rpc Wrap(WrapRequest) returns (WrapResponse); }
+message WrapRequest {}
+message WrapResponse { google.protobuf.Any detail = 1; }
+`,
+	"payload/v1/payload.proto": `syntax = "proto3";
+package payload.v1;
+message Payload { string text = 1; }
+`,
+}
+
+// startAnyTestServer serves WrapService, answering with an Any-wrapped Payload
+func startAnyTestServer(t *testing.T, wrapFile, payloadFile *desc.FileDescriptor) string {
+	t.Helper()
+
+	method := wrapFile.FindService("wrap.v1.WrapService").FindMethodByName("Wrap")
+	payloadDesc := payloadFile.FindMessage("payload.v1.Payload")
+
+	payload := dynamic.NewMessage(payloadDesc)
+	payload.SetFieldByName("text", "hello")
+	payloadBytes, err := payload.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to marshal payload: %v", err)
+	}
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer()
+	grpcServer.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "wrap.v1.WrapService",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Wrap",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := dynamic.NewMessage(method.GetInputType())
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				out := dynamic.NewMessage(method.GetOutputType())
+				out.SetFieldByName("detail", &anypb.Any{
+					TypeUrl: "type.googleapis.com/payload.v1.Payload",
+					Value:   payloadBytes,
+				})
+				return out, nil
+			},
+		}},
+	}, struct{}{})
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	return lis.Addr().String()
+}
+
+// TestInvokeGRPC_AnyResolver tests that Any payloads are expanded using the provided resolver
+func TestInvokeGRPC_AnyResolver(t *testing.T) {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(anyTestProtos)}
+	fds, err := parser.ParseFiles("wrap/v1/wrap.proto", "payload/v1/payload.proto")
+	if err != nil {
+		t.Fatalf("Failed to parse test protos: %v", err)
+	}
+	wrapFile, payloadFile := fds[0], fds[1]
+
+	endpoint := startAnyTestServer(t, wrapFile, payloadFile)
+
+	inv := New()
+	defer inv.Close()
+
+	req := InvokeRequest{
+		Endpoint:       endpoint,
+		ServiceName:    "wrap.v1.WrapService",
+		MethodName:     "Wrap",
+		RequestJSON:    json.RawMessage(`{}`),
+		TimeoutSeconds: 5,
+		MethodDesc:     wrapFile.FindService("wrap.v1.WrapService").FindMethodByName("Wrap"),
+		Transport:      catalogv1.Transport_TRANSPORT_GRPC,
+	}
+
+	// Without a resolver the payload type is not visible from wrap.proto, so
+	// the Any is returned raw
+	resp, err := inv.InvokeUnary(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Expected unresolved Any to fall back to its raw form, got error: %s", resp.Error)
+	}
+	var raw struct {
+		Detail map[string]interface{} `json:"detail"`
+	}
+	if err := json.Unmarshal(resp.ResponseJSON, &raw); err != nil {
+		t.Fatalf("Invalid response JSON: %v", err)
+	}
+	if raw.Detail["@type"] != "type.googleapis.com/payload.v1.Payload" || raw.Detail["value"] != "CgVoZWxsbw==" {
+		t.Errorf("Expected raw Any type URL and value, got %s", resp.ResponseJSON)
+	}
+
+	req.AnyResolver = dynamic.AnyResolver(nil, wrapFile, payloadFile)
+	resp, err = inv.InvokeUnary(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Error)
+	}
+
+	var got struct {
+		Detail map[string]interface{} `json:"detail"`
+	}
+	if err := json.Unmarshal(resp.ResponseJSON, &got); err != nil {
+		t.Fatalf("Invalid response JSON: %v", err)
+	}
+	if got.Detail["@type"] != "type.googleapis.com/payload.v1.Payload" || got.Detail["text"] != "hello" {
+		t.Errorf("Expected expanded Any payload, got %s", resp.ResponseJSON)
+	}
+	if strings.Contains(string(resp.ResponseJSON), `"value"`) {
+		t.Errorf("Expected no raw Any value, got %s", resp.ResponseJSON)
+	}
+}
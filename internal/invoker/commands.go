@@ -0,0 +1,81 @@
+package invoker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+)
+
+// CurlCommand renders a ready-to-paste curl command that performs the
+// invocation over the Connect protocol. The command always uses the JSON
+// encoding, as a binary body cannot be pasted into a shell.
+func CurlCommand(req InvokeRequest) (string, error) {
+	connectReq := req
+	connectReq.Transport = catalogv1.Transport_TRANSPORT_CONNECT
+	connectReq.Encoding = catalogv1.ConnectEncoding_CONNECT_ENCODING_JSON
+	description, err := Describe(connectReq)
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{"curl"}
+	if req.ForceHTTP1 {
+		parts = append(parts, "--http1.1")
+	}
+	parts = append(parts, "-X", description.HTTPMethod, shellQuote(description.URL))
+	for _, k := range sortedKeys(description.Headers) {
+		parts = append(parts, "-H", shellQuote(k+": "+description.Headers[k]))
+	}
+	if !req.UseGET {
+		parts = append(parts, "-d", shellQuote(string(description.RequestJSON)))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// GrpcurlCommand renders a ready-to-paste grpcurl command that performs the
+// invocation over gRPC
+func GrpcurlCommand(req InvokeRequest) (string, error) {
+	requestJSON, _, err := canonicalRequestJSON(req)
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{"grpcurl"}
+	if !req.UseTLS {
+		parts = append(parts, "-plaintext")
+	}
+	if req.ServerName != "" {
+		parts = append(parts, "-servername", shellQuote(req.ServerName))
+	}
+	if req.Authority != "" {
+		parts = append(parts, "-authority", shellQuote(req.Authority))
+	}
+	for _, k := range sortedKeys(req.Metadata) {
+		parts = append(parts, "-H", shellQuote(strings.ToLower(k)+": "+req.Metadata[k]))
+	}
+	parts = append(parts,
+		"-d", shellQuote(string(requestJSON)),
+		shellQuote(req.Endpoint),
+		fmt.Sprintf("%s/%s", req.ServiceName, req.MethodName),
+	)
+
+	return strings.Join(parts, " "), nil
+}
+
+// shellQuote wraps s in single quotes for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// sortedKeys returns the keys of m in lexical order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
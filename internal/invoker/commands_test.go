@@ -0,0 +1,112 @@
+package invoker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestCurlCommand tests rendering a Connect curl command
+func TestCurlCommand(t *testing.T) {
+	cmd, err := CurlCommand(InvokeRequest{
+		Endpoint:    "api.example.com",
+		ServiceName: "pkg.v1.Service",
+		MethodName:  "Say",
+		RequestJSON: json.RawMessage(`{"sentence": "it's fine"}`),
+		UseTLS:      true,
+		Metadata:    map[string]string{"Authorization": "Bearer token"},
+	})
+	if err != nil {
+		t.Fatalf("CurlCommand failed: %v", err)
+	}
+
+	want := `curl -X POST 'https://api.example.com/pkg.v1.Service/Say' ` +
+		`-H 'Authorization: Bearer token' -H 'Connect-Protocol-Version: 1' -H 'Content-Type: application/json' ` +
+		`-d '{"sentence":"it'\''s fine"}'`
+	if cmd != want {
+		t.Errorf("Unexpected command:\n got: %s\nwant: %s", cmd, want)
+	}
+}
+
+// TestGrpcurlCommand tests rendering a grpcurl command
+func TestGrpcurlCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		req  InvokeRequest
+		want string
+	}{
+		{
+			name: "plaintext with metadata",
+			req: InvokeRequest{
+				Endpoint:    "localhost:50051",
+				ServiceName: "grpc.health.v1.Health",
+				MethodName:  "Check",
+				Metadata:    map[string]string{"X-Trace": "abc"},
+			},
+			want: `grpcurl -plaintext -H 'x-trace: abc' -d '{}' 'localhost:50051' grpc.health.v1.Health/Check`,
+		},
+		{
+			name: "TLS with overrides",
+			req: InvokeRequest{
+				Endpoint:    "10.0.0.1:443",
+				ServiceName: "grpc.health.v1.Health",
+				MethodName:  "Check",
+				RequestJSON: json.RawMessage(`{"service": "x"}`),
+				UseTLS:      true,
+				ServerName:  "api.internal",
+				Authority:   "api.example.com",
+			},
+			want: `grpcurl -servername 'api.internal' -authority 'api.example.com' -d '{"service":"x"}' '10.0.0.1:443' grpc.health.v1.Health/Check`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := GrpcurlCommand(tt.req)
+			if err != nil {
+				t.Fatalf("GrpcurlCommand failed: %v", err)
+			}
+			if cmd != tt.want {
+				t.Errorf("Unexpected command:\n got: %s\nwant: %s", cmd, tt.want)
+			}
+		})
+	}
+}
+
+// TestCurlCommand_GET tests rendering a Connect GET curl command
+func TestCurlCommand_GET(t *testing.T) {
+	cmd, err := CurlCommand(InvokeRequest{
+		Endpoint:    "localhost:8080",
+		ServiceName: "pkg.v1.Service",
+		MethodName:  "Get",
+		RequestJSON: json.RawMessage(`{}`),
+		UseGET:      true,
+	})
+	if err != nil {
+		t.Fatalf("CurlCommand failed: %v", err)
+	}
+
+	want := `curl -X GET 'http://localhost:8080/pkg.v1.Service/Get?base64=1&connect=v1&encoding=json&message=e30'`
+	if cmd != want {
+		t.Errorf("Unexpected command:\n got: %s\nwant: %s", cmd, want)
+	}
+}
+
+// TestCurlCommand_ForceHTTP1 tests that HTTP/1.1-only calls pin curl to HTTP/1.1
+func TestCurlCommand_ForceHTTP1(t *testing.T) {
+	cmd, err := CurlCommand(InvokeRequest{
+		Endpoint:    "api.example.com",
+		ServiceName: "pkg.v1.Service",
+		MethodName:  "Get",
+		UseTLS:      true,
+		UseGET:      true,
+		ForceHTTP1:  true,
+	})
+	if err != nil {
+		t.Fatalf("CurlCommand failed: %v", err)
+	}
+
+	if !strings.HasPrefix(cmd, "curl --http1.1 -X GET 'https://api.example.com/") {
+		t.Errorf("Expected curl to be pinned to HTTP/1.1, got: %s", cmd)
+	}
+}
@@ -0,0 +1,215 @@
+package invoker
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// ErrUnknownCredentials is returned when a call names a credential provider
+// that is not registered
+var ErrUnknownCredentials = errors.New("unknown credential provider")
+
+// CredentialTarget describes the call a credential provider is applied to
+type CredentialTarget struct {
+	Endpoint    string
+	ServiceName string
+	MethodName  string
+	UseTLS      bool
+	ServerName  string
+}
+
+// GRPCOptions holds the parts of a gRPC call a credential provider may adjust
+type GRPCOptions struct {
+	// TLSConfig is used to dial the connection, or nil for plaintext calls.
+	// Connections are pooled per provider, so changes only take effect when
+	// a new connection is dialed.
+	TLSConfig *tls.Config
+	// Metadata is sent with the call
+	Metadata metadata.MD
+}
+
+// CredentialProvider attaches credentials to outgoing calls. Providers are
+// registered with an invoker under a name and selected per call through
+// InvokeRequest.Credentials; new authentication schemes plug in by
+// implementing this interface. Implementations must be safe for concurrent
+// use.
+type CredentialProvider interface {
+	// ApplyToGRPC adds credentials to a gRPC call
+	ApplyToGRPC(ctx context.Context, target CredentialTarget, opts *GRPCOptions) error
+	// ApplyToHTTP adds credentials to a Connect call. tlsConfig configures
+	// the request's transport and is nil for plaintext calls.
+	ApplyToHTTP(ctx context.Context, target CredentialTarget, req *http.Request, tlsConfig *tls.Config) error
+}
+
+// BearerTokenCredentials sends a static bearer token in the authorization
+// header of every call
+type BearerTokenCredentials struct {
+	Token string
+}
+
+// ApplyToGRPC implements CredentialProvider
+func (c BearerTokenCredentials) ApplyToGRPC(_ context.Context, _ CredentialTarget, opts *GRPCOptions) error {
+	opts.Metadata.Set("authorization", "Bearer "+c.Token)
+	return nil
+}
+
+// ApplyToHTTP implements CredentialProvider
+func (c BearerTokenCredentials) ApplyToHTTP(_ context.Context, _ CredentialTarget, req *http.Request, _ *tls.Config) error {
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	return nil
+}
+
+// MTLSCredentials presents a client certificate during the TLS handshake.
+// Calls using them must use TLS.
+type MTLSCredentials struct {
+	Certificate tls.Certificate
+}
+
+// NewMTLSCredentials parses a PEM-encoded client certificate chain and
+// private key
+func NewMTLSCredentials(certPEM, keyPEM []byte) (*MTLSCredentials, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	return &MTLSCredentials{Certificate: cert}, nil
+}
+
+// ApplyToGRPC implements CredentialProvider
+func (c *MTLSCredentials) ApplyToGRPC(_ context.Context, target CredentialTarget, opts *GRPCOptions) error {
+	return c.apply(target, opts.TLSConfig)
+}
+
+// ApplyToHTTP implements CredentialProvider
+func (c *MTLSCredentials) ApplyToHTTP(_ context.Context, target CredentialTarget, _ *http.Request, tlsConfig *tls.Config) error {
+	return c.apply(target, tlsConfig)
+}
+
+// apply adds the client certificate to a TLS configuration
+func (c *MTLSCredentials) apply(target CredentialTarget, tlsConfig *tls.Config) error {
+	if tlsConfig == nil {
+		return fmt.Errorf("mTLS credentials require TLS to %s", target.Endpoint)
+	}
+	tlsConfig.Certificates = append(tlsConfig.Certificates, c.Certificate)
+	return nil
+}
+
+// SetCredentialProvider registers a provider under name, replacing any
+// provider of the same name; a nil provider removes it. Pooled connections
+// dialed with the previous provider are closed.
+func (inv *Invoker) SetCredentialProvider(name string, provider CredentialProvider) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	for key, connMeta := range inv.connections {
+		if connMeta.credentials == name {
+			_ = connMeta.conn.Close()
+			delete(inv.connections, key)
+		}
+	}
+
+	if provider == nil {
+		delete(inv.credentialProviders, name)
+		return
+	}
+	if inv.credentialProviders == nil {
+		inv.credentialProviders = make(map[string]CredentialProvider)
+	}
+	inv.credentialProviders[name] = provider
+}
+
+// CredentialProviders returns the names of the registered providers, sorted
+func (inv *Invoker) CredentialProviders() []string {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	names := make([]string, 0, len(inv.credentialProviders))
+	for name := range inv.credentialProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// credentialProvider looks up a registered provider by name
+func (inv *Invoker) credentialProvider(name string) (CredentialProvider, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	provider, ok := inv.credentialProviders[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCredentials, name)
+	}
+	return provider, nil
+}
+
+// grpcOptions builds the dial TLS configuration and metadata for a gRPC
+// call, applying its credential provider if one is named
+func (inv *Invoker) grpcOptions(ctx context.Context, req InvokeRequest) (*GRPCOptions, error) {
+	opts := &GRPCOptions{Metadata: metadata.New(req.Metadata)}
+	if req.UseTLS {
+		opts.TLSConfig = inv.tlsConfig(req.ServerName)
+	}
+	if req.Credentials == "" {
+		return opts, nil
+	}
+
+	provider, err := inv.credentialProvider(req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.ApplyToGRPC(ctx, credentialTarget(req), opts); err != nil {
+		return nil, fmt.Errorf("credential provider %s failed: %w", req.Credentials, err)
+	}
+	return opts, nil
+}
+
+// applyHTTPCredentials applies a Connect call's credential provider, if one
+// is named, and returns the TLS configuration for its transport (nil for
+// plaintext calls)
+func (inv *Invoker) applyHTTPCredentials(ctx context.Context, req InvokeRequest, httpReq *http.Request) (*tls.Config, error) {
+	var tlsConfig *tls.Config
+	if req.UseTLS {
+		tlsConfig = inv.tlsConfig(req.ServerName)
+	}
+	if req.Credentials == "" {
+		return tlsConfig, nil
+	}
+
+	provider, err := inv.credentialProvider(req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.ApplyToHTTP(ctx, credentialTarget(req), httpReq, tlsConfig); err != nil {
+		return nil, fmt.Errorf("credential provider %s failed: %w", req.Credentials, err)
+	}
+	return tlsConfig, nil
+}
+
+// credentialTarget describes a call to its credential provider
+func credentialTarget(req InvokeRequest) CredentialTarget {
+	return CredentialTarget{
+		Endpoint:    req.Endpoint,
+		ServiceName: req.ServiceName,
+		MethodName:  req.MethodName,
+		UseTLS:      req.UseTLS,
+		ServerName:  req.ServerName,
+	}
+}
+
+// credentialErrorKind classifies a failure to apply credentials. Naming an
+// unregistered provider is a bad request; a provider failing to produce
+// credentials prevents connecting.
+func credentialErrorKind(err error) catalogv1.ErrorKind {
+	if errors.Is(err, ErrUnknownCredentials) {
+		return catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST
+	}
+	return catalogv1.ErrorKind_ERROR_KIND_CONNECTION
+}
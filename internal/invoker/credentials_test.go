@@ -0,0 +1,253 @@
+package invoker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// TestBearerTokenCredentials tests that a named bearer token provider
+// authenticates Connect and gRPC calls
+func TestBearerTokenCredentials(t *testing.T) {
+	var gotConnect string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotConnect = r.Header.Get("Authorization")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var gotGRPC string
+	endpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md["authorization"]) > 0 {
+			gotGRPC = md["authorization"][0]
+		}
+		return handler(ctx, req)
+	})
+
+	inv := New()
+	defer inv.Close()
+	inv.SetCredentialProvider("token", BearerTokenCredentials{Token: "secret"})
+
+	resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:    strings.TrimPrefix(server.URL, "http://"),
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		RequestJSON: json.RawMessage(`{}`),
+		Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+		Credentials: "token",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Error)
+	}
+	if gotConnect != "Bearer secret" {
+		t.Errorf("Expected Connect authorization 'Bearer secret', got %q", gotConnect)
+	}
+
+	resp, err = inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:       endpoint,
+		ServiceName:    "grpc.health.v1.Health",
+		MethodName:     "Check",
+		RequestJSON:    json.RawMessage(`{}`),
+		TimeoutSeconds: 5,
+		MethodDesc:     healthCheckMethodDescriptor(t),
+		Transport:      catalogv1.Transport_TRANSPORT_GRPC,
+		Credentials:    "token",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Error)
+	}
+	if gotGRPC != "Bearer secret" {
+		t.Errorf("Expected gRPC authorization 'Bearer secret', got %q", gotGRPC)
+	}
+
+	if names := inv.CredentialProviders(); len(names) != 1 || names[0] != "token" {
+		t.Errorf("Expected providers [token], got %v", names)
+	}
+}
+
+// TestUnknownCredentials tests that naming an unregistered provider fails the
+// call without contacting the endpoint
+func TestUnknownCredentials(t *testing.T) {
+	inv := New()
+	defer inv.Close()
+
+	inv.SetCredentialProvider("token", BearerTokenCredentials{Token: "secret"})
+	inv.SetCredentialProvider("token", nil)
+
+	for _, transport := range []catalogv1.Transport{catalogv1.Transport_TRANSPORT_CONNECT, catalogv1.Transport_TRANSPORT_GRPC} {
+		resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+			Endpoint:    closedEndpoint(t),
+			ServiceName: "grpc.health.v1.Health",
+			MethodName:  "Check",
+			RequestJSON: json.RawMessage(`{}`),
+			MethodDesc:  healthCheckMethodDescriptor(t),
+			Transport:   transport,
+			Credentials: "token",
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if resp.Success || !strings.Contains(resp.Error, ErrUnknownCredentials.Error()) {
+			t.Errorf("%v: expected unknown credential provider error, got %q", transport, resp.Error)
+		}
+		if resp.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST {
+			t.Errorf("%v: expected ERROR_KIND_INVALID_REQUEST, got %v", transport, resp.ErrorKind)
+		}
+	}
+}
+
+// TestMTLSCredentials tests presenting a client certificate to a server that
+// requires one
+func TestMTLSCredentials(t *testing.T) {
+	certPEM, keyPEM := generateClientCertificate(t)
+	creds, err := NewMTLSCredentials(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("NewMTLSCredentials failed: %v", err)
+	}
+
+	var gotSubject string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) > 0 {
+			gotSubject = r.TLS.PeerCertificates[0].Subject.CommonName
+		}
+		w.Write([]byte(`{}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	inv := New()
+	defer inv.Close()
+	inv.rootCAs = roots
+	inv.SetCredentialProvider("client", creds)
+
+	invoke := func(useTLS bool, credentials string) *InvokeResponse {
+		t.Helper()
+		resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+			Endpoint:    strings.TrimPrefix(server.URL, "https://"),
+			ServiceName: "test.v1.TestService",
+			MethodName:  "TestMethod",
+			RequestJSON: json.RawMessage(`{}`),
+			UseTLS:      useTLS,
+			Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+			Credentials: credentials,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return resp
+	}
+
+	if resp := invoke(true, "client"); !resp.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Error)
+	}
+	if gotSubject != "catalog-client" {
+		t.Errorf("Expected client certificate 'catalog-client', got %q", gotSubject)
+	}
+
+	if resp := invoke(true, ""); resp.Success {
+		t.Error("Expected handshake failure without a client certificate")
+	}
+
+	resp := invoke(false, "client")
+	if resp.Success || !strings.Contains(resp.Error, "require TLS") {
+		t.Errorf("Expected TLS required error, got %q", resp.Error)
+	}
+	if resp.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_CONNECTION {
+		t.Errorf("Expected ERROR_KIND_CONNECTION, got %v", resp.ErrorKind)
+	}
+
+	if _, err := NewMTLSCredentials([]byte("not a certificate"), keyPEM); err == nil {
+		t.Error("Expected error for invalid certificate")
+	}
+}
+
+// TestSetCredentialProvider_ClosesConnections tests that replacing a provider
+// drops connections dialed with it
+func TestSetCredentialProvider_ClosesConnections(t *testing.T) {
+	endpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	})
+
+	inv := New()
+	defer inv.Close()
+	inv.SetCredentialProvider("token", BearerTokenCredentials{Token: "secret"})
+
+	for _, credentials := range []string{"", "token"} {
+		resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+			Endpoint:    endpoint,
+			ServiceName: "grpc.health.v1.Health",
+			MethodName:  "Check",
+			RequestJSON: json.RawMessage(`{}`),
+			MethodDesc:  healthCheckMethodDescriptor(t),
+			Transport:   catalogv1.Transport_TRANSPORT_GRPC,
+			Credentials: credentials,
+		})
+		if err != nil || !resp.Success {
+			t.Fatalf("Invocation with credentials %q failed: %v %v", credentials, err, resp)
+		}
+	}
+	if got := inv.GetConnectionStats().TotalConnections; got != 2 {
+		t.Fatalf("Expected separate pooled connections per provider, got %d", got)
+	}
+
+	inv.SetCredentialProvider("token", BearerTokenCredentials{Token: "rotated"})
+	if got := inv.GetConnectionStats().TotalConnections; got != 1 {
+		t.Errorf("Expected the credentialed connection to be closed, got %d connections", got)
+	}
+}
+
+// generateClientCertificate returns a PEM-encoded self-signed client
+// certificate and key
+func generateClientCertificate(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "catalog-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to encode key: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM
+}
@@ -0,0 +1,112 @@
+package invoker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// InvocationDescription describes the request InvokeUnary would send
+type InvocationDescription struct {
+	Transport   catalogv1.Transport
+	HTTPMethod  string // Connect transport only
+	URL         string // Connect transport only
+	FullMethod  string
+	Headers     map[string]string
+	RequestJSON json.RawMessage
+	// RequestBytes is the size of the payload as it would be sent, measured
+	// like InvokeResponse.RequestBytes
+	RequestBytes int64
+}
+
+// Describe resolves the transport, target, headers and normalized payload for
+// an invocation without sending anything
+func Describe(req InvokeRequest) (*InvocationDescription, error) {
+	requestJSON, msg, err := canonicalRequestJSON(req)
+	if err != nil {
+		return nil, err
+	}
+
+	description := &InvocationDescription{
+		Transport:   resolveTransport(req.Transport),
+		FullMethod:  fmt.Sprintf("/%s/%s", req.ServiceName, req.MethodName),
+		Headers:     make(map[string]string),
+		RequestJSON: requestJSON,
+	}
+
+	if description.Transport == catalogv1.Transport_TRANSPORT_GRPC {
+		// gRPC metadata keys are always sent lowercased
+		description.Headers["content-type"] = "application/grpc"
+		for k, v := range req.Metadata {
+			description.Headers[strings.ToLower(k)] = v
+		}
+		authority := req.Endpoint
+		if req.Authority != "" {
+			authority = req.Authority
+		}
+		description.Headers[":authority"] = authority
+		if msg != nil {
+			description.RequestBytes = wireSize(msg)
+		}
+		return description, nil
+	}
+
+	// Encode the normalized payload into GET URLs, as InvokeUnary would send it
+	connectReq := req
+	connectReq.RequestJSON = requestJSON
+	body := []byte(requestJSON)
+	description.RequestBytes = int64(len(NormalizeRequestJSON(req.RequestJSON)))
+	if req.Encoding == catalogv1.ConnectEncoding_CONNECT_ENCODING_PROTO {
+		if msg == nil {
+			return nil, fmt.Errorf("method descriptor is required for the proto encoding")
+		}
+		if body, err = msg.Marshal(); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		description.RequestBytes = int64(len(body))
+	}
+	description.HTTPMethod, description.URL = connectRequestTarget(connectReq, body)
+	header := make(http.Header)
+	setConnectHeaders(header, req)
+	for k := range header {
+		description.Headers[k] = header.Get(k)
+	}
+	if req.Authority != "" {
+		description.Headers["Host"] = req.Authority
+	}
+
+	return description, nil
+}
+
+// canonicalRequestJSON parses the request payload against the method's input
+// type when available, returning the parsed message too, or compacts it
+// otherwise
+func canonicalRequestJSON(req InvokeRequest) (json.RawMessage, *dynamic.Message, error) {
+	requestJSON := NormalizeRequestJSON(req.RequestJSON)
+
+	if req.MethodDesc != nil {
+		msg := dynamic.NewMessage(req.MethodDesc.GetInputType())
+		unmarshaler := &jsonpb.Unmarshaler{AnyResolver: req.AnyResolver}
+		if err := msg.UnmarshalJSONPB(unmarshaler, requestJSON); err != nil {
+			return nil, nil, fmt.Errorf("invalid request JSON: %w", err)
+		}
+		normalized, err := msg.MarshalJSONPB(&jsonpb.Marshaler{AnyResolver: req.AnyResolver})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		return normalized, msg, nil
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, requestJSON); err != nil {
+		return nil, nil, fmt.Errorf("invalid request JSON: %w", err)
+	}
+	return buf.Bytes(), nil, nil
+}
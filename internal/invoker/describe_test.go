@@ -0,0 +1,125 @@
+package invoker
+
+import (
+	"encoding/json"
+	"testing"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+)
+
+// TestDescribe_Connect tests the description of a Connect invocation
+func TestDescribe_Connect(t *testing.T) {
+	description, err := Describe(InvokeRequest{
+		Endpoint:    "localhost:8080",
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		RequestJSON: json.RawMessage(`{ "name" : "test" }`),
+		Metadata:    map[string]string{"Authorization": "Bearer token"},
+		MethodDesc:  createTestMethodDescriptor(),
+		Transport:   catalogv1.Transport_TRANSPORT_GRPC_WEB,
+		Authority:   "api.example.com",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if description.Transport != catalogv1.Transport_TRANSPORT_CONNECT {
+		t.Errorf("Expected gRPC-Web to resolve to Connect, got %v", description.Transport)
+	}
+	if description.HTTPMethod != "POST" {
+		t.Errorf("Expected POST, got %s", description.HTTPMethod)
+	}
+	if description.URL != "http://localhost:8080/test.v1.TestService/TestMethod" {
+		t.Errorf("Unexpected URL: %s", description.URL)
+	}
+	if description.FullMethod != "/test.v1.TestService/TestMethod" {
+		t.Errorf("Unexpected full method: %s", description.FullMethod)
+	}
+
+	expectedHeaders := map[string]string{
+		"Content-Type":             "application/json",
+		"Connect-Protocol-Version": "1",
+		"Authorization":            "Bearer token",
+		"Host":                     "api.example.com",
+	}
+	for k, v := range expectedHeaders {
+		if description.Headers[k] != v {
+			t.Errorf("Expected header %s=%s, got %q", k, v, description.Headers[k])
+		}
+	}
+
+	if string(description.RequestJSON) != `{"name":"test"}` {
+		t.Errorf("Unexpected normalized JSON: %s", description.RequestJSON)
+	}
+	// Connect sends the request JSON as given
+	if description.RequestBytes != int64(len(`{ "name" : "test" }`)) {
+		t.Errorf("Unexpected request bytes: %d", description.RequestBytes)
+	}
+}
+
+// TestDescribe_ConnectGET tests that GET descriptions encode the normalized payload in the URL
+func TestDescribe_ConnectGET(t *testing.T) {
+	description, err := Describe(InvokeRequest{
+		Endpoint:    "localhost:8080",
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		RequestJSON: json.RawMessage(`{ "name" : "test" }`),
+		MethodDesc:  createTestMethodDescriptor(),
+		UseGET:      true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if description.HTTPMethod != "GET" {
+		t.Errorf("Expected GET, got %s", description.HTTPMethod)
+	}
+	// base64url of {"name":"test"}
+	want := "http://localhost:8080/test.v1.TestService/TestMethod?base64=1&connect=v1&encoding=json&message=eyJuYW1lIjoidGVzdCJ9"
+	if description.URL != want {
+		t.Errorf("Unexpected URL: %s", description.URL)
+	}
+	if _, ok := description.Headers["Content-Type"]; ok {
+		t.Error("Expected no Content-Type header for GET")
+	}
+}
+
+// TestDescribe_GRPC tests the description of a gRPC invocation
+func TestDescribe_GRPC(t *testing.T) {
+	description, err := Describe(InvokeRequest{
+		Endpoint:    "localhost:9090",
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		RequestJSON: json.RawMessage(`{}`),
+		Metadata:    map[string]string{"X-Tenant": "acme"},
+		MethodDesc:  createTestMethodDescriptor(),
+		Transport:   catalogv1.Transport_TRANSPORT_GRPC,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if description.URL != "" {
+		t.Errorf("Expected no URL for gRPC, got %s", description.URL)
+	}
+	if description.Headers["x-tenant"] != "acme" {
+		t.Errorf("Expected lowercased metadata key, got: %v", description.Headers)
+	}
+	if description.Headers[":authority"] != "localhost:9090" {
+		t.Errorf("Expected :authority to default to endpoint, got %q", description.Headers[":authority"])
+	}
+}
+
+// TestDescribe_InvalidJSON tests that payloads not matching the input type are rejected
+func TestDescribe_InvalidJSON(t *testing.T) {
+	_, err := Describe(InvokeRequest{
+		Endpoint:    "localhost:8080",
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		RequestJSON: json.RawMessage(`{"unknown": 1}`),
+		MethodDesc:  createTestMethodDescriptor(),
+	})
+	if err == nil {
+		t.Fatal("Expected error for unknown field, got nil")
+	}
+}
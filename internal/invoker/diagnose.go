@@ -0,0 +1,126 @@
+package invoker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+)
+
+// StageResult reports the outcome of one connectivity stage
+type StageResult struct {
+	Stage    catalogv1.ConnectivityStage
+	OK       bool
+	Detail   string
+	Duration time.Duration
+}
+
+// EndpointDiagnosis reports how far a connection to an endpoint gets. Stages
+// run in order and stop at the first failure, so only the last stage can
+// have failed.
+type EndpointDiagnosis struct {
+	Stages []StageResult
+	// FailedStage is the stage that failed, or unspecified when all passed
+	FailedStage catalogv1.ConnectivityStage
+}
+
+// Reachable reports whether every stage passed
+func (d *EndpointDiagnosis) Reachable() bool {
+	return d.FailedStage == catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_UNSPECIFIED
+}
+
+// DiagnoseEndpoint connects to endpoint stage by stage: DNS lookup, TCP
+// connect, TLS handshake when useTLS is set, then a health check RPC over the
+// given transport. Unlike a failed call, the result tells which layer broke.
+// The context bounds the whole diagnosis.
+func DiagnoseEndpoint(ctx context.Context, endpoint string, useTLS bool, serverName string, transport catalogv1.Transport) *EndpointDiagnosis {
+	return diagnoseEndpoint(ctx, endpoint, useTLS, serverName, transport, nil)
+}
+
+// diagnoseEndpoint implements DiagnoseEndpoint, verifying TLS against roots,
+// or the system roots when nil
+func diagnoseEndpoint(ctx context.Context, endpoint string, useTLS bool, serverName string, transport catalogv1.Transport, roots *x509.CertPool) *EndpointDiagnosis {
+	diagnosis := &EndpointDiagnosis{}
+	run := func(stage catalogv1.ConnectivityStage, check func() (string, error)) bool {
+		start := time.Now()
+		detail, err := check()
+		result := StageResult{Stage: stage, OK: err == nil, Detail: detail, Duration: time.Since(start)}
+		if err != nil {
+			result.Detail = err.Error()
+			diagnosis.FailedStage = stage
+		}
+		diagnosis.Stages = append(diagnosis.Stages, result)
+		return err == nil
+	}
+
+	host, port, splitErr := net.SplitHostPort(endpoint)
+	var addrs []string
+	if !run(catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_DNS, func() (string, error) {
+		if splitErr != nil {
+			return "", fmt.Errorf("invalid endpoint %q: %v", endpoint, splitErr)
+		}
+		if net.ParseIP(host) != nil {
+			addrs = []string{host}
+			return "IP address, no lookup needed", nil
+		}
+		var err error
+		if addrs, err = net.DefaultResolver.LookupHost(ctx, host); err != nil {
+			return "", fmt.Errorf("DNS lookup failed: %w", err)
+		}
+		return fmt.Sprintf("resolved %s to %s", host, strings.Join(addrs, ", ")), nil
+	}) {
+		return diagnosis
+	}
+
+	var conn net.Conn
+	if !run(catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_TCP, func() (string, error) {
+		var dialer net.Dialer
+		var err error
+		for _, addr := range addrs {
+			if conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(addr, port)); err == nil {
+				return "connected to " + conn.RemoteAddr().String(), nil
+			}
+		}
+		return "", fmt.Errorf("TCP connect failed: %w", err)
+	}) {
+		return diagnosis
+	}
+	defer conn.Close()
+
+	if useTLS && !run(catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_TLS, func() (string, error) {
+		tlsConfig := newTLSConfig(serverName)
+		tlsConfig.RootCAs = roots
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = host
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return "", fmt.Errorf("TLS handshake failed: %w", err)
+		}
+		return fmt.Sprintf("%s, certificate verified for %s", tls.VersionName(tlsConn.ConnectionState().Version), tlsConfig.ServerName), nil
+	}) {
+		return diagnosis
+	}
+
+	run(catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_RPC, func() (string, error) {
+		// The probes dial their own connections with the same settings
+		var result TransportProbeResult
+		if transport == catalogv1.Transport_TRANSPORT_GRPC {
+			result = probeGRPC(ctx, endpoint, useTLS, serverName, roots)
+		} else {
+			client, closeClient := newProbeClient(useTLS, serverName, roots)
+			defer closeClient()
+			result = probeConnect(ctx, client, endpoint, useTLS)
+		}
+		if !result.Supported {
+			return "", fmt.Errorf("RPC failed: %s", result.Detail)
+		}
+		return result.Detail, nil
+	})
+	return diagnosis
+}
@@ -0,0 +1,83 @@
+package invoker
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"google.golang.org/grpc"
+)
+
+// TestDiagnoseEndpoint tests that each connectivity layer's failure is
+// attributed to its stage, and that later stages are not run
+func TestDiagnoseEndpoint(t *testing.T) {
+	connectHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"unimplemented"}`))
+	})
+	tlsServer := httptest.NewTLSServer(connectHandler)
+	defer tlsServer.Close()
+	plainTLSServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer plainTLSServer.Close()
+	plaintextServer := httptest.NewServer(connectHandler)
+	defer plaintextServer.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(tlsServer.Certificate())
+	roots.AddCert(plainTLSServer.Certificate())
+
+	grpcEndpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closedEndpoint := lis.Addr().String()
+	lis.Close()
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		useTLS     bool
+		serverName string
+		transport  catalogv1.Transport
+		wantFailed catalogv1.ConnectivityStage
+		wantStages int
+	}{
+		{"reachable over TLS", tlsServer.Listener.Addr().String(), true, "example.com", catalogv1.Transport_TRANSPORT_CONNECT, catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_UNSPECIFIED, 4},
+		{"reachable gRPC", grpcEndpoint, false, "", catalogv1.Transport_TRANSPORT_GRPC, catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_UNSPECIFIED, 3},
+		{"missing port", "localhost", false, "", catalogv1.Transport_TRANSPORT_CONNECT, catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_DNS, 1},
+		{"unresolvable host", "catalog-test.invalid:443", false, "", catalogv1.Transport_TRANSPORT_CONNECT, catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_DNS, 1},
+		{"connection refused", closedEndpoint, false, "", catalogv1.Transport_TRANSPORT_CONNECT, catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_TCP, 2},
+		{"TLS to a plaintext server", plaintextServer.Listener.Addr().String(), true, "example.com", catalogv1.Transport_TRANSPORT_CONNECT, catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_TLS, 3},
+		{"TLS name mismatch", tlsServer.Listener.Addr().String(), true, "wrong.example.org", catalogv1.Transport_TRANSPORT_CONNECT, catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_TLS, 3},
+		{"not a Connect server", plainTLSServer.Listener.Addr().String(), true, "example.com", catalogv1.Transport_TRANSPORT_CONNECT, catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_RPC, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			diagnosis := diagnoseEndpoint(ctx, tt.endpoint, tt.useTLS, tt.serverName, tt.transport, roots)
+			if diagnosis.FailedStage != tt.wantFailed {
+				t.Errorf("Expected failed stage %v, got %v (%+v)", tt.wantFailed, diagnosis.FailedStage, diagnosis.Stages)
+			}
+			if len(diagnosis.Stages) != tt.wantStages {
+				t.Fatalf("Expected %d stages, got %+v", tt.wantStages, diagnosis.Stages)
+			}
+			last := diagnosis.Stages[len(diagnosis.Stages)-1]
+			if last.OK != diagnosis.Reachable() || last.Detail == "" {
+				t.Errorf("Expected the last stage to carry the outcome, got %+v", last)
+			}
+		})
+	}
+}
@@ -3,10 +3,14 @@ package invoker_test
 import (
 	"context"
 	"encoding/json"
+	"net/http"
 	"testing"
 	"time"
 
+	"connectrpc.com/connect"
+	"github.com/jhump/protoreflect/desc"
 	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	elizav1 "github.com/opentdf/connectrpc-catalog/gen/connectrpc/eliza/v1"
 	"github.com/opentdf/connectrpc-catalog/internal/elizaservice"
 	"github.com/opentdf/connectrpc-catalog/internal/invoker"
 	"github.com/opentdf/connectrpc-catalog/internal/loader"
@@ -99,3 +103,145 @@ func TestInvoker_ElizaIntegration(t *testing.T) {
 		t.Logf("gRPC response: %s", resp.ResponseJSON)
 	})
 }
+
+// TestInvoker_ElizaMetadataEcho tests that request metadata reaches the
+// service and its response headers and trailers are surfaced, per transport
+func TestInvoker_ElizaMetadataEcho(t *testing.T) {
+	server := elizaservice.NewServer("50096")
+	go func() {
+		if err := server.Start(); err != nil && err.Error() != "http: Server closed" {
+			t.Logf("Server error: %v", err)
+		}
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Stop(ctx)
+	}()
+
+	// Wait for server to start
+	time.Sleep(100 * time.Millisecond)
+
+	sayMethodDesc := elizaSayMethod(t)
+
+	inv := invoker.New()
+	defer inv.Close()
+
+	// canonical re-keys metadata so Connect and gRPC names compare equal
+	canonical := func(md map[string]string) map[string]string {
+		result := make(map[string]string, len(md))
+		for k, v := range md {
+			result[http.CanonicalHeaderKey(k)] = v
+		}
+		return result
+	}
+
+	for _, transport := range []catalogv1.Transport{catalogv1.Transport_TRANSPORT_CONNECT, catalogv1.Transport_TRANSPORT_GRPC} {
+		t.Run(transport.String(), func(t *testing.T) {
+			resp, err := inv.InvokeUnary(context.Background(), invoker.InvokeRequest{
+				Endpoint:       "localhost:50096",
+				ServiceName:    "connectrpc.eliza.v1.ElizaService",
+				MethodName:     "Say",
+				RequestJSON:    json.RawMessage(`{"sentence": "Hello"}`),
+				TimeoutSeconds: 30,
+				MethodDesc:     sayMethodDesc,
+				Transport:      transport,
+				Metadata: map[string]string{
+					"x-echo-request-id": "req-42",
+					"x-not-echoed":      "hidden",
+				},
+			})
+			if err != nil {
+				t.Fatalf("Invocation error: %v", err)
+			}
+			if !resp.Success {
+				t.Fatalf("Invocation failed: %s", resp.Error)
+			}
+
+			headers, trailers := canonical(resp.Headers), canonical(resp.Trailers)
+			if headers["X-Echo-Request-Id"] != "req-42" {
+				t.Errorf("Expected the echoed header, got %v", resp.Headers)
+			}
+			if trailers["X-Echo-Request-Id"] != "req-42" {
+				t.Errorf("Expected the echoed trailer, got %v", resp.Trailers)
+			}
+			if _, ok := headers["X-Not-Echoed"]; ok {
+				t.Error("Expected only prefixed headers to be echoed")
+			}
+		})
+	}
+}
+
+// elizaSayMethod returns the Say method from the generated descriptors,
+// which avoids depending on buf
+func elizaSayMethod(t *testing.T) *desc.MethodDescriptor {
+	t.Helper()
+	fd, err := desc.WrapFile(elizav1.File_connectrpc_eliza_v1_eliza_proto)
+	if err != nil {
+		t.Fatalf("Failed to wrap Eliza descriptors: %v", err)
+	}
+	return fd.FindService("connectrpc.eliza.v1.ElizaService").FindMethodByName("Say")
+}
+
+// TestInvoker_ElizaFaults tests that injected errors and delays surface as
+// status codes and deadline failures, per transport
+func TestInvoker_ElizaFaults(t *testing.T) {
+	server := elizaservice.NewServer("50095")
+	go func() {
+		if err := server.Start(); err != nil && err.Error() != "http: Server closed" {
+			t.Logf("Server error: %v", err)
+		}
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Stop(ctx)
+	}()
+
+	// Wait for server to start
+	time.Sleep(100 * time.Millisecond)
+
+	sayMethodDesc := elizaSayMethod(t)
+	inv := invoker.New()
+	defer inv.Close()
+
+	say := func(transport catalogv1.Transport, sentence string) *invoker.InvokeResponse {
+		t.Helper()
+		requestJSON, _ := json.Marshal(map[string]string{"sentence": sentence})
+		resp, err := inv.InvokeUnary(context.Background(), invoker.InvokeRequest{
+			Endpoint:       "localhost:50095",
+			ServiceName:    "connectrpc.eliza.v1.ElizaService",
+			MethodName:     "Say",
+			RequestJSON:    requestJSON,
+			TimeoutSeconds: 1,
+			MethodDesc:     sayMethodDesc,
+			Transport:      transport,
+		})
+		if err != nil {
+			t.Fatalf("Invocation error: %v", err)
+		}
+		return resp
+	}
+
+	for _, transport := range []catalogv1.Transport{catalogv1.Transport_TRANSPORT_CONNECT, catalogv1.Transport_TRANSPORT_GRPC} {
+		t.Run(transport.String(), func(t *testing.T) {
+			resp := say(transport, "error:not_found:no such patient")
+			if resp.Success || resp.StatusCode != int32(connect.CodeNotFound) || resp.StatusMessage != "no such patient" {
+				t.Errorf("Expected NOT_FOUND, got success=%v code=%d message=%q", resp.Success, resp.StatusCode, resp.StatusMessage)
+			}
+			if resp.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_RPC_STATUS {
+				t.Errorf("Expected ERROR_KIND_RPC_STATUS, got %v", resp.ErrorKind)
+			}
+
+			// Both transports classify the expired deadline as a timeout
+			resp = say(transport, "sleep:3000")
+			if resp.Success || resp.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_TIMEOUT {
+				t.Errorf("Expected ERROR_KIND_TIMEOUT, got success=%v kind=%v error=%q", resp.Success, resp.ErrorKind, resp.Error)
+			}
+
+			if resp := say(transport, "sleep:10:hello"); !resp.Success {
+				t.Errorf("Expected a short sleep to succeed, got %q", resp.Error)
+			}
+		})
+	}
+}
@@ -4,14 +4,24 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
 
+	"connectrpc.com/connect"
+	"github.com/golang/protobuf/jsonpb"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/dynamic"
 	"github.com/jhump/protoreflect/dynamic/grpcdynamic"
@@ -19,6 +29,7 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -27,19 +38,45 @@ const (
 	DefaultMaxConnections = 100
 	// DefaultConnectionTTL is the default time-to-live for cached connections
 	DefaultConnectionTTL = 5 * time.Minute
-	// ConnectionIdleTimeout is the timeout for idle connections
-	ConnectionIdleTimeout = 2 * time.Minute
+	// DefaultConnectionIdleTimeout is the default time after which an unused
+	// connection is closed
+	DefaultConnectionIdleTimeout = 2 * time.Minute
+	// ConnectionIdleTimeout is the timeout for idle connections.
+	//
+	// Deprecated: use DefaultConnectionIdleTimeout, or WithIdleTimeout to
+	// change it.
+	ConnectionIdleTimeout = DefaultConnectionIdleTimeout
 )
 
+// ErrInvokerClosed is returned for gRPC calls made after Close
+var ErrInvokerClosed = errors.New("invoker is closed")
+
+// ErrStreamingMethod is returned when InvokeUnary is given a streaming method
+var ErrStreamingMethod = errors.New("streaming methods not supported")
+
 // connectionMetadata tracks metadata about a cached connection
 type connectionMetadata struct {
 	conn      *grpc.ClientConn
+	endpoint  string
 	createdAt time.Time
 	lastUsed  time.Time
+	// Name of the credential provider the connection was dialed with
+	credentials string
+}
+
+// EndpointPoolOptions overrides connection pool timeouts for one endpoint.
+// Zero values fall back to the invoker-wide defaults.
+type EndpointPoolOptions struct {
+	// Connection time-to-live
+	TTL time.Duration
+	// Time after which an unused connection is closed
+	IdleTimeout time.Duration
 }
 
 // Invoker handles dynamic gRPC invocations using descriptor-based reflection
 type Invoker struct {
+	// Guards the connection pool
+	mu sync.Mutex
 	// Connection pool for reusing gRPC connections with metadata
 	connections map[string]*connectionMetadata
 	// HTTP client for Connect protocol
@@ -48,6 +85,23 @@ type Invoker struct {
 	maxConnections int
 	// Connection time-to-live
 	connectionTTL time.Duration
+	// Time after which an unused connection is closed
+	idleTimeout time.Duration
+	// Per-endpoint pool timeout overrides, keyed by endpoint address
+	endpointOptions map[string]EndpointPoolOptions
+	// Trusted roots for TLS verification (nil for the system roots)
+	rootCAs *x509.CertPool
+	// Named credential providers selectable per call
+	credentialProviders map[string]CredentialProvider
+	// Transports selected by AutoTransport, keyed like connections
+	transports map[string]transportChoice
+	// HTTP transports for TLS and HTTP/1.1-only Connect calls, keyed by
+	// connectTransportKey
+	httpTransports map[string]*connectTransport
+	// Set by Close; no new gRPC connections are pooled afterwards
+	closed bool
+	// Clock for connection ages, see WithClock
+	now func() time.Time
 }
 
 // New creates a new Invoker instance with default connection pool settings
@@ -57,17 +111,60 @@ func New() *Invoker {
 		httpClient:     &http.Client{Timeout: 30 * time.Second},
 		maxConnections: DefaultMaxConnections,
 		connectionTTL:  DefaultConnectionTTL,
+		idleTimeout:    DefaultConnectionIdleTimeout,
+		now:            time.Now,
+	}
+}
+
+// Option configures an Invoker created with NewWithLimits
+type Option func(*Invoker)
+
+// WithIdleTimeout closes pooled connections unused for the given duration.
+// A connection is closed when either its idle timeout or its TTL runs out,
+// so an idle timeout at or above the TTL has no effect; a shorter one frees
+// connections to endpoints that are no longer called without capping the
+// lifetime of busy ones. Non-positive values keep the default.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(inv *Invoker) {
+		if timeout > 0 {
+			inv.idleTimeout = timeout
+		}
+	}
+}
+
+// WithRootCAs sets the roots trusted when verifying TLS endpoints. A nil
+// pool keeps the system roots.
+func WithRootCAs(roots *x509.CertPool) Option {
+	return func(inv *Invoker) {
+		inv.rootCAs = roots
+	}
+}
+
+// WithClock sets the clock used to age pooled connections against the TTL
+// and idle timeout, letting tests advance time instead of waiting. A nil
+// clock keeps time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(inv *Invoker) {
+		if now != nil {
+			inv.now = now
+		}
 	}
 }
 
 // NewWithLimits creates a new Invoker with custom connection pool limits
-func NewWithLimits(maxConnections int, ttl time.Duration) *Invoker {
-	return &Invoker{
+func NewWithLimits(maxConnections int, ttl time.Duration, opts ...Option) *Invoker {
+	inv := &Invoker{
 		connections:    make(map[string]*connectionMetadata),
 		httpClient:     &http.Client{Timeout: 30 * time.Second},
 		maxConnections: maxConnections,
 		connectionTTL:  ttl,
+		idleTimeout:    DefaultConnectionIdleTimeout,
+		now:            time.Now,
 	}
+	for _, opt := range opts {
+		opt(inv)
+	}
+	return inv
 }
 
 // InvokeRequest contains parameters for a dynamic gRPC invocation
@@ -81,96 +178,204 @@ type InvokeRequest struct {
 	TimeoutSeconds  int32
 	Metadata        map[string]string
 	MethodDesc      *desc.MethodDescriptor
-	Transport       catalogv1.Transport // Transport protocol to use
+	Transport       catalogv1.Transport       // Transport protocol to use
+	Authority       string                    // Optional :authority (gRPC) / Host (Connect) override
+	AnyResolver     jsonpb.AnyResolver        // Optional resolver for google.protobuf.Any payloads
+	UseGET          bool                      // Send side-effect-free Connect calls as GET requests
+	MaxRecvMsgSize  int                       // Optional gRPC response size limit in bytes (default: 4MB)
+	Credentials     string                    // Optional name of a registered credential provider
+	ConnectVersion  *string                   // Optional Connect protocol version override; nil sends "1", "" omits it
+	Encoding        catalogv1.ConnectEncoding // Connect message encoding (default: JSON); proto requires MethodDesc
+	ForceHTTP1      bool                      // Keep Connect calls on HTTP/1.1, never negotiating HTTP/2
+	EnumsAsInts     bool                      // Render response enum values as numbers rather than names
+}
+
+// NormalizeRequestJSON trims surrounding whitespace from a request payload and
+// substitutes {} for empty or null payloads, which both mean a default message
+func NormalizeRequestJSON(raw json.RawMessage) json.RawMessage {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(trimmed)
 }
 
 // InvokeResponse contains the result of a gRPC invocation
 type InvokeResponse struct {
-	Success       bool
-	ResponseJSON  json.RawMessage
-	Error         string
+	Success      bool
+	ResponseJSON json.RawMessage
+	Error        string
+	// Metadata combines headers and trailers, with trailer keys prefixed by
+	// "trailer-".
+	//
+	// Deprecated: use Headers and Trailers, which keep the two apart.
 	Metadata      map[string]string
+	Headers       map[string]string
+	Trailers      map[string]string
 	StatusCode    int32
 	StatusMessage string
+	// RequestBytes and ResponseBytes are the serialized payload sizes: the
+	// JSON or binary bodies for Connect and the protobuf encodings for gRPC.
+	// A GET request counts the message before query encoding.
+	RequestBytes  int64
+	ResponseBytes int64
+	// ErrorKind classifies a failed invocation so callers can tell an
+	// unreachable endpoint from an application error
+	ErrorKind catalogv1.ErrorKind
+	// ErrorFieldPath locates the response field that could not be converted
+	// to JSON, when that is why the invocation failed
+	ErrorFieldPath string
+	// TLS is the negotiated TLS connection, empty for plaintext calls
+	TLS TLSConnection
 }
 
 // InvokeUnary performs a unary call using the specified transport
 func (inv *Invoker) InvokeUnary(ctx context.Context, req InvokeRequest) (*InvokeResponse, error) {
+	req.RequestJSON = NormalizeRequestJSON(req.RequestJSON)
+
 	// Route based on transport (default to Connect when unspecified/zero value)
-	switch req.Transport {
-	case catalogv1.Transport_TRANSPORT_GRPC:
+	if resolveTransport(req.Transport) == catalogv1.Transport_TRANSPORT_GRPC {
 		return inv.invokeGRPC(ctx, req)
+	}
+	return inv.invokeConnect(ctx, req)
+}
+
+// checkUnary returns an error wrapping ErrStreamingMethod for a streaming
+// method, naming its kind of streaming
+func checkUnary(md *desc.MethodDescriptor) error {
+	var kind string
+	switch {
+	case md.IsClientStreaming() && md.IsServerStreaming():
+		kind = "bidirectional streaming"
+	case md.IsClientStreaming():
+		kind = "client streaming"
+	case md.IsServerStreaming():
+		kind = "server streaming"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%w: %s is %s, and InvokeUnary calls unary methods only; use a streaming-capable client such as grpcurl for it",
+		ErrStreamingMethod, md.GetFullyQualifiedName(), kind)
+}
+
+// resolveTransport returns the transport actually used for a requested one
+func resolveTransport(transport catalogv1.Transport) catalogv1.Transport {
+	switch transport {
+	case catalogv1.Transport_TRANSPORT_GRPC:
+		return catalogv1.Transport_TRANSPORT_GRPC
 	case catalogv1.Transport_TRANSPORT_GRPC_WEB:
 		// gRPC-Web not yet supported, fall back to Connect
-		return inv.invokeConnect(ctx, req)
+		return catalogv1.Transport_TRANSPORT_CONNECT
 	default:
 		// TRANSPORT_CONNECT (0) or any unspecified value defaults to Connect
-		return inv.invokeConnect(ctx, req)
+		return catalogv1.Transport_TRANSPORT_CONNECT
 	}
 }
 
 // invokeConnect performs a unary call using the Connect protocol (HTTP/JSON)
 func (inv *Invoker) invokeConnect(ctx context.Context, req InvokeRequest) (*InvokeResponse, error) {
-	// Build the Connect URL: http(s)://{endpoint}/{service}/{method}
-	scheme := "http"
-	if req.UseTLS {
-		scheme = "https"
+	// Reject streaming methods up front rather than sending a unary request
+	// the server can only fail; the descriptor is optional for JSON calls
+	if req.MethodDesc != nil {
+		if err := checkUnary(req.MethodDesc); err != nil {
+			return nil, err
+		}
 	}
-	url := fmt.Sprintf("%s://%s/%s/%s", scheme, req.Endpoint, req.ServiceName, req.MethodName)
 
-	// Create HTTP request with the JSON body
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(req.RequestJSON))
+	useProto := req.Encoding == catalogv1.ConnectEncoding_CONNECT_ENCODING_PROTO
+	if useProto && req.MethodDesc == nil {
+		return nil, fmt.Errorf("method descriptor is required for the proto encoding")
+	}
+
+	// Encode the message as JSON or binary protobuf
+	body, err := encodeConnectRequest(req)
 	if err != nil {
 		return &InvokeResponse{
-			Success: false,
-			Error:   fmt.Sprintf("failed to create request: %v", err),
+			Success:   false,
+			Error:     err.Error(),
+			ErrorKind: catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST,
 		}, nil
 	}
 
-	// Set Connect protocol headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Connect-Protocol-Version", "1")
+	// Bound the call by the effective timeout; an earlier deadline of the
+	// caller's context takes precedence
+	if timeout := inv.callTimeout(req); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
-	// Add custom metadata headers
-	for k, v := range req.Metadata {
-		httpReq.Header.Set(k, v)
+	// Create HTTP request with the body, or the query-encoded message for GET
+	method, target := connectRequestTarget(req, body)
+	var reqBody io.Reader
+	if method == http.MethodPost {
+		reqBody = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, target, reqBody)
+	if err != nil {
+		return &InvokeResponse{
+			Success:   false,
+			Error:     fmt.Sprintf("failed to create request: %v", err),
+			ErrorKind: catalogv1.ErrorKind_ERROR_KIND_CONNECTION,
+		}, nil
 	}
 
-	// Create a client with timeout
-	client := inv.httpClient
-	if req.TimeoutSeconds > 0 {
-		client = &http.Client{
-			Timeout: time.Duration(req.TimeoutSeconds) * time.Second,
-		}
-		if req.UseTLS {
-			client.Transport = &http.Transport{
-				TLSClientConfig: &tls.Config{
-					ServerName: req.ServerName,
-				},
-			}
-		}
+	// Override the Host header when routing through a proxy that expects a
+	// different authority than the dial target
+	if req.Authority != "" {
+		httpReq.Host = req.Authority
 	}
 
+	// Propagate the remaining time so the server can give up when the
+	// client does; custom metadata may still override it
+	if deadline, ok := ctx.Deadline(); ok {
+		httpReq.Header.Set(ConnectTimeoutHeader, connectTimeout(time.Until(deadline)))
+	}
+
+	// Set Connect protocol and custom metadata headers
+	setConnectHeaders(httpReq.Header, req)
+
+	// Credentials may add headers or a client certificate
+	tlsConfig, err := inv.applyHTTPCredentials(ctx, req, httpReq)
+	if err != nil {
+		return &InvokeResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorKind: credentialErrorKind(err),
+		}, nil
+	}
+
+	// Create a client with the request's timeout and TLS settings
+	client, release := inv.connectClient(req, tlsConfig)
+	defer release()
+
 	// Execute the request
 	resp, err := client.Do(httpReq)
 	if err != nil {
 		return &InvokeResponse{
-			Success: false,
-			Error:   fmt.Sprintf("request failed: %v", err),
+			Success:   false,
+			Error:     fmt.Sprintf("request failed: %v", err),
+			ErrorKind: transportErrorKind(err),
 		}, nil
 	}
 	defer resp.Body.Close()
 
 	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return &InvokeResponse{
-			Success: false,
-			Error:   fmt.Sprintf("failed to read response: %v", err),
+			Success:   false,
+			Error:     fmt.Sprintf("failed to read response: %v", err),
+			ErrorKind: transportErrorKind(err),
 		}, nil
 	}
 
-	// Collect response headers as metadata
+	requestBytes := int64(len(body))
+	responseBytes := int64(len(respBody))
+
+	// Collect response headers as metadata. Connect unary responses carry
+	// trailers as headers prefixed with "Trailer-".
+	respHeaders, respTrailers := splitConnectHeaders(resp.Header)
 	respMetadata := make(map[string]string)
 	for k, v := range resp.Header {
 		if len(v) > 0 {
@@ -178,6 +383,9 @@ func (inv *Invoker) invokeConnect(ctx context.Context, req InvokeRequest) (*Invo
 		}
 	}
 
+	tlsConn := newTLSConnection(resp.TLS)
+	addTLSMetadata(respMetadata, tlsConn)
+
 	// Check for Connect error response
 	if resp.StatusCode != http.StatusOK {
 		// Try to parse Connect error format
@@ -185,33 +393,385 @@ func (inv *Invoker) invokeConnect(ctx context.Context, req InvokeRequest) (*Invo
 			Code    string `json:"code"`
 			Message string `json:"message"`
 		}
-		if json.Unmarshal(body, &connectErr) == nil && connectErr.Message != "" {
+		// Report the numeric gRPC code, as the gRPC transport does
+		if json.Unmarshal(respBody, &connectErr) == nil && (connectErr.Code != "" || connectErr.Message != "") {
+			code := connectCodeFromHTTPStatus(resp.StatusCode)
+			if connectErr.Code != "" {
+				if err := code.UnmarshalText([]byte(connectErr.Code)); err != nil {
+					code = connect.CodeUnknown
+				}
+			}
+			message := connectErr.Message
+			if message == "" {
+				message = code.String()
+			}
 			return &InvokeResponse{
 				Success:       false,
-				Error:         connectErr.Message,
-				StatusCode:    int32(resp.StatusCode),
-				StatusMessage: connectErr.Code,
+				Error:         message,
+				ErrorKind:     statusErrorKind(code),
+				StatusCode:    int32(code),
+				StatusMessage: message,
 				Metadata:      respMetadata,
+				TLS:           tlsConn,
+				Headers:       respHeaders,
+				Trailers:      respTrailers,
+				RequestBytes:  requestBytes,
+				ResponseBytes: responseBytes,
 			}, nil
 		}
 		return &InvokeResponse{
 			Success:       false,
-			Error:         fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)),
-			StatusCode:    int32(resp.StatusCode),
+			Error:         fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(respBody)),
+			ErrorKind:     catalogv1.ErrorKind_ERROR_KIND_RPC_STATUS,
+			StatusCode:    int32(connectCodeFromHTTPStatus(resp.StatusCode)),
 			StatusMessage: resp.Status,
 			Metadata:      respMetadata,
+			TLS:           tlsConn,
+			Headers:       respHeaders,
+			Trailers:      respTrailers,
+			RequestBytes:  requestBytes,
+			ResponseBytes: responseBytes,
 		}, nil
 	}
 
+	// Decode binary responses for display
+	respJSON := json.RawMessage(respBody)
+	if useProto {
+		respJSON, err = decodeConnectResponse(req, respBody)
+		if err != nil {
+			resp := &InvokeResponse{
+				Success:       false,
+				Error:         err.Error(),
+				Metadata:      respMetadata,
+				TLS:           tlsConn,
+				Headers:       respHeaders,
+				Trailers:      respTrailers,
+				RequestBytes:  requestBytes,
+				ResponseBytes: responseBytes,
+			}
+			var marshalErr *MarshalError
+			if errors.As(err, &marshalErr) {
+				resp.ErrorFieldPath = marshalErr.FieldPath
+			}
+			return resp, nil
+		}
+	} else if req.EnumsAsInts {
+		respJSON = enumsAsInts(req, respJSON)
+	}
+
 	return &InvokeResponse{
 		Success:       true,
-		ResponseJSON:  body,
+		ResponseJSON:  respJSON,
 		StatusCode:    0,
 		StatusMessage: "OK",
 		Metadata:      respMetadata,
+		TLS:           tlsConn,
+		Headers:       respHeaders,
+		Trailers:      respTrailers,
+		RequestBytes:  requestBytes,
+		ResponseBytes: responseBytes,
 	}, nil
 }
 
+// callTimeout returns the timeout of a call: the request's own, or else the
+// default of the invoker's HTTP client
+func (inv *Invoker) callTimeout(req InvokeRequest) time.Duration {
+	if req.TimeoutSeconds > 0 {
+		return time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	return inv.httpClient.Timeout
+}
+
+// connectClient returns the HTTP client for a Connect call. TLS and
+// HTTP/1.1-only calls use a transport pooled per endpoint, server name,
+// credentials and HTTP version, built from tlsConfig, so their connections
+// are reused across calls. release only has work to do after Close.
+func (inv *Invoker) connectClient(req InvokeRequest, tlsConfig *tls.Config) (*http.Client, func()) {
+	if tlsConfig == nil && req.TimeoutSeconds <= 0 && !req.ForceHTTP1 {
+		return inv.httpClient, func() {}
+	}
+
+	client := &http.Client{Transport: inv.httpClient.Transport, Timeout: inv.callTimeout(req)}
+	if tlsConfig == nil && !req.ForceHTTP1 {
+		return client, func() {}
+	}
+
+	transport, release := inv.pooledTransport(req, func() *http.Transport {
+		transport := inv.newConnectTransport(req.Endpoint)
+		transport.TLSClientConfig = tlsConfig
+		if req.ForceHTTP1 {
+			// A non-nil empty TLSNextProto stops the transport from offering
+			// h2 during the TLS handshake
+			transport.ForceAttemptHTTP2 = false
+			transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+		}
+		return transport
+	})
+	client.Transport = transport
+	return client, release
+}
+
+// connectTransport is a pooled transport for Connect calls
+type connectTransport struct {
+	transport *http.Transport
+	endpoint  string
+	lastUsed  time.Time
+}
+
+// connectTransportKey identifies the pooled transport for a Connect call.
+// Credentialed calls are pooled apart so a client certificate is never
+// shared across providers.
+func connectTransportKey(req InvokeRequest) string {
+	key := connectionKey(req.Endpoint, req.UseTLS, req.ServerName, "")
+	if req.Credentials != "" {
+		key += ":credentials=" + req.Credentials
+	}
+	if req.ForceHTTP1 {
+		key += ":http1"
+	}
+	return key
+}
+
+// newConnectTransport creates a transport whose idle connections close on
+// the endpoint's idle timeout
+func (inv *Invoker) newConnectTransport(endpoint string) *http.Transport {
+	inv.mu.Lock()
+	_, idleTimeout := inv.poolTimeouts(endpoint)
+	inv.mu.Unlock()
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.IdleConnTimeout = idleTimeout
+	return transport
+}
+
+// pooledTransport returns the transport pooled for req, creating it with
+// newTransport. After Close, transports are no longer pooled and release
+// closes the one returned.
+func (inv *Invoker) pooledTransport(req InvokeRequest, newTransport func() *http.Transport) (*http.Transport, func()) {
+	key := connectTransportKey(req)
+	now := inv.now()
+
+	inv.mu.Lock()
+	if pooled, ok := inv.httpTransports[key]; ok && !inv.closed {
+		pooled.lastUsed = now
+		inv.mu.Unlock()
+		return pooled.transport, func() {}
+	}
+	inv.mu.Unlock()
+
+	transport := newTransport()
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if inv.closed {
+		return transport, transport.CloseIdleConnections
+	}
+	// Another caller may have created one concurrently
+	if pooled, ok := inv.httpTransports[key]; ok {
+		pooled.lastUsed = now
+		return pooled.transport, func() {}
+	}
+	if inv.httpTransports == nil {
+		inv.httpTransports = make(map[string]*connectTransport)
+	}
+	inv.httpTransports[key] = &connectTransport{transport: transport, endpoint: req.Endpoint, lastUsed: now}
+	return transport, func() {}
+}
+
+// connectURL builds the Connect URL: http(s)://{endpoint}/{service}/{method}
+func connectURL(req InvokeRequest) string {
+	scheme := "http"
+	if req.UseTLS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/%s/%s", scheme, req.Endpoint, req.ServiceName, req.MethodName)
+}
+
+// connectRequestTarget returns the HTTP method and URL for a Connect unary
+// call. GET requests carry the message in the query string, per the Connect
+// protocol specification, so proxies can cache side-effect-free reads.
+func connectRequestTarget(req InvokeRequest, body []byte) (string, string) {
+	if !req.UseGET {
+		return http.MethodPost, connectURL(req)
+	}
+
+	query := url.Values{}
+	if version, ok := connectProtocolVersion(req); ok {
+		query.Set("connect", "v"+version)
+	}
+	query.Set("encoding", connectCodec(req))
+	query.Set("base64", "1")
+	query.Set("message", base64.RawURLEncoding.EncodeToString(body))
+	return http.MethodGet, connectURL(req) + "?" + query.Encode()
+}
+
+// connectCodec returns the Connect codec name for a request's encoding, as
+// used in the Content-Type header and the GET encoding parameter
+func connectCodec(req InvokeRequest) string {
+	if req.Encoding == catalogv1.ConnectEncoding_CONNECT_ENCODING_PROTO {
+		return "proto"
+	}
+	return "json"
+}
+
+// encodeConnectRequest returns the Connect request body: the request JSON
+// itself, or its binary protobuf encoding for the proto encoding
+func encodeConnectRequest(req InvokeRequest) ([]byte, error) {
+	if req.Encoding != catalogv1.ConnectEncoding_CONNECT_ENCODING_PROTO {
+		return req.RequestJSON, nil
+	}
+
+	msg := dynamic.NewMessage(req.MethodDesc.GetInputType())
+	unmarshaler := &jsonpb.Unmarshaler{AnyResolver: req.AnyResolver}
+	if err := msg.UnmarshalJSONPB(unmarshaler, req.RequestJSON); err != nil {
+		return nil, fmt.Errorf("invalid request JSON: %w", err)
+	}
+	data, err := msg.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	return data, nil
+}
+
+// decodeConnectResponse converts a binary protobuf response body to JSON
+func decodeConnectResponse(req InvokeRequest, body []byte) (json.RawMessage, error) {
+	msg := dynamic.NewMessage(req.MethodDesc.GetOutputType())
+	if err := msg.Unmarshal(body); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return marshalResponseJSON(msg, req.AnyResolver, req.EnumsAsInts)
+}
+
+const (
+	// ConnectProtocolVersionHeader carries the Connect protocol version of
+	// POST requests
+	ConnectProtocolVersionHeader = "Connect-Protocol-Version"
+	// DefaultConnectProtocolVersion is sent unless a request overrides it
+	DefaultConnectProtocolVersion = "1"
+	// ConnectTimeoutHeader carries the time remaining before the client
+	// gives up on a call, in milliseconds
+	ConnectTimeoutHeader = "Connect-Timeout-Ms"
+	// maxConnectTimeoutMs is the largest timeout the header can carry, as the
+	// protocol limits it to 10 digits
+	maxConnectTimeoutMs = 9999999999
+)
+
+// connectTimeout formats the time remaining for ConnectTimeoutHeader, in
+// whole milliseconds rounded up
+func connectTimeout(remaining time.Duration) string {
+	ms := int64((remaining + time.Millisecond - 1) / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	if ms > maxConnectTimeoutMs {
+		ms = maxConnectTimeoutMs
+	}
+	return strconv.FormatInt(ms, 10)
+}
+
+// connectProtocolVersion returns the Connect protocol version to send, or
+// false if the request omits it
+func connectProtocolVersion(req InvokeRequest) (string, bool) {
+	if req.ConnectVersion == nil {
+		return DefaultConnectProtocolVersion, true
+	}
+	return *req.ConnectVersion, *req.ConnectVersion != ""
+}
+
+// setConnectHeaders sets the Connect protocol headers followed by custom
+// metadata. GET requests carry the protocol version and encoding in the query
+// string instead.
+func setConnectHeaders(header http.Header, req InvokeRequest) {
+	if !req.UseGET {
+		header.Set("Content-Type", "application/"+connectCodec(req))
+		if version, ok := connectProtocolVersion(req); ok {
+			header.Set(ConnectProtocolVersionHeader, version)
+		}
+	}
+
+	for k, v := range req.Metadata {
+		header.Set(k, v)
+	}
+}
+
+// connectCodeFromHTTPStatus infers the error code for a Connect unary error
+// response whose body carries no code, per the Connect protocol specification
+func connectCodeFromHTTPStatus(httpStatus int) connect.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return connect.CodeInternal
+	case http.StatusUnauthorized:
+		return connect.CodeUnauthenticated
+	case http.StatusForbidden:
+		return connect.CodePermissionDenied
+	case http.StatusNotFound:
+		return connect.CodeUnimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return connect.CodeUnavailable
+	default:
+		return connect.CodeUnknown
+	}
+}
+
+// transportErrorKind classifies an error from sending a request or reading
+// its response
+func transportErrorKind(err error) catalogv1.ErrorKind {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return catalogv1.ErrorKind_ERROR_KIND_TIMEOUT
+	}
+	if IsTLSError(err) {
+		return catalogv1.ErrorKind_ERROR_KIND_TLS
+	}
+	return catalogv1.ErrorKind_ERROR_KIND_CONNECTION
+}
+
+// IsTLSError reports whether err is a failed TLS handshake or certificate
+// verification. gRPC flattens handshake failures into an UNAVAILABLE status,
+// so the crypto/tls and crypto/x509 message prefixes are matched as well.
+func IsTLSError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		alertErr     tls.AlertError
+		recordErr    tls.RecordHeaderError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	switch {
+	case errors.As(err, &verifyErr), errors.As(err, &alertErr), errors.As(err, &recordErr),
+		errors.As(err, &authorityErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "tls: ") || strings.Contains(msg, "x509: ")
+}
+
+// statusErrorKind classifies an error status returned by the server
+func statusErrorKind(code connect.Code) catalogv1.ErrorKind {
+	if code == connect.CodeDeadlineExceeded {
+		return catalogv1.ErrorKind_ERROR_KIND_TIMEOUT
+	}
+	return catalogv1.ErrorKind_ERROR_KIND_RPC_STATUS
+}
+
+// grpcErrorKind classifies a failed gRPC call. Connections are established
+// lazily, so an unreachable endpoint or failed handshake surfaces as
+// UNAVAILABLE.
+func grpcErrorKind(code connect.Code, err error) catalogv1.ErrorKind {
+	if code == connect.CodeUnavailable {
+		if IsTLSError(err) {
+			return catalogv1.ErrorKind_ERROR_KIND_TLS
+		}
+		return catalogv1.ErrorKind_ERROR_KIND_CONNECTION
+	}
+	return statusErrorKind(code)
+}
+
 // invokeGRPC performs a unary gRPC call using dynamic invocation
 func (inv *Invoker) invokeGRPC(ctx context.Context, req InvokeRequest) (*InvokeResponse, error) {
 	// Validate method descriptor
@@ -219,16 +779,36 @@ func (inv *Invoker) invokeGRPC(ctx context.Context, req InvokeRequest) (*InvokeR
 		return nil, fmt.Errorf("method descriptor is required for gRPC transport")
 	}
 
-	if req.MethodDesc.IsClientStreaming() || req.MethodDesc.IsServerStreaming() {
-		return nil, fmt.Errorf("streaming methods not supported (use InvokeUnary for unary RPCs only)")
+	if err := checkUnary(req.MethodDesc); err != nil {
+		return nil, err
 	}
 
-	// Get or create gRPC connection
-	conn, err := inv.getConnection(req.Endpoint, req.UseTLS, req.ServerName)
+	// Credentials may add metadata or a client certificate
+	grpcOpts, err := inv.grpcOptions(ctx, req)
 	if err != nil {
 		return &InvokeResponse{
-			Success: false,
-			Error:   fmt.Sprintf("connection failed: %v", err),
+			Success:   false,
+			Error:     err.Error(),
+			ErrorKind: credentialErrorKind(err),
+		}, nil
+	}
+
+	// Get or create gRPC connection. Credentialed connections are pooled
+	// apart so a client certificate is never shared across providers.
+	connKey := connectionKey(req.Endpoint, req.UseTLS, req.ServerName, req.Authority)
+	if req.Credentials != "" {
+		connKey += ":credentials=" + req.Credentials
+	}
+	conn, err := inv.pooledConnection(connKey, req.Credentials, req.Endpoint, grpcOpts.TLSConfig, req.Authority)
+	if err != nil {
+		errorKind := catalogv1.ErrorKind_ERROR_KIND_CONNECTION
+		if IsTLSError(err) {
+			errorKind = catalogv1.ErrorKind_ERROR_KIND_TLS
+		}
+		return &InvokeResponse{
+			Success:   false,
+			Error:     fmt.Sprintf("connection failed: %v", err),
+			ErrorKind: errorKind,
 		}, nil
 	}
 
@@ -238,35 +818,52 @@ func (inv *Invoker) invokeGRPC(ctx context.Context, req InvokeRequest) (*InvokeR
 	// Parse request JSON into dynamic message
 	reqMsg := dynamic.NewMessage(req.MethodDesc.GetInputType())
 
-	if err := reqMsg.UnmarshalJSON(req.RequestJSON); err != nil {
+	unmarshaler := &jsonpb.Unmarshaler{AnyResolver: req.AnyResolver}
+	if err := reqMsg.UnmarshalJSONPB(unmarshaler, req.RequestJSON); err != nil {
 		return &InvokeResponse{
-			Success: false,
-			Error:   fmt.Sprintf("invalid request JSON: %v", err),
+			Success:   false,
+			Error:     fmt.Sprintf("invalid request JSON: %v", err),
+			ErrorKind: catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST,
 		}, nil
 	}
 
-	// Setup context with timeout and metadata
+	// Setup context with the same effective timeout as the Connect path, and
+	// metadata. grpc-go sends the remaining time as grpc-timeout.
 	invokeCtx := ctx
-	if req.TimeoutSeconds > 0 {
+	if timeout := inv.callTimeout(req); timeout > 0 {
 		var cancel context.CancelFunc
-		invokeCtx, cancel = context.WithTimeout(ctx, time.Duration(req.TimeoutSeconds)*time.Second)
+		invokeCtx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 	}
 
 	// Add request metadata
-	if len(req.Metadata) > 0 {
-		md := metadata.New(req.Metadata)
-		invokeCtx = metadata.NewOutgoingContext(invokeCtx, md)
+	if len(grpcOpts.Metadata) > 0 {
+		invokeCtx = metadata.NewOutgoingContext(invokeCtx, grpcOpts.Metadata)
 	}
 
-	// Prepare response metadata capture
+	// Prepare response metadata and peer capture
 	var respHeader, respTrailer metadata.MD
+	var respPeer peer.Peer
 
-	// Invoke the method
-	respMsg, err := stub.InvokeRpc(invokeCtx, req.MethodDesc, reqMsg,
+	callOpts := []grpc.CallOption{
 		grpc.Header(&respHeader),
 		grpc.Trailer(&respTrailer),
-	)
+		grpc.Peer(&respPeer),
+	}
+	if req.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(req.MaxRecvMsgSize))
+	}
+
+	// Invoke the method
+	respMsg, err := stub.InvokeRpc(invokeCtx, req.MethodDesc, reqMsg, callOpts...)
+	respMetadata := mergeMetadata(respHeader, respTrailer)
+	var tlsConn TLSConnection
+	if tlsInfo, ok := respPeer.AuthInfo.(credentials.TLSInfo); ok {
+		tlsConn = newTLSConnection(&tlsInfo.State)
+	}
+	addTLSMetadata(respMetadata, tlsConn)
+
+	requestBytes := wireSize(reqMsg)
 
 	// Handle invocation error
 	if err != nil {
@@ -274,9 +871,14 @@ func (inv *Invoker) invokeGRPC(ctx context.Context, req InvokeRequest) (*InvokeR
 		return &InvokeResponse{
 			Success:       false,
 			Error:         err.Error(),
+			ErrorKind:     grpcErrorKind(connect.Code(statusCode), err),
 			StatusCode:    statusCode,
 			StatusMessage: statusMsg,
-			Metadata:      mergeMetadata(respHeader, respTrailer),
+			Metadata:      respMetadata,
+			TLS:           tlsConn,
+			Headers:       flattenMetadata(respHeader),
+			Trailers:      flattenMetadata(respTrailer),
+			RequestBytes:  requestBytes,
 		}, nil
 	}
 
@@ -289,12 +891,19 @@ func (inv *Invoker) invokeGRPC(ctx context.Context, req InvokeRequest) (*InvokeR
 		}, nil
 	}
 
-	respJSON, err := dynRespMsg.MarshalJSON()
+	// Expand Any payloads using the resolver when one is provided; otherwise
+	// only types visible from the response's own file are resolved
+	respJSON, err := marshalResponseJSON(dynRespMsg, req.AnyResolver, req.EnumsAsInts)
 	if err != nil {
-		return &InvokeResponse{
+		resp := &InvokeResponse{
 			Success: false,
-			Error:   fmt.Sprintf("failed to marshal response: %v", err),
-		}, nil
+			Error:   err.Error(),
+		}
+		var marshalErr *MarshalError
+		if errors.As(err, &marshalErr) {
+			resp.ErrorFieldPath = marshalErr.FieldPath
+		}
+		return resp, nil
 	}
 
 	return &InvokeResponse{
@@ -302,25 +911,58 @@ func (inv *Invoker) invokeGRPC(ctx context.Context, req InvokeRequest) (*InvokeR
 		ResponseJSON:  respJSON,
 		StatusCode:    0, // OK
 		StatusMessage: "OK",
-		Metadata:      mergeMetadata(respHeader, respTrailer),
+		Metadata:      respMetadata,
+		TLS:           tlsConn,
+		Headers:       flattenMetadata(respHeader),
+		Trailers:      flattenMetadata(respTrailer),
+		RequestBytes:  requestBytes,
+		ResponseBytes: wireSize(dynRespMsg),
 	}, nil
 }
 
+// wireSize returns the length of a message's protobuf encoding, or 0 if it
+// cannot be encoded
+func wireSize(msg *dynamic.Message) int64 {
+	data, err := msg.Marshal()
+	if err != nil {
+		return 0
+	}
+	return int64(len(data))
+}
+
 // getConnection retrieves or creates a gRPC connection with pool management
-func (inv *Invoker) getConnection(endpoint string, useTLS bool, serverName string) (*grpc.ClientConn, error) {
-	connKey := fmt.Sprintf("%s:%v:%s", endpoint, useTLS, serverName)
-	now := time.Now()
+func (inv *Invoker) getConnection(endpoint string, useTLS bool, serverName, authority string) (*grpc.ClientConn, error) {
+	var tlsConfig *tls.Config
+	if useTLS {
+		tlsConfig = inv.tlsConfig(serverName)
+	}
+	return inv.pooledConnection(connectionKey(endpoint, useTLS, serverName, authority), "", endpoint, tlsConfig, authority)
+}
+
+// pooledConnection retrieves the connection pooled under connKey or dials
+// endpoint, using TLS when tlsConfig is non-nil
+func (inv *Invoker) pooledConnection(connKey, credentialName, endpoint string, tlsConfig *tls.Config, authority string) (*grpc.ClientConn, error) {
+	now := inv.now()
+
+	inv.mu.Lock()
+
+	if inv.closed {
+		inv.mu.Unlock()
+		return nil, ErrInvokerClosed
+	}
 
 	// Clean up stale connections before checking pool
 	inv.cleanupStaleConnections()
 
 	// Check if connection already exists and is valid
 	if connMeta, exists := inv.connections[connKey]; exists {
+		ttl, _ := inv.poolTimeouts(endpoint)
 		// Check if connection is still valid and not expired
 		if connMeta.conn.GetState().String() != "SHUTDOWN" &&
-			now.Sub(connMeta.createdAt) < inv.connectionTTL {
+			now.Sub(connMeta.createdAt) < ttl {
 			// Update last used time
 			connMeta.lastUsed = now
+			inv.mu.Unlock()
 			return connMeta.conn, nil
 		}
 		// Connection is dead or expired, remove it
@@ -328,61 +970,149 @@ func (inv *Invoker) getConnection(endpoint string, useTLS bool, serverName strin
 		delete(inv.connections, connKey)
 	}
 
-	// Enforce maximum connection limit
-	if len(inv.connections) >= inv.maxConnections {
-		inv.evictOldestConnection()
-	}
+	// Dial without holding the lock so slow endpoints don't block the pool
+	inv.mu.Unlock()
 
 	// Create new connection
 	var opts []grpc.DialOption
 
-	if useTLS {
-		tlsConfig := &tls.Config{}
-		if serverName != "" {
-			tlsConfig.ServerName = serverName
-		}
-		creds := credentials.NewTLS(tlsConfig)
-		opts = append(opts, grpc.WithTransportCredentials(creds))
+	if tlsConfig != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
 	} else {
 		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	}
 
+	if authority != "" {
+		opts = append(opts, grpc.WithAuthority(authority))
+	}
+
 	// Use blocking dial with short timeout for fast failure when server is unreachable
 	dialCtx, dialCancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer dialCancel()
 
-	opts = append(opts, grpc.WithBlock())
+	// Report the last connection error rather than a bare deadline, so
+	// handshake failures can be told apart from unreachable endpoints
+	opts = append(opts, grpc.WithBlock(), grpc.WithReturnConnectionError())
 
 	conn, err := grpc.DialContext(dialCtx, endpoint, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial %s: %w", endpoint, err)
 	}
 
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	// The invoker may have been closed while dialing
+	if inv.closed {
+		_ = conn.Close()
+		return nil, ErrInvokerClosed
+	}
+
+	// Another caller may have dialed the same endpoint concurrently
+	if connMeta, exists := inv.connections[connKey]; exists {
+		_ = conn.Close()
+		connMeta.lastUsed = now
+		return connMeta.conn, nil
+	}
+
+	// Enforce maximum connection limit
+	if len(inv.connections) >= inv.maxConnections {
+		inv.evictOldestConnection()
+	}
+
 	// Cache the connection with metadata
 	inv.connections[connKey] = &connectionMetadata{
-		conn:      conn,
-		createdAt: now,
-		lastUsed:  now,
+		conn:        conn,
+		endpoint:    endpoint,
+		createdAt:   now,
+		lastUsed:    now,
+		credentials: credentialName,
 	}
 
 	return conn, nil
 }
 
-// cleanupStaleConnections removes expired or idle connections from the pool
+// connectionKey builds the pool key for a connection configuration
+func connectionKey(endpoint string, useTLS bool, serverName, authority string) string {
+	return fmt.Sprintf("%s:%v:%s:%s", endpoint, useTLS, serverName, authority)
+}
+
+// cleanupStaleConnections removes expired or idle connections from the pool,
+// along with idle Connect transports and expired AutoTransport choices.
+// The caller must hold inv.mu.
 func (inv *Invoker) cleanupStaleConnections() {
-	now := time.Now()
+	now := inv.now()
 	for key, connMeta := range inv.connections {
+		ttl, idleTimeout := inv.poolTimeouts(connMeta.endpoint)
 		// Check if connection has expired or been idle too long
-		if now.Sub(connMeta.createdAt) >= inv.connectionTTL ||
-			now.Sub(connMeta.lastUsed) >= ConnectionIdleTimeout ||
+		if now.Sub(connMeta.createdAt) >= ttl ||
+			now.Sub(connMeta.lastUsed) >= idleTimeout ||
 			connMeta.conn.GetState().String() == "SHUTDOWN" {
 			_ = connMeta.conn.Close()
 			delete(inv.connections, key)
 		}
 	}
+
+	// Calls still using a dropped transport finish normally; its
+	// connections close once idle
+	for key, pooled := range inv.httpTransports {
+		_, idleTimeout := inv.poolTimeouts(pooled.endpoint)
+		if now.Sub(pooled.lastUsed) >= idleTimeout {
+			pooled.transport.CloseIdleConnections()
+			delete(inv.httpTransports, key)
+		}
+	}
+
+	// Expired transport choices would be probed again on next use anyway
+	for key, choice := range inv.transports {
+		if now.Sub(choice.probedAt) >= inv.connectionTTL {
+			delete(inv.transports, key)
+		}
+	}
 }
 
-// evictOldestConnection removes the least recently used connection
+// PruneConnections closes pooled connections past their TTL or idle
+// timeout. The pool is otherwise pruned only when a connection is requested.
+func (inv *Invoker) PruneConnections() {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.cleanupStaleConnections()
+}
+
+// SetEndpointPoolOptions overrides the connection TTL and idle timeout for
+// connections to endpoint. Passing zero-valued options restores the defaults.
+func (inv *Invoker) SetEndpointPoolOptions(endpoint string, opts EndpointPoolOptions) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if opts == (EndpointPoolOptions{}) {
+		delete(inv.endpointOptions, endpoint)
+		return
+	}
+	if inv.endpointOptions == nil {
+		inv.endpointOptions = make(map[string]EndpointPoolOptions)
+	}
+	inv.endpointOptions[endpoint] = opts
+}
+
+// poolTimeouts returns the effective TTL and idle timeout for an endpoint.
+// The caller must hold inv.mu.
+func (inv *Invoker) poolTimeouts(endpoint string) (time.Duration, time.Duration) {
+	ttl, idleTimeout := inv.connectionTTL, inv.idleTimeout
+	if opts, ok := inv.endpointOptions[endpoint]; ok {
+		if opts.TTL > 0 {
+			ttl = opts.TTL
+		}
+		if opts.IdleTimeout > 0 {
+			idleTimeout = opts.IdleTimeout
+		}
+	}
+	return ttl, idleTimeout
+}
+
+// evictOldestConnection removes the least recently used connection.
+// The caller must hold inv.mu.
 func (inv *Invoker) evictOldestConnection() {
 	var oldestKey string
 	var oldestTime time.Time
@@ -402,8 +1132,15 @@ func (inv *Invoker) evictOldestConnection() {
 	}
 }
 
-// Close closes all open gRPC connections
+// Close closes all open gRPC connections and the idle connections of pooled
+// Connect transports. Later gRPC calls fail with ErrInvokerClosed instead of
+// opening new connections.
 func (inv *Invoker) Close() error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	inv.closed = true
+
 	var errs []error
 	for key, connMeta := range inv.connections {
 		if err := connMeta.conn.Close(); err != nil {
@@ -413,6 +1150,11 @@ func (inv *Invoker) Close() error {
 
 	inv.connections = make(map[string]*connectionMetadata)
 
+	for _, pooled := range inv.httpTransports {
+		pooled.transport.CloseIdleConnections()
+	}
+	inv.httpTransports = nil
+
 	if len(errs) > 0 {
 		return fmt.Errorf("errors closing connections: %v", errs)
 	}
@@ -454,6 +1196,37 @@ func mergeMetadata(header, trailer metadata.MD) map[string]string {
 	return result
 }
 
+// flattenMetadata converts gRPC metadata to a map, keeping the first value per key
+func flattenMetadata(md metadata.MD) map[string]string {
+	result := make(map[string]string, len(md))
+	for k, v := range md {
+		if len(v) > 0 {
+			result[k] = v[0]
+		}
+	}
+	return result
+}
+
+// splitConnectHeaders separates Connect unary response headers from trailers,
+// which the protocol sends as headers prefixed with "Trailer-"
+func splitConnectHeaders(header http.Header) (map[string]string, map[string]string) {
+	headers := make(map[string]string)
+	trailers := make(map[string]string)
+
+	for k, v := range header {
+		if len(v) == 0 {
+			continue
+		}
+		if name, ok := strings.CutPrefix(k, "Trailer-"); ok {
+			trailers[name] = v[0]
+			continue
+		}
+		headers[k] = v[0]
+	}
+
+	return headers, trailers
+}
+
 // InvokeUnarySimple is a simplified version that takes raw parameters
 // This is a convenience wrapper around InvokeUnary
 func InvokeUnarySimple(
@@ -507,13 +1280,15 @@ func ValidateRequest(req InvokeRequest) error {
 		return fmt.Errorf("method descriptor is required")
 	}
 
-	if len(req.RequestJSON) == 0 {
-		return fmt.Errorf("request JSON is required")
+	// TLS-only settings are rejected rather than silently ignored on a
+	// plaintext connection
+	if !req.UseTLS && req.ServerName != "" {
+		return fmt.Errorf("server name %q requires TLS; enable TLS or remove the server name", req.ServerName)
 	}
 
-	// Validate JSON is well-formed
+	// Validate JSON is well-formed; empty payloads normalize to {}
 	var tmp interface{}
-	if err := json.Unmarshal(req.RequestJSON, &tmp); err != nil {
+	if err := json.Unmarshal(NormalizeRequestJSON(req.RequestJSON), &tmp); err != nil {
 		return fmt.Errorf("invalid request JSON: %w", err)
 	}
 
@@ -522,17 +1297,26 @@ func ValidateRequest(req InvokeRequest) error {
 
 // ConnectionStats provides statistics about active connections
 type ConnectionStats struct {
-	TotalConnections int
+	TotalConnections  int
 	ActiveConnections int
-	EndpointCounts   map[string]int
+	EndpointCounts    map[string]int
+	MaxConnections    int           // Configured pool size
+	ConnectionTTL     time.Duration // Configured connection time-to-live
+	IdleTimeout       time.Duration // Configured idle timeout
 }
 
 // GetConnectionStats returns statistics about the invoker's connections
 func (inv *Invoker) GetConnectionStats() ConnectionStats {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
 	stats := ConnectionStats{
 		TotalConnections:  len(inv.connections),
 		ActiveConnections: 0,
 		EndpointCounts:    make(map[string]int),
+		MaxConnections:    inv.maxConnections,
+		ConnectionTTL:     inv.connectionTTL,
+		IdleTimeout:       inv.idleTimeout,
 	}
 
 	for key, connMeta := range inv.connections {
@@ -548,7 +1332,10 @@ func (inv *Invoker) GetConnectionStats() ConnectionStats {
 
 // CloseConnection closes a specific connection by endpoint
 func (inv *Invoker) CloseConnection(endpoint string, useTLS bool, serverName string) error {
-	connKey := fmt.Sprintf("%s:%v:%s", endpoint, useTLS, serverName)
+	connKey := connectionKey(endpoint, useTLS, serverName, "")
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
 
 	connMeta, exists := inv.connections[connKey]
 	if !exists {
@@ -565,7 +1352,7 @@ func (inv *Invoker) CloseConnection(endpoint string, useTLS bool, serverName str
 
 // WaitForReady waits for a connection to be ready
 func (inv *Invoker) WaitForReady(ctx context.Context, endpoint string, useTLS bool, serverName string) error {
-	conn, err := inv.getConnection(endpoint, useTLS, serverName)
+	conn, err := inv.getConnection(endpoint, useTLS, serverName, "")
 	if err != nil {
 		return err
 	}
@@ -2,17 +2,29 @@ package invoker
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
 	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
@@ -112,8 +124,29 @@ func TestValidateRequest(t *testing.T) {
 				MethodDesc:  methodDesc,
 				RequestJSON: json.RawMessage{},
 			},
-			wantErr: true,
-			errMsg:  "request JSON is required",
+			wantErr: false,
+		},
+		{
+			name: "whitespace request JSON",
+			req: InvokeRequest{
+				Endpoint:    "localhost:8080",
+				ServiceName: "test.v1.TestService",
+				MethodName:  "TestMethod",
+				MethodDesc:  methodDesc,
+				RequestJSON: json.RawMessage(" \n\t "),
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty object request JSON",
+			req: InvokeRequest{
+				Endpoint:    "localhost:8080",
+				ServiceName: "test.v1.TestService",
+				MethodName:  "TestMethod",
+				MethodDesc:  methodDesc,
+				RequestJSON: json.RawMessage(`{}`),
+			},
+			wantErr: false,
 		},
 		{
 			name: "invalid JSON",
@@ -127,6 +160,32 @@ func TestValidateRequest(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid request JSON",
 		},
+		{
+			name: "server name without TLS",
+			req: InvokeRequest{
+				Endpoint:    "localhost:8080",
+				ServiceName: "test.v1.TestService",
+				MethodName:  "TestMethod",
+				MethodDesc:  methodDesc,
+				RequestJSON: json.RawMessage(`{}`),
+				ServerName:  "api.internal",
+			},
+			wantErr: true,
+			errMsg:  "requires TLS",
+		},
+		{
+			name: "server name with TLS",
+			req: InvokeRequest{
+				Endpoint:    "localhost:8443",
+				ServiceName: "test.v1.TestService",
+				MethodName:  "TestMethod",
+				MethodDesc:  methodDesc,
+				RequestJSON: json.RawMessage(`{}`),
+				UseTLS:      true,
+				ServerName:  "api.internal",
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -148,6 +207,31 @@ func TestValidateRequest(t *testing.T) {
 	}
 }
 
+// TestNormalizeRequestJSON tests normalization of empty, whitespace and null payloads
+func TestNormalizeRequestJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "empty", input: "", want: "{}"},
+		{name: "whitespace", input: " \n\t ", want: "{}"},
+		{name: "null", input: "null", want: "{}"},
+		{name: "padded null", input: "  null\n", want: "{}"},
+		{name: "padded object", input: "  {\"name\": \"test\"}\n", want: `{"name": "test"}`},
+		{name: "invalid JSON untouched", input: "{invalid", want: "{invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeRequestJSON(json.RawMessage(tt.input))
+			if string(got) != tt.want {
+				t.Errorf("NormalizeRequestJSON(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
 // TestInvokeConnect tests the Connect protocol invocation
 func TestInvokeConnect(t *testing.T) {
 	tests := []struct {
@@ -187,8 +271,36 @@ func TestInvokeConnect(t *testing.T) {
 				if resp.Error != "internal server error" {
 					t.Errorf("Expected error 'internal server error', got: %s", resp.Error)
 				}
-				if resp.StatusCode != http.StatusInternalServerError {
-					t.Errorf("Expected status code %d, got: %d", http.StatusInternalServerError, resp.StatusCode)
+				if resp.StatusCode != int32(codes.Internal) {
+					t.Errorf("Expected status code %d, got: %d", codes.Internal, resp.StatusCode)
+				}
+				if resp.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_RPC_STATUS {
+					t.Errorf("Expected RPC_STATUS error kind, got: %v", resp.ErrorKind)
+				}
+			},
+		},
+		{
+			name:           "connect not found error",
+			serverResponse: `{"code": "not_found", "message": "widget 42 not found"}`,
+			serverStatus:   http.StatusNotFound,
+			wantSuccess:    false,
+			checkResponse: func(t *testing.T, resp *InvokeResponse) {
+				if resp.StatusCode != int32(codes.NotFound) {
+					t.Errorf("Expected status code %d, got: %d", codes.NotFound, resp.StatusCode)
+				}
+				if resp.StatusMessage != "widget 42 not found" {
+					t.Errorf("Expected status message 'widget 42 not found', got: %s", resp.StatusMessage)
+				}
+			},
+		},
+		{
+			name:           "connect error without code",
+			serverResponse: `{"message": "slow down"}`,
+			serverStatus:   http.StatusTooManyRequests,
+			wantSuccess:    false,
+			checkResponse: func(t *testing.T, resp *InvokeResponse) {
+				if resp.StatusCode != int32(codes.Unavailable) {
+					t.Errorf("Expected status code %d, got: %d", codes.Unavailable, resp.StatusCode)
 				}
 			},
 		},
@@ -306,6 +418,264 @@ func TestInvokeConnect_Metadata(t *testing.T) {
 	}
 }
 
+// TestInvokeConnect_EmptyBody tests that empty payloads are sent as an empty object
+func TestInvokeConnect_EmptyBody(t *testing.T) {
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	inv := New()
+	defer inv.Close()
+
+	for _, input := range []string{"", "   ", "null"} {
+		_, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+			Endpoint:    server.URL[len("http://"):],
+			ServiceName: "test.v1.TestService",
+			MethodName:  "TestMethod",
+			RequestJSON: json.RawMessage(input),
+			Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", input, err)
+		}
+		if gotBody != "{}" {
+			t.Errorf("Expected body {} for input %q, got %q", input, gotBody)
+		}
+	}
+}
+
+// TestInvokeConnect_GET tests sending side-effect-free calls as Connect GET requests
+func TestInvokeConnect_GET(t *testing.T) {
+	var gotMethod, gotPath, gotContentType string
+	var gotQuery url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotQuery = r.URL.Query()
+		gotContentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":"ok"}`))
+	}))
+	defer server.Close()
+
+	inv := New()
+	defer inv.Close()
+
+	resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:    server.URL[len("http://"):],
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		RequestJSON: json.RawMessage(`{"name":"a+b/c?"}`),
+		Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+		UseGET:      true,
+	})
+	if err != nil {
+		t.Fatalf("InvokeUnary failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Error)
+	}
+
+	if gotMethod != http.MethodGet {
+		t.Errorf("Expected GET, got %s", gotMethod)
+	}
+	if gotPath != "/test.v1.TestService/TestMethod" {
+		t.Errorf("Unexpected path: %s", gotPath)
+	}
+	if gotContentType != "" {
+		t.Errorf("Expected no Content-Type for GET, got %q", gotContentType)
+	}
+	if gotQuery.Get("connect") != "v1" || gotQuery.Get("encoding") != "json" || gotQuery.Get("base64") != "1" {
+		t.Errorf("Unexpected protocol query parameters: %v", gotQuery)
+	}
+	message, err := base64.RawURLEncoding.DecodeString(gotQuery.Get("message"))
+	if err != nil {
+		t.Fatalf("Failed to decode message: %v", err)
+	}
+	if string(message) != `{"name":"a+b/c?"}` {
+		t.Errorf("Unexpected message: %s", message)
+	}
+}
+
+// TestInvokeConnect_ProtocolVersion tests overriding and omitting the
+// Connect protocol version
+func TestInvokeConnect_ProtocolVersion(t *testing.T) {
+	var gotHeader []string
+	var gotQuery url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Values(ConnectProtocolVersionHeader)
+		gotQuery = r.URL.Query()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	inv := New()
+	defer inv.Close()
+
+	invoke := func(version *string, useGET bool) {
+		t.Helper()
+		resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+			Endpoint:       server.URL[len("http://"):],
+			ServiceName:    "test.v1.TestService",
+			MethodName:     "TestMethod",
+			RequestJSON:    json.RawMessage(`{}`),
+			Transport:      catalogv1.Transport_TRANSPORT_CONNECT,
+			UseGET:         useGET,
+			ConnectVersion: version,
+		})
+		if err != nil || !resp.Success {
+			t.Fatalf("InvokeUnary failed: %v %v", err, resp)
+		}
+	}
+	version := func(v string) *string { return &v }
+
+	invoke(nil, false)
+	if len(gotHeader) != 1 || gotHeader[0] != DefaultConnectProtocolVersion {
+		t.Errorf("Expected the default version, got %v", gotHeader)
+	}
+
+	invoke(version("2"), false)
+	if len(gotHeader) != 1 || gotHeader[0] != "2" {
+		t.Errorf("Expected version 2, got %v", gotHeader)
+	}
+
+	invoke(version(""), false)
+	if len(gotHeader) != 0 {
+		t.Errorf("Expected no version header, got %v", gotHeader)
+	}
+
+	invoke(version("2"), true)
+	if gotQuery.Get("connect") != "v2" {
+		t.Errorf("Expected connect=v2, got %v", gotQuery)
+	}
+
+	invoke(version(""), true)
+	if _, ok := gotQuery["connect"]; ok {
+		t.Errorf("Expected no connect query parameter, got %v", gotQuery)
+	}
+}
+
+// TestInvokeConnect_ProtoEncoding tests binary protobuf requests and responses
+// on the Connect transport
+func TestInvokeConnect_ProtoEncoding(t *testing.T) {
+	var gotContentType string
+	var gotService string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodGet {
+			gotContentType = "application/" + r.URL.Query().Get("encoding")
+			body, _ = base64.RawURLEncoding.DecodeString(r.URL.Query().Get("message"))
+		}
+		var checkReq healthpb.HealthCheckRequest
+		if err := proto.Unmarshal(body, &checkReq); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		gotService = checkReq.Service
+		respBody, _ := proto.Marshal(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+		w.Header().Set("Content-Type", "application/proto")
+		w.Write(respBody)
+	}))
+	defer server.Close()
+
+	inv := New()
+	defer inv.Close()
+
+	for _, useGET := range []bool{false, true} {
+		resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+			Endpoint:    server.URL[len("http://"):],
+			ServiceName: "grpc.health.v1.Health",
+			MethodName:  "Check",
+			RequestJSON: json.RawMessage(`{"service":"catalog"}`),
+			MethodDesc:  healthCheckMethodDescriptor(t),
+			Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+			UseGET:      useGET,
+			Encoding:    catalogv1.ConnectEncoding_CONNECT_ENCODING_PROTO,
+		})
+		if err != nil || !resp.Success {
+			t.Fatalf("InvokeUnary (GET=%v) failed: %v %v", useGET, err, resp)
+		}
+		if gotContentType != "application/proto" {
+			t.Errorf("Expected the proto encoding, got %q", gotContentType)
+		}
+		if gotService != "catalog" {
+			t.Errorf("Expected the request to decode with service %q, got %q", "catalog", gotService)
+		}
+		if string(resp.ResponseJSON) != `{"status":"SERVING"}` {
+			t.Errorf("Expected the response as JSON, got %s", resp.ResponseJSON)
+		}
+		if resp.RequestBytes != int64(len("\n\x07catalog")) {
+			t.Errorf("Expected the binary request size, got %d", resp.RequestBytes)
+		}
+	}
+
+	// The proto encoding needs a method descriptor to convert messages
+	_, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:    server.URL[len("http://"):],
+		ServiceName: "grpc.health.v1.Health",
+		MethodName:  "Check",
+		Encoding:    catalogv1.ConnectEncoding_CONNECT_ENCODING_PROTO,
+	})
+	if err == nil {
+		t.Error("Expected an error without a method descriptor")
+	}
+}
+
+// TestInvokeConnect_PayloadSizes tests request and response size reporting
+func TestInvokeConnect_PayloadSizes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/test.v1.TestService/Fail" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"not_found","message":"missing"}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":"hello"}`))
+	}))
+	defer server.Close()
+
+	inv := New()
+	defer inv.Close()
+
+	tests := []struct {
+		method       string
+		wantResponse int64
+		wantSuccess  bool
+	}{
+		{method: "TestMethod", wantResponse: int64(len(`{"message":"hello"}`)), wantSuccess: true},
+		{method: "Fail", wantResponse: int64(len(`{"code":"not_found","message":"missing"}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+				Endpoint:    server.URL[len("http://"):],
+				ServiceName: "test.v1.TestService",
+				MethodName:  tt.method,
+				RequestJSON: json.RawMessage(`{"name":"test"}`),
+			})
+			if err != nil {
+				t.Fatalf("InvokeUnary failed: %v", err)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Fatalf("Expected success=%v, got %v (%s)", tt.wantSuccess, resp.Success, resp.Error)
+			}
+			if resp.RequestBytes != int64(len(`{"name":"test"}`)) {
+				t.Errorf("Unexpected request bytes: %d", resp.RequestBytes)
+			}
+			if resp.ResponseBytes != tt.wantResponse {
+				t.Errorf("Expected %d response bytes, got %d", tt.wantResponse, resp.ResponseBytes)
+			}
+		})
+	}
+}
+
 // TestInvokeConnect_Timeout tests timeout configuration
 func TestInvokeConnect_Timeout(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -342,6 +712,148 @@ func TestInvokeConnect_Timeout(t *testing.T) {
 	if !contains(resp.Error, "request failed") {
 		t.Errorf("Expected timeout error, got: %s", resp.Error)
 	}
+	if resp.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_TIMEOUT {
+		t.Errorf("Expected TIMEOUT error kind, got: %v", resp.ErrorKind)
+	}
+}
+
+// TestInvokeConnect_TimeoutHeader tests that the remaining time is sent as
+// Connect-Timeout-Ms, bounded by the caller's deadline
+func TestInvokeConnect_TimeoutHeader(t *testing.T) {
+	var gotTimeout string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTimeout = r.Header.Get(ConnectTimeoutHeader)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	inv := New()
+	defer inv.Close()
+
+	invoke := func(ctx context.Context, timeoutSeconds int32) int64 {
+		t.Helper()
+		resp, err := inv.InvokeUnary(ctx, InvokeRequest{
+			Endpoint:       server.URL[len("http://"):],
+			ServiceName:    "test.v1.TestService",
+			MethodName:     "TestMethod",
+			RequestJSON:    json.RawMessage(`{}`),
+			TimeoutSeconds: timeoutSeconds,
+			Transport:      catalogv1.Transport_TRANSPORT_CONNECT,
+		})
+		if err != nil || !resp.Success {
+			t.Fatalf("InvokeUnary failed: %v %v", err, resp)
+		}
+		ms, err := strconv.ParseInt(gotTimeout, 10, 64)
+		if err != nil {
+			t.Fatalf("Expected a numeric %s header, got %q", ConnectTimeoutHeader, gotTimeout)
+		}
+		return ms
+	}
+
+	if ms := invoke(context.Background(), 5); ms <= 4000 || ms > 5000 {
+		t.Errorf("Expected about 5000ms, got %d", ms)
+	}
+	if ms := invoke(context.Background(), 0); ms <= 29000 || ms > 30000 {
+		t.Errorf("Expected the 30s default, got %d", ms)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if ms := invoke(ctx, 5); ms > 1000 {
+		t.Errorf("Expected the caller's earlier deadline, got %d", ms)
+	}
+}
+
+// TestInvokeGRPC_Deadline tests that gRPC calls carry the effective timeout
+// as their deadline
+func TestInvokeGRPC_Deadline(t *testing.T) {
+	var remaining time.Duration
+	endpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if deadline, ok := ctx.Deadline(); ok {
+			remaining = time.Until(deadline)
+		}
+		return handler(ctx, req)
+	})
+
+	inv := New()
+	defer inv.Close()
+
+	for _, tt := range []struct {
+		timeoutSeconds int32
+		want           time.Duration
+	}{
+		{5, 5 * time.Second},
+		{0, 30 * time.Second},
+	} {
+		remaining = 0
+		resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+			Endpoint:       endpoint,
+			ServiceName:    "grpc.health.v1.Health",
+			MethodName:     "Check",
+			RequestJSON:    json.RawMessage(`{}`),
+			TimeoutSeconds: tt.timeoutSeconds,
+			MethodDesc:     healthCheckMethodDescriptor(t),
+			Transport:      catalogv1.Transport_TRANSPORT_GRPC,
+		})
+		if err != nil || !resp.Success {
+			t.Fatalf("InvokeUnary failed: %v %v", err, resp)
+		}
+		if remaining <= tt.want-time.Second || remaining > tt.want {
+			t.Errorf("timeout %d: expected a deadline about %v away, got %v", tt.timeoutSeconds, tt.want, remaining)
+		}
+	}
+}
+
+// TestConnectTimeout tests formatting of the Connect-Timeout-Ms header
+func TestConnectTimeout(t *testing.T) {
+	tests := []struct {
+		remaining time.Duration
+		want      string
+	}{
+		{1500 * time.Millisecond, "1500"},
+		{1500*time.Millisecond + time.Microsecond, "1501"},
+		{0, "1"},
+		{-time.Second, "1"},
+		{200 * 24 * time.Hour, "9999999999"},
+	}
+	for _, tt := range tests {
+		if got := connectTimeout(tt.remaining); got != tt.want {
+			t.Errorf("connectTimeout(%v) = %s, want %s", tt.remaining, got, tt.want)
+		}
+	}
+}
+
+// TestInvokeConnect_ConnectionRefused tests that an unreachable endpoint is
+// reported as a connection error
+func TestInvokeConnect_ConnectionRefused(t *testing.T) {
+	inv := New()
+	defer inv.Close()
+
+	resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:    closedEndpoint(t),
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		RequestJSON: json.RawMessage(`{}`),
+		Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Success || resp.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_CONNECTION {
+		t.Errorf("Expected CONNECTION error kind, got: %v (%s)", resp.ErrorKind, resp.Error)
+	}
+}
+
+// closedEndpoint returns a local address with nothing listening on it
+func closedEndpoint(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
 }
 
 // TestTransportSelection tests that different transports are routed correctly
@@ -426,7 +938,7 @@ func TestConnectionPool(t *testing.T) {
 
 	for _, ep := range endpoints {
 		// Try to get connection (will fail since no server)
-		_, err := inv.getConnection(ep.endpoint, ep.useTLS, ep.serverName)
+		_, err := inv.getConnection(ep.endpoint, ep.useTLS, ep.serverName, "")
 		// We expect an error since there's no server listening
 		if err == nil {
 			t.Logf("Warning: Expected connection error for %s", ep.endpoint)
@@ -603,6 +1115,41 @@ func TestInvokeGRPC_Validation(t *testing.T) {
 	_ = resp
 }
 
+// TestInvokeConnect_StreamingNotSupported tests that streaming methods are
+// rejected before a Connect request is sent
+func TestInvokeConnect_StreamingNotSupported(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	inv := New()
+	defer inv.Close()
+
+	for _, md := range []*desc.MethodDescriptor{
+		createTestStreamingMethodDescriptor(true, false),
+		createTestStreamingMethodDescriptor(false, true),
+		createTestStreamingMethodDescriptor(true, true),
+	} {
+		_, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+			Endpoint:    server.URL[len("http://"):],
+			ServiceName: "test.v1.TestService",
+			MethodName:  md.GetName(),
+			RequestJSON: json.RawMessage(`{}`),
+			Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+			MethodDesc:  md,
+		})
+		if !errors.Is(err, ErrStreamingMethod) {
+			t.Errorf("client=%v server=%v: expected ErrStreamingMethod, got %v", md.IsClientStreaming(), md.IsServerStreaming(), err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("Expected no requests to be sent, got %d", requests)
+	}
+}
+
 // TestInvokeGRPC_StreamingNotSupported tests that streaming methods are rejected
 func TestInvokeGRPC_StreamingNotSupported(t *testing.T) {
 	inv := New()
@@ -646,8 +1193,429 @@ func TestInvokeGRPC_StreamingNotSupported(t *testing.T) {
 	}
 }
 
+// TestInvokeGRPC_Authority tests that the :authority override is applied to the dial
+func TestInvokeGRPC_Authority(t *testing.T) {
+	var gotAuthority string
+	endpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md[":authority"]) > 0 {
+			gotAuthority = md[":authority"][0]
+		}
+		return handler(ctx, req)
+	})
+
+	inv := New()
+	defer inv.Close()
+
+	resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:       endpoint,
+		ServiceName:    "grpc.health.v1.Health",
+		MethodName:     "Check",
+		RequestJSON:    json.RawMessage(`{}`),
+		TimeoutSeconds: 5,
+		MethodDesc:     healthCheckMethodDescriptor(t),
+		Transport:      catalogv1.Transport_TRANSPORT_GRPC,
+		Authority:      "health.internal.example",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Error)
+	}
+
+	if gotAuthority != "health.internal.example" {
+		t.Errorf("Expected :authority 'health.internal.example', got '%s'", gotAuthority)
+	}
+}
+
+// TestInvokeGRPC_HeadersAndTrailers tests that headers and trailers with the same name stay separate
+func TestInvokeGRPC_HeadersAndTrailers(t *testing.T) {
+	endpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		grpc.SetHeader(ctx, metadata.Pairs("x-shared", "header-value"))
+		grpc.SetTrailer(ctx, metadata.Pairs("x-shared", "trailer-value"))
+		return handler(ctx, req)
+	})
+
+	inv := New()
+	defer inv.Close()
+
+	resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:       endpoint,
+		ServiceName:    "grpc.health.v1.Health",
+		MethodName:     "Check",
+		RequestJSON:    json.RawMessage(`{}`),
+		TimeoutSeconds: 5,
+		MethodDesc:     healthCheckMethodDescriptor(t),
+		Transport:      catalogv1.Transport_TRANSPORT_GRPC,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Error)
+	}
+
+	if resp.Headers["x-shared"] != "header-value" {
+		t.Errorf("Expected header x-shared=header-value, got: %v", resp.Headers)
+	}
+	if resp.Trailers["x-shared"] != "trailer-value" {
+		t.Errorf("Expected trailer x-shared=trailer-value, got: %v", resp.Trailers)
+	}
+
+	// The deprecated combined map keeps its prefixed form
+	if resp.Metadata["x-shared"] != "header-value" || resp.Metadata["trailer-x-shared"] != "trailer-value" {
+		t.Errorf("Expected combined metadata with prefixed trailer, got: %v", resp.Metadata)
+	}
+}
+
+// TestInvokeGRPC_PayloadSizes tests that gRPC sizes are protobuf encoding lengths
+func TestInvokeGRPC_PayloadSizes(t *testing.T) {
+	endpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	})
+
+	inv := New()
+	defer inv.Close()
+
+	resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:       endpoint,
+		ServiceName:    "grpc.health.v1.Health",
+		MethodName:     "Check",
+		RequestJSON:    json.RawMessage(`{}`),
+		TimeoutSeconds: 5,
+		MethodDesc:     healthCheckMethodDescriptor(t),
+		Transport:      catalogv1.Transport_TRANSPORT_GRPC,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Error)
+	}
+
+	// An empty request encodes to nothing; status SERVING is one tag and one varint
+	if resp.RequestBytes != 0 {
+		t.Errorf("Expected 0 request bytes, got %d", resp.RequestBytes)
+	}
+	if resp.ResponseBytes != 2 {
+		t.Errorf("Expected 2 response bytes, got %d", resp.ResponseBytes)
+	}
+}
+
+// TestInvokeGRPC_ErrorKind tests that gRPC failures are classified
+func TestInvokeGRPC_ErrorKind(t *testing.T) {
+	endpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		var fail string
+		if values := md.Get("x-fail"); len(values) > 0 {
+			fail = values[0]
+		}
+		switch fail {
+		case "deadline":
+			return nil, status.Error(codes.DeadlineExceeded, "too slow")
+		case "permission":
+			return nil, status.Error(codes.PermissionDenied, "denied")
+		}
+		return handler(ctx, req)
+	})
+
+	// The test server's certificate is not trusted by the system roots
+	untrusted := httptest.NewUnstartedServer(http.NotFoundHandler())
+	untrusted.EnableHTTP2 = true
+	untrusted.StartTLS()
+	defer untrusted.Close()
+
+	tests := []struct {
+		name        string
+		endpoint    string
+		useTLS      bool
+		requestJSON string
+		fail        string
+		want        catalogv1.ErrorKind
+	}{
+		{name: "unreachable endpoint", endpoint: closedEndpoint(t), requestJSON: `{}`, want: catalogv1.ErrorKind_ERROR_KIND_CONNECTION},
+		{name: "untrusted certificate", endpoint: untrusted.Listener.Addr().String(), useTLS: true, requestJSON: `{}`, want: catalogv1.ErrorKind_ERROR_KIND_TLS},
+		{name: "invalid request JSON", endpoint: endpoint, requestJSON: `{"unknown": 1}`, want: catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST},
+		{name: "deadline exceeded", endpoint: endpoint, requestJSON: `{}`, fail: "deadline", want: catalogv1.ErrorKind_ERROR_KIND_TIMEOUT},
+		{name: "application status", endpoint: endpoint, requestJSON: `{}`, fail: "permission", want: catalogv1.ErrorKind_ERROR_KIND_RPC_STATUS},
+		{name: "success", endpoint: endpoint, requestJSON: `{}`, want: catalogv1.ErrorKind_ERROR_KIND_UNSPECIFIED},
+	}
+
+	inv := New()
+	defer inv.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := InvokeRequest{
+				Endpoint:       tt.endpoint,
+				ServiceName:    "grpc.health.v1.Health",
+				MethodName:     "Check",
+				RequestJSON:    json.RawMessage(tt.requestJSON),
+				TimeoutSeconds: 5,
+				UseTLS:         tt.useTLS,
+				MethodDesc:     healthCheckMethodDescriptor(t),
+				Transport:      catalogv1.Transport_TRANSPORT_GRPC,
+			}
+			if tt.fail != "" {
+				req.Metadata = map[string]string{"x-fail": tt.fail}
+			}
+
+			resp, err := inv.InvokeUnary(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if resp.ErrorKind != tt.want {
+				t.Errorf("Expected %v, got %v (%s)", tt.want, resp.ErrorKind, resp.Error)
+			}
+		})
+	}
+}
+
+// TestInvokeConnect_HeadersAndTrailers tests that Connect trailer headers are split out
+func TestInvokeConnect_HeadersAndTrailers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Shared", "header-value")
+		w.Header().Set("Trailer-X-Shared", "trailer-value")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	inv := New()
+	defer inv.Close()
+
+	resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:    server.URL[len("http://"):],
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		RequestJSON: json.RawMessage(`{}`),
+		Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if resp.Headers["X-Shared"] != "header-value" {
+		t.Errorf("Expected header X-Shared=header-value, got: %v", resp.Headers)
+	}
+	if resp.Trailers["X-Shared"] != "trailer-value" {
+		t.Errorf("Expected trailer X-Shared=trailer-value, got: %v", resp.Trailers)
+	}
+	if _, exists := resp.Headers["Trailer-X-Shared"]; exists {
+		t.Error("Trailer should not appear in headers")
+	}
+}
+
+// TestGetConnection_Concurrent tests that concurrent dials to one endpoint share a pooled connection
+func TestGetConnection_Concurrent(t *testing.T) {
+	endpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	})
+
+	inv := New()
+	defer inv.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := inv.WaitForReady(context.Background(), endpoint, false, ""); err != nil {
+				t.Errorf("WaitForReady failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stats := inv.GetConnectionStats(); stats.TotalConnections != 1 {
+		t.Errorf("Expected 1 pooled connection, got %d", stats.TotalConnections)
+	}
+}
+
+// TestClose_ConcurrentInvocations tests that Close is safe to call while
+// invocations are in flight; run with -race
+func TestClose_ConcurrentInvocations(t *testing.T) {
+	endpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	})
+	methodDesc := healthCheckMethodDescriptor(t)
+
+	inv := New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+					Endpoint:       endpoint,
+					ServiceName:    "grpc.health.v1.Health",
+					MethodName:     "Check",
+					RequestJSON:    json.RawMessage(`{}`),
+					TimeoutSeconds: 5,
+					MethodDesc:     methodDesc,
+					Transport:      catalogv1.Transport_TRANSPORT_GRPC,
+				})
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+					return
+				}
+				if !resp.Success && !contains(resp.Error, ErrInvokerClosed.Error()) && !contains(resp.Error, "Canceled") {
+					t.Errorf("Unexpected failure: %s", resp.Error)
+				}
+			}
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if err := inv.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	wg.Wait()
+
+	if _, err := inv.getConnection(endpoint, false, "", ""); !errors.Is(err, ErrInvokerClosed) {
+		t.Errorf("Expected ErrInvokerClosed after Close, got %v", err)
+	}
+	if stats := inv.GetConnectionStats(); stats.TotalConnections != 0 {
+		t.Errorf("Expected no pooled connections after Close, got %d", stats.TotalConnections)
+	}
+}
+
+// TestEndpointPoolOptions tests that per-endpoint TTLs and idle timeouts override the defaults
+func TestEndpointPoolOptions(t *testing.T) {
+	passthrough := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	}
+	ephemeral := startTestGRPCServer(t, passthrough)
+	idle := startTestGRPCServer(t, passthrough)
+	stable := startTestGRPCServer(t, passthrough)
+
+	inv := New()
+	defer inv.Close()
+
+	inv.SetEndpointPoolOptions(ephemeral, EndpointPoolOptions{TTL: time.Minute})
+	inv.SetEndpointPoolOptions(idle, EndpointPoolOptions{IdleTimeout: 30 * time.Second})
+
+	for _, endpoint := range []string{ephemeral, idle, stable} {
+		if _, err := inv.getConnection(endpoint, false, "", ""); err != nil {
+			t.Fatalf("getConnection(%s) failed: %v", endpoint, err)
+		}
+	}
+
+	// Age every connection past the overrides but within the defaults
+	inv.mu.Lock()
+	for _, connMeta := range inv.connections {
+		connMeta.createdAt = connMeta.createdAt.Add(-90 * time.Second)
+		connMeta.lastUsed = connMeta.lastUsed.Add(-90 * time.Second)
+	}
+	inv.cleanupStaleConnections()
+	_, hasEphemeral := inv.connections[connectionKey(ephemeral, false, "", "")]
+	_, hasIdle := inv.connections[connectionKey(idle, false, "", "")]
+	_, hasStable := inv.connections[connectionKey(stable, false, "", "")]
+	inv.mu.Unlock()
+
+	if hasEphemeral {
+		t.Error("Expected connection with short TTL to be cleaned up")
+	}
+	if hasIdle {
+		t.Error("Expected connection with short idle timeout to be cleaned up")
+	}
+	if !hasStable {
+		t.Error("Expected connection using the defaults to be kept")
+	}
+
+	// Clearing the override restores the defaults
+	inv.SetEndpointPoolOptions(ephemeral, EndpointPoolOptions{})
+	inv.mu.Lock()
+	ttl, idleTimeout := inv.poolTimeouts(ephemeral)
+	inv.mu.Unlock()
+	if ttl != DefaultConnectionTTL || idleTimeout != ConnectionIdleTimeout {
+		t.Errorf("Expected default timeouts, got %v and %v", ttl, idleTimeout)
+	}
+}
+
+// TestWithIdleTimeout tests that an idle timeout shorter than the TTL evicts
+// unused connections long before they expire
+func TestWithIdleTimeout(t *testing.T) {
+	passthrough := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	}
+	idle := startTestGRPCServer(t, passthrough)
+	busy := startTestGRPCServer(t, passthrough)
+
+	now := time.Now()
+	clock := func() time.Time { return now }
+	inv := NewWithLimits(DefaultMaxConnections, time.Hour, WithIdleTimeout(30*time.Second), WithClock(clock))
+	defer inv.Close()
+
+	if stats := inv.GetConnectionStats(); stats.IdleTimeout != 30*time.Second || stats.ConnectionTTL != time.Hour {
+		t.Fatalf("Unexpected pool settings: %+v", stats)
+	}
+
+	for _, endpoint := range []string{idle, busy} {
+		if _, err := inv.getConnection(endpoint, false, "", ""); err != nil {
+			t.Fatalf("getConnection(%s) failed: %v", endpoint, err)
+		}
+	}
+
+	// Both connections are 40 seconds old, well within the TTL, but only
+	// one was used in the last 30 seconds
+	now = now.Add(20 * time.Second)
+	if _, err := inv.getConnection(busy, false, "", ""); err != nil {
+		t.Fatalf("getConnection(%s) failed: %v", busy, err)
+	}
+	now = now.Add(20 * time.Second)
+
+	inv.PruneConnections()
+	counts := inv.GetConnectionStats().EndpointCounts
+	hasIdle := counts[connectionKey(idle, false, "", "")] > 0
+	hasBusy := counts[connectionKey(busy, false, "", "")] > 0
+
+	if hasIdle {
+		t.Error("Expected the idle connection to be evicted before its TTL")
+	}
+	if !hasBusy {
+		t.Error("Expected the recently used connection to be kept")
+	}
+
+	// Non-positive values keep the default
+	if got := NewWithLimits(1, time.Minute, WithIdleTimeout(0)).GetConnectionStats().IdleTimeout; got != DefaultConnectionIdleTimeout {
+		t.Errorf("Expected the default idle timeout, got %v", got)
+	}
+}
+
 // Helper functions
 
+// startTestGRPCServer starts a gRPC server exposing the standard health service
+// with the given interceptor and returns its address
+func startTestGRPCServer(t *testing.T, interceptor grpc.UnaryServerInterceptor) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(interceptor))
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	return lis.Addr().String()
+}
+
+// healthCheckMethodDescriptor returns the descriptor for grpc.health.v1.Health/Check
+func healthCheckMethodDescriptor(t *testing.T) *desc.MethodDescriptor {
+	t.Helper()
+
+	fd, err := desc.LoadFileDescriptor("grpc/health/v1/health.proto")
+	if err != nil {
+		t.Fatalf("Failed to load health descriptor: %v", err)
+	}
+	return fd.FindService("grpc.health.v1.Health").FindMethodByName("Check")
+}
+
 // createTestMethodDescriptor creates a test method descriptor for unary RPC
 func createTestMethodDescriptor() *desc.MethodDescriptor {
 	// Create test file descriptor set
@@ -0,0 +1,544 @@
+package invoker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// anyPlaceholderTypeURL stands in for unresolvable Any values while
+// marshaling; it is always resolvable
+const anyPlaceholderTypeURL = "type.googleapis.com/google.protobuf.Empty"
+
+// MarshalError reports a response field that could not be converted to JSON
+type MarshalError struct {
+	// FieldPath locates the field using JSON names, e.g. "items[2].detail"
+	FieldPath string
+	// MessageType is the fully qualified type of the field's value
+	MessageType string
+	Err         error
+}
+
+func (e *MarshalError) Error() string {
+	switch {
+	case e.FieldPath == "":
+		return fmt.Sprintf("failed to marshal response: %v", e.Err)
+	case e.MessageType == "":
+		return fmt.Sprintf("failed to marshal response field %s: %v", e.FieldPath, e.Err)
+	default:
+		return fmt.Sprintf("failed to marshal response field %s (%s): %v", e.FieldPath, e.MessageType, e.Err)
+	}
+}
+
+func (e *MarshalError) Unwrap() error {
+	return e.Err
+}
+
+// fieldPathElem is a field's JSON name (string), a map key (mapKey) or a
+// repeated field index (int)
+type fieldPathElem interface{}
+
+// mapKey is a map key in a field path, rendered as a JSON object key
+type mapKey string
+
+// anyFallback is an unresolvable Any value and where it sits in the JSON
+type anyFallback struct {
+	path    []fieldPathElem
+	typeURL string
+	value   []byte
+}
+
+// marshalResponseJSON converts a response to JSON. Any values whose type the
+// resolver can't find are emitted as their type URL and base64-encoded value
+// rather than failing the response; other failures return a *MarshalError
+// naming the offending field. With enumsAsInts, enum values are rendered as
+// numbers rather than names.
+func marshalResponseJSON(msg *dynamic.Message, resolver jsonpb.AnyResolver, enumsAsInts bool) (json.RawMessage, error) {
+	marshaler := &jsonpb.Marshaler{AnyResolver: resolver, EnumsAsInts: enumsAsInts}
+	data, err := msg.MarshalJSONPB(marshaler)
+	if err == nil {
+		return data, nil
+	}
+
+	// Marshal a copy with placeholders for unresolvable Any values, then
+	// splice their raw form back in
+	if resolver == nil {
+		// Mirror the marshaler's default, which resolves types visible
+		// from the message's own file
+		resolver = dynamic.AnyResolver(nil, msg.GetMessageDescriptor().GetFile())
+	}
+	copied, copyErr := copyMessage(msg)
+	if copyErr != nil {
+		return nil, &MarshalError{MessageType: msg.GetMessageDescriptor().GetFullyQualifiedName(), Err: err}
+	}
+	var fallbacks []anyFallback
+	replaceUnresolvedAny(copied, nil, resolver, &fallbacks)
+
+	if len(fallbacks) > 0 {
+		if data, err := copied.MarshalJSONPB(marshaler); err == nil {
+			return spliceAnyFallbacks(data, fallbacks)
+		}
+	}
+
+	return nil, locateMarshalFailure(copied, nil, marshaler, err)
+}
+
+// enumsAsInts rewrites the enum values of a JSON response received as-is,
+// such as a Connect JSON response, as numbers. Only enum values change;
+// everything else, including fields the method's output type doesn't
+// define, is kept as received. The response is returned unchanged if it
+// doesn't match the shape of the output type.
+func enumsAsInts(req InvokeRequest, data json.RawMessage) json.RawMessage {
+	if req.MethodDesc == nil {
+		return data
+	}
+	md := req.MethodDesc.GetOutputType()
+	resolver := req.AnyResolver
+	if resolver == nil {
+		resolver = dynamic.AnyResolver(nil, md.GetFile())
+	}
+	rewritten, err := rewriteEnums(md, data, resolver)
+	if err != nil {
+		return data
+	}
+	return rewritten
+}
+
+// rewriteEnums rewrites the enum values of data, the JSON form of a message
+// of type md, as numbers, keeping key order and all other values
+func rewriteEnums(md *desc.MessageDescriptor, data json.RawMessage, resolver jsonpb.AnyResolver) (json.RawMessage, error) {
+	if isWellKnownType(md) {
+		if md.GetFullyQualifiedName() != "google.protobuf.Any" {
+			// Other well-known types have their own JSON forms
+			return data, nil
+		}
+		return rewriteAnyEnums(data, resolver)
+	}
+
+	keys, values, err := decodeObject(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		fd := md.FindFieldByJSONName(key)
+		if fd == nil {
+			fd = md.FindFieldByName(key)
+		}
+		if fd == nil {
+			continue
+		}
+		if values[i], err = rewriteFieldEnums(fd, values[i], resolver); err != nil {
+			return nil, err
+		}
+	}
+	return encodeObject(keys, values), nil
+}
+
+// rewriteAnyEnums rewrites the enum values of an Any whose type the resolver
+// finds. Unresolvable values are kept as received.
+func rewriteAnyEnums(data json.RawMessage, resolver jsonpb.AnyResolver) (json.RawMessage, error) {
+	var header struct {
+		Type string `json:"@type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	resolved, err := resolver.Resolve(header.Type)
+	if err != nil {
+		return data, nil
+	}
+	md, err := resolvedMessageDescriptor(resolved)
+	if err != nil {
+		return data, nil
+	}
+	if isWellKnownType(md) {
+		// Well-known types nest their JSON form under "value"
+		return data, nil
+	}
+	// The embedded message's fields sit beside "@type", which matches no field
+	return rewriteEnums(md, data, resolver)
+}
+
+// rewriteFieldEnums rewrites the enum values of a field's JSON value
+func rewriteFieldEnums(fd *desc.FieldDescriptor, value json.RawMessage, resolver jsonpb.AnyResolver) (json.RawMessage, error) {
+	if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+		return value, nil
+	}
+	switch {
+	case fd.IsMap():
+		valueField := fd.GetMapValueType()
+		if valueField.GetEnumType() == nil && valueField.GetMessageType() == nil {
+			return value, nil
+		}
+		keys, values, err := decodeObject(value)
+		if err != nil {
+			return nil, err
+		}
+		for i := range values {
+			if values[i], err = rewriteValueEnums(valueField, values[i], resolver); err != nil {
+				return nil, err
+			}
+		}
+		return encodeObject(keys, values), nil
+	case fd.IsRepeated():
+		if fd.GetEnumType() == nil && fd.GetMessageType() == nil {
+			return value, nil
+		}
+		var elems []json.RawMessage
+		if err := json.Unmarshal(value, &elems); err != nil {
+			return nil, err
+		}
+		for i := range elems {
+			var err error
+			if elems[i], err = rewriteValueEnums(fd, elems[i], resolver); err != nil {
+				return nil, err
+			}
+		}
+		return json.Marshal(elems)
+	default:
+		return rewriteValueEnums(fd, value, resolver)
+	}
+}
+
+// rewriteValueEnums rewrites a single value of a field: an enum name becomes
+// its number and messages are rewritten recursively
+func rewriteValueEnums(fd *desc.FieldDescriptor, value json.RawMessage, resolver jsonpb.AnyResolver) (json.RawMessage, error) {
+	if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+		return value, nil
+	}
+	if enum := fd.GetEnumType(); enum != nil {
+		var name string
+		if err := json.Unmarshal(value, &name); err != nil {
+			// Already a number
+			var number int32
+			if err := json.Unmarshal(value, &number); err != nil {
+				return nil, err
+			}
+			return value, nil
+		}
+		enumValue := enum.FindValueByName(name)
+		if enumValue == nil {
+			return nil, fmt.Errorf("unknown value %q for enum %s", name, enum.GetFullyQualifiedName())
+		}
+		return json.RawMessage(strconv.Itoa(int(enumValue.GetNumber()))), nil
+	}
+	if md := fd.GetMessageType(); md != nil {
+		return rewriteEnums(md, value, resolver)
+	}
+	return value, nil
+}
+
+// decodeObject splits a JSON object into its keys and raw values, in order
+func decodeObject(data json.RawMessage) ([]string, []json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("expected a JSON object")
+	}
+	var keys []string
+	var values []json.RawMessage
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	return keys, values, nil
+}
+
+// encodeObject joins keys and raw values into a JSON object
+func encodeObject(keys []string, values []json.RawMessage) json.RawMessage {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encoded, _ := json.Marshal(key)
+		buf.Write(encoded)
+		buf.WriteByte(':')
+		buf.Write(values[i])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// copyMessage returns a deep copy of msg
+func copyMessage(msg *dynamic.Message) (*dynamic.Message, error) {
+	data, err := msg.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	copied := dynamic.NewMessage(msg.GetMessageDescriptor())
+	if err := copied.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+// replaceUnresolvedAny walks msg, replacing Any values the resolver can't
+// resolve with a placeholder and recording their original contents
+func replaceUnresolvedAny(msg *dynamic.Message, path []fieldPathElem, resolver jsonpb.AnyResolver, fallbacks *[]anyFallback) {
+	visit := func(value interface{}, path []fieldPathElem) (interface{}, bool) {
+		switch v := value.(type) {
+		case *anypb.Any:
+			if replacement, ok := replaceAny(v, path, resolver, fallbacks); ok {
+				return replacement, true
+			}
+		case *dynamic.Message:
+			replaceUnresolvedAny(v, path, resolver, fallbacks)
+		}
+		return nil, false
+	}
+
+	forEachMessageValue(msg, path, func(fd *desc.FieldDescriptor, key interface{}, value interface{}, path []fieldPathElem) {
+		replacement, ok := visit(value, path)
+		if !ok {
+			return
+		}
+		switch {
+		case fd.IsMap():
+			msg.PutMapField(fd, key, replacement)
+		case fd.IsRepeated():
+			msg.SetRepeatedField(fd, key.(int), replacement)
+		default:
+			msg.SetField(fd, replacement)
+		}
+	})
+}
+
+// replaceAny returns the replacement for an Any value that needs one: a
+// placeholder if its type is unresolvable, or else a copy whose embedded
+// message has its own unresolvable Any values replaced, at any depth
+func replaceAny(v *anypb.Any, path []fieldPathElem, resolver jsonpb.AnyResolver, fallbacks *[]anyFallback) (*anypb.Any, bool) {
+	resolved, err := resolver.Resolve(v.GetTypeUrl())
+	if err != nil {
+		*fallbacks = append(*fallbacks, anyFallback{path: path, typeURL: v.GetTypeUrl(), value: v.GetValue()})
+		return &anypb.Any{TypeUrl: anyPlaceholderTypeURL}, true
+	}
+	md, err := resolvedMessageDescriptor(resolved)
+	if err != nil {
+		return nil, false
+	}
+
+	before := len(*fallbacks)
+	var value []byte
+	if md.GetFullyQualifiedName() == "google.protobuf.Any" {
+		// An Any holding an Any nests it under "value"
+		inner := &anypb.Any{}
+		if err := proto.Unmarshal(v.GetValue(), inner); err != nil {
+			return nil, false
+		}
+		replacement, ok := replaceAny(inner, appendPath(path, "value"), resolver, fallbacks)
+		if !ok {
+			return nil, false
+		}
+		value, err = proto.Marshal(replacement)
+	} else {
+		// The embedded message's fields sit beside "@type"
+		inner := dynamic.NewMessage(md)
+		if err := inner.Unmarshal(v.GetValue()); err != nil {
+			return nil, false
+		}
+		replaceUnresolvedAny(inner, path, resolver, fallbacks)
+		if len(*fallbacks) == before {
+			return nil, false
+		}
+		value, err = inner.Marshal()
+	}
+	if err != nil {
+		*fallbacks = (*fallbacks)[:before]
+		return nil, false
+	}
+	return &anypb.Any{TypeUrl: v.GetTypeUrl(), Value: value}, true
+}
+
+// resolvedMessageDescriptor returns the descriptor of a message returned by
+// an AnyResolver
+func resolvedMessageDescriptor(resolved protoiface.MessageV1) (*desc.MessageDescriptor, error) {
+	if dm, ok := resolved.(*dynamic.Message); ok {
+		return dm.GetMessageDescriptor(), nil
+	}
+	return desc.LoadMessageDescriptorForMessage(resolved)
+}
+
+// locateMarshalFailure finds the deepest message value under msg that fails
+// to marshal and reports its path, falling back to msg itself
+func locateMarshalFailure(msg *dynamic.Message, path []fieldPathElem, marshaler *jsonpb.Marshaler, err error) error {
+	var located error
+	forEachMessageValue(msg, path, func(fd *desc.FieldDescriptor, _ interface{}, value interface{}, path []fieldPathElem) {
+		if located != nil {
+			return
+		}
+		switch v := value.(type) {
+		case *dynamic.Message:
+			if _, fieldErr := v.MarshalJSONPB(marshaler); fieldErr != nil {
+				located = locateMarshalFailure(v, path, marshaler, fieldErr)
+			}
+		case jsonpbMessage:
+			var buf bytes.Buffer
+			if fieldErr := marshaler.Marshal(&buf, v); fieldErr != nil {
+				located = &MarshalError{
+					FieldPath:   formatFieldPath(path),
+					MessageType: fd.GetMessageType().GetFullyQualifiedName(),
+					Err:         fieldErr,
+				}
+			}
+		}
+	})
+	if located != nil {
+		return located
+	}
+
+	return &MarshalError{
+		FieldPath:   formatFieldPath(path),
+		MessageType: msg.GetMessageDescriptor().GetFullyQualifiedName(),
+		Err:         err,
+	}
+}
+
+// jsonpbMessage is a generated message that jsonpb can marshal
+type jsonpbMessage interface {
+	Reset()
+	String() string
+	ProtoMessage()
+}
+
+// forEachMessageValue calls fn for every message value held by msg's fields,
+// with its JSON path. key is the map key or repeated index, if any.
+func forEachMessageValue(msg *dynamic.Message, path []fieldPathElem, fn func(fd *desc.FieldDescriptor, key interface{}, value interface{}, path []fieldPathElem)) {
+	for _, fd := range msg.GetMessageDescriptor().GetFields() {
+		if fd.GetMessageType() == nil || !msg.HasField(fd) {
+			continue
+		}
+		fieldPath := appendPath(path, fd.GetJSONName())
+
+		switch value := msg.GetField(fd).(type) {
+		case map[interface{}]interface{}:
+			if fd.GetMapValueType().GetMessageType() == nil {
+				continue
+			}
+			for k, v := range value {
+				fn(fd, k, v, appendPath(fieldPath, mapKey(fmt.Sprint(k))))
+			}
+		case []interface{}:
+			for i, v := range value {
+				fn(fd, i, v, appendPath(fieldPath, i))
+			}
+		default:
+			fn(fd, nil, value, fieldPath)
+		}
+	}
+}
+
+// appendPath returns path extended by elem without aliasing path
+func appendPath(path []fieldPathElem, elem fieldPathElem) []fieldPathElem {
+	extended := make([]fieldPathElem, len(path), len(path)+1)
+	copy(extended, path)
+	return append(extended, elem)
+}
+
+// formatFieldPath renders a path as dotted field names with [index] and
+// ["key"] suffixes for repeated and map fields
+func formatFieldPath(path []fieldPathElem) string {
+	var b strings.Builder
+	for i, elem := range path {
+		switch e := elem.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", e)
+		case mapKey:
+			fmt.Fprintf(&b, "[%q]", string(e))
+		case string:
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(e)
+		}
+	}
+	return b.String()
+}
+
+// spliceAnyFallbacks replaces the placeholders in data with objects holding
+// each unresolvable Any's type URL and base64-encoded value
+func spliceAnyFallbacks(data []byte, fallbacks []anyFallback) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, &MarshalError{Err: err}
+	}
+
+	for _, fallback := range fallbacks {
+		raw := map[string]interface{}{
+			"@type": fallback.typeURL,
+			"value": base64.StdEncoding.EncodeToString(fallback.value),
+		}
+		if !setJSONPath(root, fallback.path, raw) {
+			return nil, &MarshalError{
+				FieldPath: formatFieldPath(fallback.path),
+				Err:       fmt.Errorf("unresolvable Any %s not found in output", fallback.typeURL),
+			}
+		}
+	}
+
+	spliced, err := json.Marshal(root)
+	if err != nil {
+		return nil, &MarshalError{Err: err}
+	}
+	return spliced, nil
+}
+
+// setJSONPath replaces the value at path in a decoded JSON document
+func setJSONPath(node interface{}, path []fieldPathElem, value interface{}) bool {
+	if len(path) == 0 {
+		return false
+	}
+	last := len(path) == 1
+
+	switch elem := path[0].(type) {
+	case int:
+		arr, ok := node.([]interface{})
+		if !ok || elem >= len(arr) {
+			return false
+		}
+		if last {
+			arr[elem] = value
+			return true
+		}
+		return setJSONPath(arr[elem], path[1:], value)
+	default:
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		key := fmt.Sprint(elem)
+		child, exists := obj[key]
+		if !exists {
+			return false
+		}
+		if last {
+			obj[key] = value
+			return true
+		}
+		return setJSONPath(child, path[1:], value)
+	}
+}
@@ -0,0 +1,225 @@
+package invoker
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// marshalTestProtos defines a response nesting Any values in repeated and map
+// fields, and a payload type, with an enum, the response file does not import
+var marshalTestProtos = map[string]string{
+	"nest/v1/nest.proto": `syntax = "proto3";
+package nest.v1;
+import "google/protobuf/any.proto";
+message Item { google.protobuf.Any detail = 1; }
+message Response {
+  repeated Item items = 1;
+  map<string, google.protobuf.Any> by_name = 2;
+  string note = 3;
+}
+`,
+	"payload/v1/payload.proto": `syntax = "proto3";
+package payload.v1;
+enum Level { LEVEL_UNSPECIFIED = 0; LEVEL_HIGH = 2; }
+message Payload { string text = 1; Level level = 2; }
+service PayloadService { rpc Echo(Payload) returns (Payload); }
+`,
+}
+
+// parseMarshalTestProtos returns the nest and payload files
+func parseMarshalTestProtos(t *testing.T) (*desc.FileDescriptor, *desc.FileDescriptor) {
+	t.Helper()
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(marshalTestProtos)}
+	fds, err := parser.ParseFiles("nest/v1/nest.proto", "payload/v1/payload.proto")
+	if err != nil {
+		t.Fatalf("Failed to parse test protos: %v", err)
+	}
+	return fds[0], fds[1]
+}
+
+// newNestResponse builds a Response whose second item and "b" entry hold the given Any
+func newNestResponse(t *testing.T, nestFile *desc.FileDescriptor, detail *anypb.Any) *dynamic.Message {
+	t.Helper()
+	resp := dynamic.NewMessage(nestFile.FindMessage("nest.v1.Response"))
+	for _, value := range []*anypb.Any{{TypeUrl: anyPlaceholderTypeURL}, detail} {
+		item := dynamic.NewMessage(nestFile.FindMessage("nest.v1.Item"))
+		item.SetFieldByName("detail", value)
+		resp.AddRepeatedFieldByName("items", item)
+	}
+	resp.PutMapFieldByName("by_name", "b", detail)
+	resp.SetFieldByName("note", "kept")
+	return resp
+}
+
+// TestMarshalResponseJSON_UnresolvedAny tests that unknown Any types are
+// returned as their type URL and base64 value
+func TestMarshalResponseJSON_UnresolvedAny(t *testing.T) {
+	nestFile, _ := parseMarshalTestProtos(t)
+	resp := newNestResponse(t, nestFile, &anypb.Any{
+		TypeUrl: "type.googleapis.com/payload.v1.Payload",
+		Value:   []byte("\x0a\x05hello"),
+	})
+
+	data, err := marshalResponseJSON(resp, dynamic.AnyResolver(nil, nestFile), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got struct {
+		Items []struct {
+			Detail map[string]interface{} `json:"detail"`
+		} `json:"items"`
+		ByName map[string]map[string]interface{} `json:"byName"`
+		Note   string                            `json:"note"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+
+	raw := map[string]interface{}{"@type": "type.googleapis.com/payload.v1.Payload", "value": "CgVoZWxsbw=="}
+	for name, detail := range map[string]map[string]interface{}{"items[1]": got.Items[1].Detail, "byName": got.ByName["b"]} {
+		if detail["@type"] != raw["@type"] || detail["value"] != raw["value"] {
+			t.Errorf("Expected raw Any at %s, got %v", name, detail)
+		}
+	}
+	if got.Items[0].Detail["@type"] != anyPlaceholderTypeURL {
+		t.Errorf("Expected resolvable Any to be expanded, got %v", got.Items[0].Detail)
+	}
+	if got.Note != "kept" {
+		t.Errorf("Expected other fields to be kept, got %q", got.Note)
+	}
+}
+
+// TestMarshalResponseJSON_NestedUnresolvedAny tests that an unknown Any
+// type inside a resolvable Any, directly or wrapped in another Any, is also
+// returned as its type URL and base64 value
+func TestMarshalResponseJSON_NestedUnresolvedAny(t *testing.T) {
+	nestFile, _ := parseMarshalTestProtos(t)
+	unresolved := &anypb.Any{
+		TypeUrl: "type.googleapis.com/payload.v1.Payload",
+		Value:   []byte("\x0a\x05hello"),
+	}
+	item := dynamic.NewMessage(nestFile.FindMessage("nest.v1.Item"))
+	item.SetFieldByName("detail", unresolved)
+	itemData, err := item.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to marshal item: %v", err)
+	}
+	wrappedItem := &anypb.Any{TypeUrl: "type.googleapis.com/nest.v1.Item", Value: itemData}
+	anyData, err := proto.Marshal(unresolved)
+	if err != nil {
+		t.Fatalf("Failed to marshal Any: %v", err)
+	}
+	wrappedAny := &anypb.Any{TypeUrl: "type.googleapis.com/google.protobuf.Any", Value: anyData}
+
+	resp := dynamic.NewMessage(nestFile.FindMessage("nest.v1.Response"))
+	resp.PutMapFieldByName("by_name", "item", wrappedItem)
+	resp.PutMapFieldByName("by_name", "any", wrappedAny)
+
+	data, err := marshalResponseJSON(resp, dynamic.AnyResolver(nil, nestFile), false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var got struct {
+		ByName struct {
+			Item struct {
+				Type   string            `json:"@type"`
+				Detail map[string]string `json:"detail"`
+			} `json:"item"`
+			Any struct {
+				Type  string            `json:"@type"`
+				Value map[string]string `json:"value"`
+			} `json:"any"`
+		} `json:"byName"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Invalid JSON: %v (%s)", err, data)
+	}
+
+	raw := map[string]string{"@type": "type.googleapis.com/payload.v1.Payload", "value": "CgVoZWxsbw=="}
+	if got.ByName.Item.Type != "type.googleapis.com/nest.v1.Item" || !reflect.DeepEqual(got.ByName.Item.Detail, raw) {
+		t.Errorf("Expected the raw Any inside the expanded item, got %s", data)
+	}
+	if got.ByName.Any.Type != "type.googleapis.com/google.protobuf.Any" || !reflect.DeepEqual(got.ByName.Any.Value, raw) {
+		t.Errorf("Expected the raw Any inside the wrapping Any, got %s", data)
+	}
+}
+
+// TestMarshalResponseJSON_FieldPath tests that other failures name the field
+func TestMarshalResponseJSON_FieldPath(t *testing.T) {
+	nestFile, payloadFile := parseMarshalTestProtos(t)
+	resp := newNestResponse(t, nestFile, &anypb.Any{
+		TypeUrl: "type.googleapis.com/payload.v1.Payload",
+		Value:   []byte("\x0a\x05he"), // truncated
+	})
+	resp.ClearFieldByName("by_name")
+
+	_, err := marshalResponseJSON(resp, dynamic.AnyResolver(nil, nestFile, payloadFile), false)
+	var marshalErr *MarshalError
+	if !errors.As(err, &marshalErr) {
+		t.Fatalf("Expected *MarshalError, got %v", err)
+	}
+	if marshalErr.FieldPath != "items[1].detail" {
+		t.Errorf("Expected path items[1].detail, got %q (%v)", marshalErr.FieldPath, err)
+	}
+	if marshalErr.MessageType != "google.protobuf.Any" {
+		t.Errorf("Expected google.protobuf.Any, got %q", marshalErr.MessageType)
+	}
+}
+
+// TestFormatFieldPath tests rendering of field paths
+func TestFormatFieldPath(t *testing.T) {
+	path := []fieldPathElem{"items", 2, "byName", mapKey("a b"), "detail"}
+	if got := formatFieldPath(path); got != `items[2].byName["a b"].detail` {
+		t.Errorf("Unexpected path: %s", got)
+	}
+}
+
+// TestEnumsAsInts tests that enum values render as numbers when requested,
+// both when marshaling a decoded response and re-encoding a JSON one
+func TestEnumsAsInts(t *testing.T) {
+	_, payloadFile := parseMarshalTestProtos(t)
+	payload := dynamic.NewMessage(payloadFile.FindMessage("payload.v1.Payload"))
+	payload.SetFieldByName("level", int32(2))
+
+	data, err := marshalResponseJSON(payload, nil, false)
+	if err != nil || string(data) != `{"level":"LEVEL_HIGH"}` {
+		t.Errorf("Expected the enum name by default, got %s (%v)", data, err)
+	}
+	data, err = marshalResponseJSON(payload, nil, true)
+	if err != nil || string(data) != `{"level":2}` {
+		t.Errorf("Expected the enum number, got %s (%v)", data, err)
+	}
+
+	req := InvokeRequest{MethodDesc: payloadFile.FindService("payload.v1.PayloadService").FindMethodByName("Echo")}
+	if got := enumsAsInts(req, json.RawMessage(`{"text":"hi","level":"LEVEL_HIGH"}`)); string(got) != `{"text":"hi","level":2}` {
+		t.Errorf("Expected the re-encoded response with a numeric enum, got %s", got)
+	}
+	// Fields the schema doesn't define are kept as received
+	got := enumsAsInts(req, json.RawMessage(`{"text":"hi","extra":{"level":"LEVEL_HIGH"},"level":"LEVEL_HIGH"}`))
+	if string(got) != `{"text":"hi","extra":{"level":"LEVEL_HIGH"},"level":2}` {
+		t.Errorf("Expected only the known enum rewritten, got %s", got)
+	}
+	// Responses that don't match the schema are left as received
+	if got := enumsAsInts(req, json.RawMessage(`{"level":true}`)); string(got) != `{"level":true}` {
+		t.Errorf("Expected the response unchanged, got %s", got)
+	}
+
+	// Enums inside an Any whose type resolves are rewritten too
+	nestFile, _ := parseMarshalTestProtos(t)
+	item := nestFile.FindMessage("nest.v1.Item")
+	resolver := dynamic.AnyResolver(nil, payloadFile)
+	data, err = rewriteEnums(item, json.RawMessage(`{"detail":{"@type":"type.googleapis.com/payload.v1.Payload","level":"LEVEL_HIGH"}}`), resolver)
+	if err != nil || string(data) != `{"detail":{"@type":"type.googleapis.com/payload.v1.Payload","level":2}}` {
+		t.Errorf("Expected the enum inside the Any rewritten, got %s (%v)", data, err)
+	}
+}
@@ -0,0 +1,151 @@
+package invoker
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// oauthRefreshMargin is how long before expiry a cached token is replaced
+	oauthRefreshMargin = 30 * time.Second
+	// oauthTokenTimeout bounds a token request when the call has no deadline
+	oauthTokenTimeout = 10 * time.Second
+)
+
+// OAuth2ClientCredentials obtains access tokens with the OAuth2 client
+// credentials grant and sends them as bearer tokens. Tokens are cached and
+// fetched again shortly before they expire.
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// HTTPClient sends token requests; nil uses http.DefaultClient
+	HTTPClient *http.Client
+
+	// Guards the cached token; held while fetching so concurrent calls
+	// share one token request
+	mu     sync.Mutex
+	token  string
+	expiry time.Time // zero if the token server gave no lifetime
+}
+
+// oauthTokenResponse is the token endpoint's response, per RFC 6749 section 5
+type oauthTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// ApplyToGRPC implements CredentialProvider
+func (c *OAuth2ClientCredentials) ApplyToGRPC(ctx context.Context, _ CredentialTarget, opts *GRPCOptions) error {
+	token, err := c.Token(ctx)
+	if err != nil {
+		return err
+	}
+	opts.Metadata.Set("authorization", "Bearer "+token)
+	return nil
+}
+
+// ApplyToHTTP implements CredentialProvider
+func (c *OAuth2ClientCredentials) ApplyToHTTP(ctx context.Context, _ CredentialTarget, req *http.Request, _ *tls.Config) error {
+	token, err := c.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token returns a cached access token, requesting a new one if there is none
+// or it expires within oauthRefreshMargin
+func (c *OAuth2ClientCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && (c.expiry.IsZero() || time.Until(c.expiry) > oauthRefreshMargin) {
+		return c.token, nil
+	}
+
+	token, expiresIn, err := c.fetchToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	c.token = token
+	c.expiry = time.Time{}
+	if expiresIn > 0 {
+		c.expiry = time.Now().Add(expiresIn)
+	}
+	return token, nil
+}
+
+// fetchToken performs the client credentials grant against the token URL
+func (c *OAuth2ClientCredentials) fetchToken(ctx context.Context) (string, time.Duration, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, oauthTokenTimeout)
+		defer cancel()
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid token URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// RFC 6749 section 2.3.1 form-encodes the credentials before basic auth
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tokenResp oauthTokenResponse
+	decodeErr := json.Unmarshal(body, &tokenResp)
+	if resp.StatusCode != http.StatusOK {
+		if decodeErr == nil && tokenResp.Error != "" {
+			if tokenResp.ErrorDescription != "" {
+				return "", 0, fmt.Errorf("token request rejected: %s: %s", tokenResp.Error, tokenResp.ErrorDescription)
+			}
+			return "", 0, fmt.Errorf("token request rejected: %s", tokenResp.Error)
+		}
+		return "", 0, fmt.Errorf("token request failed: HTTP %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return "", 0, fmt.Errorf("invalid token response: %w", decodeErr)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", 0, fmt.Errorf("token response has no access_token")
+	}
+	if tokenResp.TokenType != "" && !strings.EqualFold(tokenResp.TokenType, "bearer") {
+		return "", 0, fmt.Errorf("unsupported token type %q", tokenResp.TokenType)
+	}
+
+	return tokenResp.AccessToken, time.Duration(tokenResp.ExpiresIn) * time.Second, nil
+}
@@ -0,0 +1,142 @@
+package invoker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+)
+
+// startTokenServer serves client credentials grants, issuing numbered tokens
+// with the given lifetime, and counts the requests it receives
+func startTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		id, secret, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || id != "client" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client","error_description":"bad credentials"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d-%s", n, r.FormValue("scope")),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// TestOAuth2ClientCredentials tests that tokens are fetched once, cached and
+// sent as bearer tokens
+func TestOAuth2ClientCredentials(t *testing.T) {
+	tokenServer, requests := startTokenServer(t, 3600)
+
+	var gotAuth []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = append(gotAuth, r.Header.Get("Authorization"))
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	inv := New()
+	defer inv.Close()
+	inv.SetCredentialProvider("oauth", &OAuth2ClientCredentials{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"read", "write"},
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+			Endpoint:    strings.TrimPrefix(server.URL, "http://"),
+			ServiceName: "test.v1.TestService",
+			MethodName:  "TestMethod",
+			RequestJSON: json.RawMessage(`{}`),
+			Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+			Credentials: "oauth",
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !resp.Success {
+			t.Fatalf("Expected success, got error: %s", resp.Error)
+		}
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 token request, got %d", got)
+	}
+	for _, auth := range gotAuth {
+		if auth != "Bearer token-1-read write" {
+			t.Errorf("Expected cached bearer token, got %q", auth)
+		}
+	}
+}
+
+// TestOAuth2ClientCredentials_Refresh tests that tokens close to expiry are
+// replaced
+func TestOAuth2ClientCredentials_Refresh(t *testing.T) {
+	// Tokens that expire within the refresh margin are never reused
+	tokenServer, requests := startTokenServer(t, 10)
+
+	creds := &OAuth2ClientCredentials{TokenURL: tokenServer.URL, ClientID: "client", ClientSecret: "s3cret"}
+	first, err := creds.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	second, err := creds.Token(context.Background())
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+
+	if first == second || requests.Load() != 2 {
+		t.Errorf("Expected a fresh token per call, got %q and %q after %d requests", first, second, requests.Load())
+	}
+}
+
+// TestOAuth2ClientCredentials_Rejected tests that token endpoint errors fail
+// the invocation
+func TestOAuth2ClientCredentials_Rejected(t *testing.T) {
+	tokenServer, _ := startTokenServer(t, 3600)
+
+	inv := New()
+	defer inv.Close()
+	inv.SetCredentialProvider("oauth", &OAuth2ClientCredentials{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "wrong",
+	})
+
+	resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:    closedEndpoint(t),
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		RequestJSON: json.RawMessage(`{}`),
+		Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+		Credentials: "oauth",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Error, "invalid_client: bad credentials") {
+		t.Errorf("Expected token rejection error, got %q", resp.Error)
+	}
+	if strings.Contains(resp.Error, "wrong") {
+		t.Errorf("Error must not include the client secret: %q", resp.Error)
+	}
+	if resp.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_CONNECTION {
+		t.Errorf("Expected ERROR_KIND_CONNECTION, got %v", resp.ErrorKind)
+	}
+}
@@ -0,0 +1,203 @@
+package invoker
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// probeService and probeMethod name the procedure used to probe endpoints.
+	// Health checks are cheap and side-effect free, and servers that don't
+	// implement them still answer with a protocol-specific error.
+	probeService = "grpc.health.v1.Health"
+	probeMethod  = "Check"
+	// maxProbeResponseBytes bounds how much of a Connect probe response is read
+	maxProbeResponseBytes = 64 * 1024
+)
+
+// TransportProbeResult reports whether an endpoint appears to speak one transport
+type TransportProbeResult struct {
+	Transport catalogv1.Transport
+	Supported bool
+	Detail    string
+}
+
+// TransportProbe summarizes the transports an endpoint appears to support
+type TransportProbe struct {
+	Results []TransportProbeResult
+	// Recommended is the transport to use when the caller hasn't chosen one:
+	// Connect when supported, then gRPC, falling back to Connect
+	Recommended catalogv1.Transport
+}
+
+// ProbeTransports sends a cheap request over each transport to endpoint and
+// reports which ones get a protocol-conformant answer. The context bounds the
+// whole probe.
+func ProbeTransports(ctx context.Context, endpoint string, useTLS bool, serverName string) *TransportProbe {
+	return probeTransports(ctx, endpoint, useTLS, serverName, nil)
+}
+
+// probeTransports implements ProbeTransports, verifying TLS against roots, or
+// the system roots when nil
+func probeTransports(ctx context.Context, endpoint string, useTLS bool, serverName string, roots *x509.CertPool) *TransportProbe {
+	client, closeClient := newProbeClient(useTLS, serverName, roots)
+	defer closeClient()
+
+	results := []TransportProbeResult{
+		probeConnect(ctx, client, endpoint, useTLS),
+		probeGRPC(ctx, endpoint, useTLS, serverName, roots),
+	}
+
+	probe := &TransportProbe{
+		Results:     results,
+		Recommended: catalogv1.Transport_TRANSPORT_CONNECT,
+	}
+	for _, result := range results {
+		if result.Supported {
+			probe.Recommended = result.Transport
+			break
+		}
+	}
+	return probe
+}
+
+// transportChoice is a transport selected by probing, cached per endpoint
+type transportChoice struct {
+	transport catalogv1.Transport
+	probedAt  time.Time
+}
+
+// AutoTransport returns the transport ProbeTransports recommends for
+// endpoint. The choice is cached for the connection TTL, so only the first
+// call to an endpoint pays for the probes. Probes that find no supported
+// transport aren't cached, as the endpoint may just be down.
+func (inv *Invoker) AutoTransport(ctx context.Context, endpoint string, useTLS bool, serverName string) catalogv1.Transport {
+	key := connectionKey(endpoint, useTLS, serverName, "")
+
+	inv.mu.Lock()
+	choice, ok := inv.transports[key]
+	ttl := inv.connectionTTL
+	inv.mu.Unlock()
+	if ok && inv.now().Sub(choice.probedAt) < ttl {
+		return choice.transport
+	}
+
+	probe := probeTransports(ctx, endpoint, useTLS, serverName, inv.rootCAs)
+	for _, result := range probe.Results {
+		if result.Supported {
+			inv.mu.Lock()
+			// Pruning here too bounds the cache for invokers that never
+			// dial gRPC
+			inv.cleanupStaleConnections()
+			if inv.transports == nil {
+				inv.transports = make(map[string]transportChoice)
+			}
+			inv.transports[key] = transportChoice{transport: probe.Recommended, probedAt: inv.now()}
+			inv.mu.Unlock()
+			break
+		}
+	}
+	return probe.Recommended
+}
+
+// newProbeClient returns an HTTP client for the probes of one run, and a
+// function that closes its idle connections once the run is done. TLS
+// verifies against roots, or the system roots when nil.
+func newProbeClient(useTLS bool, serverName string, roots *x509.CertPool) (*http.Client, func()) {
+	transport := &http.Transport{}
+	if useTLS {
+		tlsConfig := newTLSConfig(serverName)
+		tlsConfig.RootCAs = roots
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{Transport: transport}, transport.CloseIdleConnections
+}
+
+// probeConnect sends a Connect unary request with client and checks for a
+// success or a Connect error body in response
+func probeConnect(ctx context.Context, client *http.Client, endpoint string, useTLS bool) TransportProbeResult {
+	result := TransportProbeResult{Transport: catalogv1.Transport_TRANSPORT_CONNECT}
+
+	req := InvokeRequest{Endpoint: endpoint, ServiceName: probeService, MethodName: probeMethod, UseTLS: useTLS}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, connectURL(req), bytes.NewReader([]byte("{}")))
+	if err != nil {
+		result.Detail = fmt.Sprintf("failed to create request: %v", err)
+		return result
+	}
+	setConnectHeaders(httpReq.Header, req)
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		result.Detail = fmt.Sprintf("request failed: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		result.Supported = true
+		result.Detail = "health check succeeded"
+		return result
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxProbeResponseBytes))
+	var connectErr struct {
+		Code string `json:"code"`
+	}
+	if json.Unmarshal(body, &connectErr) == nil && connectErr.Code != "" {
+		result.Supported = true
+		result.Detail = fmt.Sprintf("Connect error %q", connectErr.Code)
+		return result
+	}
+
+	result.Detail = fmt.Sprintf("HTTP %d without a Connect error body", resp.StatusCode)
+	return result
+}
+
+// probeGRPC sends a gRPC health check over HTTP/2 (h2c when TLS is off). Any
+// gRPC status other than a transport failure means the endpoint speaks gRPC.
+// TLS verifies against roots, or the system roots when nil.
+func probeGRPC(ctx context.Context, endpoint string, useTLS bool, serverName string, roots *x509.CertPool) TransportProbeResult {
+	result := TransportProbeResult{Transport: catalogv1.Transport_TRANSPORT_GRPC}
+
+	creds := insecure.NewCredentials()
+	if useTLS {
+		tlsConfig := newTLSConfig(serverName)
+		tlsConfig.RootCAs = roots
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		result.Detail = fmt.Sprintf("failed to create client: %v", err)
+		return result
+	}
+	defer conn.Close()
+
+	err = conn.Invoke(ctx, fmt.Sprintf("/%s/%s", probeService, probeMethod),
+		&healthpb.HealthCheckRequest{}, &healthpb.HealthCheckResponse{})
+	switch code := status.Code(err); code {
+	case codes.OK:
+		result.Supported = true
+		result.Detail = "health check succeeded"
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		result.Detail = fmt.Sprintf("gRPC call failed: %v", err)
+	default:
+		result.Supported = true
+		result.Detail = fmt.Sprintf("gRPC status %s", code)
+	}
+	return result
+}
@@ -0,0 +1,112 @@
+package invoker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"google.golang.org/grpc"
+)
+
+// TestProbeTransports tests transport detection against gRPC-only and Connect-only servers
+func TestProbeTransports(t *testing.T) {
+	grpcEndpoint := startTestGRPCServer(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	})
+
+	connectServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"unimplemented","message":"no health service"}`))
+	}))
+	defer connectServer.Close()
+
+	plainServer := httptest.NewServer(http.NotFoundHandler())
+	defer plainServer.Close()
+
+	tests := []struct {
+		name            string
+		endpoint        string
+		wantConnect     bool
+		wantGRPC        bool
+		wantRecommended catalogv1.Transport
+	}{
+		{"gRPC server", grpcEndpoint, false, true, catalogv1.Transport_TRANSPORT_GRPC},
+		{"Connect server", connectServer.URL[len("http://"):], true, false, catalogv1.Transport_TRANSPORT_CONNECT},
+		{"plain HTTP server", plainServer.URL[len("http://"):], false, false, catalogv1.Transport_TRANSPORT_CONNECT},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			probe := ProbeTransports(ctx, tt.endpoint, false, "")
+
+			supported := make(map[catalogv1.Transport]bool)
+			for _, result := range probe.Results {
+				supported[result.Transport] = result.Supported
+				if result.Detail == "" {
+					t.Errorf("Expected detail for %v", result.Transport)
+				}
+			}
+			if supported[catalogv1.Transport_TRANSPORT_CONNECT] != tt.wantConnect {
+				t.Errorf("Expected Connect supported=%v, got %+v", tt.wantConnect, probe.Results)
+			}
+			if supported[catalogv1.Transport_TRANSPORT_GRPC] != tt.wantGRPC {
+				t.Errorf("Expected gRPC supported=%v, got %+v", tt.wantGRPC, probe.Results)
+			}
+			if probe.Recommended != tt.wantRecommended {
+				t.Errorf("Expected recommended %v, got %v", tt.wantRecommended, probe.Recommended)
+			}
+		})
+	}
+}
+
+// TestAutoTransport tests that the selected transport is cached per endpoint
+// until the connection TTL passes
+func TestAutoTransport(t *testing.T) {
+	var probes atomic.Int32
+	connectServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			probes.Add(1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"unimplemented"}`))
+	}))
+	defer connectServer.Close()
+	endpoint := connectServer.URL[len("http://"):]
+
+	now := time.Now()
+	inv := NewWithLimits(DefaultMaxConnections, time.Minute, WithClock(func() time.Time { return now }))
+	defer inv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if got := inv.AutoTransport(ctx, endpoint, false, ""); got != catalogv1.Transport_TRANSPORT_CONNECT {
+			t.Fatalf("Expected Connect, got %v", got)
+		}
+	}
+	if got := probes.Load(); got != 1 {
+		t.Errorf("Expected one probe for repeated calls, got %d", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	inv.AutoTransport(ctx, endpoint, false, "")
+	if got := probes.Load(); got != 2 {
+		t.Errorf("Expected a new probe after the TTL, got %d probes", got)
+	}
+
+	// Pruning drops choices past the TTL
+	now = now.Add(2 * time.Minute)
+	inv.PruneConnections()
+	if len(inv.transports) != 0 {
+		t.Errorf("Expected expired transport choices to be pruned, got %d", len(inv.transports))
+	}
+}
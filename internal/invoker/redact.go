@@ -0,0 +1,254 @@
+package invoker
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// RedactedValue replaces redacted metadata values and message fields
+const RedactedValue = "***"
+
+// DefaultRedactedMetadataKeys are always redacted, as they carry credentials
+var DefaultRedactedMetadataKeys = []string{"authorization", "cookie", "proxy-authorization", "x-api-key"}
+
+// Redactor hides sensitive values of an invocation before it is recorded or
+// logged. Metadata is redacted by key; message fields are redacted when
+// marked with the standard debug_redact option or with one of the configured
+// custom bool field options.
+type Redactor struct {
+	// metadataKeys holds lower-cased metadata keys
+	metadataKeys map[string]bool
+	// fieldOptions holds fully qualified names of bool extensions of
+	// google.protobuf.FieldOptions that mark a field as sensitive
+	fieldOptions map[protoreflect.FullName]bool
+}
+
+// NewRedactor creates a redactor for the given metadata keys, in addition to
+// DefaultRedactedMetadataKeys, and custom field options such as
+// "acme.v1.sensitive"
+func NewRedactor(metadataKeys, fieldOptions []string) *Redactor {
+	r := &Redactor{
+		metadataKeys: make(map[string]bool),
+		fieldOptions: make(map[protoreflect.FullName]bool),
+	}
+	for _, key := range append(append([]string(nil), DefaultRedactedMetadataKeys...), metadataKeys...) {
+		r.metadataKeys[strings.ToLower(strings.TrimSpace(key))] = true
+	}
+	for _, option := range fieldOptions {
+		r.fieldOptions[protoreflect.FullName(strings.TrimSpace(option))] = true
+	}
+	return r
+}
+
+// Metadata returns a copy of md with the values of redacted keys replaced.
+// Keys are compared case-insensitively.
+func (r *Redactor) Metadata(md map[string]string) map[string]string {
+	if md == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(md))
+	for k, v := range md {
+		if r.metadataKeys[strings.ToLower(k)] {
+			v = RedactedValue
+		}
+		redacted[k] = v
+	}
+	return redacted
+}
+
+// JSON returns data, the JSON form of an md message or an array of them,
+// with sensitive fields replaced at any depth. Custom field options are
+// resolved against types, typically the session registry's resolver. Data
+// that can't be parsed is redacted whole, since its fields can't be told
+// apart.
+func (r *Redactor) JSON(md *desc.MessageDescriptor, data json.RawMessage, types protoregistry.ExtensionTypeResolver) json.RawMessage {
+	redacted, err := r.redactValue(md, data, types, nil)
+	if err != nil {
+		encoded, _ := json.Marshal(RedactedValue)
+		return encoded
+	}
+	return redacted
+}
+
+// Error returns an invocation's error text with the values of redacted
+// metadata and request fields replaced, since upstream errors may echo
+// them. requestJSON is the JSON form of an md message.
+func (r *Redactor) Error(text string, metadata map[string]string, md *desc.MessageDescriptor, requestJSON json.RawMessage, types protoregistry.ExtensionTypeResolver) string {
+	if text == "" {
+		return text
+	}
+
+	var secrets []string
+	for k, v := range metadata {
+		if !r.metadataKeys[strings.ToLower(k)] {
+			continue
+		}
+		secrets = append(secrets, v)
+		// The credential of "Bearer <token>" may be echoed on its own
+		if _, credential, ok := strings.Cut(v, " "); ok {
+			secrets = append(secrets, credential)
+		}
+	}
+	if md != nil && len(requestJSON) > 0 {
+		_, _ = r.redactValue(md, requestJSON, types, &secrets)
+	}
+
+	// Longer values first, so no part of one is left behind by replacing a
+	// shorter value it contains
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	for _, secret := range secrets {
+		if strings.TrimSpace(secret) != "" {
+			text = strings.ReplaceAll(text, secret, RedactedValue)
+		}
+	}
+	return text
+}
+
+// redactValue redacts a JSON object or array of objects of type md. If found
+// is non-nil, the string values of redacted fields are appended to it.
+func (r *Redactor) redactValue(md *desc.MessageDescriptor, data json.RawMessage, types protoregistry.ExtensionTypeResolver, found *[]string) (json.RawMessage, error) {
+	// Well-known types have special JSON forms and no fields to mark
+	if isWellKnownType(md) {
+		return data, nil
+	}
+
+	trimmed := strings.TrimSpace(string(data))
+	switch {
+	case trimmed == "null":
+		return data, nil
+	case strings.HasPrefix(trimmed, "["):
+		var elements []json.RawMessage
+		if err := json.Unmarshal(data, &elements); err != nil {
+			return nil, err
+		}
+		for i, element := range elements {
+			redacted, err := r.redactValue(md, element, types, found)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = redacted
+		}
+		return json.Marshal(elements)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for name, value := range fields {
+		fd := md.FindFieldByJSONName(name)
+		if fd == nil {
+			fd = md.FindFieldByName(name)
+		}
+		if fd == nil {
+			continue
+		}
+
+		if r.sensitive(fd, types) {
+			if found != nil {
+				collectStrings(value, found)
+			}
+			fields[name], _ = json.Marshal(RedactedValue)
+			continue
+		}
+
+		redacted, err := r.redactField(fd, value, types, found)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = redacted
+	}
+	return json.Marshal(fields)
+}
+
+// redactField redacts within a field's value when it holds messages
+func (r *Redactor) redactField(fd *desc.FieldDescriptor, value json.RawMessage, types protoregistry.ExtensionTypeResolver, found *[]string) (json.RawMessage, error) {
+	if !fd.IsMap() {
+		if fd.GetMessageType() == nil {
+			return value, nil
+		}
+		// Repeated fields are arrays, which redactValue walks
+		return r.redactValue(fd.GetMessageType(), value, types, found)
+	}
+
+	valueField := fd.GetMapValueType()
+	if valueField.GetMessageType() == nil {
+		return value, nil
+	}
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(value, &entries); err != nil {
+		return nil, err
+	}
+	for key, entry := range entries {
+		redacted, err := r.redactField(valueField, entry, types, found)
+		if err != nil {
+			return nil, err
+		}
+		entries[key] = redacted
+	}
+	return json.Marshal(entries)
+}
+
+// collectStrings appends the strings within a JSON value to found
+func collectStrings(value json.RawMessage, found *[]string) {
+	var decoded interface{}
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return
+	}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			*found = append(*found, v)
+		case []interface{}:
+			for _, element := range v {
+				walk(element)
+			}
+		case map[string]interface{}:
+			for _, element := range v {
+				walk(element)
+			}
+		}
+	}
+	walk(decoded)
+}
+
+// sensitive reports whether a field is marked with debug_redact or one of
+// the configured custom options
+func (r *Redactor) sensitive(fd *desc.FieldDescriptor, types protoregistry.ExtensionTypeResolver) bool {
+	opts := fd.GetFieldOptions()
+	if opts.GetDebugRedact() {
+		return true
+	}
+	if len(r.fieldOptions) == 0 || opts == nil || types == nil {
+		return false
+	}
+
+	// Custom options are unknown fields until parsed against the session's
+	// extensions
+	data, err := proto.Marshal(opts)
+	if err != nil || len(data) == 0 {
+		return false
+	}
+	resolved := opts.ProtoReflect().New().Interface()
+	if err := (proto.UnmarshalOptions{Resolver: types}).Unmarshal(data, resolved); err != nil {
+		return false
+	}
+
+	marked := false
+	resolved.ProtoReflect().Range(func(ext protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if ext.IsExtension() && r.fieldOptions[ext.FullName()] && ext.Kind() == protoreflect.BoolKind && v.Bool() {
+			marked = true
+			return false
+		}
+		return true
+	})
+	return marked
+}
@@ -0,0 +1,127 @@
+package invoker
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// redactTestProtos mark fields with debug_redact and a custom option, at the
+// top level and inside singular, repeated and map message fields
+var redactTestProtos = map[string]string{
+	"acme/v1/options.proto": `syntax = "proto3";
+package acme.v1;
+import "google/protobuf/descriptor.proto";
+extend google.protobuf.FieldOptions { bool sensitive = 50001; }
+`,
+	"acme/v1/login.proto": `syntax = "proto3";
+package acme.v1;
+import "acme/v1/options.proto";
+import "google/protobuf/timestamp.proto";
+message Card { string number = 1 [(acme.v1.sensitive) = true]; string holder = 2; }
+message LoginRequest {
+  string user_name = 1;
+  string password = 2 [debug_redact = true];
+  Card card = 3;
+  repeated Card cards = 4;
+  map<string, Card> cards_by_label = 5;
+  google.protobuf.Timestamp at = 6;
+}
+`,
+}
+
+// TestRedactor_JSON tests that marked fields are redacted at any depth, by
+// JSON or proto name, and that custom options apply only when configured
+func TestRedactor_JSON(t *testing.T) {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(redactTestProtos)}
+	fds, err := parser.ParseFiles("acme/v1/login.proto")
+	if err != nil {
+		t.Fatalf("Failed to parse test protos: %v", err)
+	}
+	md := fds[0].FindMessage("acme.v1.LoginRequest")
+	types := &protoregistry.Types{}
+	sensitive := fds[0].GetDependencies()[0].FindExtensionByName("acme.v1.sensitive")
+	if err := types.RegisterExtension(dynamicpb.NewExtensionType(sensitive.UnwrapField())); err != nil {
+		t.Fatalf("RegisterExtension failed: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		fieldOptions []string
+		in           string
+		want         string
+	}{
+		{"debug_redact", nil, `{"userName":"ann","password":"hunter2"}`, `{"userName":"ann","password":"***"}`},
+		{"proto name", nil, `{"user_name":"ann","password":"hunter2"}`, `{"user_name":"ann","password":"***"}`},
+		{"custom option not configured", nil, `{"card":{"number":"4111","holder":"ann"}}`, `{"card":{"number":"4111","holder":"ann"}}`},
+		{"nested", []string{"acme.v1.sensitive"}, `{"card":{"number":"4111","holder":"ann"}}`, `{"card":{"number":"***","holder":"ann"}}`},
+		{"repeated", []string{"acme.v1.sensitive"}, `{"cards":[{"number":"1"},{"holder":"ann"}]}`, `{"cards":[{"number":"***"},{"holder":"ann"}]}`},
+		{"map", []string{"acme.v1.sensitive"}, `{"cardsByLabel":{"work":{"number":"1"}}}`, `{"cardsByLabel":{"work":{"number":"***"}}}`},
+		{"array request", nil, `[{"password":"a"},{"password":"b"}]`, `[{"password":"***"},{"password":"***"}]`},
+		{"well-known type", nil, `{"at":"2024-01-01T00:00:00Z"}`, `{"at":"2024-01-01T00:00:00Z"}`},
+		{"unknown field kept", nil, `{"extra":1}`, `{"extra":1}`},
+		{"unparseable", nil, `{"password":`, `"***"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewRedactor(nil, tt.fieldOptions).JSON(md, json.RawMessage(tt.in), types)
+			var gotValue, wantValue any
+			if err := json.Unmarshal(got, &gotValue); err != nil {
+				t.Fatalf("Redacted JSON is invalid: %s", got)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantValue); err != nil {
+				t.Fatalf("Invalid want: %v", err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestRedactor_Metadata tests that default and configured keys are redacted
+// case-insensitively without modifying the input
+func TestRedactor_Metadata(t *testing.T) {
+	md := map[string]string{"Authorization": "Bearer x", "x-tenant-secret": "s", "x-request-id": "1"}
+	got := NewRedactor([]string{"X-Tenant-Secret"}, nil).Metadata(md)
+
+	want := map[string]string{"Authorization": RedactedValue, "x-tenant-secret": RedactedValue, "x-request-id": "1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if md["Authorization"] != "Bearer x" {
+		t.Error("Expected the input metadata to be left unchanged")
+	}
+	if NewRedactor(nil, nil).Metadata(nil) != nil {
+		t.Error("Expected nil metadata to stay nil")
+	}
+}
+
+// TestRedactor_Error tests that values of redacted metadata and request
+// fields echoed in an error are replaced
+func TestRedactor_Error(t *testing.T) {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(redactTestProtos)}
+	fds, err := parser.ParseFiles("acme/v1/login.proto")
+	if err != nil {
+		t.Fatalf("Failed to parse test protos: %v", err)
+	}
+	md := fds[0].FindMessage("acme.v1.LoginRequest")
+
+	text := `user "ann" with password "hunter2" and token "tok-123" (Bearer tok-123) rejected`
+	got := NewRedactor(nil, nil).Error(text,
+		map[string]string{"Authorization": "Bearer tok-123", "x-request-id": "ann"},
+		md, json.RawMessage(`{"userName":"ann","password":"hunter2"}`), nil)
+
+	want := `user "ann" with password "***" and token "***" (***) rejected`
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got := NewRedactor(nil, nil).Error("", map[string]string{"authorization": "x"}, md, nil, nil); got != "" {
+		t.Errorf("Expected empty text to stay empty, got %q", got)
+	}
+}
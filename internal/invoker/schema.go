@@ -0,0 +1,148 @@
+package invoker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// SchemaError reports request JSON that does not match the method's input
+// message, such as an unknown field or a value of the wrong type
+type SchemaError struct {
+	// FieldPath locates the field using JSON names, e.g. "items[2].detail"
+	FieldPath string
+	// MessageType is the fully qualified type of the message holding the field
+	MessageType string
+	Err         error
+}
+
+func (e *SchemaError) Error() string {
+	if e.FieldPath == "" {
+		return fmt.Sprintf("request does not match %s: %v", e.MessageType, e.Err)
+	}
+	return fmt.Sprintf("invalid request field %s (%s): %v", e.FieldPath, e.MessageType, e.Err)
+}
+
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateRequestSchema is ValidateRequest that also checks the request JSON
+// against the method's input message, as the invocation will parse it. A
+// mismatch returns a *SchemaError naming the offending field.
+func ValidateRequestSchema(req InvokeRequest) error {
+	if err := ValidateRequest(req); err != nil {
+		return err
+	}
+
+	requestJSON := NormalizeRequestJSON(req.RequestJSON)
+	md := req.MethodDesc.GetInputType()
+	unmarshaler := &jsonpb.Unmarshaler{AnyResolver: req.AnyResolver}
+	if err := dynamic.NewMessage(md).UnmarshalJSONPB(unmarshaler, requestJSON); err != nil {
+		return locateSchemaFailure(md, requestJSON, nil, unmarshaler, err)
+	}
+	return nil
+}
+
+// locateSchemaFailure finds the deepest field of data, a JSON object for md,
+// that fails to unmarshal and reports its path, falling back to data itself
+func locateSchemaFailure(md *desc.MessageDescriptor, data []byte, path []fieldPathElem, unmarshaler *jsonpb.Unmarshaler, err error) error {
+	var fields map[string]json.RawMessage
+	if isWellKnownType(md) || json.Unmarshal(data, &fields) != nil {
+		return &SchemaError{FieldPath: formatFieldPath(path), MessageType: md.GetFullyQualifiedName(), Err: err}
+	}
+
+	// Visit fields in a stable order so the same request reports the same field
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msg := dynamic.NewMessage(md)
+	for _, name := range names {
+		fieldPath := appendPath(path, name)
+		fd := msg.FindFieldDescriptorByJSONName(name)
+		if fd == nil {
+			return &SchemaError{
+				FieldPath:   formatFieldPath(fieldPath),
+				MessageType: md.GetFullyQualifiedName(),
+				Err:         fmt.Errorf("unknown field %q", name),
+			}
+		}
+
+		single, _ := json.Marshal(map[string]json.RawMessage{name: fields[name]})
+		fieldErr := dynamic.NewMessage(md).UnmarshalJSONPB(unmarshaler, single)
+		if fieldErr == nil {
+			continue
+		}
+		if located := locateFieldFailure(fd, fields[name], fieldPath, unmarshaler); located != nil {
+			return located
+		}
+		return &SchemaError{FieldPath: formatFieldPath(fieldPath), MessageType: md.GetFullyQualifiedName(), Err: fieldErr}
+	}
+
+	// Every field parses on its own, e.g. two members of the same oneof
+	return &SchemaError{FieldPath: formatFieldPath(path), MessageType: md.GetFullyQualifiedName(), Err: err}
+}
+
+// locateFieldFailure descends into the message values of a field that fails
+// to unmarshal, returning nil when the failure is the field's own
+func locateFieldFailure(fd *desc.FieldDescriptor, data json.RawMessage, path []fieldPathElem, unmarshaler *jsonpb.Unmarshaler) error {
+	valueType := fd.GetMessageType()
+	if fd.IsMap() {
+		valueType = fd.GetMapValueType().GetMessageType()
+	}
+	if valueType == nil || isWellKnownType(valueType) {
+		return nil
+	}
+
+	check := func(value json.RawMessage, path []fieldPathElem) error {
+		if err := dynamic.NewMessage(valueType).UnmarshalJSONPB(unmarshaler, value); err != nil {
+			return locateSchemaFailure(valueType, value, path, unmarshaler, err)
+		}
+		return nil
+	}
+
+	switch {
+	case fd.IsMap():
+		var entries map[string]json.RawMessage
+		if json.Unmarshal(data, &entries) != nil {
+			return nil
+		}
+		keys := make([]string, 0, len(entries))
+		for key := range entries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := check(entries[key], appendPath(path, mapKey(key))); err != nil {
+				return err
+			}
+		}
+	case fd.IsRepeated():
+		var elems []json.RawMessage
+		if json.Unmarshal(data, &elems) != nil {
+			return nil
+		}
+		for i, elem := range elems {
+			if err := check(elem, appendPath(path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return check(data, path)
+	}
+	return nil
+}
+
+// isWellKnownType reports whether md has a special JSON form, such as a
+// Timestamp string, whose errors can't be narrowed to one of its fields
+func isWellKnownType(md *desc.MessageDescriptor) bool {
+	return strings.HasPrefix(md.GetFullyQualifiedName(), "google.protobuf.")
+}
@@ -0,0 +1,91 @@
+package invoker
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// schemaTestProto defines a request nesting messages in singular, repeated
+// and map fields
+const schemaTestProto = `syntax = "proto3";
+package shop.v1;
+import "google/protobuf/timestamp.proto";
+message Address { string city = 1; int32 zip_code = 2; }
+message Customer { string name = 1; Address address = 2; }
+message Line { string sku = 1; int64 quantity = 2; }
+message OrderRequest {
+  Customer customer = 1;
+  repeated Line lines = 2;
+  map<string, Address> addresses = 3;
+  google.protobuf.Timestamp placed_at = 4;
+  bool gift = 5;
+}
+message OrderResponse {}
+service OrderService { rpc Order(OrderRequest) returns (OrderResponse); }
+`
+
+// TestValidateRequestSchema tests that schema mismatches are reported with
+// the path of the offending field
+func TestValidateRequestSchema(t *testing.T) {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"shop/v1/shop.proto": schemaTestProto})}
+	fds, err := parser.ParseFiles("shop/v1/shop.proto")
+	if err != nil {
+		t.Fatalf("Failed to parse test proto: %v", err)
+	}
+	methodDesc := fds[0].FindService("shop.v1.OrderService").FindMethodByName("Order")
+
+	tests := []struct {
+		name        string
+		requestJSON string
+		fieldPath   string
+		messageType string
+	}{
+		{"valid", `{"customer":{"name":"a","address":{"zipCode":1}},"lines":[{"sku":"x","quantity":"2"}],"placedAt":"2024-01-01T00:00:00Z"}`, "", ""},
+		{"proto names", `{"placed_at":"2024-01-01T00:00:00Z","customer":{"address":{"zip_code":1}}}`, "", ""},
+		{"empty", ``, "", ""},
+		{"unknown top-level field", `{"gift":true,"coupon":"x"}`, "coupon", "shop.v1.OrderRequest"},
+		{"unknown nested field", `{"customer":{"address":{"street":"x"}}}`, "customer.address.street", "shop.v1.Address"},
+		{"type mismatch", `{"gift":"yes"}`, "gift", "shop.v1.OrderRequest"},
+		{"repeated element", `{"lines":[{"sku":"x"},{"quantity":true}]}`, "lines[1].quantity", "shop.v1.Line"},
+		{"map value", `{"addresses":{"home":{"zipCode":"north"}}}`, `addresses["home"].zipCode`, "shop.v1.Address"},
+		{"well-known type", `{"placedAt":"yesterday"}`, "placedAt", "shop.v1.OrderRequest"},
+		{"not an object", `[1]`, "", "shop.v1.OrderRequest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRequestSchema(InvokeRequest{
+				Endpoint:    "localhost:8080",
+				ServiceName: "shop.v1.OrderService",
+				MethodName:  "Order",
+				MethodDesc:  methodDesc,
+				RequestJSON: json.RawMessage(tt.requestJSON),
+			})
+			if tt.messageType == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got: %v", err)
+				}
+				return
+			}
+
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("Expected a *SchemaError, got %v", err)
+			}
+			if schemaErr.FieldPath != tt.fieldPath {
+				t.Errorf("Expected field path %q, got %q (%v)", tt.fieldPath, schemaErr.FieldPath, err)
+			}
+			if schemaErr.MessageType != tt.messageType {
+				t.Errorf("Expected message type %q, got %q", tt.messageType, schemaErr.MessageType)
+			}
+		})
+	}
+
+	// Basic validation still applies
+	if err := ValidateRequestSchema(InvokeRequest{MethodDesc: methodDesc}); err == nil || !contains(err.Error(), "endpoint is required") {
+		t.Errorf("Expected the endpoint to be required, got %v", err)
+	}
+}
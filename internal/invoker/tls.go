@@ -0,0 +1,152 @@
+package invoker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Well-known response metadata keys describing the negotiated TLS connection
+const (
+	MetadataKeyTLSVersion     = "x-catalog-tls-version"
+	MetadataKeyTLSCipherSuite = "x-catalog-tls-cipher-suite"
+)
+
+// CertificateInfo describes a single certificate presented by a peer
+type CertificateInfo struct {
+	Subject     string
+	Issuer      string
+	DNSNames    []string
+	IPAddresses []string
+	NotBefore   time.Time
+	NotAfter    time.Time
+}
+
+// TLSInfo contains the result of a TLS handshake with an endpoint
+type TLSInfo struct {
+	Certificates      []CertificateInfo // Leaf first
+	Verified          bool
+	VerificationError string
+}
+
+// newTLSConfig builds the client TLS configuration shared by all transports
+func newTLSConfig(serverName string) *tls.Config {
+	tlsConfig := &tls.Config{}
+	if serverName != "" {
+		tlsConfig.ServerName = serverName
+	}
+	return tlsConfig
+}
+
+// tlsConfig builds the client TLS configuration for this invoker's calls
+func (inv *Invoker) tlsConfig(serverName string) *tls.Config {
+	tlsConfig := newTLSConfig(serverName)
+	tlsConfig.RootCAs = inv.rootCAs
+	return tlsConfig
+}
+
+// InspectTLS performs a TLS handshake with the endpoint without sending an RPC
+// and reports the peer certificate chain and whether it verifies against the
+// system roots
+func InspectTLS(ctx context.Context, endpoint, serverName string) (*TLSInfo, error) {
+	return inspectTLS(ctx, endpoint, serverName, nil)
+}
+
+// inspectTLS implements InspectTLS, verifying against roots (nil for system roots)
+func inspectTLS(ctx context.Context, endpoint, serverName string, roots *x509.CertPool) (*TLSInfo, error) {
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+		}
+		serverName = host
+	}
+
+	// Skip verification during the handshake so the chain can be reported
+	// even when it doesn't verify; verification is done explicitly below
+	tlsConfig := newTLSConfig(serverName)
+	tlsConfig.InsecureSkipVerify = true
+
+	dialer := &tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("TLS handshake with %s failed: %w", endpoint, err)
+	}
+	defer conn.Close()
+
+	peerCerts := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(peerCerts) == 0 {
+		return nil, fmt.Errorf("no certificates presented by %s", endpoint)
+	}
+
+	info := &TLSInfo{
+		Certificates: make([]CertificateInfo, len(peerCerts)),
+	}
+	for i, cert := range peerCerts {
+		info.Certificates[i] = newCertificateInfo(cert)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range peerCerts[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = peerCerts[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	info.Verified = err == nil
+	if err != nil {
+		info.VerificationError = err.Error()
+	}
+
+	return info, nil
+}
+
+// newCertificateInfo extracts the reported fields from a certificate
+func newCertificateInfo(cert *x509.Certificate) CertificateInfo {
+	ips := make([]string, len(cert.IPAddresses))
+	for i, ip := range cert.IPAddresses {
+		ips[i] = ip.String()
+	}
+
+	return CertificateInfo{
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		DNSNames:    cert.DNSNames,
+		IPAddresses: ips,
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+	}
+}
+
+// TLSConnection describes the TLS connection negotiated for a call
+type TLSConnection struct {
+	Version     string
+	CipherSuite string
+}
+
+// newTLSConnection describes a completed handshake; plaintext connections
+// (nil state) give the zero value
+func newTLSConnection(state *tls.ConnectionState) TLSConnection {
+	if state == nil || !state.HandshakeComplete {
+		return TLSConnection{}
+	}
+	return TLSConnection{
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+	}
+}
+
+// addTLSMetadata records the negotiated TLS version and cipher suite in
+// response metadata; plaintext connections add nothing
+func addTLSMetadata(md map[string]string, conn TLSConnection) {
+	if conn == (TLSConnection{}) {
+		return
+	}
+	md[MetadataKeyTLSVersion] = conn.Version
+	md[MetadataKeyTLSCipherSuite] = conn.CipherSuite
+}
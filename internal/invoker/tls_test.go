@@ -0,0 +1,285 @@
+package invoker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+)
+
+// TestInspectTLS tests certificate reporting and verification against trusted roots
+func TestInspectTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	endpoint := server.Listener.Addr().String()
+
+	info, err := inspectTLS(context.Background(), endpoint, "example.com", roots)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(info.Certificates) == 0 {
+		t.Fatal("Expected at least one certificate")
+	}
+	leaf := info.Certificates[0]
+	if len(leaf.DNSNames) == 0 || leaf.DNSNames[0] != "example.com" {
+		t.Errorf("Expected SAN example.com, got %v", leaf.DNSNames)
+	}
+	if len(leaf.IPAddresses) == 0 {
+		t.Error("Expected IP SANs to be reported")
+	}
+	if !leaf.NotAfter.After(leaf.NotBefore) {
+		t.Errorf("Expected NotAfter after NotBefore, got %v / %v", leaf.NotBefore, leaf.NotAfter)
+	}
+	if !info.Verified {
+		t.Errorf("Expected chain to verify, got: %s", info.VerificationError)
+	}
+}
+
+// TestInspectTLS_NameMismatch tests that a name mismatch is reported rather than failing
+func TestInspectTLS_NameMismatch(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	info, err := inspectTLS(context.Background(), server.Listener.Addr().String(), "wrong.example.org", roots)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info.Verified {
+		t.Error("Expected verification to fail for mismatched server name")
+	}
+	if info.VerificationError == "" {
+		t.Error("Expected a verification error")
+	}
+}
+
+// TestInspectTLS_NotTLS tests that a plaintext endpoint returns a handshake error
+func TestInspectTLS_NotTLS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if _, err := InspectTLS(context.Background(), server.Listener.Addr().String(), ""); err == nil {
+		t.Fatal("Expected handshake error for plaintext endpoint")
+	}
+}
+
+// TestInvokeConnect_TLSMetadata tests that negotiated TLS details are reported in metadata
+func TestInvokeConnect_TLSMetadata(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	inv := New()
+	defer inv.Close()
+	inv.rootCAs = roots // trusts the test certificate
+
+	resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+		Endpoint:    strings.TrimPrefix(server.URL, "https://"),
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		RequestJSON: json.RawMessage(`{}`),
+		UseTLS:      true,
+		Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Error)
+	}
+
+	if resp.Metadata[MetadataKeyTLSVersion] == "" {
+		t.Errorf("Expected %s in metadata, got %v", MetadataKeyTLSVersion, resp.Metadata)
+	}
+	if resp.Metadata[MetadataKeyTLSCipherSuite] == "" {
+		t.Errorf("Expected %s in metadata, got %v", MetadataKeyTLSCipherSuite, resp.Metadata)
+	}
+	if resp.TLS.Version != resp.Metadata[MetadataKeyTLSVersion] || resp.TLS.CipherSuite != resp.Metadata[MetadataKeyTLSCipherSuite] {
+		t.Errorf("Expected the TLS connection to match the metadata, got %+v", resp.TLS)
+	}
+}
+
+// TestInvokeConnect_ReusesTLSConnections tests that TLS Connect calls share
+// a pooled transport, with or without a timeout, instead of handshaking anew
+func TestInvokeConnect_ReusesTLSConnections(t *testing.T) {
+	var handshakes atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			handshakes.Add(1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	inv := NewWithLimits(DefaultMaxConnections, DefaultConnectionTTL, WithRootCAs(roots))
+	defer inv.Close()
+
+	for _, timeoutSeconds := range []int32{0, 5, 5} {
+		resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+			Endpoint:       strings.TrimPrefix(server.URL, "https://"),
+			ServiceName:    "test.v1.TestService",
+			MethodName:     "TestMethod",
+			RequestJSON:    json.RawMessage(`{}`),
+			UseTLS:         true,
+			TimeoutSeconds: timeoutSeconds,
+			Transport:      catalogv1.Transport_TRANSPORT_CONNECT,
+		})
+		if err != nil || !resp.Success {
+			t.Fatalf("Expected success, got %v, %v", resp, err)
+		}
+	}
+	if got := handshakes.Load(); got != 1 {
+		t.Errorf("Expected 1 connection for 3 calls, got %d", got)
+	}
+}
+
+// TestInvokeConnect_ServerName tests that the TLS server name override applies
+// to Connect calls with and without a timeout
+func TestInvokeConnect_ServerName(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	inv := New()
+	defer inv.Close()
+	inv.rootCAs = roots
+
+	// The test certificate covers example.com but not localhost
+	endpoint := strings.Replace(strings.TrimPrefix(server.URL, "https://"), "127.0.0.1", "localhost", 1)
+
+	tests := []struct {
+		name           string
+		serverName     string
+		timeoutSeconds int32
+		wantSuccess    bool
+	}{
+		{name: "hostname mismatch", wantSuccess: false},
+		{name: "override without timeout", serverName: "example.com", wantSuccess: true},
+		{name: "override with timeout", serverName: "example.com", timeoutSeconds: 5, wantSuccess: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+				Endpoint:       endpoint,
+				ServiceName:    "test.v1.TestService",
+				MethodName:     "TestMethod",
+				RequestJSON:    json.RawMessage(`{}`),
+				UseTLS:         true,
+				ServerName:     tt.serverName,
+				TimeoutSeconds: tt.timeoutSeconds,
+				Transport:      catalogv1.Transport_TRANSPORT_CONNECT,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if resp.Success != tt.wantSuccess {
+				t.Errorf("Expected success=%v, got %v (%s)", tt.wantSuccess, resp.Success, resp.Error)
+			}
+		})
+	}
+}
+
+// TestInvokeConnect_ForceHTTP1 tests that ForceHTTP1 keeps Connect calls on
+// HTTP/1.1 against a server that would negotiate HTTP/2
+func TestInvokeConnect_ForceHTTP1(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Negotiated-Proto", r.Proto)
+		w.Write([]byte(`{}`))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	inv := New()
+	defer inv.Close()
+	inv.rootCAs = roots
+
+	tests := []struct {
+		name       string
+		forceHTTP1 bool
+		wantProto  string
+	}{
+		{name: "default", wantProto: "HTTP/2.0"},
+		{name: "forced", forceHTTP1: true, wantProto: "HTTP/1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := inv.InvokeUnary(context.Background(), InvokeRequest{
+				Endpoint:    strings.TrimPrefix(server.URL, "https://"),
+				ServiceName: "test.v1.TestService",
+				MethodName:  "TestMethod",
+				RequestJSON: json.RawMessage(`{}`),
+				UseTLS:      true,
+				ForceHTTP1:  tt.forceHTTP1,
+				Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !resp.Success {
+				t.Fatalf("Expected success, got error: %s", resp.Error)
+			}
+			if got := resp.Headers["X-Negotiated-Proto"]; got != tt.wantProto {
+				t.Errorf("Expected %s, got %q", tt.wantProto, got)
+			}
+		})
+	}
+}
+
+// TestAddTLSMetadata tests formatting of the negotiated TLS details
+func TestAddTLSMetadata(t *testing.T) {
+	md := make(map[string]string)
+	addTLSMetadata(md, newTLSConnection(nil))
+	if len(md) != 0 {
+		t.Errorf("Expected no metadata for plaintext connection, got %v", md)
+	}
+
+	conn := newTLSConnection(&tls.ConnectionState{
+		HandshakeComplete: true,
+		Version:           tls.VersionTLS13,
+		CipherSuite:       tls.TLS_AES_128_GCM_SHA256,
+	})
+	if conn.Version != "TLS 1.3" || conn.CipherSuite != "TLS_AES_128_GCM_SHA256" {
+		t.Errorf("Expected TLS 1.3 and TLS_AES_128_GCM_SHA256, got %+v", conn)
+	}
+	addTLSMetadata(md, conn)
+	if md[MetadataKeyTLSVersion] != "TLS 1.3" {
+		t.Errorf("Expected TLS 1.3, got %q", md[MetadataKeyTLSVersion])
+	}
+	if md[MetadataKeyTLSCipherSuite] != "TLS_AES_128_GCM_SHA256" {
+		t.Errorf("Expected TLS_AES_128_GCM_SHA256, got %q", md[MetadataKeyTLSCipherSuite])
+	}
+}
@@ -0,0 +1,75 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Accessor loads proto descriptors from a source URL, for backends such as
+// artifact stores that the built-in loaders do not cover
+type Accessor func(sourceURL *url.URL) (*descriptorpb.FileDescriptorSet, error)
+
+// ErrNoAccessor is returned when no accessor is registered for a URL's scheme
+var ErrNoAccessor = errors.New("no accessor registered for scheme")
+
+var (
+	accessorsMu sync.RWMutex
+	accessors   = make(map[string]Accessor)
+)
+
+// RegisterAccessor makes accessor handle source URLs with the given scheme,
+// replacing any accessor registered for it. Schemes are case-insensitive; a
+// nil accessor unregisters the scheme.
+func RegisterAccessor(scheme string, accessor Accessor) {
+	accessorsMu.Lock()
+	defer accessorsMu.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	if accessor == nil {
+		delete(accessors, scheme)
+		return
+	}
+	accessors[scheme] = accessor
+}
+
+// AccessorSchemes returns the schemes with a registered accessor, sorted
+func AccessorSchemes() []string {
+	accessorsMu.RLock()
+	defer accessorsMu.RUnlock()
+
+	schemes := make([]string, 0, len(accessors))
+	for scheme := range accessors {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// LoadFromAccessor loads proto descriptors from sourceURL using the accessor
+// registered for its scheme
+func LoadFromAccessor(sourceURL string) (*descriptorpb.FileDescriptorSet, error) {
+	parsed, err := url.Parse(sourceURL)
+	if err != nil || parsed.Scheme == "" {
+		return nil, fmt.Errorf("invalid source URL %q: a scheme is required", sourceURL)
+	}
+
+	// url.Parse lowercases the scheme
+	accessorsMu.RLock()
+	accessor := accessors[parsed.Scheme]
+	accessorsMu.RUnlock()
+	if accessor == nil {
+		return nil, fmt.Errorf("%w %q", ErrNoAccessor, parsed.Scheme)
+	}
+
+	fds, err := accessor(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s accessor failed: %w", parsed.Scheme, err)
+	}
+	return fds, nil
+}
@@ -0,0 +1,49 @@
+package loader
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestLoadFromAccessor tests dispatching source URLs to registered accessors
+func TestLoadFromAccessor(t *testing.T) {
+	var gotURL *url.URL
+	RegisterAccessor("Artifacts", func(sourceURL *url.URL) (*descriptorpb.FileDescriptorSet, error) {
+		gotURL = sourceURL
+		return &descriptorpb.FileDescriptorSet{
+			File: []*descriptorpb.FileDescriptorProto{{Name: proto.String("api.proto")}},
+		}, nil
+	})
+	defer RegisterAccessor("artifacts", nil)
+
+	fds, err := LoadFromAccessor("artifacts://store/acme/api?version=2")
+	if err != nil {
+		t.Fatalf("LoadFromAccessor failed: %v", err)
+	}
+	if len(fds.File) != 1 || fds.File[0].GetName() != "api.proto" {
+		t.Errorf("Expected the accessor's descriptors, got %v", fds)
+	}
+	if gotURL.Host != "store" || gotURL.Query().Get("version") != "2" {
+		t.Errorf("Expected the parsed source URL, got %v", gotURL)
+	}
+
+	found := false
+	for _, scheme := range AccessorSchemes() {
+		found = found || scheme == "artifacts"
+	}
+	if !found {
+		t.Errorf("Expected artifacts among %v", AccessorSchemes())
+	}
+
+	RegisterAccessor("artifacts", nil)
+	if _, err := LoadFromAccessor("artifacts://store/acme/api"); !errors.Is(err, ErrNoAccessor) {
+		t.Errorf("Expected ErrNoAccessor after unregistering, got %v", err)
+	}
+	if _, err := LoadFromAccessor("no-scheme"); err == nil {
+		t.Error("Expected error for a URL without a scheme")
+	}
+}
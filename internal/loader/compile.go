@@ -0,0 +1,146 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// CompileError describes a single problem found while compiling proto source
+type CompileError struct {
+	Filename string
+	Line     int
+	Column   int
+	Message  string
+}
+
+// Error implements the error interface
+func (e CompileError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d:%d: %s", e.Filename, e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Filename, e.Message)
+}
+
+// CompileResult contains the outcome of compiling inline proto source
+type CompileResult struct {
+	// Descriptors for the submitted files and their imports, dependencies first.
+	// Nil when compilation failed.
+	Descriptors *descriptorpb.FileDescriptorSet
+	// Services, messages and enums defined by the submitted files
+	Info DescriptorInfo
+	// Problems reported by the parser
+	Errors []CompileError
+}
+
+// CompileProto compiles a proto file submitted as text, along with any
+// additional files it imports, using the native protoparse parser. All
+// sources are served from memory; imports must resolve to one of the
+// submitted files or a well-known type.
+func CompileProto(filename, content string, additionalFiles map[string]string) (*CompileResult, error) {
+	sources := make(map[string]string, len(additionalFiles)+1)
+	for name, source := range additionalFiles {
+		sources[name] = source
+	}
+	sources[filename] = content
+
+	filenames := make([]string, 0, len(sources))
+	for name := range sources {
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("invalid file name %q: must be a relative path", name)
+		}
+		filenames = append(filenames, name)
+	}
+	sort.Strings(filenames)
+
+	result := &CompileResult{}
+	parser := protoparse.Parser{
+		Accessor:              protoparse.FileContentsFromMap(sources),
+		IncludeSourceCodeInfo: true,
+		ErrorReporter: func(err protoparse.ErrorWithPos) error {
+			// Keep going so every problem is reported at once
+			result.Errors = append(result.Errors, newCompileError(err))
+			return nil
+		},
+	}
+
+	fds, err := parser.ParseFiles(filenames...)
+	if err != nil {
+		if len(result.Errors) == 0 {
+			result.Errors = append(result.Errors, compileErrorFrom(err, filename))
+		}
+		return result, nil
+	}
+
+	submitted := &descriptorpb.FileDescriptorSet{}
+	for _, fd := range fds {
+		submitted.File = append(submitted.File, fd.AsFileDescriptorProto())
+	}
+	result.Info = GetDescriptorInfo(submitted)
+	result.Descriptors = withDependencies(fds)
+
+	return result, nil
+}
+
+// compileErrorFrom converts an error returned by the parser rather than
+// through the error reporter
+func compileErrorFrom(err error, filename string) CompileError {
+	var errWithPos protoparse.ErrorWithPos
+	if errors.As(err, &errWithPos) {
+		return newCompileError(errWithPos)
+	}
+	return CompileError{Filename: filename, Message: compileErrorMessage(err)}
+}
+
+// newCompileError converts a positioned parser error
+func newCompileError(err protoparse.ErrorWithPos) CompileError {
+	pos := err.GetPosition()
+	return CompileError{
+		Filename: pos.Filename,
+		Line:     pos.Line,
+		Column:   pos.Col,
+		Message:  compileErrorMessage(err.Unwrap()),
+	}
+}
+
+// compileErrorMessage describes a parser error, naming the missing file when
+// an import could not be resolved among the submitted files
+func compileErrorMessage(err error) string {
+	if errors.Is(err, fs.ErrNotExist) {
+		// The parser reports "<import>: file does not exist"
+		missing := strings.TrimSuffix(err.Error(), ": "+fs.ErrNotExist.Error())
+		return fmt.Sprintf("unresolved import %q: file was not supplied", missing)
+	}
+	return err.Error()
+}
+
+// withDependencies builds a descriptor set containing the given files and all
+// of their transitive imports, ordered so dependencies precede dependents
+func withDependencies(fds []*desc.FileDescriptor) *descriptorpb.FileDescriptorSet {
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if seen[fd.GetName()] {
+			return
+		}
+		seen[fd.GetName()] = true
+		for _, dep := range fd.GetDependencies() {
+			add(dep)
+		}
+		set.File = append(set.File, fd.AsFileDescriptorProto())
+	}
+
+	for _, fd := range fds {
+		add(fd)
+	}
+	return set
+}
@@ -0,0 +1,130 @@
+package loader
+
+import (
+	"testing"
+)
+
+const compileTestProto = `syntax = "proto3";
+
+package playground.v1;
+
+import "google/protobuf/timestamp.proto";
+
+service GreeterService {
+  rpc Greet(GreetRequest) returns (GreetResponse);
+}
+
+message GreetRequest {
+  string name = 1;
+}
+
+message GreetResponse {
+  string greeting = 1;
+  google.protobuf.Timestamp at = 2;
+}
+
+enum Mood {
+  MOOD_UNSPECIFIED = 0;
+}
+`
+
+// TestCompileProto tests compiling a valid snippet
+func TestCompileProto(t *testing.T) {
+	result, err := CompileProto("greeter.proto", compileTestProto, nil)
+	if err != nil {
+		t.Fatalf("CompileProto failed: %v", err)
+	}
+
+	if len(result.Errors) != 0 {
+		t.Fatalf("Expected no compile errors, got %v", result.Errors)
+	}
+	if len(result.Info.Services) != 1 || result.Info.Services[0] != "playground.v1.GreeterService" {
+		t.Errorf("Unexpected services: %v", result.Info.Services)
+	}
+	if len(result.Info.Messages) != 2 {
+		t.Errorf("Expected 2 messages, got %v", result.Info.Messages)
+	}
+	if len(result.Info.Enums) != 1 {
+		t.Errorf("Expected 1 enum, got %v", result.Info.Enums)
+	}
+
+	// Imports are included ahead of the files that use them
+	files := result.Descriptors.File
+	if len(files) != 2 || files[0].GetName() != "google/protobuf/timestamp.proto" || files[1].GetName() != "greeter.proto" {
+		t.Errorf("Unexpected descriptor order: %v", files)
+	}
+}
+
+// TestCompileProto_SyntaxError tests that parse errors carry their position
+func TestCompileProto_SyntaxError(t *testing.T) {
+	source := "syntax = \"proto3\";\n\nmessage Broken {\n  string name = ;\n}\n"
+
+	result, err := CompileProto("broken.proto", source, nil)
+	if err != nil {
+		t.Fatalf("CompileProto failed: %v", err)
+	}
+
+	if result.Descriptors != nil {
+		t.Error("Expected no descriptors for invalid source")
+	}
+	if len(result.Errors) == 0 {
+		t.Fatal("Expected compile errors")
+	}
+
+	compileErr := result.Errors[0]
+	if compileErr.Filename != "broken.proto" || compileErr.Line != 4 || compileErr.Column == 0 {
+		t.Errorf("Unexpected error position: %+v", compileErr)
+	}
+}
+
+// TestCompileProto_AdditionalFiles tests that submitted files can import each other
+func TestCompileProto_AdditionalFiles(t *testing.T) {
+	app := `syntax = "proto3";
+package app.v1;
+import "common/v1/common.proto";
+message Wrapper { common.v1.Shared shared = 1; }
+`
+	common := `syntax = "proto3";
+package common.v1;
+message Shared { string id = 1; }
+`
+
+	result, err := CompileProto("app.proto", app, map[string]string{"common/v1/common.proto": common})
+	if err != nil {
+		t.Fatalf("CompileProto failed: %v", err)
+	}
+	if len(result.Errors) != 0 {
+		t.Fatalf("Expected no compile errors, got %v", result.Errors)
+	}
+	if len(result.Info.Messages) != 2 {
+		t.Errorf("Expected messages from both files, got %v", result.Info.Messages)
+	}
+}
+
+// TestCompileProto_UnresolvedImport tests that a missing dependency is named in the error
+func TestCompileProto_UnresolvedImport(t *testing.T) {
+	source := "syntax = \"proto3\";\nimport \"common.proto\";\nmessage A {}\n"
+
+	result, err := CompileProto("a.proto", source, nil)
+	if err != nil {
+		t.Fatalf("CompileProto failed: %v", err)
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 compile error, got %v", result.Errors)
+	}
+
+	compileErr := result.Errors[0]
+	if compileErr.Message != `unresolved import "common.proto": file was not supplied` {
+		t.Errorf("Unexpected message: %s", compileErr.Message)
+	}
+	if compileErr.Filename != "a.proto" || compileErr.Line != 2 {
+		t.Errorf("Expected error at the import statement, got %+v", compileErr)
+	}
+}
+
+// TestCompileProto_InvalidFilename tests that paths escaping the temp dir are rejected
+func TestCompileProto_InvalidFilename(t *testing.T) {
+	if _, err := CompileProto("../escape.proto", compileTestProto, nil); err == nil {
+		t.Fatal("Expected error for non-local file name")
+	}
+}
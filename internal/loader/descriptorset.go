@@ -0,0 +1,219 @@
+package loader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	// DefaultMaxDescriptorSetSize bounds how much is read from a descriptor set source
+	DefaultMaxDescriptorSetSize = 64 << 20
+	// descriptorSetFetchTimeout is the timeout for fetching a descriptor set over HTTP
+	descriptorSetFetchTimeout = 30 * time.Second
+)
+
+// gzipMagic is the header that starts every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+var (
+	// ErrDescriptorSetTooLarge reports a descriptor set over the size limit
+	ErrDescriptorSetTooLarge = errors.New("descriptor set too large")
+	// ErrMalformedDescriptorSet reports bytes that don't decode as a FileDescriptorSet
+	ErrMalformedDescriptorSet = errors.New("not a valid FileDescriptorSet")
+	// ErrConflictingFile reports a file defined differently by two descriptor sets
+	ErrConflictingFile = errors.New("conflicting file definitions")
+)
+
+// descriptorSetExtensions are the file extensions recognized as descriptor
+// sets when loading a directory, each optionally followed by ".gz"
+var descriptorSetExtensions = []string{".bin", ".pb"}
+
+// LoadFromDescriptorSet loads a binary FileDescriptorSet (e.g. the output of
+// `buf build -o image.bin`) from a local file, optionally gzip-compressed
+func LoadFromDescriptorSet(path string) (*descriptorpb.FileDescriptorSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+
+	return decodeDescriptorSet(data, path, DefaultMaxDescriptorSetSize)
+}
+
+// LoadFromDescriptorSetDir loads every descriptor set file (*.bin or *.pb,
+// optionally gzipped) under dir and merges them into one set. Files defined
+// by more than one set are kept once; a file whose definitions differ beyond
+// source code info fails with ErrConflictingFile.
+func LoadFromDescriptorSetDir(dir string) (*descriptorpb.FileDescriptorSet, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && isDescriptorSetFile(d.Name()) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set directory: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no descriptor set files (*.bin, *.pb) found in %s", dir)
+	}
+
+	merged := &descriptorpb.FileDescriptorSet{}
+	definedIn := make(map[string]string)
+	byName := make(map[string]*descriptorpb.FileDescriptorProto)
+	for _, path := range paths {
+		fds, err := LoadFromDescriptorSet(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		for _, file := range fds.GetFile() {
+			name := file.GetName()
+			existing, ok := byName[name]
+			if !ok {
+				byName[name] = file
+				definedIn[name] = path
+				merged.File = append(merged.File, file)
+				continue
+			}
+			if !sameFileDefinition(existing, file) {
+				return nil, fmt.Errorf("%w: %s differs between %s and %s", ErrConflictingFile, name, definedIn[name], path)
+			}
+		}
+	}
+
+	return merged, nil
+}
+
+// isDescriptorSetFile reports whether a file name has a descriptor set extension
+func isDescriptorSetFile(name string) bool {
+	name = strings.TrimSuffix(name, ".gz")
+	for _, ext := range descriptorSetExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameFileDefinition compares two file descriptors, ignoring source code
+// info, which depends on build flags rather than the definitions
+func sameFileDefinition(a, b *descriptorpb.FileDescriptorProto) bool {
+	if a.SourceCodeInfo == nil && b.SourceCodeInfo == nil {
+		return proto.Equal(a, b)
+	}
+
+	a = proto.Clone(a).(*descriptorpb.FileDescriptorProto)
+	b = proto.Clone(b).(*descriptorpb.FileDescriptorProto)
+	a.SourceCodeInfo = nil
+	b.SourceCodeInfo = nil
+	return proto.Equal(a, b)
+}
+
+// DecodeDescriptorSet decodes uploaded binary FileDescriptorSet bytes,
+// optionally gzip-compressed. Oversized input, before or after decompression,
+// fails with ErrDescriptorSetTooLarge and undecodable input with
+// ErrMalformedDescriptorSet.
+func DecodeDescriptorSet(data []byte, maxSize int) (*descriptorpb.FileDescriptorSet, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDescriptorSetSize
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrDescriptorSetTooLarge, len(data), maxSize)
+	}
+
+	return decodeDescriptorSet(data, "", maxSize)
+}
+
+// DecodeDescriptorSetJSON decodes a protojson-encoded FileDescriptorSet, such
+// as the catalog's own JSON export. Oversized input fails with
+// ErrDescriptorSetTooLarge and invalid JSON with ErrMalformedDescriptorSet.
+func DecodeDescriptorSetJSON(data []byte, maxSize int) (*descriptorpb.FileDescriptorSet, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDescriptorSetSize
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrDescriptorSetTooLarge, len(data), maxSize)
+	}
+
+	fds := &descriptorpb.FileDescriptorSet{}
+	if err := protojson.Unmarshal(data, fds); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedDescriptorSet, err)
+	}
+
+	return fds, nil
+}
+
+// LoadFromURL fetches a binary FileDescriptorSet over HTTP(S), optionally
+// gzip-compressed
+func LoadFromURL(rawURL string) (*descriptorpb.FileDescriptorSet, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid descriptor set URL %q: must be http or https", rawURL)
+	}
+
+	client := &http.Client{Timeout: descriptorSetFetchTimeout}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch descriptor set: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch descriptor set: HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxDescriptorSetSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+	if len(data) > DefaultMaxDescriptorSetSize {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrDescriptorSetTooLarge, DefaultMaxDescriptorSetSize)
+	}
+
+	return decodeDescriptorSet(data, parsed.Path, DefaultMaxDescriptorSetSize)
+}
+
+// decodeDescriptorSet unmarshals a descriptor set, transparently decompressing
+// it when it starts with the gzip header or its name ends in ".gz"
+func decodeDescriptorSet(data []byte, name string, maxSize int) (*descriptorpb.FileDescriptorSet, error) {
+	if bytes.HasPrefix(data, gzipMagic) || strings.HasSuffix(name, ".gz") {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to decompress: %v", ErrMalformedDescriptorSet, err)
+		}
+		defer zr.Close()
+
+		data, err = io.ReadAll(io.LimitReader(zr, int64(maxSize)+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to decompress: %v", ErrMalformedDescriptorSet, err)
+		}
+		if len(data) > maxSize {
+			return nil, fmt.Errorf("%w: decompressed size exceeds %d bytes", ErrDescriptorSetTooLarge, maxSize)
+		}
+	}
+
+	fds := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, fds); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedDescriptorSet, err)
+	}
+
+	return fds, nil
+}
@@ -0,0 +1,277 @@
+package loader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testDescriptorSetBytes returns a serialized descriptor set for timestamp.proto
+func testDescriptorSetBytes(t *testing.T) []byte {
+	t.Helper()
+
+	fds := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+		},
+	}
+	data, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+	return data
+}
+
+// gzipBytes compresses data with gzip
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("Failed to gzip: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestLoadFromDescriptorSet tests loading plain and gzipped descriptor set files
+func TestLoadFromDescriptorSet(t *testing.T) {
+	data := testDescriptorSetBytes(t)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		filename string
+		contents []byte
+	}{
+		{name: "plain", filename: "image.bin", contents: data},
+		{name: "gzip by magic header", filename: "image.bin", contents: gzipBytes(t, data)},
+		{name: "gzip by extension", filename: "image.bin.gz", contents: gzipBytes(t, data)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.filename)
+			if err := os.WriteFile(path, tt.contents, 0o644); err != nil {
+				t.Fatalf("Failed to write descriptor set: %v", err)
+			}
+
+			fds, err := LoadFromDescriptorSet(path)
+			if err != nil {
+				t.Fatalf("LoadFromDescriptorSet failed: %v", err)
+			}
+			if len(fds.File) != 1 || fds.File[0].GetName() != "google/protobuf/timestamp.proto" {
+				t.Errorf("Unexpected files: %v", fds.File)
+			}
+		})
+	}
+}
+
+// TestLoadFromDescriptorSet_CorruptGzip tests that a bad gzip stream is reported
+func TestLoadFromDescriptorSet_CorruptGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.bin.gz")
+	if err := os.WriteFile(path, []byte("not gzip"), 0o644); err != nil {
+		t.Fatalf("Failed to write descriptor set: %v", err)
+	}
+
+	if _, err := LoadFromDescriptorSet(path); err == nil {
+		t.Fatal("Expected error for corrupt gzip data")
+	}
+}
+
+// TestLoadFromURL tests fetching a gzipped descriptor set over HTTP
+func TestLoadFromURL(t *testing.T) {
+	body := gzipBytes(t, testDescriptorSetBytes(t))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/image.bin" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	fds, err := LoadFromURL(server.URL + "/image.bin")
+	if err != nil {
+		t.Fatalf("LoadFromURL failed: %v", err)
+	}
+	if len(fds.File) != 1 {
+		t.Errorf("Expected 1 file, got %d", len(fds.File))
+	}
+
+	if _, err := LoadFromURL(server.URL + "/missing.bin"); err == nil {
+		t.Error("Expected error for HTTP 404")
+	}
+	if _, err := LoadFromURL("file:///etc/passwd"); err == nil {
+		t.Error("Expected error for non-HTTP URL")
+	}
+}
+
+// TestDecodeDescriptorSet tests decoding uploaded bytes and classifying failures
+func TestDecodeDescriptorSet(t *testing.T) {
+	data := testDescriptorSetBytes(t)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		maxSize int
+		wantErr error
+	}{
+		{name: "plain", data: data},
+		{name: "gzip", data: gzipBytes(t, data)},
+		{name: "too large", data: data, maxSize: len(data) - 1, wantErr: ErrDescriptorSetTooLarge},
+		{name: "too large after decompression", data: gzipBytes(t, data), maxSize: len(data) - 1, wantErr: ErrDescriptorSetTooLarge},
+		{name: "truncated", data: data[:len(data)/2], wantErr: ErrMalformedDescriptorSet},
+		{name: "corrupt gzip", data: append([]byte{0x1f, 0x8b}, "garbage"...), wantErr: ErrMalformedDescriptorSet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fds, err := DecodeDescriptorSet(tt.data, tt.maxSize)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeDescriptorSet failed: %v", err)
+			}
+			if len(fds.File) != 1 {
+				t.Errorf("Expected 1 file, got %d", len(fds.File))
+			}
+		})
+	}
+}
+
+// TestDecodeDescriptorSetJSON tests decoding protojson descriptor sets
+func TestDecodeDescriptorSetJSON(t *testing.T) {
+	fds := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(testDescriptorSetBytes(t), fds); err != nil {
+		t.Fatalf("Failed to unmarshal test descriptor set: %v", err)
+	}
+	data, err := protojson.Marshal(fds)
+	if err != nil {
+		t.Fatalf("Failed to marshal test descriptor set: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		maxSize int
+		wantErr error
+	}{
+		{name: "valid", data: data},
+		{name: "too large", data: data, maxSize: len(data) - 1, wantErr: ErrDescriptorSetTooLarge},
+		{name: "invalid JSON", data: []byte(`{"file": [`), wantErr: ErrMalformedDescriptorSet},
+		{name: "unknown field", data: []byte(`{"files": []}`), wantErr: ErrMalformedDescriptorSet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeDescriptorSetJSON(tt.data, tt.maxSize)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeDescriptorSetJSON failed: %v", err)
+			}
+			if len(decoded.File) != 1 {
+				t.Errorf("Expected 1 file, got %d", len(decoded.File))
+			}
+		})
+	}
+}
+
+// TestLoadFromDescriptorSetDir tests merging the descriptor sets in a directory
+func TestLoadFromDescriptorSetDir(t *testing.T) {
+	timestampFile := protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto)
+	durationFile := protodesc.ToFileDescriptorProto(durationpb.File_google_protobuf_duration_proto)
+
+	writeSet := func(t *testing.T, path string, files ...*descriptorpb.FileDescriptorProto) {
+		t.Helper()
+		data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: files})
+		if err != nil {
+			t.Fatalf("Failed to marshal descriptor set: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("Failed to write descriptor set: %v", err)
+		}
+	}
+
+	t.Run("merges and de-duplicates", func(t *testing.T) {
+		dir := t.TempDir()
+		writeSet(t, filepath.Join(dir, "a.bin"), timestampFile)
+		writeSet(t, filepath.Join(dir, "nested", "b.pb"), timestampFile, durationFile)
+		if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a descriptor set"), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+
+		fds, err := LoadFromDescriptorSetDir(dir)
+		if err != nil {
+			t.Fatalf("LoadFromDescriptorSetDir failed: %v", err)
+		}
+		if len(fds.File) != 2 {
+			t.Fatalf("Expected 2 files, got %d", len(fds.File))
+		}
+		if fds.File[0].GetName() != timestampFile.GetName() || fds.File[1].GetName() != durationFile.GetName() {
+			t.Errorf("Unexpected files: %s, %s", fds.File[0].GetName(), fds.File[1].GetName())
+		}
+	})
+
+	t.Run("conflicting definitions", func(t *testing.T) {
+		dir := t.TempDir()
+		conflicting := proto.Clone(timestampFile).(*descriptorpb.FileDescriptorProto)
+		conflicting.MessageType[0].Name = proto.String("Instant")
+		writeSet(t, filepath.Join(dir, "a.bin"), timestampFile)
+		writeSet(t, filepath.Join(dir, "b.bin"), conflicting)
+
+		_, err := LoadFromDescriptorSetDir(dir)
+		if !errors.Is(err, ErrConflictingFile) {
+			t.Fatalf("Expected ErrConflictingFile, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "google/protobuf/timestamp.proto") {
+			t.Errorf("Expected error to name the file, got %v", err)
+		}
+	})
+
+	t.Run("source info differences are not conflicts", func(t *testing.T) {
+		dir := t.TempDir()
+		withInfo := proto.Clone(timestampFile).(*descriptorpb.FileDescriptorProto)
+		withInfo.SourceCodeInfo = &descriptorpb.SourceCodeInfo{}
+		writeSet(t, filepath.Join(dir, "a.bin"), withInfo)
+		writeSet(t, filepath.Join(dir, "b.bin"), timestampFile)
+
+		if _, err := LoadFromDescriptorSetDir(dir); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		if _, err := LoadFromDescriptorSetDir(t.TempDir()); err == nil {
+			t.Error("Expected error for a directory without descriptor sets")
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+var (
+	// ErrGitNotInstalled is returned when the git executable is not on the PATH
+	ErrGitNotInstalled = errors.New("git not installed or not in PATH")
+
+	// ErrSSHHostKey is returned when the SSH host key of a remote is unknown
+	// or does not match known_hosts
+	ErrSSHHostKey = errors.New("SSH host key verification failed")
+
+	// ErrSSHPermissionDenied is returned when a remote rejects the SSH keys
+	// offered for authentication
+	ErrSSHPermissionDenied = errors.New("SSH authentication failed")
+)
+
+// gitCommandTimeout bounds a whole checkout, so an unresponsive remote
+// cannot hold a load forever
+const gitCommandTimeout = 5 * time.Minute
+
+var (
+	gitSSHCommandMu sync.RWMutex
+	gitSSHCommand   string
+)
+
+// SetGitSSHCommand sets the command git runs to connect to SSH remotes, as
+// with GIT_SSH_COMMAND (e.g., "ssh -i /keys/deploy_key"). Empty uses the
+// environment's GIT_SSH_COMMAND or plain ssh with the ambient agent and keys.
+// The command always runs with BatchMode so it fails instead of prompting.
+func SetGitSSHCommand(command string) {
+	gitSSHCommandMu.Lock()
+	defer gitSSHCommandMu.Unlock()
+	gitSSHCommand = command
+}
+
+// LoadFromGit loads proto descriptors from any git remote, such as GitLab,
+// Bitbucket or a self-hosted server. SSH remotes ("git@host:owner/repo.git"
+// or "ssh://...") authenticate with the ambient SSH agent and keys, or the
+// command set by SetGitSSHCommand. ref selects a branch, tag or commit
+// (default: the remote's HEAD) and subdir limits the build to a directory
+// of the repository (default: the repository root).
+func LoadFromGit(remoteURL, ref, subdir string) (*descriptorpb.FileDescriptorSet, error) {
+	return LoadFromGitWithProgress(remoteURL, ref, subdir, nil)
+}
+
+// LoadFromGitWithProgress is LoadFromGit, reporting its stages to progress
+func LoadFromGitWithProgress(remoteURL, ref, subdir string, progress ProgressFunc) (*descriptorpb.FileDescriptorSet, error) {
+	if subdir != "" && !filepath.IsLocal(subdir) {
+		return nil, fmt.Errorf("invalid subdir %q: must be a relative path within the repository", subdir)
+	}
+
+	// Create temporary directory for the checkout
+	tmpDir, err := os.MkdirTemp("", "connectrpc-catalog-git-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	progress.report(StageCloning, fmt.Sprintf("cloning %s", remoteURL))
+	if err := checkoutGit(remoteURL, ref, tmpDir); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(tmpDir, subdir)
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("subdir %q not found in %s", subdir, remoteURL)
+	}
+
+	// Load protos from the checked out directory
+	return LoadFromPathWithProgress(path, progress)
+}
+
+// checkoutGit fetches a single commit of remoteURL at ref into dir, which
+// must exist and be empty
+func checkoutGit(remoteURL, ref, dir string) error {
+	if remoteURL == "" || strings.HasPrefix(remoteURL, "-") {
+		return fmt.Errorf("invalid git remote %q", remoteURL)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid git ref %q", ref)
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return ErrGitNotInstalled
+	}
+
+	// Fail instead of prompting for credentials or host key confirmation
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if command := sshCommand(); command != "" {
+		env = append(env, "GIT_SSH_COMMAND="+command)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+	defer cancel()
+
+	// Fetching the ref directly works for branches, tags and, where the
+	// server allows it, commit hashes, which clone --branch does not accept
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", "--", remoteURL, ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	}
+	for _, args := range steps {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = env
+		// ssh, spawned by git, can outlive a killed git and hold stderr open
+		cmd.WaitDelay = time.Second
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("git %s timed out after %s", args[0], gitCommandTimeout)
+			}
+			if sshErr := sshError(remoteURL, stderr.String()); sshErr != nil {
+				return sshErr
+			}
+			return fmt.Errorf("git %s failed: %w (stderr: %s)", args[0], err, stderr.String())
+		}
+	}
+	return nil
+}
+
+// sshCommand returns the GIT_SSH_COMMAND for a checkout: the command set by
+// SetGitSSHCommand, else the environment's, else plain ssh, with BatchMode
+// appended. It is empty when the environment picks a program through
+// GIT_SSH, which takes no options.
+func sshCommand() string {
+	gitSSHCommandMu.RLock()
+	command := gitSSHCommand
+	gitSSHCommandMu.RUnlock()
+
+	if command == "" {
+		command = os.Getenv("GIT_SSH_COMMAND")
+	}
+	if command == "" {
+		if os.Getenv("GIT_SSH") != "" {
+			return ""
+		}
+		command = "ssh"
+	}
+	return command + " -o BatchMode=yes"
+}
+
+// isSSHRemote reports whether a git remote is reached over SSH, either as
+// an ssh:// URL or in the scp-like "user@host:path" form
+func isSSHRemote(remoteURL string) bool {
+	if strings.HasPrefix(remoteURL, "ssh://") || strings.HasPrefix(remoteURL, "git+ssh://") {
+		return true
+	}
+	if strings.Contains(remoteURL, "://") {
+		return false
+	}
+	// git treats "host:path" as scp-like unless a slash precedes the colon
+	colon := strings.Index(remoteURL, ":")
+	return colon > 0 && !strings.Contains(remoteURL[:colon], "/")
+}
+
+// sshHost returns the host of an SSH remote, for error messages
+func sshHost(remoteURL string) string {
+	host := remoteURL
+	for _, prefix := range []string{"ssh://", "git+ssh://"} {
+		host = strings.TrimPrefix(host, prefix)
+	}
+	if i := strings.Index(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	if i := strings.IndexAny(host, ":/"); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+// sshError recognizes SSH failures in git's stderr and turns them into
+// errors that say how to fix them, or returns nil
+func sshError(remoteURL, stderr string) error {
+	if !isSSHRemote(remoteURL) {
+		return nil
+	}
+	host := sshHost(remoteURL)
+	switch {
+	case strings.Contains(stderr, "Host key verification failed"),
+		strings.Contains(stderr, "REMOTE HOST IDENTIFICATION HAS CHANGED"):
+		return fmt.Errorf("%w for %s: add its key to known_hosts (e.g., ssh-keyscan %s >> ~/.ssh/known_hosts)", ErrSSHHostKey, host, host)
+	case strings.Contains(stderr, "Permission denied"):
+		return fmt.Errorf("%w for %s: make sure a key with access to the repository is loaded in the SSH agent or set the git SSH command to use one", ErrSSHPermissionDenied, host)
+	default:
+		return nil
+	}
+}
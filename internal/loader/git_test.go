@@ -0,0 +1,207 @@
+package loader
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createGitRepo creates a local repository whose proto file changes between
+// the tagged commit and HEAD
+func createGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping test")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir,
+			"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(dir, "proto"), 0o755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "proto", "api.proto"), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	git("init", "--quiet")
+	write("// v1\n")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "v1")
+	git("tag", "v1")
+	write("// v2\n")
+	git("commit", "--quiet", "-am", "v2")
+	return dir
+}
+
+// TestCheckoutGit tests fetching a remote's HEAD and a tag
+func TestCheckoutGit(t *testing.T) {
+	remote := "file://" + createGitRepo(t)
+
+	for ref, want := range map[string]string{"": "// v2\n", "v1": "// v1\n"} {
+		dir := t.TempDir()
+		if err := checkoutGit(remote, ref, dir); err != nil {
+			t.Fatalf("checkoutGit(%q) failed: %v", ref, err)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "proto", "api.proto"))
+		if err != nil {
+			t.Fatalf("Failed to read checkout: %v", err)
+		}
+		if string(got) != want {
+			t.Errorf("ref %q: expected %q, got %q", ref, want, got)
+		}
+	}
+
+	if err := checkoutGit(remote, "missing", t.TempDir()); err == nil {
+		t.Error("Expected error for an unknown ref")
+	}
+}
+
+// TestLoadFromGit_InvalidArguments tests rejecting arguments git would
+// misinterpret and subdirs outside the repository
+func TestLoadFromGit_InvalidArguments(t *testing.T) {
+	tests := []struct {
+		remote, ref, subdir, want string
+	}{
+		{"--upload-pack=touch /tmp/x", "", "", "invalid git remote"},
+		{"https://example.com/repo.git", "--output=x", "", "invalid git ref"},
+		{"https://example.com/repo.git", "", "../outside", "invalid subdir"},
+		{"https://example.com/repo.git", "", "/etc", "invalid subdir"},
+	}
+	for _, tt := range tests {
+		_, err := LoadFromGit(tt.remote, tt.ref, tt.subdir)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadFromGit(%q, %q, %q): expected %q error, got %v", tt.remote, tt.ref, tt.subdir, tt.want, err)
+		}
+	}
+
+	remote := "file://" + createGitRepo(t)
+	if _, err := LoadFromGit(remote, "", "missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected missing subdir error, got %v", err)
+	}
+}
+
+// TestIsSSHRemote tests recognizing SSH remotes and their hosts
+func TestIsSSHRemote(t *testing.T) {
+	tests := []struct {
+		remote string
+		ssh    bool
+		host   string
+	}{
+		{"git@gitlab.com:acme/protos.git", true, "gitlab.com"},
+		{"ssh://git@bitbucket.org:7999/acme/protos.git", true, "bitbucket.org"},
+		{"git+ssh://git.internal/protos.git", true, "git.internal"},
+		{"https://gitlab.com/acme/protos.git", false, ""},
+		{"file:///srv/protos.git", false, ""},
+		{"./local/dir:with-colon", false, ""},
+		{"/srv/protos.git", false, ""},
+	}
+	for _, tt := range tests {
+		if got := isSSHRemote(tt.remote); got != tt.ssh {
+			t.Errorf("isSSHRemote(%q) = %v, want %v", tt.remote, got, tt.ssh)
+		}
+		if tt.ssh {
+			if got := sshHost(tt.remote); got != tt.host {
+				t.Errorf("sshHost(%q) = %q, want %q", tt.remote, got, tt.host)
+			}
+		}
+	}
+}
+
+// TestCheckoutGit_SSHErrors tests that SSH failures are reported distinctly
+// and that the configured SSH command is used
+func TestCheckoutGit_SSHErrors(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed, skipping test")
+	}
+	defer SetGitSSHCommand("")
+
+	tests := []struct {
+		stderr string
+		want   error
+	}{
+		{"Host key verification failed.", ErrSSHHostKey},
+		{"git@git.internal: Permission denied (publickey).", ErrSSHPermissionDenied},
+	}
+	for _, tt := range tests {
+		// A fake ssh that fails the way the real one would
+		script := filepath.Join(t.TempDir(), "fake-ssh")
+		content := "#!/bin/sh\necho '" + tt.stderr + "' >&2\nexit 255\n"
+		if err := os.WriteFile(script, []byte(content), 0o755); err != nil {
+			t.Fatalf("Failed to write script: %v", err)
+		}
+		SetGitSSHCommand(script)
+
+		err := checkoutGit("git@git.internal:acme/protos.git", "", t.TempDir())
+		if !errors.Is(err, tt.want) {
+			t.Errorf("Expected %v, got %v", tt.want, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "git.internal") {
+			t.Errorf("Expected the error to name the host, got %v", err)
+		}
+	}
+}
+
+// TestSSHCommand tests that the SSH command for git always runs in batch
+// mode, whether it comes from SetGitSSHCommand, the environment or the default
+func TestSSHCommand(t *testing.T) {
+	defer SetGitSSHCommand("")
+
+	tests := []struct {
+		name   string
+		set    string
+		env    string
+		gitSSH string
+		want   string
+	}{
+		{name: "default", want: "ssh -o BatchMode=yes"},
+		{name: "environment", env: "ssh -p 2222", want: "ssh -p 2222 -o BatchMode=yes"},
+		{name: "configured", set: "ssh -i /keys/deploy_key", env: "ssh -p 2222", want: "ssh -i /keys/deploy_key -o BatchMode=yes"},
+		{name: "GIT_SSH program", gitSSH: "/usr/bin/plink", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GIT_SSH_COMMAND", tt.env)
+			t.Setenv("GIT_SSH", tt.gitSSH)
+			SetGitSSHCommand(tt.set)
+
+			if got := sshCommand(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestLoadFromGitWithProgress tests that a git load reports cloning before
+// building, whether or not buf is installed to finish the build
+func TestLoadFromGitWithProgress(t *testing.T) {
+	remote := "file://" + createGitRepo(t)
+
+	var stages []LoadStage
+	_, err := LoadFromGitWithProgress(remote, "", "", func(stage LoadStage, message string) {
+		if message == "" {
+			t.Errorf("Expected a message for stage %s", stage)
+		}
+		stages = append(stages, stage)
+	})
+	// Only the build may fail, and only for want of buf
+	if err != nil && !strings.Contains(err.Error(), "buf build failed") {
+		t.Errorf("Expected the load to succeed or fail in buf, got %v", err)
+	}
+
+	if len(stages) != 2 || stages[0] != StageCloning || stages[1] != StageBuilding {
+		t.Errorf("Expected cloning then building, got %v", stages)
+	}
+}
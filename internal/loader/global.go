@@ -0,0 +1,41 @@
+package loader
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// LoadFromGlobalRegistry builds a descriptor set from files linked into the
+// binary, such as the catalog's own catalog/v1/catalog.proto, looked up by
+// path in protoregistry.GlobalFiles. Their imports are included, each file
+// after its dependencies.
+func LoadFromGlobalRegistry(paths ...string) (*descriptorpb.FileDescriptorSet, error) {
+	fds := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+		fds.File = append(fds.File, protodesc.ToFileDescriptorProto(fd))
+	}
+
+	for _, path := range paths {
+		fd, err := protoregistry.GlobalFiles.FindFileByPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("file %s is not linked into the server: %w", path, err)
+		}
+		add(fd)
+	}
+	return fds, nil
+}
@@ -0,0 +1,36 @@
+package loader
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/reflect/protoregistry"
+	_ "google.golang.org/protobuf/types/known/typepb"
+)
+
+// TestLoadFromGlobalRegistry tests building a descriptor set from linked-in
+// files, with imports ordered before the files that need them
+func TestLoadFromGlobalRegistry(t *testing.T) {
+	fds, err := LoadFromGlobalRegistry("google/protobuf/type.proto")
+	if err != nil {
+		t.Fatalf("LoadFromGlobalRegistry failed: %v", err)
+	}
+
+	index := make(map[string]int)
+	for i, file := range fds.File {
+		index[file.GetName()] = i
+	}
+	typeIndex, ok := index["google/protobuf/type.proto"]
+	if !ok || len(fds.File) != 3 {
+		t.Fatalf("Expected type.proto and its 2 imports, got %v", index)
+	}
+	for _, dep := range []string{"google/protobuf/any.proto", "google/protobuf/source_context.proto"} {
+		if i, ok := index[dep]; !ok || i > typeIndex {
+			t.Errorf("Expected %s before type.proto, got %v", dep, index)
+		}
+	}
+
+	if _, err := LoadFromGlobalRegistry("missing/v1/missing.proto"); !errors.Is(err, protoregistry.NotFound) {
+		t.Errorf("Expected NotFound for an unlinked file, got %v", err)
+	}
+}
@@ -13,6 +13,11 @@ import (
 
 // LoadFromPath loads proto descriptors from a local filesystem path using buf build
 func LoadFromPath(path string) (*descriptorpb.FileDescriptorSet, error) {
+	return LoadFromPathWithProgress(path, nil)
+}
+
+// LoadFromPathWithProgress is LoadFromPath, reporting its stages to progress
+func LoadFromPathWithProgress(path string, progress ProgressFunc) (*descriptorpb.FileDescriptorSet, error) {
 	// Verify path exists
 	if _, err := os.Stat(path); err != nil {
 		return nil, fmt.Errorf("path does not exist: %w", err)
@@ -28,6 +33,7 @@ func LoadFromPath(path string) (*descriptorpb.FileDescriptorSet, error) {
 	defer os.Remove(tmpPath)
 
 	// Run buf build to generate descriptor set
+	progress.report(StageBuilding, "building protos with buf")
 	cmd := exec.Command("buf", "build", path, "-o", tmpPath)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
@@ -51,8 +57,18 @@ func LoadFromPath(path string) (*descriptorpb.FileDescriptorSet, error) {
 }
 
 // LoadFromGitHub loads proto descriptors from a GitHub repository
-// Expected format: "github.com/owner/repo" or "github.com/owner/repo/subdir"
+// Expected format: "github.com/owner/repo" or "github.com/owner/repo/subdir",
+// or an SSH remote such as "git@github.com:owner/repo.git" for private repos
 func LoadFromGitHub(repo string) (*descriptorpb.FileDescriptorSet, error) {
+	return LoadFromGitHubWithProgress(repo, nil)
+}
+
+// LoadFromGitHubWithProgress is LoadFromGitHub, reporting its stages to progress
+func LoadFromGitHubWithProgress(repo string, progress ProgressFunc) (*descriptorpb.FileDescriptorSet, error) {
+	if isSSHRemote(repo) {
+		return LoadFromGitWithProgress(repo, "", "", progress)
+	}
+
 	// Create temporary directory for cloning
 	tmpDir, err := os.MkdirTemp("", "connectrpc-catalog-git-*")
 	if err != nil {
@@ -62,6 +78,7 @@ func LoadFromGitHub(repo string) (*descriptorpb.FileDescriptorSet, error) {
 
 	// Clone the repository
 	gitURL := fmt.Sprintf("https://%s.git", repo)
+	progress.report(StageCloning, fmt.Sprintf("cloning %s", gitURL))
 	cmd := exec.Command("git", "clone", "--depth", "1", gitURL, tmpDir)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
@@ -71,12 +88,18 @@ func LoadFromGitHub(repo string) (*descriptorpb.FileDescriptorSet, error) {
 	}
 
 	// Load protos from the cloned directory
-	return LoadFromPath(tmpDir)
+	return LoadFromPathWithProgress(tmpDir, progress)
 }
 
 // LoadFromBufModule loads proto descriptors from a Buf registry module
 // Expected format: "buf.build/owner/repo" or "owner/repo"
 func LoadFromBufModule(module string) (*descriptorpb.FileDescriptorSet, error) {
+	return LoadFromBufModuleWithProgress(module, nil)
+}
+
+// LoadFromBufModuleWithProgress is LoadFromBufModule, reporting its stages to
+// progress
+func LoadFromBufModuleWithProgress(module string, progress ProgressFunc) (*descriptorpb.FileDescriptorSet, error) {
 	// Create temporary directory for buf export
 	tmpDir, err := os.MkdirTemp("", "connectrpc-catalog-buf-*")
 	if err != nil {
@@ -85,6 +108,7 @@ func LoadFromBufModule(module string) (*descriptorpb.FileDescriptorSet, error) {
 	defer os.RemoveAll(tmpDir)
 
 	// Step 1: Export the module from BSR to local directory
+	progress.report(StageExporting, fmt.Sprintf("exporting %s", module))
 	exportCmd := exec.Command("buf", "export", module, "-o", tmpDir)
 	var exportStderr bytes.Buffer
 	exportCmd.Stderr = &exportStderr
@@ -103,6 +127,7 @@ func LoadFromBufModule(module string) (*descriptorpb.FileDescriptorSet, error) {
 	defer os.Remove(tmpPath)
 
 	// Step 2: Build descriptor set from exported protos
+	progress.report(StageBuilding, "building protos with buf")
 	buildCmd := exec.Command("buf", "build", tmpDir, "-o", tmpPath)
 	var buildStderr bytes.Buffer
 	buildCmd.Stderr = &buildStderr
@@ -196,17 +221,24 @@ func ValidateBufInstallation() error {
 type SourceType string
 
 const (
-	SourceTypePath       SourceType = "path"
-	SourceTypeGitHub     SourceType = "github"
-	SourceTypeBufModule  SourceType = "buf_module"
-	SourceTypeReflection SourceType = "reflection"
+	SourceTypePath             SourceType = "path"
+	SourceTypeGitHub           SourceType = "github"
+	SourceTypeBufModule        SourceType = "buf_module"
+	SourceTypeReflection       SourceType = "reflection"
+	SourceTypeDescriptorSet    SourceType = "descriptor_set"
+	SourceTypeURL              SourceType = "url"
+	SourceTypeDescriptorSetDir SourceType = "descriptor_set_dir"
+	SourceTypeGit              SourceType = "git"
+	SourceTypeAccessor         SourceType = "accessor"
 )
 
 // LoadSource represents a proto source configuration
 type LoadSource struct {
-	Type             SourceType
-	Value            string
+	Type              SourceType
+	Value             string
 	ReflectionOptions *ReflectionOptions // Optional, only for reflection sources
+	GitRef            string             // Optional, only for git sources
+	GitSubdir         string             // Optional, only for git sources
 }
 
 // Load is a unified loader that dispatches to the appropriate loader function
@@ -224,6 +256,16 @@ func Load(source LoadSource) (*descriptorpb.FileDescriptorSet, error) {
 			opts = *source.ReflectionOptions
 		}
 		return LoadFromReflection(source.Value, opts)
+	case SourceTypeDescriptorSet:
+		return LoadFromDescriptorSet(source.Value)
+	case SourceTypeURL:
+		return LoadFromURL(source.Value)
+	case SourceTypeDescriptorSetDir:
+		return LoadFromDescriptorSetDir(source.Value)
+	case SourceTypeGit:
+		return LoadFromGit(source.Value, source.GitRef, source.GitSubdir)
+	case SourceTypeAccessor:
+		return LoadFromAccessor(source.Value)
 	default:
 		return nil, fmt.Errorf("unknown source type: %s", source.Type)
 	}
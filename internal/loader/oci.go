@@ -0,0 +1,337 @@
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Manifest media types accepted for OCI artifacts
+const (
+	ociManifestMediaType    = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// OCIDescriptorSetMediaTypes are the layer media types loaded as descriptor
+// sets, gzipped or not. Buf images are supersets of a FileDescriptorSet and
+// decode as one.
+var OCIDescriptorSetMediaTypes = []string{
+	"application/vnd.google.protobuf.filedescriptorset",
+	"application/vnd.google.protobuf.filedescriptorset+gzip",
+	"application/vnd.buf.image.v1+binary",
+	"application/vnd.buf.image.v1+binary+gzip",
+	"application/x-protobuf-descriptor-set",
+}
+
+// ErrUnsupportedMediaType reports an OCI manifest or artifact the loader
+// can't read as a descriptor set
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// ociReference is a parsed OCI reference
+type ociReference struct {
+	scheme     string // "https", or "http" for plain-HTTP registries
+	registry   string
+	repository string
+	reference  string // tag or digest
+}
+
+// ociManifest holds the fields of an image manifest the loader reads
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+// ociDescriptor identifies a blob
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// LoadFromOCI pulls an OCI artifact, e.g.
+// "ghcr.io/acme/protos:v1" or "registry.example.com/protos@sha256:...", and
+// decodes its descriptor set layer. The token, if any, is sent as a bearer
+// token; without one, the registry's anonymous token flow is followed.
+// Registries served over plain HTTP, such as a local test registry, take an
+// "http://" prefix. A layer over maxSize bytes, before or after
+// decompression, fails with ErrDescriptorSetTooLarge.
+func LoadFromOCI(reference, token string, maxSize int) (*descriptorpb.FileDescriptorSet, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDescriptorSetSize
+	}
+	ref, err := parseOCIReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	c := &ociClient{
+		http:    &http.Client{Timeout: descriptorSetFetchTimeout},
+		ref:     ref,
+		token:   token,
+		maxSize: maxSize,
+	}
+
+	manifest, err := c.manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	layer, ok := descriptorSetLayer(manifest.Layers)
+	if !ok {
+		mediaTypes := make([]string, len(manifest.Layers))
+		for i, l := range manifest.Layers {
+			mediaTypes[i] = l.MediaType
+		}
+		return nil, fmt.Errorf("%w: no descriptor set layer in %s (layers: %s; expected one of %s)",
+			ErrUnsupportedMediaType, reference, strings.Join(mediaTypes, ", "), strings.Join(OCIDescriptorSetMediaTypes, ", "))
+	}
+	if layer.Size > int64(maxSize) {
+		return nil, fmt.Errorf("%w: layer is %d bytes, limit %d", ErrDescriptorSetTooLarge, layer.Size, maxSize)
+	}
+
+	data, err := c.blob(layer)
+	if err != nil {
+		return nil, err
+	}
+	// Gzipped layers are recognized by their header
+	return decodeDescriptorSet(data, layer.Digest, maxSize)
+}
+
+// parseOCIReference splits "[scheme://]registry/repository[:tag|@digest]".
+// A missing tag means "latest".
+func parseOCIReference(reference string) (ociReference, error) {
+	ref := ociReference{scheme: "https"}
+	rest := reference
+	if scheme, after, ok := strings.Cut(rest, "://"); ok {
+		if scheme != "http" && scheme != "https" {
+			return ociReference{}, fmt.Errorf("invalid OCI reference %q: scheme must be http or https", reference)
+		}
+		ref.scheme, rest = scheme, after
+	}
+
+	registry, repository, ok := strings.Cut(rest, "/")
+	if !ok || registry == "" || repository == "" {
+		return ociReference{}, fmt.Errorf("invalid OCI reference %q: expected registry/repository[:tag|@digest]", reference)
+	}
+
+	switch {
+	case strings.Contains(repository, "@"):
+		repository, ref.reference, _ = strings.Cut(repository, "@")
+	case strings.LastIndex(repository, ":") > strings.LastIndex(repository, "/"):
+		colon := strings.LastIndex(repository, ":")
+		repository, ref.reference = repository[:colon], repository[colon+1:]
+	default:
+		ref.reference = "latest"
+	}
+	if repository == "" || ref.reference == "" {
+		return ociReference{}, fmt.Errorf("invalid OCI reference %q: expected registry/repository[:tag|@digest]", reference)
+	}
+
+	ref.registry, ref.repository = registry, repository
+	return ref, nil
+}
+
+// descriptorSetLayer returns the first layer with a descriptor set media type
+func descriptorSetLayer(layers []ociDescriptor) (ociDescriptor, bool) {
+	for _, layer := range layers {
+		for _, mediaType := range OCIDescriptorSetMediaTypes {
+			if layer.MediaType == mediaType {
+				return layer, true
+			}
+		}
+	}
+	return ociDescriptor{}, false
+}
+
+// ociClient is a minimal client for the OCI distribution API, pulling one
+// repository
+type ociClient struct {
+	http    *http.Client
+	ref     ociReference
+	token   string
+	maxSize int
+}
+
+// manifest fetches and decodes the referenced image manifest
+func (c *ociClient) manifest() (*ociManifest, error) {
+	resp, err := c.get("manifests/"+c.ref.reference, ociManifestMediaType+", "+dockerManifestMediaType)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI manifest: %w", err)
+	}
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode OCI manifest: %w", err)
+	}
+
+	mediaType := manifest.MediaType
+	if mediaType == "" {
+		mediaType, _, _ = strings.Cut(resp.Header.Get("Content-Type"), ";")
+	}
+	if mediaType != ociManifestMediaType && mediaType != dockerManifestMediaType {
+		return nil, fmt.Errorf("%w: manifest %q (indexes and other manifest types can't be loaded; reference a single artifact)",
+			ErrUnsupportedMediaType, mediaType)
+	}
+	return &manifest, nil
+}
+
+// blob fetches a blob and verifies its digest
+func (c *ociClient) blob(layer ociDescriptor) ([]byte, error) {
+	algorithm, want, ok := strings.Cut(layer.Digest, ":")
+	if !ok || algorithm != "sha256" {
+		return nil, fmt.Errorf("unsupported layer digest %q: only sha256 is supported", layer.Digest)
+	}
+
+	resp, err := c.get("blobs/"+layer.Digest, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(c.maxSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI layer: %w", err)
+	}
+	if len(data) > c.maxSize {
+		return nil, fmt.Errorf("%w: exceeds %d bytes", ErrDescriptorSetTooLarge, c.maxSize)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != want {
+		return nil, fmt.Errorf("OCI layer digest mismatch: expected %s, got sha256:%s", layer.Digest, got)
+	}
+	return data, nil
+}
+
+// get requests a path under the repository, answering an anonymous bearer
+// challenge once when no token was given
+func (c *ociClient) get(path, accept string) (*http.Response, error) {
+	target := fmt.Sprintf("%s://%s/v2/%s/%s", c.ref.scheme, c.ref.registry, c.ref.repository, path)
+	resp, err := c.do(target, accept, c.token)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		token, err := c.anonymousToken(challenge)
+		if err != nil {
+			return nil, err
+		}
+		// Reuse the token for the remaining requests
+		c.token = token
+		if resp, err = c.do(target, accept, token); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return nil, fmt.Errorf("failed to fetch OCI %s: HTTP %d (check the registry token)", path, resp.StatusCode)
+		default:
+			return nil, fmt.Errorf("failed to fetch OCI %s: HTTP %d", path, resp.StatusCode)
+		}
+	}
+	return resp, nil
+}
+
+// do sends a GET request with an optional bearer token
+func (c *ociClient) do(target, accept, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OCI request: %w", err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach OCI registry: %w", err)
+	}
+	return resp, nil
+}
+
+// anonymousToken requests a token from the realm of a bearer challenge such
+// as `Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="..."`
+func (c *ociClient) anonymousToken(challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("OCI registry requires authentication (challenge %q); provide a token", challenge)
+	}
+	attrs := parseChallengeParams(params)
+	realm, err := url.Parse(attrs["realm"])
+	if err != nil || attrs["realm"] == "" {
+		return "", fmt.Errorf("invalid OCI auth challenge %q", challenge)
+	}
+
+	query := realm.Query()
+	if service := attrs["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := attrs["scope"]
+	if scope == "" {
+		scope = "repository:" + c.ref.repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	resp, err := c.do(realm.String(), "", "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("anonymous OCI token request failed: HTTP %d (the artifact may require a token)", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode OCI token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("OCI token response carried no token")
+}
+
+// parseChallengeParams parses the comma-separated key="value" parameters of
+// a WWW-Authenticate challenge
+func parseChallengeParams(params string) map[string]string {
+	attrs := make(map[string]string)
+	for params != "" {
+		var key, value string
+		key, params, _ = strings.Cut(params, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if strings.HasPrefix(params, `"`) {
+			value, params, _ = strings.Cut(params[1:], `"`)
+			_, params, _ = strings.Cut(params, ",")
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+		attrs[key] = strings.TrimSpace(value)
+	}
+	return attrs
+}
@@ -0,0 +1,163 @@
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeOCIRegistry serves one repository, "acme/protos", tagged v1, whose
+// manifest has a layer of the given media type. With requireAuth it issues
+// anonymous tokens from /token and accepts only those or "user-token".
+func fakeOCIRegistry(t *testing.T, layerMediaType string, layer []byte, requireAuth bool) *httptest.Server {
+	t.Helper()
+	sum := sha256.Sum256(layer)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	manifest, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     ociManifestMediaType,
+		"layers": []ociDescriptor{
+			{MediaType: "application/vnd.acme.readme", Digest: "sha256:00", Size: 1},
+			{MediaType: layerMediaType, Digest: digest, Size: int64(len(layer))},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode manifest: %v", err)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:acme/protos:pull" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"token":"anonymous-token"}`))
+			return
+		}
+
+		if requireAuth {
+			auth := r.Header.Get("Authorization")
+			if auth != "Bearer anonymous-token" && auth != "Bearer user-token" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="fake",scope="repository:acme/protos:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		switch r.URL.Path {
+		case "/v2/acme/protos/manifests/v1":
+			w.Header().Set("Content-Type", ociManifestMediaType)
+			w.Write(manifest)
+		case "/v2/acme/protos/blobs/" + digest:
+			w.Write(layer)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return server
+}
+
+// TestLoadFromOCI tests pulling a descriptor set layer anonymously and with a token
+func TestLoadFromOCI(t *testing.T) {
+	tests := []struct {
+		name        string
+		mediaType   string
+		layer       []byte
+		requireAuth bool
+		token       string
+	}{
+		{"plain layer", "application/vnd.google.protobuf.filedescriptorset", testDescriptorSetBytes(t), false, ""},
+		{"gzip layer", "application/vnd.google.protobuf.filedescriptorset+gzip", gzipBytes(t, testDescriptorSetBytes(t)), false, ""},
+		{"anonymous token", "application/vnd.buf.image.v1+binary", testDescriptorSetBytes(t), true, ""},
+		{"user token", "application/vnd.buf.image.v1+binary", testDescriptorSetBytes(t), true, "user-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakeOCIRegistry(t, tt.mediaType, tt.layer, tt.requireAuth)
+			defer server.Close()
+
+			fds, err := LoadFromOCI(server.URL+"/acme/protos:v1", tt.token, 0)
+			if err != nil {
+				t.Fatalf("LoadFromOCI failed: %v", err)
+			}
+			if len(fds.File) != 1 {
+				t.Errorf("Expected 1 file, got %d", len(fds.File))
+			}
+		})
+	}
+}
+
+// TestLoadFromOCI_Errors tests failures for unsupported artifacts, rejected
+// tokens and invalid references
+func TestLoadFromOCI_Errors(t *testing.T) {
+	server := fakeOCIRegistry(t, "application/vnd.oci.image.layer.v1.tar", []byte("tar"), true)
+	defer server.Close()
+
+	_, err := LoadFromOCI(server.URL+"/acme/protos:v1", "", 0)
+	if !errors.Is(err, ErrUnsupportedMediaType) || !strings.Contains(err.Error(), "application/vnd.oci.image.layer.v1.tar") {
+		t.Errorf("Expected ErrUnsupportedMediaType naming the layer, got %v", err)
+	}
+	if _, err := LoadFromOCI(server.URL+"/acme/protos:v1", "wrong-token", 0); err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("Expected an authentication failure, got %v", err)
+	}
+	if _, err := LoadFromOCI(server.URL+"/acme/protos:missing", "", 0); err == nil || !strings.Contains(err.Error(), "HTTP 404") {
+		t.Errorf("Expected HTTP 404 for a missing tag, got %v", err)
+	}
+
+	for _, reference := range []string{"protos", "ftp://registry/protos", "registry/protos@"} {
+		if _, err := LoadFromOCI(reference, "", 0); err == nil || !strings.Contains(err.Error(), "invalid OCI reference") {
+			t.Errorf("Expected an invalid reference error for %q, got %v", reference, err)
+		}
+	}
+}
+
+// TestParseOCIReference tests splitting references into registry,
+// repository and tag or digest
+func TestParseOCIReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		want      ociReference
+	}{
+		{"ghcr.io/acme/protos:v1", ociReference{"https", "ghcr.io", "acme/protos", "v1"}},
+		{"ghcr.io/acme/protos", ociReference{"https", "ghcr.io", "acme/protos", "latest"}},
+		{"localhost:5000/protos@sha256:abc", ociReference{"https", "localhost:5000", "protos", "sha256:abc"}},
+		{"http://localhost:5000/acme/protos:v2", ociReference{"http", "localhost:5000", "acme/protos", "v2"}},
+	}
+	for _, tt := range tests {
+		got, err := parseOCIReference(tt.reference)
+		if err != nil {
+			t.Errorf("parseOCIReference(%q) failed: %v", tt.reference, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseOCIReference(%q): expected %+v, got %+v", tt.reference, tt.want, got)
+		}
+	}
+}
+
+// TestLoadFromOCI_MaxSize tests that the configured size limit applies to
+// the layer before and after decompression
+func TestLoadFromOCI_MaxSize(t *testing.T) {
+	data := testDescriptorSetBytes(t)
+	plain := fakeOCIRegistry(t, "application/vnd.google.protobuf.filedescriptorset", data, false)
+	defer plain.Close()
+	if _, err := LoadFromOCI(plain.URL+"/acme/protos:v1", "", len(data)-1); !errors.Is(err, ErrDescriptorSetTooLarge) {
+		t.Errorf("Expected ErrDescriptorSetTooLarge for an oversized layer, got %v", err)
+	}
+	if _, err := LoadFromOCI(plain.URL+"/acme/protos:v1", "", len(data)); err != nil {
+		t.Errorf("Expected a layer at the limit to load, got %v", err)
+	}
+
+	gzipped := fakeOCIRegistry(t, "application/vnd.google.protobuf.filedescriptorset+gzip", gzipBytes(t, data), false)
+	defer gzipped.Close()
+	if _, err := LoadFromOCI(gzipped.URL+"/acme/protos:v1", "", len(data)-1); !errors.Is(err, ErrDescriptorSetTooLarge) {
+		t.Errorf("Expected ErrDescriptorSetTooLarge after decompression, got %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package loader
+
+// LoadStage names a step of a slow load, reported through a ProgressFunc
+type LoadStage string
+
+const (
+	// StageCloning fetches a git repository
+	StageCloning LoadStage = "cloning"
+	// StageExporting exports a module from the Buf registry
+	StageExporting LoadStage = "exporting"
+	// StageBuilding compiles protos into a descriptor set with buf
+	StageBuilding LoadStage = "building"
+)
+
+// ProgressFunc is called when a load enters a stage, with a description of
+// what it is doing. Loads call it from the loading goroutine.
+type ProgressFunc func(stage LoadStage, message string)
+
+// report calls f if it is set
+func (f ProgressFunc) report(stage LoadStage, message string) {
+	if f != nil {
+		f(stage, message)
+	}
+}
@@ -10,15 +10,20 @@ package loader
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/grpcreflect"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
@@ -27,10 +32,136 @@ type ReflectionOptions struct {
 	UseTLS         bool
 	ServerName     string
 	TimeoutSeconds int32
+	// Authority overrides the :authority header sent to the server. Useful when
+	// the endpoint sits behind an L7 proxy that routes on a different host than
+	// the dial target.
+	Authority string
+	// IncludeServices, when non-empty, limits discovery to these services.
+	// Listing a default-excluded service here opts it back in.
+	IncludeServices []string
+	// ExcludeServices lists services to skip in addition to DefaultExcludedServices
+	ExcludeServices []string
+	// DisableDefaultExcludes stops DefaultExcludedServices from being skipped
+	DisableDefaultExcludes bool
 }
 
+// DefaultExcludedServices are infrastructure services skipped during
+// reflection discovery unless opted back in
+var DefaultExcludedServices = []string{
+	"grpc.reflection.v1alpha.ServerReflection",
+	"grpc.reflection.v1.ServerReflection",
+	"grpc.health.v1.Health",
+	"grpc.channelz.v1.Channelz",
+}
+
+// serviceFilter decides which discovered services are loaded
+type serviceFilter struct {
+	include map[string]bool
+	exclude map[string]bool
+}
+
+// newServiceFilter builds the service filter for a set of reflection options
+func newServiceFilter(opts ReflectionOptions) serviceFilter {
+	f := serviceFilter{
+		include: make(map[string]bool, len(opts.IncludeServices)),
+		exclude: make(map[string]bool),
+	}
+	for _, name := range opts.IncludeServices {
+		f.include[name] = true
+	}
+	if !opts.DisableDefaultExcludes {
+		for _, name := range DefaultExcludedServices {
+			// An explicit include overrides a default exclusion
+			if !f.include[name] {
+				f.exclude[name] = true
+			}
+		}
+	}
+	for _, name := range opts.ExcludeServices {
+		f.exclude[name] = true
+	}
+	return f
+}
+
+// allows reports whether a service should be loaded
+func (f serviceFilter) allows(name string) bool {
+	if f.exclude[name] {
+		return false
+	}
+	return len(f.include) == 0 || f.include[name]
+}
+
+// ReflectionResult contains the descriptors discovered via reflection along
+// with any non-fatal problems encountered
+type ReflectionResult struct {
+	Descriptors *descriptorpb.FileDescriptorSet
+	// Services lists the services whose descriptors were loaded
+	Services []string
+	// Warnings describes services that were skipped, e.g. because their
+	// descriptor could not be fetched after retrying
+	Warnings []string
+}
+
+// ErrNoServices is returned when reflection lists no services to load, either
+// because the server only exposes infrastructure services such as
+// reflection itself or because the include/exclude options filtered out
+// every service
+var ErrNoServices = errors.New("no services found via reflection")
+
+// ServiceResolutionError records why a discovered service's descriptor could
+// not be fetched
+type ServiceResolutionError struct {
+	Service string
+	Err     error
+}
+
+// UnresolvedServicesError is returned when a server lists services to load
+// but none of their descriptors could be fetched
+type UnresolvedServicesError struct {
+	Endpoint string
+	Services []ServiceResolutionError
+}
+
+func (e *UnresolvedServicesError) Error() string {
+	failures := make([]string, len(e.Services))
+	for i, svc := range e.Services {
+		failures[i] = fmt.Sprintf("%s: %v", svc.Service, svc.Err)
+	}
+	return fmt.Sprintf("none of the %d services listed by %s could be resolved via reflection: %s",
+		len(e.Services), e.Endpoint, strings.Join(failures, "; "))
+}
+
+const (
+	// symbolLookupAttempts is the number of tries for each FileContainingSymbol call
+	symbolLookupAttempts = 3
+	// symbolLookupBackoff is the delay before the first retry, doubled on each subsequent one
+	symbolLookupBackoff = 100 * time.Millisecond
+	// maxConcurrentReflectionLoads bounds the number of endpoints queried at
+	// once by LoadFromReflectionEndpoints
+	maxConcurrentReflectionLoads = 4
+)
+
 // LoadFromReflection fetches proto descriptors from a gRPC server via reflection
 func LoadFromReflection(endpoint string, opts ReflectionOptions) (*descriptorpb.FileDescriptorSet, error) {
+	result, err := LoadFromReflectionWithWarnings(endpoint, opts)
+	if err != nil {
+		return nil, err
+	}
+	return result.Descriptors, nil
+}
+
+// LoadFromReflectionWithWarnings fetches proto descriptors from a gRPC server
+// via reflection, reporting services that had to be skipped as warnings.
+// Results are shared through the process-wide reflection cache, see
+// SetReflectionCacheTTL.
+func LoadFromReflectionWithWarnings(endpoint string, opts ReflectionOptions) (*ReflectionResult, error) {
+	return defaultReflectionCache.Load(endpoint, opts)
+}
+
+// loadFromReflection queries an endpoint via reflection. It also returns the
+// server's advertised ReflectionVersionHeader; if that equals knownVersion
+// the descriptors are not fetched and the result is nil.
+func loadFromReflection(endpoint string, opts ReflectionOptions, knownVersion string) (*ReflectionResult, string, error) {
 	// Set default timeout
 	timeout := time.Duration(opts.TimeoutSeconds) * time.Second
 	if timeout <= 0 {
@@ -40,59 +171,65 @@ func LoadFromReflection(endpoint string, opts ReflectionOptions) (*descriptorpb.
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	// Configure dial options
-	var dialOpts []grpc.DialOption
-	if opts.UseTLS {
-		tlsConfig := &tls.Config{}
-		if opts.ServerName != "" {
-			tlsConfig.ServerName = opts.ServerName
-		}
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
-	} else {
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	}
-
 	// Connect to the server
-	conn, err := grpc.DialContext(ctx, endpoint, dialOpts...)
+	conn, err := grpc.DialContext(ctx, endpoint, reflectionDialOptions(opts)...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to %s: %w", endpoint, err)
+		return nil, "", fmt.Errorf("failed to connect to %s: %w", endpoint, err)
 	}
 	defer conn.Close()
 
 	// Create reflection client (try v1alpha first, most common)
-	refClient := grpcreflect.NewClientV1Alpha(ctx, grpc_reflection_v1alpha.NewServerReflectionClient(conn))
+	stub := &versionCapturingClient{ServerReflectionClient: grpc_reflection_v1alpha.NewServerReflectionClient(conn)}
+	refClient := grpcreflect.NewClientV1Alpha(ctx, stub)
 	defer refClient.Reset()
 
 	// List all services
 	services, err := refClient.ListServices()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list services via reflection: %w", err)
+		return nil, "", fmt.Errorf("failed to list services via reflection: %w", err)
+	}
+	version := stub.Version()
+	if knownVersion != "" && version == knownVersion {
+		return nil, version, nil
 	}
 
 	// Collect all file descriptors
 	fileDescriptors := make(map[string]*desc.FileDescriptor)
+	result := &ReflectionResult{}
+
+	filter := newServiceFilter(opts)
+	var skipped []string
+	var failures []ServiceResolutionError
 
 	for _, svcName := range services {
-		// Skip reflection service itself
-		if svcName == "grpc.reflection.v1alpha.ServerReflection" ||
-			svcName == "grpc.reflection.v1.ServerReflection" {
+		// Skip infrastructure and filtered-out services
+		if !filter.allows(svcName) {
+			skipped = append(skipped, svcName)
 			continue
 		}
 
-		// Get file descriptor for this service
-		fd, err := refClient.FileContainingSymbol(svcName)
+		// Get file descriptor for this service, retrying transient failures
+		var fd *desc.FileDescriptor
+		err := retryTransient(ctx, func() error {
+			var lookupErr error
+			fd, lookupErr = refClient.FileContainingSymbol(svcName)
+			return lookupErr
+		})
 		if err != nil {
-			// Log warning but continue with other services
-			fmt.Printf("Warning: could not get descriptor for %s: %v\n", svcName, err)
+			// Report but continue with other services
+			failures = append(failures, ServiceResolutionError{Service: svcName, Err: err})
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("could not get descriptor for %s: %v", svcName, err))
 			continue
 		}
 
 		// Collect this file and all its dependencies
 		collectFileDescriptors(fd, fileDescriptors)
+		result.Services = append(result.Services, svcName)
 	}
 
 	if len(fileDescriptors) == 0 {
-		return nil, fmt.Errorf("no service descriptors found via reflection")
+		return nil, "", noServicesError(endpoint, skipped, failures)
 	}
 
 	// Convert to FileDescriptorSet
@@ -104,7 +241,203 @@ func LoadFromReflection(endpoint string, opts ReflectionOptions) (*descriptorpb.
 		fds.File = append(fds.File, fd.AsFileDescriptorProto())
 	}
 
-	return fds, nil
+	result.Descriptors = fds
+	return result, version, nil
+}
+
+// MultiReflectionResult contains the descriptors merged from several
+// reflection endpoints
+type MultiReflectionResult struct {
+	Descriptors *descriptorpb.FileDescriptorSet
+	// ServiceOrigins maps each loaded service to the endpoints exposing it,
+	// in the order the endpoints were given
+	ServiceOrigins map[string][]string
+	// Failures holds the error for each endpoint that could not be loaded
+	Failures map[string]error
+	// Warnings are the per-endpoint warnings, prefixed with the endpoint,
+	// plus any files that differed between endpoints
+	Warnings []string
+}
+
+// LoadFromReflectionEndpoints fetches descriptors from several gRPC servers
+// via reflection and merges them. Files shared between endpoints are loaded
+// once; if two endpoints define a file differently the first endpoint's
+// definition wins and a warning is reported. Endpoints that fail are recorded
+// in Failures; an error is returned only if every endpoint fails.
+func LoadFromReflectionEndpoints(endpoints []string, opts ReflectionOptions) (*MultiReflectionResult, error) {
+	endpoints = uniqueStrings(endpoints)
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no reflection endpoints specified")
+	}
+
+	// Query the endpoints concurrently, keeping results in request order
+	results := make([]*ReflectionResult, len(endpoints))
+	errs := make([]error, len(endpoints))
+	sem := make(chan struct{}, maxConcurrentReflectionLoads)
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i], errs[i] = LoadFromReflectionWithWarnings(endpoint, opts)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	merged := &MultiReflectionResult{
+		Descriptors:    &descriptorpb.FileDescriptorSet{},
+		ServiceOrigins: make(map[string][]string),
+		Failures:       make(map[string]error),
+	}
+	definedBy := make(map[string]string)
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+
+	for i, endpoint := range endpoints {
+		if errs[i] != nil {
+			merged.Failures[endpoint] = errs[i]
+			continue
+		}
+		result := results[i]
+
+		for _, warning := range result.Warnings {
+			merged.Warnings = append(merged.Warnings, endpoint+": "+warning)
+		}
+		for _, file := range result.Descriptors.File {
+			name := file.GetName()
+			existing, ok := files[name]
+			if !ok {
+				files[name] = file
+				definedBy[name] = endpoint
+				merged.Descriptors.File = append(merged.Descriptors.File, file)
+				continue
+			}
+			if !sameFileDefinition(existing, file) {
+				merged.Warnings = append(merged.Warnings, fmt.Sprintf(
+					"%s differs between %s and %s; using the definition from %s",
+					name, definedBy[name], endpoint, definedBy[name]))
+			}
+		}
+		for _, svc := range result.Services {
+			merged.ServiceOrigins[svc] = append(merged.ServiceOrigins[svc], endpoint)
+		}
+	}
+
+	if len(merged.Failures) == len(endpoints) {
+		failures := make([]string, len(endpoints))
+		for i, endpoint := range endpoints {
+			failures[i] = fmt.Sprintf("%s: %v", endpoint, errs[i])
+		}
+		return nil, fmt.Errorf("all reflection endpoints failed: %s", strings.Join(failures, "; "))
+	}
+
+	return merged, nil
+}
+
+// uniqueStrings returns the non-empty values in order, without repeats
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// noServicesError explains why reflection produced no descriptors: every
+// service that was tried failed to resolve, or there was nothing to try
+func noServicesError(endpoint string, skipped []string, failures []ServiceResolutionError) error {
+	if len(failures) > 0 {
+		return &UnresolvedServicesError{Endpoint: endpoint, Services: failures}
+	}
+
+	var filtered []string
+	for _, name := range skipped {
+		if !isDefaultExcluded(name) {
+			filtered = append(filtered, name)
+		}
+	}
+	if len(filtered) > 0 {
+		return fmt.Errorf("%w: %s lists %s but none match the include/exclude options",
+			ErrNoServices, endpoint, strings.Join(filtered, ", "))
+	}
+	return fmt.Errorf("%w: %s exposes only reflection and other infrastructure services", ErrNoServices, endpoint)
+}
+
+// isDefaultExcluded reports whether a service is in DefaultExcludedServices
+func isDefaultExcluded(name string) bool {
+	for _, excluded := range DefaultExcludedServices {
+		if name == excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// retryTransient calls fn up to symbolLookupAttempts times with exponential
+// backoff while it fails with a transient gRPC code (Unavailable or
+// DeadlineExceeded). Other errors are returned immediately.
+func retryTransient(ctx context.Context, fn func() error) error {
+	backoff := symbolLookupBackoff
+
+	var err error
+	for attempt := 1; attempt <= symbolLookupAttempts; attempt++ {
+		err = fn()
+		if err == nil || !isTransient(err) || attempt == symbolLookupAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+// isTransient reports whether an error is worth retrying
+func isTransient(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTLSError reports whether a gRPC error comes from a failed TLS handshake.
+// The transport reports it as UNAVAILABLE with the crypto/tls or crypto/x509
+// error text in the status message.
+func isTLSError(err error) bool {
+	msg := status.Convert(err).Message()
+	return strings.Contains(msg, "tls: ") || strings.Contains(msg, "x509: ")
+}
+
+// reflectionDialOptions builds the gRPC dial options for a reflection connection
+func reflectionDialOptions(opts ReflectionOptions) []grpc.DialOption {
+	var dialOpts []grpc.DialOption
+	if opts.UseTLS {
+		tlsConfig := &tls.Config{}
+		if opts.ServerName != "" {
+			tlsConfig.ServerName = opts.ServerName
+		}
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if opts.Authority != "" {
+		dialOpts = append(dialOpts, grpc.WithAuthority(opts.Authority))
+	}
+
+	return dialOpts
 }
 
 // collectFileDescriptors recursively collects a file descriptor and all its dependencies
@@ -121,21 +454,35 @@ func collectFileDescriptors(fd *desc.FileDescriptor, collected map[string]*desc.
 	}
 }
 
-// CheckReflectionSupport tests if an endpoint supports gRPC reflection
-func CheckReflectionSupport(endpoint string, useTLS bool) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// DefaultReflectionCheckTimeout bounds CheckReflectionSupport when no
+// timeout is given
+const DefaultReflectionCheckTimeout = 5 * time.Second
 
-	var dialOpts []grpc.DialOption
-	if useTLS {
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
-	} else {
-		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// ErrEndpointUnreachable is returned by CheckReflectionSupport when the
+// endpoint could not be reached within the timeout
+var ErrEndpointUnreachable = errors.New("endpoint unreachable")
+
+// ErrTLSHandshake is returned by CheckReflectionSupport when the endpoint
+// was reached but the TLS handshake or certificate verification failed
+var ErrTLSHandshake = errors.New("TLS handshake failed")
+
+// CheckReflectionSupport tests if an endpoint supports gRPC reflection. A
+// server that is reachable but does not implement reflection reports false
+// with a nil error; an endpoint that cannot be reached within timeout
+// returns an error wrapping ErrEndpointUnreachable, and one whose TLS
+// handshake fails an error wrapping ErrTLSHandshake. A non-positive timeout
+// uses DefaultReflectionCheckTimeout.
+func CheckReflectionSupport(endpoint string, useTLS bool, timeout time.Duration) (bool, error) {
+	if timeout <= 0 {
+		timeout = DefaultReflectionCheckTimeout
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	conn, err := grpc.DialContext(ctx, endpoint, dialOpts...)
+	// The client connects lazily, so reachability shows in the first call
+	conn, err := grpc.NewClient(endpoint, reflectionDialOptions(ReflectionOptions{UseTLS: useTLS})...)
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("invalid endpoint %s: %w", endpoint, err)
 	}
 	defer conn.Close()
 
@@ -143,5 +490,16 @@ func CheckReflectionSupport(endpoint string, useTLS bool) (bool, error) {
 	defer refClient.Reset()
 
 	_, err = refClient.ListServices()
-	return err == nil, err
+	switch {
+	case err == nil:
+		return true, nil
+	case status.Code(err) == codes.Unimplemented:
+		return false, nil
+	case status.Code(err) == codes.Unavailable && isTLSError(err):
+		return false, fmt.Errorf("%w: %s: %v", ErrTLSHandshake, endpoint, err)
+	case isTransient(err) || ctx.Err() != nil:
+		return false, fmt.Errorf("%w: %s: %v", ErrEndpointUnreachable, endpoint, err)
+	default:
+		return false, err
+	}
 }
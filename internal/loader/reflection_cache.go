@@ -0,0 +1,238 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	// DefaultReflectionCacheTTL is how long reflected descriptors are reused
+	// before the endpoint is queried again
+	DefaultReflectionCacheTTL = 30 * time.Second
+
+	// ReflectionVersionHeader is response metadata a server may send on the
+	// reflection stream to advertise the version of its descriptors. When an
+	// expired cache entry's version matches, the entry is reused after a
+	// single ListServices round-trip instead of fetching every descriptor.
+	ReflectionVersionHeader = "x-reflection-content-version"
+
+	// reflectionCacheRetention is how many TTLs an expired entry is kept for
+	// revalidation by version
+	reflectionCacheRetention = 10
+)
+
+// ReflectionCache shares reflection results across loads of the same
+// endpoint. It is safe for concurrent use; concurrent loads of one endpoint
+// share a single reflection round-trip.
+type ReflectionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*reflectionCacheEntry
+	loads   singleflight.Group
+}
+
+// reflectionCacheEntry is a cached reflection result
+type reflectionCacheEntry struct {
+	endpoint string
+	result   *ReflectionResult
+	// version is the server's advertised ReflectionVersionHeader, if any
+	version  string
+	storedAt time.Time
+}
+
+// defaultReflectionCache backs LoadFromReflection and
+// LoadFromReflectionWithWarnings
+var defaultReflectionCache = NewReflectionCache(DefaultReflectionCacheTTL)
+
+// NewReflectionCache creates a cache whose entries are fresh for ttl. A
+// non-positive ttl disables caching.
+func NewReflectionCache(ttl time.Duration) *ReflectionCache {
+	return &ReflectionCache{
+		ttl:     ttl,
+		entries: make(map[string]*reflectionCacheEntry),
+	}
+}
+
+// SetReflectionCacheTTL sets the TTL of the process-wide reflection cache.
+// A non-positive ttl disables caching and drops cached entries.
+func SetReflectionCacheTTL(ttl time.Duration) {
+	defaultReflectionCache.SetTTL(ttl)
+}
+
+// InvalidateReflectionCache drops the process-wide cache entries for
+// endpoint, or every entry if endpoint is empty
+func InvalidateReflectionCache(endpoint string) {
+	defaultReflectionCache.Invalidate(endpoint)
+}
+
+// SetTTL changes how long entries are fresh. A non-positive ttl disables
+// caching and drops cached entries.
+func (c *ReflectionCache) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ttl = ttl
+	if ttl <= 0 {
+		c.entries = make(map[string]*reflectionCacheEntry)
+	}
+}
+
+// Invalidate drops the entries for endpoint, or every entry if endpoint is
+// empty
+func (c *ReflectionCache) Invalidate(endpoint string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if endpoint == "" || entry.endpoint == endpoint {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Load returns the reflection result for endpoint, reusing a fresh cached
+// result for the same endpoint and options. Callers get their own copy of
+// the descriptors. Failed loads are not cached.
+func (c *ReflectionCache) Load(endpoint string, opts ReflectionOptions) (*ReflectionResult, error) {
+	key := reflectionCacheKey(endpoint, opts)
+
+	c.mu.Lock()
+	ttl := c.ttl
+	entry := c.entries[key]
+	c.mu.Unlock()
+
+	if ttl <= 0 {
+		result, _, err := loadFromReflection(endpoint, opts, "")
+		return result, err
+	}
+	if entry != nil && time.Since(entry.storedAt) < ttl {
+		return cloneReflectionResult(entry.result), nil
+	}
+
+	var knownVersion string
+	if entry != nil {
+		knownVersion = entry.version
+	}
+
+	loaded, err, _ := c.loads.Do(key, func() (interface{}, error) {
+		result, version, err := loadFromReflection(endpoint, opts, knownVersion)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			// The server's descriptors are unchanged since entry was stored
+			result = entry.result
+		}
+		c.store(key, &reflectionCacheEntry{
+			endpoint: endpoint,
+			result:   result,
+			version:  version,
+			storedAt: time.Now(),
+		})
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cloneReflectionResult(loaded.(*ReflectionResult)), nil
+}
+
+// store caches an entry and drops entries kept past their retention
+func (c *ReflectionCache) store(key string, entry *reflectionCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl <= 0 {
+		return
+	}
+	for k, e := range c.entries {
+		if time.Since(e.storedAt) >= c.ttl*reflectionCacheRetention {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = entry
+}
+
+// reflectionCacheKey identifies the endpoint and options that determine a
+// reflection result
+func reflectionCacheKey(endpoint string, opts ReflectionOptions) string {
+	include := append([]string(nil), opts.IncludeServices...)
+	exclude := append([]string(nil), opts.ExcludeServices...)
+	sort.Strings(include)
+	sort.Strings(exclude)
+	return fmt.Sprintf("%s|tls=%v|sni=%s|authority=%s|include=%s|exclude=%s|defaults=%v",
+		endpoint, opts.UseTLS, opts.ServerName, opts.Authority,
+		strings.Join(include, ","), strings.Join(exclude, ","), !opts.DisableDefaultExcludes)
+}
+
+// cloneReflectionResult copies a result so callers may modify its descriptors
+func cloneReflectionResult(result *ReflectionResult) *ReflectionResult {
+	return &ReflectionResult{
+		Descriptors: proto.Clone(result.Descriptors).(*descriptorpb.FileDescriptorSet),
+		Services:    append([]string(nil), result.Services...),
+		Warnings:    append([]string(nil), result.Warnings...),
+	}
+}
+
+// versionCapturingClient records the ReflectionVersionHeader sent by the
+// server on reflection streams
+type versionCapturingClient struct {
+	grpc_reflection_v1alpha.ServerReflectionClient
+
+	mu      sync.Mutex
+	version string
+}
+
+// ServerReflectionInfo implements grpc_reflection_v1alpha.ServerReflectionClient
+func (c *versionCapturingClient) ServerReflectionInfo(ctx context.Context, opts ...grpc.CallOption) (grpc_reflection_v1alpha.ServerReflection_ServerReflectionInfoClient, error) {
+	stream, err := c.ServerReflectionClient.ServerReflectionInfo(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &versionCapturingStream{ServerReflection_ServerReflectionInfoClient: stream, client: c}, nil
+}
+
+// Version returns the advertised version, or "" if none was seen
+func (c *versionCapturingClient) Version() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version
+}
+
+// versionCapturingStream reads the response header after the first message
+type versionCapturingStream struct {
+	grpc_reflection_v1alpha.ServerReflection_ServerReflectionInfoClient
+	client *versionCapturingClient
+	once   sync.Once
+}
+
+// Recv implements grpc_reflection_v1alpha.ServerReflection_ServerReflectionInfoClient
+func (s *versionCapturingStream) Recv() (*grpc_reflection_v1alpha.ServerReflectionResponse, error) {
+	resp, err := s.ServerReflection_ServerReflectionInfoClient.Recv()
+	if err == nil {
+		s.once.Do(func() {
+			// The header has arrived with the first message, so this
+			// does not block
+			header, headerErr := s.Header()
+			if headerErr != nil {
+				return
+			}
+			if values := header.Get(ReflectionVersionHeader); len(values) > 0 {
+				s.client.mu.Lock()
+				s.client.version = values[0]
+				s.client.mu.Unlock()
+			}
+		})
+	}
+	return resp, err
+}
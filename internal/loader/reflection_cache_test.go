@@ -0,0 +1,168 @@
+package loader
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+)
+
+// countingReflectionServer starts a reflection server that counts streams
+// and request messages and advertises *version when it is non-empty
+func countingReflectionServer(t *testing.T, version *atomic.Value) (string, *atomic.Int32, *atomic.Int32) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	var streams, messages atomic.Int32
+	grpcServer := grpc.NewServer(grpc.StreamInterceptor(
+		func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			streams.Add(1)
+			if v, _ := version.Load().(string); v != "" {
+				ss.SetHeader(metadata.Pairs(ReflectionVersionHeader, v))
+			}
+			return handler(srv, &countingServerStream{ServerStream: ss, messages: &messages})
+		},
+	))
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	reflection.Register(grpcServer)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	return lis.Addr().String(), &streams, &messages
+}
+
+// countingServerStream counts received messages
+type countingServerStream struct {
+	grpc.ServerStream
+	messages *atomic.Int32
+}
+
+func (s *countingServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.messages.Add(1)
+	}
+	return err
+}
+
+// TestReflectionCache tests that loads of the same endpoint and options share
+// one reflection round-trip until invalidated
+func TestReflectionCache(t *testing.T) {
+	var version atomic.Value
+	endpoint, streams, _ := countingReflectionServer(t, &version)
+
+	cache := NewReflectionCache(time.Minute)
+	opts := ReflectionOptions{TimeoutSeconds: 5, IncludeServices: []string{"grpc.health.v1.Health"}}
+
+	// Concurrent loads share one round-trip
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Load(endpoint, opts); err != nil {
+				t.Errorf("Load failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	first, err := cache.Load(endpoint, opts)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := streams.Load(); got != 1 {
+		t.Errorf("Expected 1 reflection stream, got %d", got)
+	}
+
+	// Callers get their own copy
+	first.Descriptors.File = nil
+	second, err := cache.Load(endpoint, opts)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(second.Descriptors.File) == 0 {
+		t.Error("Modifying a returned result changed the cache")
+	}
+
+	// Different options are cached separately
+	if _, err := cache.Load(endpoint, ReflectionOptions{TimeoutSeconds: 5, DisableDefaultExcludes: true}); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := streams.Load(); got != 2 {
+		t.Errorf("Expected a new stream for different options, got %d", got)
+	}
+
+	cache.Invalidate(endpoint)
+	if _, err := cache.Load(endpoint, opts); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := streams.Load(); got != 3 {
+		t.Errorf("Expected a new stream after invalidation, got %d", got)
+	}
+}
+
+// TestReflectionCache_Version tests that expired entries are revalidated
+// against the server's advertised version
+func TestReflectionCache_Version(t *testing.T) {
+	var version atomic.Value
+	version.Store("v1")
+	endpoint, _, messages := countingReflectionServer(t, &version)
+
+	// Entries expire immediately, so every load goes to the server
+	cache := NewReflectionCache(time.Nanosecond)
+	opts := ReflectionOptions{TimeoutSeconds: 5, IncludeServices: []string{"grpc.health.v1.Health"}}
+
+	load := func() int32 {
+		t.Helper()
+		before := messages.Load()
+		result, err := cache.Load(endpoint, opts)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if len(result.Descriptors.File) == 0 {
+			t.Fatal("Expected descriptors")
+		}
+		return messages.Load() - before
+	}
+
+	if got := load(); got < 2 {
+		t.Fatalf("Expected the first load to fetch descriptors, got %d requests", got)
+	}
+	if got := load(); got != 1 {
+		t.Errorf("Expected an unchanged version to need only ListServices, got %d requests", got)
+	}
+
+	version.Store("v2")
+	if got := load(); got < 2 {
+		t.Errorf("Expected a new version to fetch descriptors, got %d requests", got)
+	}
+}
+
+// TestReflectionCache_Disabled tests that a zero TTL always queries the server
+func TestReflectionCache_Disabled(t *testing.T) {
+	var version atomic.Value
+	endpoint, streams, _ := countingReflectionServer(t, &version)
+
+	cache := NewReflectionCache(0)
+	opts := ReflectionOptions{TimeoutSeconds: 5, IncludeServices: []string{"grpc.health.v1.Health"}}
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Load(endpoint, opts); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+	}
+	if got := streams.Load(); got != 2 {
+		t.Errorf("Expected 2 reflection streams, got %d", got)
+	}
+}
@@ -1,7 +1,9 @@
 package loader_test
 
 import (
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/opentdf/connectrpc-catalog/internal/loader"
 )
@@ -36,7 +38,15 @@ func ExampleLoadFromReflection() {
 // ExampleCheckReflectionSupport demonstrates checking if a server supports reflection
 func ExampleCheckReflectionSupport() {
 	// Check if server supports gRPC reflection
-	supported, err := loader.CheckReflectionSupport("localhost:50051", false)
+	supported, err := loader.CheckReflectionSupport("localhost:50051", false, 2*time.Second)
+	if errors.Is(err, loader.ErrEndpointUnreachable) {
+		fmt.Println("Server is not reachable")
+		return
+	}
+	if errors.Is(err, loader.ErrTLSHandshake) {
+		fmt.Println("Server certificate was rejected")
+		return
+	}
 	if err != nil {
 		fmt.Printf("Error checking reflection support: %v\n", err)
 		return
@@ -1,7 +1,22 @@
 package loader
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 func TestReflectionOptions_DefaultTimeout(t *testing.T) {
@@ -39,6 +54,419 @@ func TestReflectionOptions_TLSConfig(t *testing.T) {
 	}
 }
 
-// Note: Integration tests for LoadFromReflection and CheckReflectionSupport
-// would require a running gRPC server with reflection enabled.
-// These should be added as part of integration test suite.
+func TestLoadFromReflection_Authority(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	// Capture the :authority the reflection client sends
+	authorities := make(chan string, 10)
+	grpcServer := grpc.NewServer(grpc.StreamInterceptor(
+		func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if md, ok := metadata.FromIncomingContext(ss.Context()); ok && len(md[":authority"]) > 0 {
+				authorities <- md[":authority"][0]
+			}
+			return handler(srv, ss)
+		},
+	))
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	reflection.Register(grpcServer)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	opts := ReflectionOptions{
+		TimeoutSeconds:  5,
+		Authority:       "catalog.internal.example",
+		IncludeServices: []string{"grpc.health.v1.Health"},
+	}
+
+	fds, err := LoadFromReflection(lis.Addr().String(), opts)
+	if err != nil {
+		t.Fatalf("LoadFromReflection failed: %v", err)
+	}
+	if len(fds.File) == 0 {
+		t.Fatal("Expected file descriptors from reflection")
+	}
+
+	select {
+	case got := <-authorities:
+		if got != opts.Authority {
+			t.Errorf("Expected :authority %q, got %q", opts.Authority, got)
+		}
+	default:
+		t.Fatal("Server did not observe a reflection request")
+	}
+}
+
+// TestLoadFromReflectionWithWarnings tests that unresolvable services are reported, not dropped silently
+func TestLoadFromReflectionWithWarnings(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	// A service whose descriptor the reflection service cannot find
+	grpcServer.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "ghost.v1.GhostService",
+		HandlerType: (*interface{})(nil),
+		Metadata:    "ghost/v1/ghost.proto",
+	}, struct{}{})
+	reflection.Register(grpcServer)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	result, err := LoadFromReflectionWithWarnings(lis.Addr().String(), ReflectionOptions{
+		TimeoutSeconds:  5,
+		IncludeServices: []string{"grpc.health.v1.Health", "ghost.v1.GhostService"},
+	})
+	if err != nil {
+		t.Fatalf("LoadFromReflectionWithWarnings failed: %v", err)
+	}
+
+	if len(result.Descriptors.File) == 0 {
+		t.Error("Expected descriptors for the resolvable services")
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "ghost.v1.GhostService") {
+		t.Errorf("Expected a warning naming the ghost service, got %v", result.Warnings)
+	}
+}
+
+// TestLoadFromReflection_NoServices tests that an empty catalog is explained by
+// whether services failed to resolve or there were none to load
+func TestLoadFromReflection_NoServices(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	for _, name := range []string{"ghost.v1.GhostService", "ghost.v1.PhantomService"} {
+		grpcServer.RegisterService(&grpc.ServiceDesc{
+			ServiceName: name,
+			HandlerType: (*interface{})(nil),
+			Metadata:    "ghost/v1/ghost.proto",
+		}, struct{}{})
+	}
+	reflection.Register(grpcServer)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+	endpoint := lis.Addr().String()
+
+	// Every listed service fails to resolve
+	_, err = LoadFromReflection(endpoint, ReflectionOptions{TimeoutSeconds: 5})
+	var unresolved *UnresolvedServicesError
+	if !errors.As(err, &unresolved) {
+		t.Fatalf("Expected UnresolvedServicesError, got %v", err)
+	}
+	if len(unresolved.Services) != 2 || unresolved.Endpoint != endpoint {
+		t.Errorf("Expected both ghost services for %s, got %+v", endpoint, unresolved)
+	}
+	for _, name := range []string{"ghost.v1.GhostService", "ghost.v1.PhantomService"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected error to name %s, got %v", name, err)
+		}
+	}
+
+	// Services exist but the options filter them all out
+	_, err = LoadFromReflection(endpoint, ReflectionOptions{
+		TimeoutSeconds:  5,
+		ExcludeServices: []string{"ghost.v1.GhostService", "ghost.v1.PhantomService"},
+	})
+	if !errors.Is(err, ErrNoServices) || !strings.Contains(err.Error(), "include/exclude") {
+		t.Errorf("Expected filtered-out ErrNoServices, got %v", err)
+	}
+
+	// A server exposing nothing but infrastructure services
+	bare := grpc.NewServer()
+	healthpb.RegisterHealthServer(bare, health.NewServer())
+	reflection.Register(bare)
+	bareLis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go bare.Serve(bareLis)
+	defer bare.Stop()
+
+	_, err = LoadFromReflection(bareLis.Addr().String(), ReflectionOptions{TimeoutSeconds: 5})
+	if !errors.Is(err, ErrNoServices) || !strings.Contains(err.Error(), "only reflection") {
+		t.Errorf("Expected reflection-only ErrNoServices, got %v", err)
+	}
+}
+
+// TestLoadFromReflectionEndpoints tests merging several reflection endpoints
+// with provenance and partial failures
+func TestLoadFromReflectionEndpoints(t *testing.T) {
+	startServer := func() string {
+		lis, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		grpcServer := grpc.NewServer()
+		healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+		reflection.Register(grpcServer)
+		go grpcServer.Serve(lis)
+		t.Cleanup(grpcServer.Stop)
+		return lis.Addr().String()
+	}
+	first, second := startServer(), startServer()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	unreachable := lis.Addr().String()
+	lis.Close()
+
+	opts := ReflectionOptions{
+		TimeoutSeconds:  2,
+		IncludeServices: []string{"grpc.health.v1.Health"},
+	}
+	result, err := LoadFromReflectionEndpoints([]string{first, unreachable, second, first}, opts)
+	if err != nil {
+		t.Fatalf("LoadFromReflectionEndpoints failed: %v", err)
+	}
+
+	// Both servers share the health proto, which is loaded once
+	names := make(map[string]int)
+	for _, file := range result.Descriptors.File {
+		names[file.GetName()]++
+	}
+	for name, count := range names {
+		if count != 1 {
+			t.Errorf("Expected %s once, got %d", name, count)
+		}
+	}
+
+	origins := result.ServiceOrigins["grpc.health.v1.Health"]
+	if len(origins) != 2 || origins[0] != first || origins[1] != second {
+		t.Errorf("Expected origins [%s %s], got %v", first, second, origins)
+	}
+	if len(result.Failures) != 1 || result.Failures[unreachable] == nil {
+		t.Errorf("Expected only %s to fail, got %v", unreachable, result.Failures)
+	}
+
+	if _, err := LoadFromReflectionEndpoints([]string{unreachable}, opts); err == nil || !strings.Contains(err.Error(), unreachable) {
+		t.Errorf("Expected error naming %s when every endpoint fails, got %v", unreachable, err)
+	}
+	if _, err := LoadFromReflectionEndpoints(nil, opts); err == nil {
+		t.Error("Expected error for no endpoints")
+	}
+}
+
+// TestServiceFilter tests include/exclude handling for discovered services
+func TestServiceFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ReflectionOptions
+		allowed []string
+		denied  []string
+	}{
+		{
+			name:    "defaults skip infrastructure services",
+			opts:    ReflectionOptions{},
+			allowed: []string{"acme.v1.OrderService"},
+			denied:  DefaultExcludedServices,
+		},
+		{
+			name:    "defaults can be disabled",
+			opts:    ReflectionOptions{DisableDefaultExcludes: true},
+			allowed: append([]string{"acme.v1.OrderService"}, DefaultExcludedServices...),
+		},
+		{
+			name:    "include list restricts and opts back in",
+			opts:    ReflectionOptions{IncludeServices: []string{"acme.v1.OrderService", "grpc.health.v1.Health"}},
+			allowed: []string{"acme.v1.OrderService", "grpc.health.v1.Health"},
+			denied:  []string{"acme.v1.UserService", "grpc.channelz.v1.Channelz"},
+		},
+		{
+			name:    "exclude list adds to defaults",
+			opts:    ReflectionOptions{ExcludeServices: []string{"acme.v1.AdminService"}},
+			allowed: []string{"acme.v1.OrderService"},
+			denied:  []string{"acme.v1.AdminService", "grpc.health.v1.Health"},
+		},
+		{
+			name: "exclude wins over include",
+			opts: ReflectionOptions{
+				IncludeServices: []string{"acme.v1.OrderService"},
+				ExcludeServices: []string{"acme.v1.OrderService"},
+			},
+			denied: []string{"acme.v1.OrderService"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := newServiceFilter(tt.opts)
+			for _, name := range tt.allowed {
+				if !filter.allows(name) {
+					t.Errorf("Expected %s to be allowed", name)
+				}
+			}
+			for _, name := range tt.denied {
+				if filter.allows(name) {
+					t.Errorf("Expected %s to be skipped", name)
+				}
+			}
+		})
+	}
+}
+
+// TestRetryTransient tests that only transient gRPC failures are retried
+func TestRetryTransient(t *testing.T) {
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "succeeds after transient failure",
+			errs:      []error{status.Error(codes.Unavailable, "blip"), nil},
+			wantCalls: 2,
+		},
+		{
+			name: "gives up after bounded attempts",
+			errs: []error{
+				status.Error(codes.DeadlineExceeded, "slow"),
+				status.Error(codes.DeadlineExceeded, "slow"),
+				status.Error(codes.DeadlineExceeded, "slow"),
+				nil,
+			},
+			wantCalls: symbolLookupAttempts,
+			wantErr:   true,
+		},
+		{
+			name:      "does not retry permanent failure",
+			errs:      []error{status.Error(codes.NotFound, "no such symbol"), nil},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "does not retry non-status error",
+			errs:      []error{errors.New("boom"), nil},
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retryTransient(context.Background(), func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("Expected %d calls, got %d", tt.wantCalls, calls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestRetryTransient_ContextDone tests that a cancelled context stops retrying
+func TestRetryTransient_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	start := time.Now()
+	err := retryTransient(ctx, func() error {
+		calls++
+		return status.Error(codes.Unavailable, "down")
+	})
+
+	if err == nil || calls != 1 {
+		t.Errorf("Expected a single failed call, got %d calls and error %v", calls, err)
+	}
+	if time.Since(start) >= symbolLookupBackoff {
+		t.Error("Expected no backoff wait after cancellation")
+	}
+}
+
+// TestCheckReflectionSupport tests telling servers with and without
+// reflection apart from unreachable endpoints
+func TestCheckReflectionSupport(t *testing.T) {
+	serve := func(withReflection bool) string {
+		t.Helper()
+		lis, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		grpcServer := grpc.NewServer()
+		healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+		if withReflection {
+			reflection.Register(grpcServer)
+		}
+		go grpcServer.Serve(lis)
+		t.Cleanup(grpcServer.Stop)
+		return lis.Addr().String()
+	}
+
+	supported, err := CheckReflectionSupport(serve(true), false, time.Second)
+	if err != nil || !supported {
+		t.Errorf("Expected reflection support, got %v, %v", supported, err)
+	}
+
+	supported, err = CheckReflectionSupport(serve(false), false, time.Second)
+	if err != nil || supported {
+		t.Errorf("Expected a reachable server without reflection, got %v, %v", supported, err)
+	}
+
+	// Nothing listens on a closed port
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closed := lis.Addr().String()
+	lis.Close()
+
+	supported, err = CheckReflectionSupport(closed, false, time.Second)
+	if !errors.Is(err, ErrEndpointUnreachable) || supported {
+		t.Errorf("Expected ErrEndpointUnreachable, got %v, %v", supported, err)
+	}
+
+	// A peer that accepts but never speaks HTTP/2 is bounded by the timeout
+	silent, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer silent.Close()
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	_, err = CheckReflectionSupport(silent.Addr().String(), false, 200*time.Millisecond)
+	if !errors.Is(err, ErrEndpointUnreachable) {
+		t.Errorf("Expected ErrEndpointUnreachable, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the timeout to bound the check, took %v", elapsed)
+	}
+
+	// An untrusted certificate is a TLS failure, not an unreachable endpoint
+	untrusted := httptest.NewUnstartedServer(http.NotFoundHandler())
+	untrusted.EnableHTTP2 = true
+	untrusted.StartTLS()
+	defer untrusted.Close()
+
+	_, err = CheckReflectionSupport(untrusted.Listener.Addr().String(), true, time.Second)
+	if !errors.Is(err, ErrTLSHandshake) {
+		t.Errorf("Expected ErrTLSHandshake, got %v", err)
+	}
+}
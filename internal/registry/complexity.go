@@ -0,0 +1,140 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+const (
+	// largeMessageFieldCount is the number of fields across all reachable
+	// types at which a message is considered large
+	largeMessageFieldCount = 100
+	// largeMessageDepth is the nesting depth at which a message is considered large
+	largeMessageDepth = 6
+	// largeCollectionDepth is the nesting depth at which repeated or map
+	// fields make a message large
+	largeCollectionDepth = 3
+)
+
+// MessageComplexity estimates the structural weight of a message type, as a
+// hint for how large its encoded instances may get
+type MessageComplexity struct {
+	// FieldCount counts the fields of the message and of every message type
+	// reachable from it, each type counted once
+	FieldCount int
+	// MaxDepth is the longest chain of nested messages, counting the message
+	// itself as depth 1 and stopping at recursive references
+	MaxDepth int
+	// HasRepeated reports a repeated field anywhere in the message tree
+	HasRepeated bool
+	// HasMap reports a map field anywhere in the message tree
+	HasMap bool
+	// Recursive reports a message type that (transitively) contains itself
+	Recursive bool
+	// Large is set for recursive messages, deeply nested messages, messages
+	// with many fields, and messages with repeated or map fields nested at
+	// least a few levels deep
+	Large bool
+}
+
+// MessageComplexity returns the structural weight of a registered message,
+// computed when the message was registered
+func (r *Registry) MessageComplexity(messageName string) (MessageComplexity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.complexity[messageName]
+	if !ok {
+		return MessageComplexity{}, fmt.Errorf("message not found: %s", messageName)
+	}
+	return c, nil
+}
+
+// lockedComplexity returns the cached complexity of md, computing it for
+// types the registry doesn't hold. The caller must hold r.mu.
+func (r *Registry) lockedComplexity(md *desc.MessageDescriptor) MessageComplexity {
+	name := md.GetFullyQualifiedName()
+	if r.messages[name] == md {
+		if c, ok := r.complexity[name]; ok {
+			return c
+		}
+	}
+	return ComputeComplexity(md)
+}
+
+// ComputeComplexity estimates the structural weight of a message type
+func ComputeComplexity(md *desc.MessageDescriptor) MessageComplexity {
+	m := &complexityMeasurer{
+		counted: make(map[string]bool),
+		path:    make(map[string]bool),
+		depths:  make(map[string]int),
+	}
+	c := &m.complexity
+	c.MaxDepth, _ = m.measure(md)
+
+	collections := c.HasRepeated || c.HasMap
+	c.Large = c.Recursive ||
+		c.MaxDepth >= largeMessageDepth ||
+		c.FieldCount >= largeMessageFieldCount ||
+		(collections && c.MaxDepth >= largeCollectionDepth)
+	return *c
+}
+
+// complexityMeasurer walks a message tree accumulating MessageComplexity
+type complexityMeasurer struct {
+	complexity MessageComplexity
+	counted    map[string]bool // types whose fields were counted
+	path       map[string]bool // types on the current path
+	depths     map[string]int  // depths of fully measured, non-recursive subtrees
+}
+
+// measure returns the nesting depth of md and whether its subtree refers back
+// to a type on the current path, which makes the depth path-dependent
+func (m *complexityMeasurer) measure(md *desc.MessageDescriptor) (int, bool) {
+	name := md.GetFullyQualifiedName()
+	if depth, ok := m.depths[name]; ok {
+		return depth, false
+	}
+
+	m.path[name] = true
+	defer delete(m.path, name)
+
+	countFields := !m.counted[name]
+	m.counted[name] = true
+
+	depth := 1
+	cyclic := false
+	for _, field := range md.GetFields() {
+		fieldMsg := field.GetMessageType()
+		if field.IsMap() {
+			m.complexity.HasMap = true
+			// Map entries are synthetic; only the value type adds nesting
+			fieldMsg = field.GetMapValueType().GetMessageType()
+		} else if field.IsRepeated() {
+			m.complexity.HasRepeated = true
+		}
+		if countFields {
+			m.complexity.FieldCount++
+		}
+
+		if fieldMsg == nil {
+			continue
+		}
+		if m.path[fieldMsg.GetFullyQualifiedName()] {
+			m.complexity.Recursive = true
+			cyclic = true
+			continue
+		}
+		d, subCyclic := m.measure(fieldMsg)
+		cyclic = cyclic || subCyclic
+		if d+1 > depth {
+			depth = d + 1
+		}
+	}
+
+	if !cyclic {
+		m.depths[name] = depth
+	}
+	return depth, cyclic
+}
@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"testing"
+)
+
+// TestMessageComplexity tests structural weight estimation
+func TestMessageComplexity(t *testing.T) {
+	const source = `syntax = "proto3";
+package complexity.v1;
+
+service ComplexityService {
+  rpc Get(Flat) returns (Tree);
+}
+
+message Flat { string id = 1; int32 count = 2; }
+
+message Tree {
+  string name = 1;
+  repeated Tree children = 2;
+}
+
+message Labeled { map<string, Flat> labels = 1; }
+
+message Deep { L1 l1 = 1; repeated string tags = 2; }
+message L1 { L2 l2 = 1; }
+message L2 { Flat flat = 1; Flat other = 2; }
+`
+	reg := New()
+	fds := parseTestProtos(t, map[string]string{"complexity/v1/complexity.proto": source}, "complexity/v1/complexity.proto")
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	tests := []struct {
+		message string
+		want    MessageComplexity
+	}{
+		{
+			message: "complexity.v1.Flat",
+			want:    MessageComplexity{FieldCount: 2, MaxDepth: 1},
+		},
+		{
+			message: "complexity.v1.Tree",
+			want:    MessageComplexity{FieldCount: 2, MaxDepth: 1, HasRepeated: true, Recursive: true, Large: true},
+		},
+		{
+			message: "complexity.v1.Labeled",
+			want:    MessageComplexity{FieldCount: 3, MaxDepth: 2, HasMap: true},
+		},
+		{
+			// Flat is reached twice but its fields are counted once
+			message: "complexity.v1.Deep",
+			want:    MessageComplexity{FieldCount: 7, MaxDepth: 4, HasRepeated: true, Large: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			got, err := reg.MessageComplexity(tt.message)
+			if err != nil {
+				t.Fatalf("MessageComplexity failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := reg.MessageComplexity("complexity.v1.Missing"); err == nil {
+		t.Error("Expected error for non-existent message")
+	}
+
+	services := reg.ListServices()
+	if len(services) != 1 || len(services[0].Methods) != 1 {
+		t.Fatalf("Unexpected services: %+v", services)
+	}
+	if !services[0].Methods[0].OutputComplexity.Large {
+		t.Error("Expected recursive output type to be flagged large")
+	}
+	// Removed messages drop their cached complexity
+	reg.RemoveFiles("complexity/v1/complexity.proto")
+	if _, err := reg.MessageComplexity("complexity.v1.Flat"); err == nil {
+		t.Error("Expected error for a removed message")
+	}
+}
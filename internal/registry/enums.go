@@ -0,0 +1,58 @@
+package registry
+
+import (
+	"github.com/jhump/protoreflect/desc"
+)
+
+// EnumValues maps an enum's value names to numbers and back. Where values
+// alias one number, Names holds the first declared.
+type EnumValues struct {
+	Numbers map[string]int32
+	Names   map[int32]string
+}
+
+// newEnumValues builds the name and number mappings of an enum
+func newEnumValues(enum *desc.EnumDescriptor) EnumValues {
+	values := EnumValues{
+		Numbers: make(map[string]int32, len(enum.GetValues())),
+		Names:   make(map[int32]string, len(enum.GetValues())),
+	}
+	for _, value := range enum.GetValues() {
+		values.Numbers[value.GetName()] = value.GetNumber()
+		if _, ok := values.Names[value.GetNumber()]; !ok {
+			values.Names[value.GetNumber()] = value.GetName()
+		}
+	}
+	return values
+}
+
+// CollectEnumValues returns the value mappings of every enum used by a field
+// of the root messages or of any message they reach, keyed by fully
+// qualified enum name
+func CollectEnumValues(roots ...*desc.MessageDescriptor) map[string]EnumValues {
+	enums := make(map[string]EnumValues)
+	seen := make(map[string]bool)
+	var walk func(msg *desc.MessageDescriptor)
+	walk = func(msg *desc.MessageDescriptor) {
+		if seen[msg.GetFullyQualifiedName()] {
+			return
+		}
+		seen[msg.GetFullyQualifiedName()] = true
+
+		for _, field := range msg.GetFields() {
+			typeField := field
+			if field.IsMap() {
+				typeField = field.GetMapValueType()
+			}
+			if enum := typeField.GetEnumType(); enum != nil {
+				enums[enum.GetFullyQualifiedName()] = newEnumValues(enum)
+			} else if fieldMsg := typeField.GetMessageType(); fieldMsg != nil {
+				walk(fieldMsg)
+			}
+		}
+	}
+	for _, root := range roots {
+		walk(root)
+	}
+	return enums
+}
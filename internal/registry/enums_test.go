@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCollectEnumValues tests collecting the enums reachable from a message
+// through nested, repeated and map fields, with aliases and recursion
+func TestCollectEnumValues(t *testing.T) {
+	const source = `syntax = "proto3";
+package enums.v1;
+enum Status {
+  option allow_alias = true;
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+  STATUS_ENABLED = 1;
+}
+enum Color { COLOR_UNSPECIFIED = 0; COLOR_RED = 2; }
+enum Unused { UNUSED_UNSPECIFIED = 0; }
+message Tag { Color color = 1; }
+message Item {
+  Status status = 1;
+  repeated Tag tags = 2;
+  map<string, Color> colors = 3;
+  Item parent = 4;
+}
+`
+	fds := parseTestProtos(t, map[string]string{"enums/v1/enums.proto": source}, "enums/v1/enums.proto")
+	reg := New()
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	md, err := reg.GetMessageDescriptor("enums.v1.Item")
+	if err != nil {
+		t.Fatalf("GetMessageDescriptor failed: %v", err)
+	}
+
+	enums := CollectEnumValues(md)
+	if len(enums) != 2 {
+		t.Fatalf("Expected Status and Color, got %v", enums)
+	}
+
+	status := enums["enums.v1.Status"]
+	wantNumbers := map[string]int32{"STATUS_UNSPECIFIED": 0, "STATUS_ACTIVE": 1, "STATUS_ENABLED": 1}
+	if !reflect.DeepEqual(status.Numbers, wantNumbers) {
+		t.Errorf("Expected numbers %v, got %v", wantNumbers, status.Numbers)
+	}
+	wantNames := map[int32]string{0: "STATUS_UNSPECIFIED", 1: "STATUS_ACTIVE"}
+	if !reflect.DeepEqual(status.Names, wantNames) {
+		t.Errorf("Expected the first declared alias in names %v, got %v", wantNames, status.Names)
+	}
+	if got := enums["enums.v1.Color"].Names[2]; got != "COLOR_RED" {
+		t.Errorf("Expected COLOR_RED for 2, got %q", got)
+	}
+}
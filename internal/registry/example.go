@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// exampleSkippedTypes are well-known types whose empty value cannot be
+// rendered as JSON, so they are left unset in generated examples
+var exampleSkippedTypes = map[string]bool{
+	"google.protobuf.Any":   true,
+	"google.protobuf.Value": true,
+}
+
+// GenerateExampleJSON builds an example JSON payload for a message. Every
+// field is present with its default value; nested messages are expanded,
+// repeated message fields get a single element, and only the first member of
+// each oneof is set. Recursive references and messages beyond the schema
+// depth limit are left unset.
+func (r *Registry) GenerateExampleJSON(messageName string) (string, error) {
+	md, err := r.GetMessageDescriptor(messageName)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.RLock()
+	maxDepth := r.schemaDepthLimit()
+	r.mu.RUnlock()
+
+	return exampleJSON(md, maxDepth)
+}
+
+// ExampleJSON renders the example payload for a message type as indented
+// JSON, expanding up to DefaultMaxSchemaDepth levels
+func ExampleJSON(md *desc.MessageDescriptor) (string, error) {
+	return exampleJSON(md, DefaultMaxSchemaDepth)
+}
+
+// exampleJSON renders the example payload for a message type, expanding up
+// to maxDepth levels
+func exampleJSON(md *desc.MessageDescriptor, maxDepth int) (string, error) {
+	marshaler := &jsonpb.Marshaler{EmitDefaults: true, Indent: "  "}
+	out, err := exampleMessage(md, map[string]bool{md.GetFullyQualifiedName(): true}, maxDepth).MarshalJSONPB(marshaler)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// ExampleMessage builds the example message for a message type, as rendered
+// by ExampleJSON
+func ExampleMessage(md *desc.MessageDescriptor) *dynamic.Message {
+	return exampleMessage(md, map[string]bool{md.GetFullyQualifiedName(): true}, DefaultMaxSchemaDepth)
+}
+
+// populateExample fills msg with example values; seen holds the message types
+// on the current path to stop recursion, which stops at maxDepth levels
+func populateExample(msg *dynamic.Message, seen map[string]bool, maxDepth int) {
+	for _, field := range msg.GetMessageDescriptor().GetFields() {
+		if field.IsMap() {
+			continue
+		}
+		if oneOf := field.GetOneOf(); oneOf != nil && !oneOf.IsSynthetic() && oneOf.GetChoices()[0] != field {
+			continue
+		}
+
+		fieldMsg := field.GetMessageType()
+		if fieldMsg == nil {
+			// Oneof members are omitted unless explicitly set
+			if field.GetOneOf() != nil && !field.IsRepeated() {
+				msg.SetField(field, field.GetDefaultValue())
+			}
+			continue
+		}
+
+		// Stop at recursive references and at the schema depth limit
+		name := fieldMsg.GetFullyQualifiedName()
+		if seen[name] || exampleSkippedTypes[name] || len(seen) >= maxDepth {
+			continue
+		}
+		seen[name] = true
+		child := exampleMessage(fieldMsg, seen, maxDepth)
+		delete(seen, name)
+
+		if field.IsRepeated() {
+			msg.AddRepeatedField(field, child)
+		} else {
+			msg.SetField(field, child)
+		}
+	}
+}
+
+// exampleMessage creates a populated example message of the given type
+func exampleMessage(md *desc.MessageDescriptor, seen map[string]bool, maxDepth int) *dynamic.Message {
+	msg := dynamic.NewMessage(md)
+	populateExample(msg, seen, maxDepth)
+	return msg
+}
@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestGenerateExampleJSON tests example payload generation
+func TestGenerateExampleJSON(t *testing.T) {
+	const source = `syntax = "proto3";
+package example.v1;
+
+import "google/protobuf/timestamp.proto";
+
+message CreateRequest {
+  string name = 1;
+  int64 count = 2;
+  Kind kind = 3;
+  Item item = 4;
+  repeated Item items = 5;
+  repeated string tags = 6;
+  map<string, string> labels = 7;
+  google.protobuf.Timestamp created_at = 8;
+  oneof target {
+    string user_id = 9;
+    string group_id = 10;
+  }
+  CreateRequest parent = 11;
+}
+
+message Item { string sku = 1; }
+
+enum Kind {
+  KIND_UNSPECIFIED = 0;
+  KIND_SMALL = 1;
+}
+`
+	reg := New()
+	fds := parseTestProtos(t, map[string]string{"example/v1/example.proto": source}, "example/v1/example.proto")
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	example, err := reg.GenerateExampleJSON("example.v1.CreateRequest")
+	if err != nil {
+		t.Fatalf("GenerateExampleJSON failed: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal([]byte(example), &got); err != nil {
+		t.Fatalf("Example is not valid JSON: %v\n%s", err, example)
+	}
+
+	want := map[string]any{
+		"name":      "",
+		"count":     "0",
+		"kind":      "KIND_UNSPECIFIED",
+		"item":      map[string]any{"sku": ""},
+		"items":     []any{map[string]any{"sku": ""}},
+		"tags":      []any{},
+		"labels":    map[string]any{},
+		"createdAt": "1970-01-01T00:00:00Z",
+		"userId":    "",
+		"parent":    nil,
+	}
+	for key, value := range want {
+		gotJSON, _ := json.Marshal(got[key])
+		wantJSON, _ := json.Marshal(value)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("Field %s: expected %s, got %s", key, wantJSON, gotJSON)
+		}
+	}
+	if _, ok := got["groupId"]; ok {
+		t.Error("Expected only the first oneof member to be set")
+	}
+}
+
+// TestGenerateExampleJSON_NotFound tests error when the message doesn't exist
+func TestGenerateExampleJSON_NotFound(t *testing.T) {
+	if _, err := New().GenerateExampleJSON("nonexistent.Message"); err == nil {
+		t.Error("Expected error for non-existent message, got nil")
+	}
+}
+
+// TestGenerateExampleJSON_MaxDepth tests that examples stop expanding at the
+// registry's schema depth limit
+func TestGenerateExampleJSON_MaxDepth(t *testing.T) {
+	reg := New()
+	fds := parseTestProtos(t, map[string]string{"deep/v1/deep.proto": `syntax = "proto3";
+package deep.v1;
+message A { B b = 1; }
+message B { C c = 1; }
+message C { string name = 1; }
+`}, "deep/v1/deep.proto")
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	reg.SetMaxSchemaDepth(2)
+
+	example, err := reg.GenerateExampleJSON("deep.v1.A")
+	if err != nil {
+		t.Fatalf("GenerateExampleJSON failed: %v", err)
+	}
+	var got struct {
+		B struct {
+			C any `json:"c"`
+		} `json:"b"`
+	}
+	if err := json.Unmarshal([]byte(example), &got); err != nil {
+		t.Fatalf("Example is not valid JSON: %v\n%s", err, example)
+	}
+	if got.B.C != nil {
+		t.Errorf("Expected c beyond the depth limit to be unset, got %s", example)
+	}
+
+	reg.SetMaxSchemaDepth(0)
+	if example, _ := reg.GenerateExampleJSON("deep.v1.A"); !strings.Contains(example, `"name"`) {
+		t.Errorf("Expected the default limit to expand c, got %s", example)
+	}
+}
@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoprint"
+)
+
+// ExportProto renders a registered file as .proto source text. Comments are
+// included when the file was loaded with source info.
+func (r *Registry) ExportProto(fileName string) (string, error) {
+	r.mu.RLock()
+	fd, exists := r.files[fileName]
+	r.mu.RUnlock()
+	if !exists {
+		return "", fmt.Errorf("file not found: %s", fileName)
+	}
+
+	printer := &protoprint.Printer{}
+	source, err := printer.PrintProtoToString(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", fileName, err)
+	}
+	return source, nil
+}
+
+// FileImports returns the files a registered file imports, transitively, with
+// each file following the files it imports
+func (r *Registry) FileImports(fileName string) ([]string, error) {
+	r.mu.RLock()
+	fd, exists := r.files[fileName]
+	r.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("file not found: %s", fileName)
+	}
+
+	var imports []string
+	visited := map[string]bool{fileName: true}
+	var visit func(fd *desc.FileDescriptor)
+	visit = func(fd *desc.FileDescriptor) {
+		for _, dep := range fd.GetDependencies() {
+			if visited[dep.GetName()] {
+				continue
+			}
+			visited[dep.GetName()] = true
+			visit(dep)
+			imports = append(imports, dep.GetName())
+		}
+	}
+	visit(fd)
+	return imports, nil
+}
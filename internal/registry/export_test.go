@@ -0,0 +1,58 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// TestExportProto tests rendering registered files back to .proto source
+func TestExportProto(t *testing.T) {
+	reg := New()
+	fds := parseTestProtos(t, pruneTestProtos, "orders/v1/orders.proto")
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	source, err := reg.ExportProto("orders/v1/orders.proto")
+	if err != nil {
+		t.Fatalf("ExportProto failed: %v", err)
+	}
+	for _, want := range []string{
+		"package orders.v1;",
+		`import "common/v1/common.proto";`,
+		"// Reports on orders.",
+		"rpc Summarize ( PlaceRequest ) returns ( Order );",
+		"common.v1.Money total = 1;",
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("Expected source to contain %q, got:\n%s", want, source)
+		}
+	}
+
+	// The rendered source compiles back to the same services
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{
+		"orders/v1/orders.proto": source,
+		"common/v1/common.proto": pruneTestProtos["common/v1/common.proto"],
+	})}
+	reparsed, err := parser.ParseFiles("orders/v1/orders.proto")
+	if err != nil {
+		t.Fatalf("Rendered source does not compile: %v", err)
+	}
+	if got := len(reparsed[0].GetServices()); got != 2 {
+		t.Errorf("Expected 2 services after reparsing, got %d", got)
+	}
+
+	imports, err := reg.FileImports("orders/v1/orders.proto")
+	if err != nil {
+		t.Fatalf("FileImports failed: %v", err)
+	}
+	if got := strings.Join(imports, ","); got != "google/protobuf/timestamp.proto,common/v1/common.proto" {
+		t.Errorf("Unexpected imports: %s", got)
+	}
+
+	if _, err := reg.ExportProto("missing.proto"); err == nil {
+		t.Error("Expected an error for an unregistered file")
+	}
+}
@@ -0,0 +1,133 @@
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+// fieldPathSegment is one field of a path, with the repeated index or map
+// key that selects an element of it, if any
+type fieldPathSegment struct {
+	name    string
+	key     string
+	hasKey  bool
+	display string
+}
+
+// ResolveFieldPath returns the descriptor of the field at a dotted path
+// within a registered message, such as "user.address.zip". Fields may be
+// named by their JSON or proto names. A repeated field takes an index
+// ("items[2].sku") and a map field a key ("labels[\"env\"]" or
+// "labels[env]"); a map segment resolves to the map's value field. Repeated
+// message fields may also be crossed without an index ("items.sku").
+func (r *Registry) ResolveFieldPath(messageName, path string) (*desc.FieldDescriptor, error) {
+	md, err := r.GetMessageDescriptor(messageName)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var field *desc.FieldDescriptor
+	var resolved string
+	for i, segment := range segments {
+		if md == nil {
+			return nil, fmt.Errorf("invalid field path %q: %s is not a message", path, resolved)
+		}
+		field = md.FindFieldByJSONName(segment.name)
+		if field == nil {
+			field = md.FindFieldByName(segment.name)
+		}
+		if field == nil {
+			return nil, fmt.Errorf("invalid field path %q: %s has no field %q", path, md.GetFullyQualifiedName(), segment.name)
+		}
+
+		if resolved != "" {
+			resolved += "."
+		}
+		resolved += segment.display
+
+		switch {
+		case segment.hasKey && field.IsMap():
+			field = field.GetMapValueType()
+		case segment.hasKey && field.IsRepeated():
+			if index, err := strconv.Atoi(segment.key); err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid field path %q: index %q of %s is not a non-negative integer", path, segment.key, segment.name)
+			}
+		case segment.hasKey:
+			return nil, fmt.Errorf("invalid field path %q: %s is neither repeated nor a map", path, segment.name)
+		case field.IsMap() && i < len(segments)-1:
+			return nil, fmt.Errorf("invalid field path %q: map field %s needs a key, e.g. %s[\"key\"]", path, segment.name, segment.name)
+		}
+		md = field.GetMessageType()
+	}
+	return field, nil
+}
+
+// parseFieldPath splits a field path into its segments
+func parseFieldPath(path string) ([]fieldPathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("field path is required")
+	}
+
+	var segments []fieldPathSegment
+	rest := path
+	for {
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		segment := fieldPathSegment{name: rest[:end], display: rest[:end]}
+		if segment.name == "" {
+			return nil, fmt.Errorf("invalid field path %q: empty field name", path)
+		}
+		rest = rest[end:]
+
+		if strings.HasPrefix(rest, "[") {
+			key, length, err := parseFieldPathKey(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field path %q: %w", path, err)
+			}
+			segment.key, segment.hasKey = key, true
+			segment.display += rest[:length]
+			rest = rest[length:]
+		}
+		segments = append(segments, segment)
+
+		if rest == "" {
+			return segments, nil
+		}
+		if rest[0] != '.' || len(rest) == 1 {
+			return nil, fmt.Errorf("invalid field path %q: expected a field after %q", path, path[:len(path)-len(rest)])
+		}
+		rest = rest[1:]
+	}
+}
+
+// parseFieldPathKey parses a bracketed index or key at the start of s, either
+// bare ("[2]", "[env]") or quoted ("[\"a.b\"]"), returning it and the number
+// of bytes it spans
+func parseFieldPathKey(s string) (string, int, error) {
+	if strings.HasPrefix(s, `["`) {
+		quoted, err := strconv.QuotedPrefix(s[1:])
+		if err != nil || !strings.HasPrefix(s[1+len(quoted):], "]") {
+			return "", 0, fmt.Errorf("unterminated key in %s", s)
+		}
+		key, _ := strconv.Unquote(quoted)
+		return key, len(quoted) + 2, nil
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return "", 0, fmt.Errorf("missing ] in %s", s)
+	}
+	if end == 1 {
+		return "", 0, fmt.Errorf("empty index or key in %s", s)
+	}
+	return s[1:end], end + 1, nil
+}
@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestResolveFieldPath tests resolving dotted paths through nested,
+// repeated and map fields, and rejecting paths that don't fit the schema
+func TestResolveFieldPath(t *testing.T) {
+	const source = `syntax = "proto3";
+package paths.v1;
+
+message Order {
+  Customer customer = 1;
+  repeated LineItem line_items = 2;
+  map<string, Address> addresses = 3;
+  map<string, string> labels = 4;
+  repeated string tags = 5;
+}
+
+message Customer {
+  string name = 1;
+  Address shipping_address = 2;
+}
+
+message LineItem {
+  string sku = 1;
+  int32 quantity = 2;
+}
+
+message Address {
+  string city = 1;
+  string zip_code = 2;
+}
+`
+	reg := New()
+	fds := parseTestProtos(t, map[string]string{"paths/v1/paths.proto": source}, "paths/v1/paths.proto")
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"customer", "paths.v1.Order.customer"},
+		{"customer.name", "paths.v1.Customer.name"},
+		{"customer.shippingAddress.zipCode", "paths.v1.Address.zip_code"},
+		{"customer.shipping_address.zip_code", "paths.v1.Address.zip_code"},
+		{"lineItems", "paths.v1.Order.line_items"},
+		{"lineItems[2].sku", "paths.v1.LineItem.sku"},
+		{"line_items.quantity", "paths.v1.LineItem.quantity"},
+		{"addresses[home].city", "paths.v1.Address.city"},
+		{`addresses["a.b"].city`, "paths.v1.Address.city"},
+		{`labels["env"]`, "paths.v1.Order.LabelsEntry.value"},
+		{"tags[0]", "paths.v1.Order.tags"},
+	}
+	for _, tt := range tests {
+		field, err := reg.ResolveFieldPath("paths.v1.Order", tt.path)
+		if err != nil {
+			t.Errorf("ResolveFieldPath(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if got := field.GetFullyQualifiedName(); got != tt.want {
+			t.Errorf("ResolveFieldPath(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	errTests := []struct {
+		message string
+		path    string
+		want    string
+	}{
+		{"paths.v1.Missing", "name", "not found"},
+		{"paths.v1.Order", "", "required"},
+		{"paths.v1.Order", "customer.email", `has no field "email"`},
+		{"paths.v1.Order", "customer.name.first", "customer.name is not a message"},
+		{"paths.v1.Order", "addresses.city", "needs a key"},
+		{"paths.v1.Order", "lineItems[first].sku", "non-negative integer"},
+		{"paths.v1.Order", "customer[0]", "neither repeated nor a map"},
+		{"paths.v1.Order", "customer..name", "empty field name"},
+		{"paths.v1.Order", "customer.", "expected a field"},
+		{"paths.v1.Order", "lineItems[0", "missing ]"},
+		{"paths.v1.Order", `addresses["home].city`, "unterminated key"},
+		{"paths.v1.Order", "lineItems[]", "empty index"},
+	}
+	for _, tt := range errTests {
+		_, err := reg.ResolveFieldPath(tt.message, tt.path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ResolveFieldPath(%s, %q): expected error containing %q, got %v", tt.message, tt.path, tt.want, err)
+		}
+	}
+}
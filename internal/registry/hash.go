@@ -0,0 +1,101 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+)
+
+// ServiceSchemaHash computes a deterministic hash of a service's interface:
+// its methods and every message and enum they transitively reference. Names,
+// field numbers, types, labels and streaming flags contribute to the hash;
+// comments, options and declaration order do not.
+func (r *Registry) ServiceSchemaHash(serviceName string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	svc, exists := r.services[serviceName]
+	if !exists {
+		return "", fmt.Errorf("service not found: %s", serviceName)
+	}
+
+	lines := []string{"service " + svc.GetFullyQualifiedName()}
+	messages := make(map[string]*desc.MessageDescriptor)
+	enums := make(map[string]*desc.EnumDescriptor)
+
+	for _, method := range svc.GetMethods() {
+		lines = append(lines, fmt.Sprintf("method %s %s %s %t %t",
+			method.GetName(),
+			method.GetInputType().GetFullyQualifiedName(),
+			method.GetOutputType().GetFullyQualifiedName(),
+			method.IsClientStreaming(),
+			method.IsServerStreaming(),
+		))
+		collectReferencedTypes(method.GetInputType(), messages, enums)
+		collectReferencedTypes(method.GetOutputType(), messages, enums)
+	}
+
+	for _, msg := range messages {
+		lines = append(lines, "message "+msg.GetFullyQualifiedName())
+		for _, field := range msg.GetFields() {
+			lines = append(lines, fmt.Sprintf("field %s %d %s %s %s %s",
+				msg.GetFullyQualifiedName(),
+				field.GetNumber(),
+				field.GetName(),
+				field.GetLabel(),
+				field.GetType(),
+				fieldTypeName(field),
+			))
+		}
+	}
+
+	for _, enum := range enums {
+		lines = append(lines, "enum "+enum.GetFullyQualifiedName())
+		for _, value := range enum.GetValues() {
+			lines = append(lines, fmt.Sprintf("value %s %d %s",
+				enum.GetFullyQualifiedName(),
+				value.GetNumber(),
+				value.GetName(),
+			))
+		}
+	}
+
+	// Sorting makes the hash independent of declaration and map order
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// collectReferencedTypes gathers a message and all messages and enums reachable from its fields
+func collectReferencedTypes(msg *desc.MessageDescriptor, messages map[string]*desc.MessageDescriptor, enums map[string]*desc.EnumDescriptor) {
+	name := msg.GetFullyQualifiedName()
+	if _, seen := messages[name]; seen {
+		return
+	}
+	messages[name] = msg
+
+	for _, field := range msg.GetFields() {
+		if fieldMsg := field.GetMessageType(); fieldMsg != nil {
+			collectReferencedTypes(fieldMsg, messages, enums)
+		}
+		if fieldEnum := field.GetEnumType(); fieldEnum != nil {
+			enums[fieldEnum.GetFullyQualifiedName()] = fieldEnum
+		}
+	}
+}
+
+// fieldTypeName returns the referenced type name of a message or enum field
+func fieldTypeName(field *desc.FieldDescriptor) string {
+	if msg := field.GetMessageType(); msg != nil {
+		return msg.GetFullyQualifiedName()
+	}
+	if enum := field.GetEnumType(); enum != nil {
+		return enum.GetFullyQualifiedName()
+	}
+	return "-"
+}
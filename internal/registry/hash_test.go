@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+// hashTestProto is the baseline service used to compare schema hashes
+const hashTestProto = `syntax = "proto3";
+package hash.v1;
+
+// Orders things.
+service OrderService {
+  rpc Get(GetRequest) returns (Order);
+  rpc Watch(GetRequest) returns (stream Order);
+}
+
+message GetRequest { string id = 1; }
+
+message Order {
+  string id = 1;
+  Status status = 2;
+  repeated Item items = 3;
+}
+
+message Item { string sku = 1; int32 quantity = 2; }
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_OPEN = 1;
+}
+
+message Unrelated { string note = 1; }
+`
+
+// schemaHash registers a single proto source and hashes hash.v1.OrderService
+func schemaHash(t *testing.T, source string) string {
+	t.Helper()
+
+	reg := New()
+	fds := parseTestProtos(t, map[string]string{"hash/v1/hash.proto": source}, "hash/v1/hash.proto")
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	hash, err := reg.ServiceSchemaHash("hash.v1.OrderService")
+	if err != nil {
+		t.Fatalf("ServiceSchemaHash failed: %v", err)
+	}
+	return hash
+}
+
+// TestServiceSchemaHash tests which changes affect the schema hash
+func TestServiceSchemaHash(t *testing.T) {
+	base := schemaHash(t, hashTestProto)
+
+	if again := schemaHash(t, hashTestProto); again != base {
+		t.Errorf("Expected stable hash, got %s and %s", base, again)
+	}
+
+	unchanged := []struct {
+		name string
+		old  string
+		new  string
+	}{
+		{"comment", "// Orders things.\n", "// Manages orders.\n"},
+		{"unrelated message", "message Unrelated { string note = 1; }", "message Unrelated { string note = 1; int64 extra = 2; }"},
+		{"method order", "  rpc Get(GetRequest) returns (Order);\n  rpc Watch(GetRequest) returns (stream Order);", "  rpc Watch(GetRequest) returns (stream Order);\n  rpc Get(GetRequest) returns (Order);"},
+		{"field order", "  Status status = 2;\n  repeated Item items = 3;", "  repeated Item items = 3;\n  Status status = 2;"},
+	}
+	for _, tt := range unchanged {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schemaHash(t, replace(t, hashTestProto, tt.old, tt.new)); got != base {
+				t.Errorf("Expected hash to be unchanged")
+			}
+		})
+	}
+
+	changed := []struct {
+		name string
+		old  string
+		new  string
+	}{
+		{"field number", "int32 quantity = 2;", "int32 quantity = 3;"},
+		{"field type", "int32 quantity = 2;", "int64 quantity = 2;"},
+		{"nested enum value", "STATUS_OPEN = 1;", "STATUS_OPEN = 1;\n  STATUS_CLOSED = 2;"},
+		{"streaming flag", "returns (stream Order)", "returns (Order)"},
+		{"method name", "rpc Get(", "rpc Fetch("},
+	}
+	for _, tt := range changed {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schemaHash(t, replace(t, hashTestProto, tt.old, tt.new)); got == base {
+				t.Errorf("Expected hash to change")
+			}
+		})
+	}
+
+}
+
+// TestServiceSchemaHash_NotFound tests error when service doesn't exist
+func TestServiceSchemaHash_NotFound(t *testing.T) {
+	if _, err := New().ServiceSchemaHash("nonexistent.Service"); err == nil {
+		t.Error("Expected error for non-existent service, got nil")
+	}
+}
+
+// replace substitutes old with new in source, failing if old is absent
+func replace(t *testing.T, source, old, new string) string {
+	t.Helper()
+
+	if !strings.Contains(source, old) {
+		t.Fatalf("Test source does not contain %q", old)
+	}
+	return strings.Replace(source, old, new, 1)
+}
@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// extensionNumber identifies an extension by extended message and field
+// number
+type extensionNumber struct {
+	message string
+	number  int32
+}
+
+// indexExtensions records the extensions declared in a file, including those
+// nested inside messages, so custom options can be resolved by name or number
+func (r *Registry) indexExtensions(fd *desc.FileDescriptor) {
+	for _, ext := range fd.GetExtensions() {
+		r.indexExtension(ext)
+	}
+	for _, msg := range fd.GetMessageTypes() {
+		r.indexNestedExtensions(msg)
+	}
+}
+
+// indexNestedExtensions recursively indexes extensions declared inside a message
+func (r *Registry) indexNestedExtensions(msg *desc.MessageDescriptor) {
+	for _, ext := range msg.GetNestedExtensions() {
+		r.indexExtension(ext)
+	}
+	for _, nested := range msg.GetNestedMessageTypes() {
+		r.indexNestedExtensions(nested)
+	}
+}
+
+// indexExtension indexes one extension by name and by number
+func (r *Registry) indexExtension(ext *desc.FieldDescriptor) {
+	r.extensions[ext.GetFullyQualifiedName()] = ext
+	r.extensionNumbers[extensionNumber{ext.GetOwner().GetFullyQualifiedName(), ext.GetNumber()}] = ext
+}
+
+// extractCustomOptions returns the extension (custom) options set on an options
+// message, keyed by fully qualified extension name with JSON-encoded values.
+// Standard options such as "deprecated" are not included. Returns nil when no
+// custom options are present.
+func extractCustomOptions(opts proto.Message, types TypeResolver) map[string]string {
+	if opts == nil || !opts.ProtoReflect().IsValid() {
+		return nil
+	}
+
+	// Custom options arrive as unknown fields unless their extension is linked
+	// into the binary; round-trip through the wire format so they are parsed
+	// against the extensions registered in this session
+	data, err := proto.Marshal(opts)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	resolved := opts.ProtoReflect().New().Interface()
+	if err := (proto.UnmarshalOptions{Resolver: types}).Unmarshal(data, resolved); err != nil {
+		return nil
+	}
+
+	result := make(map[string]string)
+	resolved.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if !fd.IsExtension() {
+			return true
+		}
+		if value, ok := marshalOptionValue(resolved, fd, types); ok {
+			result[string(fd.FullName())] = value
+		}
+		return true
+	})
+
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// marshalOptionValue renders a single extension value as compact JSON
+func marshalOptionValue(msg proto.Message, fd protoreflect.FieldDescriptor, types TypeResolver) (string, bool) {
+	// Marshal a copy holding only this extension and pick its value out of the
+	// protojson output so enums, messages and lists use canonical JSON forms
+	single := msg.ProtoReflect().New()
+	single.Set(fd, msg.ProtoReflect().Get(fd))
+
+	data, err := protojson.MarshalOptions{Resolver: types}.Marshal(single.Interface())
+	if err != nil {
+		return "", false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", false
+	}
+
+	for key, raw := range fields {
+		if strings.Trim(key, "[]") != string(fd.FullName()) {
+			continue
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, raw); err != nil {
+			return string(raw), true
+		}
+		return compact.String(), true
+	}
+
+	return "", false
+}
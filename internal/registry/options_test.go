@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// parseTestProtos compiles in-memory proto sources into a FileDescriptorSet
+// that includes all transitive dependencies
+func parseTestProtos(t *testing.T, sources map[string]string, filenames ...string) *descriptorpb.FileDescriptorSet {
+	t.Helper()
+
+	parser := protoparse.Parser{
+		Accessor:              protoparse.FileContentsFromMap(sources),
+		IncludeSourceCodeInfo: true,
+	}
+	fds, err := parser.ParseFiles(filenames...)
+	if err != nil {
+		t.Fatalf("Failed to parse test protos: %v", err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	var add func(fd *desc.FileDescriptor)
+	add = func(fd *desc.FileDescriptor) {
+		if seen[fd.GetName()] {
+			return
+		}
+		seen[fd.GetName()] = true
+		for _, dep := range fd.GetDependencies() {
+			add(dep)
+		}
+		set.File = append(set.File, fd.AsFileDescriptorProto())
+	}
+	for _, fd := range fds {
+		add(fd)
+	}
+
+	return set
+}
+
+const customOptionsProto = `
+syntax = "proto3";
+
+package opts.v1;
+
+import "google/protobuf/descriptor.proto";
+
+message RateLimit {
+  int32 requests_per_second = 1;
+}
+
+extend google.protobuf.ServiceOptions {
+  RateLimit rate_limit = 50001;
+}
+
+extend google.protobuf.MethodOptions {
+  string auth_scope = 50002;
+}
+
+message GetRequest {}
+message GetResponse {}
+
+service OptionService {
+  option (rate_limit) = { requests_per_second: 10 };
+
+  rpc Get(GetRequest) returns (GetResponse) {
+    option (auth_scope) = "read:things";
+    option deprecated = true;
+  }
+
+  rpc Plain(GetRequest) returns (GetResponse);
+}
+`
+
+// TestCustomOptions tests extraction of custom service and method options
+func TestCustomOptions(t *testing.T) {
+	registry := New()
+	fds := parseTestProtos(t, map[string]string{"opts.proto": customOptionsProto}, "opts.proto")
+
+	if err := registry.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	info, _, err := registry.GetServiceSchema("opts.v1.OptionService")
+	if err != nil {
+		t.Fatalf("GetServiceSchema failed: %v", err)
+	}
+
+	if got := info.Options["opts.v1.rate_limit"]; got != `{"requestsPerSecond":10}` {
+		t.Errorf("Expected rate_limit service option, got %q (all: %v)", got, info.Options)
+	}
+
+	for _, method := range info.Methods {
+		switch method.Name {
+		case "Get":
+			if got := method.Options["opts.v1.auth_scope"]; got != `"read:things"` {
+				t.Errorf("Expected auth_scope method option, got %q (all: %v)", got, method.Options)
+			}
+			if _, ok := method.Options["deprecated"]; ok {
+				t.Error("Standard options should not be reported as custom options")
+			}
+		case "Plain":
+			if len(method.Options) != 0 {
+				t.Errorf("Expected no custom options, got %v", method.Options)
+			}
+		}
+	}
+
+	// ListServices should report the same options
+	services := registry.ListServices()
+	if len(services) != 1 {
+		t.Fatalf("Expected 1 service, got %d", len(services))
+	}
+	if services[0].Options["opts.v1.rate_limit"] == "" {
+		t.Error("Expected ListServices to include custom service options")
+	}
+}
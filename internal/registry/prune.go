@@ -0,0 +1,127 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// PruneToServices returns the part of fds needed for the named services:
+// the files declaring them and everything those files import, transitively,
+// with every other service removed. Messages and enums the services use are
+// kept because their files are imported. fds is not modified.
+func PruneToServices(fds *descriptorpb.FileDescriptorSet, services []string) (*descriptorpb.FileDescriptorSet, error) {
+	wanted := make(map[string]bool, len(services))
+	for _, name := range services {
+		wanted[strings.TrimPrefix(name, ".")] = true
+	}
+
+	byName := make(map[string]*descriptorpb.FileDescriptorProto, len(fds.GetFile()))
+	found := make(map[string]bool, len(wanted))
+	var roots []string
+	for _, fdpb := range fds.GetFile() {
+		byName[fdpb.GetName()] = fdpb
+		for _, svc := range fdpb.GetService() {
+			if name := qualifiedName(fdpb.GetPackage(), svc.GetName()); wanted[name] {
+				if !found[name] {
+					roots = append(roots, fdpb.GetName())
+				}
+				found[name] = true
+			}
+		}
+	}
+
+	var missing []string
+	for _, name := range services {
+		if !found[strings.TrimPrefix(name, ".")] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("services not found: %s", strings.Join(missing, ", "))
+	}
+
+	// Keep the import closure of the files declaring the services
+	keep := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		fdpb, ok := byName[name]
+		if !ok || keep[name] {
+			return
+		}
+		keep[name] = true
+		for _, dep := range fdpb.GetDependency() {
+			visit(dep)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+
+	pruned := &descriptorpb.FileDescriptorSet{}
+	for _, fdpb := range fds.GetFile() {
+		if !keep[fdpb.GetName()] {
+			continue
+		}
+		if len(fdpb.GetService()) > 0 {
+			fdpb = withServices(fdpb, wanted)
+		}
+		pruned.File = append(pruned.File, fdpb)
+	}
+	return pruned, nil
+}
+
+// serviceFieldNumber is the FileDescriptorProto field holding services, the
+// first element of their source code info paths
+const serviceFieldNumber = 6
+
+// withServices returns a copy of fdpb declaring only the wanted services,
+// with source code info renumbered to match
+func withServices(fdpb *descriptorpb.FileDescriptorProto, wanted map[string]bool) *descriptorpb.FileDescriptorProto {
+	// newIndex maps kept service indexes to their position in the copy
+	newIndex := make(map[int32]int32)
+	for i, svc := range fdpb.GetService() {
+		if wanted[qualifiedName(fdpb.GetPackage(), svc.GetName())] {
+			newIndex[int32(i)] = int32(len(newIndex))
+		}
+	}
+	if len(newIndex) == len(fdpb.GetService()) {
+		return fdpb
+	}
+
+	copied := proto.Clone(fdpb).(*descriptorpb.FileDescriptorProto)
+	services := copied.Service
+	copied.Service = nil
+	for i, svc := range services {
+		if _, ok := newIndex[int32(i)]; ok {
+			copied.Service = append(copied.Service, svc)
+		}
+	}
+
+	if info := copied.GetSourceCodeInfo(); info != nil {
+		var locations []*descriptorpb.SourceCodeInfo_Location
+		for _, loc := range info.GetLocation() {
+			path := loc.GetPath()
+			if len(path) >= 2 && path[0] == serviceFieldNumber {
+				index, ok := newIndex[path[1]]
+				if !ok {
+					continue
+				}
+				path[1] = index
+			}
+			locations = append(locations, loc)
+		}
+		info.Location = locations
+	}
+	return copied
+}
+
+// qualifiedName joins a package and a declared name
+func qualifiedName(pkg, name string) string {
+	if pkg == "" {
+		return name
+	}
+	return pkg + "." + name
+}
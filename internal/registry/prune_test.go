@@ -0,0 +1,89 @@
+package registry
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// pruneTestProtos spreads three services over files sharing common types
+var pruneTestProtos = map[string]string{
+	"common/v1/common.proto": `syntax = "proto3";
+package common.v1;
+import "google/protobuf/timestamp.proto";
+message Money { int64 units = 1; string currency = 2; }
+message Audit { google.protobuf.Timestamp at = 1; }
+`,
+	"orders/v1/orders.proto": `syntax = "proto3";
+package orders.v1;
+import "common/v1/common.proto";
+
+// Places orders.
+service OrderService {
+  rpc Place(PlaceRequest) returns (Order);
+}
+
+// Reports on orders.
+service ReportService {
+  rpc Summarize(PlaceRequest) returns (Order);
+}
+
+message PlaceRequest { common.v1.Money total = 1; }
+message Order { string id = 1; common.v1.Audit audit = 2; }
+`,
+	"billing/v1/billing.proto": `syntax = "proto3";
+package billing.v1;
+import "common/v1/common.proto";
+service BillingService {
+  rpc Charge(common.v1.Money) returns (common.v1.Money);
+}
+`,
+}
+
+// TestPruneToServices tests that pruning keeps one service and the files it
+// depends on
+func TestPruneToServices(t *testing.T) {
+	fds := parseTestProtos(t, pruneTestProtos, "orders/v1/orders.proto", "billing/v1/billing.proto")
+
+	pruned, err := PruneToServices(fds, []string{"orders.v1.ReportService"})
+	if err != nil {
+		t.Fatalf("PruneToServices failed: %v", err)
+	}
+
+	var files []string
+	for _, fdpb := range pruned.GetFile() {
+		files = append(files, fdpb.GetName())
+	}
+	sort.Strings(files)
+	if got := strings.Join(files, ","); got != "common/v1/common.proto,google/protobuf/timestamp.proto,orders/v1/orders.proto" {
+		t.Errorf("Unexpected files: %s", got)
+	}
+
+	reg := New()
+	if err := reg.Register(pruned); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	services := reg.ListServices()
+	if len(services) != 1 || services[0].Name != "orders.v1.ReportService" {
+		t.Fatalf("Expected only ReportService, got %v", services)
+	}
+	if _, err := reg.GetMethodDescriptor("orders.v1.ReportService", "Summarize"); err != nil {
+		t.Errorf("Expected the kept method to resolve: %v", err)
+	}
+
+	// Comments stay attached to the kept service after renumbering
+	if doc := services[0].Documentation; !strings.Contains(doc, "Reports on orders.") {
+		t.Errorf("Expected the service comment to be kept, got %q", doc)
+	}
+
+	// The input is left as it was
+	for _, fdpb := range fds.GetFile() {
+		if fdpb.GetName() == "orders/v1/orders.proto" && len(fdpb.GetService()) != 2 {
+			t.Errorf("Expected the input to keep both services, got %d", len(fdpb.GetService()))
+		}
+	}
+
+	if _, err := PruneToServices(fds, []string{"orders.v1.OrderService", "nope.v1.Missing"}); err == nil || !strings.Contains(err.Error(), "nope.v1.Missing") {
+		t.Errorf("Expected an error naming the missing service, got %v", err)
+	}
+}
@@ -1,32 +1,81 @@
 package registry
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 
+	"github.com/golang/protobuf/jsonpb"
+	protov1 "github.com/golang/protobuf/proto"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/runtime/protoiface"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 // Registry maintains an in-memory descriptor registry for dynamic gRPC invocation
 type Registry struct {
-	mu       sync.RWMutex
-	files    map[string]*desc.FileDescriptor
-	services map[string]*desc.ServiceDescriptor
-	messages map[string]*desc.MessageDescriptor
+	mu         sync.RWMutex
+	files      map[string]*desc.FileDescriptor
+	services   map[string]*desc.ServiceDescriptor
+	messages   map[string]*desc.MessageDescriptor
+	extensions map[string]*desc.FieldDescriptor
+	// extensionNumbers indexes extensions by extended message and number
+	extensionNumbers map[extensionNumber]*desc.FieldDescriptor
+	// complexity caches each message's MessageComplexity
+	complexity map[string]MessageComplexity
+	// maxSchemaDepth bounds schema expansion; zero means DefaultMaxSchemaDepth
+	maxSchemaDepth int
+	// encodedSize caches the descriptor set's encoded size plus one; zero
+	// means it must be recomputed
+	encodedSize atomic.Int64
 }
 
+// DefaultMaxSchemaDepth is the number of levels of nested message types
+// expanded by schema and example generation
+const DefaultMaxSchemaDepth = 32
+
 // New creates a new empty registry
 func New() *Registry {
 	return &Registry{
-		files:    make(map[string]*desc.FileDescriptor),
-		services: make(map[string]*desc.ServiceDescriptor),
-		messages: make(map[string]*desc.MessageDescriptor),
+		files:      make(map[string]*desc.FileDescriptor),
+		services:   make(map[string]*desc.ServiceDescriptor),
+		messages:   make(map[string]*desc.MessageDescriptor),
+		extensions: make(map[string]*desc.FieldDescriptor),
+
+		extensionNumbers: make(map[extensionNumber]*desc.FieldDescriptor),
+		complexity:       make(map[string]MessageComplexity),
+	}
+}
+
+// SetMaxSchemaDepth sets how many levels of nested message types
+// GetServiceSchema expands. Non-positive values restore DefaultMaxSchemaDepth.
+func (r *Registry) SetMaxSchemaDepth(depth int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if depth < 0 {
+		depth = 0
 	}
+	r.maxSchemaDepth = depth
+}
+
+// schemaDepthLimit returns the effective schema depth limit. The caller must
+// hold r.mu.
+func (r *Registry) schemaDepthLimit() int {
+	if r.maxSchemaDepth > 0 {
+		return r.maxSchemaDepth
+	}
+	return DefaultMaxSchemaDepth
 }
 
 // Register adds a FileDescriptorSet to the registry
@@ -42,24 +91,18 @@ func (r *Registry) Register(fds *descriptorpb.FileDescriptorSet) error {
 
 	// Process each file descriptor
 	for _, fdpb := range fds.File {
-		// Convert to jhump/protoreflect descriptor for easier access
-		fd, err := desc.CreateFileDescriptor(fdpb)
+		// Convert to jhump/protoreflect descriptor for easier access. Wrap the
+		// already-linked file so imports within the set resolve.
+		linked, err := files.FindFileByPath(fdpb.GetName())
 		if err != nil {
-			return fmt.Errorf("failed to create file descriptor for %s: %w", fdpb.GetName(), err)
+			return fmt.Errorf("failed to find file descriptor for %s: %w", fdpb.GetName(), err)
 		}
-
-		// Store file descriptor
-		r.files[fd.GetName()] = fd
-
-		// Index services
-		for _, svc := range fd.GetServices() {
-			r.services[svc.GetFullyQualifiedName()] = svc
+		fd, err := desc.WrapFile(linked)
+		if err != nil {
+			return fmt.Errorf("failed to create file descriptor for %s: %w", fdpb.GetName(), err)
 		}
 
-		// Index messages
-		for _, msg := range fd.GetMessageTypes() {
-			r.indexMessage(msg)
-		}
+		r.indexFile(fd)
 	}
 
 	// Also process using protoreflect for additional validation
@@ -76,9 +119,188 @@ func (r *Registry) Register(fds *descriptorpb.FileDescriptorSet) error {
 	return nil
 }
 
+// FileError records a file that could not be registered
+type FileError struct {
+	File string
+	Err  error
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.File, e.Err)
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
+}
+
+// RegisterBestEffort registers each file of a FileDescriptorSet independently.
+// Files may import files registered by earlier loads. Files that fail to
+// link, such as those with unresolved imports or symbols that conflict
+// within the set, are skipped along with the files that depend on them, and
+// reported in the returned slice; every other file is indexed.
+func (r *Registry) RegisterBestEffort(fds *descriptorpb.FileDescriptorSet) []FileError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing := make([]protoreflect.FileDescriptor, 0, len(r.files))
+	for _, fd := range r.files {
+		existing = append(existing, fd.UnwrapFile())
+	}
+
+	linked, fileErrs := linkFiles(fds, existing)
+	for _, fd := range linked {
+		r.indexFile(fd)
+	}
+	return fileErrs
+}
+
+// CheckDescriptors links every file of a FileDescriptorSet without registering
+// anything and reports the files with unresolved imports or symbols
+func CheckDescriptors(fds *descriptorpb.FileDescriptorSet) []FileError {
+	_, fileErrs := linkFiles(fds, nil)
+	return fileErrs
+}
+
+// linkFiles links each file against the files of the same set that precede it
+// in dependency order, falling back to the existing files, and returns the
+// files that linked and errors for the rest. As with Register, a file name
+// given twice with different contents and symbols defined twice within the
+// set are errors; files of the set replace existing files of the same name.
+func linkFiles(fds *descriptorpb.FileDescriptorSet, existing []protoreflect.FileDescriptor) ([]*desc.FileDescriptor, []FileError) {
+	var linkedFiles []*desc.FileDescriptor
+	var fileErrs []FileError
+	set := new(protoregistry.Files)
+
+	seen := make(map[string]*descriptorpb.FileDescriptorProto, len(fds.GetFile()))
+	var unique []*descriptorpb.FileDescriptorProto
+	for _, fdpb := range fds.GetFile() {
+		if prev, ok := seen[fdpb.GetName()]; ok {
+			if !proto.Equal(prev, fdpb) {
+				fileErrs = append(fileErrs, FileError{File: fdpb.GetName(), Err: fmt.Errorf("file appears multiple times with different contents")})
+			}
+			continue
+		}
+		seen[fdpb.GetName()] = fdpb
+		unique = append(unique, fdpb)
+	}
+
+	// Existing files the set doesn't replace. They were linked when
+	// registered, so a conflict among them only hides the later one.
+	previous := new(protoregistry.Files)
+	for _, fd := range existing {
+		if _, replaced := seen[fd.Path()]; !replaced {
+			_ = previous.RegisterFile(fd)
+		}
+	}
+	resolver := layeredFiles{set, previous}
+
+	for _, fdpb := range dependencyOrder(unique) {
+		file, err := protodesc.NewFile(fdpb, resolver)
+		if err == nil {
+			err = set.RegisterFile(file)
+		}
+		if err != nil {
+			fileErrs = append(fileErrs, FileError{File: fdpb.GetName(), Err: err})
+			continue
+		}
+
+		fd, err := desc.WrapFile(file)
+		if err != nil {
+			fileErrs = append(fileErrs, FileError{File: fdpb.GetName(), Err: err})
+			continue
+		}
+		linkedFiles = append(linkedFiles, fd)
+	}
+
+	return linkedFiles, fileErrs
+}
+
+// layeredFiles resolves files and symbols from the first registry that has
+// them
+type layeredFiles []*protoregistry.Files
+
+func (l layeredFiles) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	for _, files := range l {
+		if fd, err := files.FindFileByPath(path); err == nil {
+			return fd, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+func (l layeredFiles) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	for _, files := range l {
+		if d, err := files.FindDescriptorByName(name); err == nil {
+			return d, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+// dependencyOrder sorts files so each follows the files it imports from the
+// same set. Imports outside the set and import cycles don't block ordering;
+// linking reports them instead.
+func dependencyOrder(files []*descriptorpb.FileDescriptorProto) []*descriptorpb.FileDescriptorProto {
+	byName := make(map[string]*descriptorpb.FileDescriptorProto, len(files))
+	for _, fdpb := range files {
+		byName[fdpb.GetName()] = fdpb
+	}
+
+	ordered := make([]*descriptorpb.FileDescriptorProto, 0, len(files))
+	visited := make(map[string]bool, len(files))
+	var visit func(fdpb *descriptorpb.FileDescriptorProto)
+	visit = func(fdpb *descriptorpb.FileDescriptorProto) {
+		if visited[fdpb.GetName()] {
+			return
+		}
+		visited[fdpb.GetName()] = true
+		for _, dep := range fdpb.GetDependency() {
+			if depFile, ok := byName[dep]; ok {
+				visit(depFile)
+			}
+		}
+		ordered = append(ordered, fdpb)
+	}
+	for _, fdpb := range files {
+		visit(fdpb)
+	}
+	return ordered
+}
+
+// StripSourceInfo removes the source code info (comments and spans) from
+// every file of fds in place. Registering the result saves memory for large
+// APIs at the cost of empty documentation fields.
+func StripSourceInfo(fds *descriptorpb.FileDescriptorSet) {
+	for _, fdpb := range fds.GetFile() {
+		fdpb.SourceCodeInfo = nil
+	}
+}
+
+// indexFile stores a file descriptor and indexes its services, messages and
+// extensions. The caller must hold r.mu.
+func (r *Registry) indexFile(fd *desc.FileDescriptor) {
+	// Store file descriptor
+	r.files[fd.GetName()] = fd
+	r.encodedSize.Store(0)
+
+	// Index services
+	for _, svc := range fd.GetServices() {
+		r.services[svc.GetFullyQualifiedName()] = svc
+	}
+
+	// Index messages
+	for _, msg := range fd.GetMessageTypes() {
+		r.indexMessage(msg)
+	}
+
+	// Index extensions (used to resolve custom options)
+	r.indexExtensions(fd)
+}
+
 // indexMessage recursively indexes a message and its nested types
 func (r *Registry) indexMessage(msg *desc.MessageDescriptor) {
 	r.messages[msg.GetFullyQualifiedName()] = msg
+	r.complexity[msg.GetFullyQualifiedName()] = ComputeComplexity(msg)
 
 	// Index nested messages
 	for _, nested := range msg.GetNestedMessageTypes() {
@@ -92,6 +314,13 @@ type ServiceInfo struct {
 	Package       string
 	Methods       []MethodInfo
 	Documentation string
+	// DocumentationAvailable reports whether the declaring file carried
+	// source info, telling an empty Documentation apart from one that
+	// could not be known (e.g. reflection-loaded services)
+	DocumentationAvailable bool
+	// Options holds custom (extension) service options keyed by fully
+	// qualified extension name, with JSON-encoded values
+	Options map[string]string
 }
 
 // MethodInfo contains metadata about a gRPC method
@@ -102,6 +331,16 @@ type MethodInfo struct {
 	Documentation   string
 	ClientStreaming bool
 	ServerStreaming bool
+	// DocumentationAvailable reports whether the declaring file carried
+	// source info
+	DocumentationAvailable bool
+	// Options holds custom (extension) method options keyed by fully
+	// qualified extension name, with JSON-encoded values
+	Options map[string]string
+	// InputComplexity and OutputComplexity estimate the weight of the
+	// request and response types
+	InputComplexity  MessageComplexity
+	OutputComplexity MessageComplexity
 }
 
 // ListServices returns all registered services
@@ -109,25 +348,14 @@ func (r *Registry) ListServices() []ServiceInfo {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	types := r.lockedResolver()
+
 	services := make([]ServiceInfo, 0, len(r.services))
 	for _, svc := range r.services {
-		info := ServiceInfo{
-			Name:          svc.GetFullyQualifiedName(),
-			Package:       svc.GetFile().GetPackage(),
-			Documentation: extractComments(svc.GetSourceInfo()),
-			Methods:       make([]MethodInfo, 0, len(svc.GetMethods())),
-		}
+		info := newServiceInfo(svc, types)
 
 		for _, method := range svc.GetMethods() {
-			methodInfo := MethodInfo{
-				Name:            method.GetName(),
-				InputType:       method.GetInputType().GetFullyQualifiedName(),
-				OutputType:      method.GetOutputType().GetFullyQualifiedName(),
-				Documentation:   extractComments(method.GetSourceInfo()),
-				ClientStreaming: method.IsClientStreaming(),
-				ServerStreaming: method.IsServerStreaming(),
-			}
-			info.Methods = append(info.Methods, methodInfo)
+			info.Methods = append(info.Methods, r.newMethodInfo(method, types))
 		}
 
 		services = append(services, info)
@@ -136,6 +364,104 @@ func (r *Registry) ListServices() []ServiceInfo {
 	return services
 }
 
+// newServiceInfo builds the service metadata without its methods
+func newServiceInfo(svc *desc.ServiceDescriptor, types TypeResolver) ServiceInfo {
+	return ServiceInfo{
+		Name:                   svc.GetFullyQualifiedName(),
+		Package:                svc.GetFile().GetPackage(),
+		Documentation:          extractComments(svc.GetSourceInfo()),
+		DocumentationAvailable: hasSourceInfo(svc.GetFile()),
+		Methods:                make([]MethodInfo, 0, len(svc.GetMethods())),
+		Options:                extractCustomOptions(svc.GetServiceOptions(), types),
+	}
+}
+
+// newMethodInfo builds the metadata for a single method. The caller must hold
+// r.mu.
+func (r *Registry) newMethodInfo(method *desc.MethodDescriptor, types TypeResolver) MethodInfo {
+	return MethodInfo{
+		Name:             method.GetName(),
+		InputType:        method.GetInputType().GetFullyQualifiedName(),
+		OutputType:       method.GetOutputType().GetFullyQualifiedName(),
+		Documentation:    extractComments(method.GetSourceInfo()),
+		ClientStreaming:  method.IsClientStreaming(),
+		ServerStreaming:  method.IsServerStreaming(),
+		Options:          extractCustomOptions(method.GetMethodOptions(), types),
+		InputComplexity:  r.lockedComplexity(method.GetInputType()),
+		OutputComplexity: r.lockedComplexity(method.GetOutputType()),
+
+		DocumentationAvailable: hasSourceInfo(method.GetFile()),
+	}
+}
+
+// MethodSummary is the minimal method metadata needed to list a service's methods
+type MethodSummary struct {
+	Name            string
+	InputType       string
+	OutputType      string
+	ClientStreaming bool
+	ServerStreaming bool
+}
+
+// ListMethods returns a summary of each method of a service, in declaration order
+func (r *Registry) ListMethods(serviceName string) ([]MethodSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	svc, exists := r.services[serviceName]
+	if !exists {
+		return nil, fmt.Errorf("service not found: %s", serviceName)
+	}
+
+	methods := make([]MethodSummary, len(svc.GetMethods()))
+	for i, method := range svc.GetMethods() {
+		methods[i] = MethodSummary{
+			Name:            method.GetName(),
+			InputType:       method.GetInputType().GetFullyQualifiedName(),
+			OutputType:      method.GetOutputType().GetFullyQualifiedName(),
+			ClientStreaming: method.IsClientStreaming(),
+			ServerStreaming: method.IsServerStreaming(),
+		}
+	}
+
+	return methods, nil
+}
+
+// MethodRef identifies a method of a registered service
+type MethodRef struct {
+	Service         string
+	Method          string
+	ClientStreaming bool
+	ServerStreaming bool
+}
+
+// ListAllMethods returns every method of every registered service, sorted by
+// service and then method name
+func (r *Registry) ListAllMethods() []MethodRef {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var methods []MethodRef
+	for name, svc := range r.services {
+		for _, method := range svc.GetMethods() {
+			methods = append(methods, MethodRef{
+				Service:         name,
+				Method:          method.GetName(),
+				ClientStreaming: method.IsClientStreaming(),
+				ServerStreaming: method.IsServerStreaming(),
+			})
+		}
+	}
+
+	sort.Slice(methods, func(i, j int) bool {
+		if methods[i].Service != methods[j].Service {
+			return methods[i].Service < methods[j].Service
+		}
+		return methods[i].Method < methods[j].Method
+	})
+	return methods
+}
+
 // GetService retrieves a service descriptor by fully qualified name
 func (r *Registry) GetService(name string) (*desc.ServiceDescriptor, error) {
 	r.mu.RLock()
@@ -167,6 +493,27 @@ func (r *Registry) GetMethodDescriptor(serviceName, methodName string) (*desc.Me
 	return method, nil
 }
 
+// SplitMethodPath splits a full method path such as "pkg.Service/Method" or
+// "/pkg.Service/Method" into its service and method names
+func SplitMethodPath(path string) (serviceName, methodName string, err error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(path), "/")
+	serviceName, methodName, ok := strings.Cut(trimmed, "/")
+	if !ok || serviceName == "" || methodName == "" || strings.Contains(methodName, "/") {
+		return "", "", fmt.Errorf("invalid method path %q: expected pkg.Service/Method", path)
+	}
+	return serviceName, methodName, nil
+}
+
+// GetMethodDescriptorByPath retrieves a method descriptor by its full method
+// path, as accepted by SplitMethodPath
+func (r *Registry) GetMethodDescriptorByPath(path string) (*desc.MethodDescriptor, error) {
+	serviceName, methodName, err := SplitMethodPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetMethodDescriptor(serviceName, methodName)
+}
+
 // GetMessageDescriptor retrieves a message descriptor by fully qualified name
 func (r *Registry) GetMessageDescriptor(msgName string) (*desc.MessageDescriptor, error) {
 	r.mu.RLock()
@@ -180,6 +527,53 @@ func (r *Registry) GetMessageDescriptor(msgName string) (*desc.MessageDescriptor
 	return msg, nil
 }
 
+// AnyResolver returns a resolver for google.protobuf.Any payloads backed by
+// the registry's message index. Type URLs are looked up by the name after the
+// last slash; names not in the registry fall back to well-known and
+// generated types.
+func (r *Registry) AnyResolver() jsonpb.AnyResolver {
+	return &anyResolver{types: &typeResolver{registry: r, fallback: protoregistry.GlobalTypes}}
+}
+
+// anyResolver adapts a typeResolver to jsonpb.AnyResolver
+type anyResolver struct {
+	types *typeResolver
+}
+
+// Resolve implements jsonpb.AnyResolver. Registered types resolve to
+// dynamic messages, others to their generated types.
+func (a *anyResolver) Resolve(typeURL string) (protoiface.MessageV1, error) {
+	if md, ok := a.types.message(messageNameFromURL(typeURL)); ok {
+		return dynamic.NewMessage(md), nil
+	}
+
+	mt, err := a.types.fallback.FindMessageByURL(typeURL)
+	if err != nil {
+		return nil, err
+	}
+	return protov1.MessageV1(mt.New().Interface()), nil
+}
+
+// GetServiceInfo returns a service's metadata without generating the schemas
+// of its message types
+func (r *Registry) GetServiceInfo(serviceName string) (*ServiceInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	svc, exists := r.services[serviceName]
+	if !exists {
+		return nil, fmt.Errorf("service not found: %s", serviceName)
+	}
+
+	types := r.lockedResolver()
+	info := newServiceInfo(svc, types)
+	for _, method := range svc.GetMethods() {
+		info.Methods = append(info.Methods, r.newMethodInfo(method, types))
+	}
+
+	return &info, nil
+}
+
 // GetServiceSchema returns detailed schema information for a service
 func (r *Registry) GetServiceSchema(serviceName string) (*ServiceInfo, map[string]string, error) {
 	r.mu.RLock()
@@ -191,93 +585,123 @@ func (r *Registry) GetServiceSchema(serviceName string) (*ServiceInfo, map[strin
 	}
 
 	// Build service info
-	info := ServiceInfo{
-		Name:          svc.GetFullyQualifiedName(),
-		Package:       svc.GetFile().GetPackage(),
-		Documentation: extractComments(svc.GetSourceInfo()),
-		Methods:       make([]MethodInfo, 0, len(svc.GetMethods())),
-	}
-
-	// Track all message types used by this service
-	messageSchemas := make(map[string]string)
-	messagesSeen := make(map[string]bool)
+	types := r.lockedResolver()
+	info := newServiceInfo(svc, types)
 
+	// Collect schemas for the input and output types of every method
+	var roots []*desc.MessageDescriptor
 	for _, method := range svc.GetMethods() {
-		methodInfo := MethodInfo{
-			Name:            method.GetName(),
-			InputType:       method.GetInputType().GetFullyQualifiedName(),
-			OutputType:      method.GetOutputType().GetFullyQualifiedName(),
-			Documentation:   extractComments(method.GetSourceInfo()),
-			ClientStreaming: method.IsClientStreaming(),
-			ServerStreaming: method.IsServerStreaming(),
-		}
-		info.Methods = append(info.Methods, methodInfo)
-
-		// Collect schemas for input and output types
-		r.collectMessageSchema(method.GetInputType(), messageSchemas, messagesSeen)
-		r.collectMessageSchema(method.GetOutputType(), messageSchemas, messagesSeen)
+		info.Methods = append(info.Methods, r.newMethodInfo(method, types))
+		roots = append(roots, method.GetInputType(), method.GetOutputType())
 	}
 
-	return &info, messageSchemas, nil
+	return &info, r.collectMessageSchemas(roots), nil
 }
 
-// collectMessageSchema recursively collects JSON Schema for a message and its dependencies
-func (r *Registry) collectMessageSchema(msg *desc.MessageDescriptor, schemas map[string]string, seen map[string]bool) {
-	name := msg.GetFullyQualifiedName()
-	if seen[name] {
-		return
+// collectMessageSchemas collects JSON Schema for the root messages and every
+// message type they reach. The walk is breadth-first with cycle detection
+// keyed on fully qualified name, so each type is generated once, at its
+// shallowest depth. Types beyond the maximum schema depth get a placeholder
+// definition, keeping the $ref of the field that reaches them resolvable.
+func (r *Registry) collectMessageSchemas(roots []*desc.MessageDescriptor) map[string]string {
+	type pending struct {
+		msg   *desc.MessageDescriptor
+		depth int
 	}
-	seen[name] = true
 
-	// Generate JSON Schema for this message
-	schema := r.generateJSONSchema(msg)
-	schemas[name] = schema
-
-	// Recursively process field types
-	for _, field := range msg.GetFields() {
-		if field.GetMessageType() != nil {
-			r.collectMessageSchema(field.GetMessageType(), schemas, seen)
+	maxDepth := r.schemaDepthLimit()
+	schemas := make(map[string]string)
+	seen := make(map[string]bool)
+	var queue []pending
+	enqueue := func(msg *desc.MessageDescriptor, depth int) {
+		if name := msg.GetFullyQualifiedName(); !seen[name] {
+			seen[name] = true
+			queue = append(queue, pending{msg: msg, depth: depth})
 		}
 	}
 
-	// Process nested types
-	for _, nested := range msg.GetNestedMessageTypes() {
-		r.collectMessageSchema(nested, schemas, seen)
+	for _, root := range roots {
+		enqueue(root, 1)
 	}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		name := next.msg.GetFullyQualifiedName()
+		if next.depth > maxDepth {
+			schemas[name] = truncatedJSONSchema(next.msg)
+			continue
+		}
+		schemas[name] = r.generateJSONSchema(next.msg)
+
+		for _, field := range next.msg.GetFields() {
+			if field.GetMessageType() != nil {
+				enqueue(field.GetMessageType(), next.depth+1)
+			}
+		}
+		for _, nested := range next.msg.GetNestedMessageTypes() {
+			enqueue(nested, next.depth+1)
+		}
+	}
+
+	return schemas
 }
 
-// generateJSONSchema generates a JSON Schema representation of a message
+// truncatedJSONSchema is the placeholder definition for a message beyond the
+// schema depth limit
+func truncatedJSONSchema(msg *desc.MessageDescriptor) string {
+	return fmt.Sprintf(`{
+  "type": "object",
+  "title": %s,
+  "description": "not expanded: schema depth limit reached"
+}`, jsonString(msg.GetName()))
+}
+
+// generateJSONSchema generates a JSON Schema representation of a message.
+// Properties are emitted in field number order so the output is identical
+// across runs and independent of declaration order.
 func (r *Registry) generateJSONSchema(msg *desc.MessageDescriptor) string {
 	// Simplified JSON Schema generation
 	// In production, use a proper JSON Schema generator
-	schema := fmt.Sprintf(`{
+	fields := append([]*desc.FieldDescriptor(nil), msg.GetFields()...)
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].GetNumber() < fields[j].GetNumber()
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `{
   "type": "object",
-  "title": "%s",
-  "properties": {`, msg.GetName())
+  "title": %s,
+  "properties": {`, jsonString(msg.GetName()))
 
-	for i, field := range msg.GetFields() {
+	for i, field := range fields {
 		if i > 0 {
-			schema += ","
+			b.WriteString(",")
 		}
-		fieldType := getJSONType(field)
-		schema += fmt.Sprintf(`
-    "%s": {
-      "type": "%s"`, field.GetName(), fieldType)
+		fmt.Fprintf(&b, `
+    %s: {
+      "type": "%s"`, jsonString(field.GetName()), getJSONType(field))
 
 		if field.GetMessageType() != nil {
-			schema += fmt.Sprintf(`,
-      "$ref": "#/definitions/%s"`, field.GetMessageType().GetFullyQualifiedName())
+			fmt.Fprintf(&b, `,
+      "$ref": %s`, jsonString("#/definitions/"+field.GetMessageType().GetFullyQualifiedName()))
 		}
 
-		schema += `
-    }`
+		b.WriteString(`
+    }`)
 	}
 
-	schema += `
+	b.WriteString(`
   }
-}`
+}`)
 
-	return schema
+	return b.String()
+}
+
+// jsonString encodes s as a JSON string literal
+func jsonString(s string) string {
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
 }
 
 // getJSONType maps protobuf field types to JSON types
@@ -304,6 +728,12 @@ func getJSONType(field *desc.FieldDescriptor) string {
 	}
 }
 
+// hasSourceInfo reports whether a file was loaded with source code info,
+// without which no element of it can have documentation
+func hasSourceInfo(fd *desc.FileDescriptor) bool {
+	return fd.AsFileDescriptorProto().GetSourceCodeInfo() != nil
+}
+
 // extractComments extracts leading comments from source code info
 func extractComments(info *descriptorpb.SourceCodeInfo_Location) string {
 	if info == nil {
@@ -323,13 +753,76 @@ func (r *Registry) Clear() {
 	r.files = make(map[string]*desc.FileDescriptor)
 	r.services = make(map[string]*desc.ServiceDescriptor)
 	r.messages = make(map[string]*desc.MessageDescriptor)
+	r.extensions = make(map[string]*desc.FieldDescriptor)
+	r.extensionNumbers = make(map[extensionNumber]*desc.FieldDescriptor)
+	r.complexity = make(map[string]MessageComplexity)
+	r.encodedSize.Store(0)
+}
+
+// RemoveFiles unregisters the named files along with the services, messages
+// and extensions they define. A named file that a remaining file imports,
+// directly or through other files, stays registered.
+func (r *Registry) RemoveFiles(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := r.files[name]; ok {
+			removed[name] = true
+		}
+	}
+
+	// Keep every removed file reachable from a file that stays
+	var pending []*desc.FileDescriptor
+	for name, fd := range r.files {
+		if !removed[name] {
+			pending = append(pending, fd)
+		}
+	}
+	for len(pending) > 0 {
+		fd := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		for _, dep := range fd.GetDependencies() {
+			if removed[dep.GetName()] {
+				delete(removed, dep.GetName())
+				pending = append(pending, r.files[dep.GetName()])
+			}
+		}
+	}
+
+	for name := range removed {
+		delete(r.files, name)
+	}
+
+	// Rebuild the indexes from the remaining files, in name order so a
+	// symbol defined twice resolves the same way every time
+	remaining := make([]string, 0, len(r.files))
+	for name := range r.files {
+		remaining = append(remaining, name)
+	}
+	sort.Strings(remaining)
+
+	files := r.files
+	r.files = make(map[string]*desc.FileDescriptor, len(files))
+	r.services = make(map[string]*desc.ServiceDescriptor)
+	r.messages = make(map[string]*desc.MessageDescriptor)
+	r.extensions = make(map[string]*desc.FieldDescriptor)
+	r.extensionNumbers = make(map[extensionNumber]*desc.FieldDescriptor)
+	r.complexity = make(map[string]MessageComplexity)
+	for _, name := range remaining {
+		r.indexFile(files[name])
+	}
+	r.encodedSize.Store(0)
 }
 
 // Stats returns statistics about the registry
 type Stats struct {
 	FileCount    int
 	ServiceCount int
+	// MessageCount and EnumCount include nested types
 	MessageCount int
+	EnumCount    int
 }
 
 // GetStats returns current registry statistics
@@ -337,11 +830,51 @@ func (r *Registry) GetStats() Stats {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	enums := 0
+	for _, fd := range r.files {
+		enums += len(fd.GetEnumTypes())
+	}
+	for _, msg := range r.messages {
+		enums += len(msg.GetNestedEnumTypes())
+	}
+
 	return Stats{
 		FileCount:    len(r.files),
 		ServiceCount: len(r.services),
 		MessageCount: len(r.messages),
+		EnumCount:    enums,
+	}
+}
+
+// GetPackageStats returns registry statistics grouped by proto package.
+// Files without a package are grouped under "".
+func (r *Registry) GetPackageStats() map[string]Stats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	packages := make(map[string]Stats)
+	for _, fd := range r.files {
+		stats := packages[fd.GetPackage()]
+		stats.FileCount++
+		stats.ServiceCount += len(fd.GetServices())
+		stats.EnumCount += len(fd.GetEnumTypes())
+		messages, enums := countNestedTypes(fd.GetMessageTypes())
+		stats.MessageCount += messages
+		stats.EnumCount += enums
+		packages[fd.GetPackage()] = stats
+	}
+	return packages
+}
+
+// countNestedTypes counts the given messages and every message and enum
+// nested within them
+func countNestedTypes(msgs []*desc.MessageDescriptor) (messages, enums int) {
+	for _, msg := range msgs {
+		nestedMessages, nestedEnums := countNestedTypes(msg.GetNestedMessageTypes())
+		messages += 1 + nestedMessages
+		enums += len(msg.GetNestedEnumTypes()) + nestedEnums
 	}
+	return messages, enums
 }
 
 // HasService checks if a service is registered
@@ -426,9 +959,13 @@ func (r *Registry) Clone() *Registry {
 	defer r.mu.RUnlock()
 
 	clone := New()
+	clone.maxSchemaDepth = r.maxSchemaDepth
 	clone.files = make(map[string]*desc.FileDescriptor, len(r.files))
 	clone.services = make(map[string]*desc.ServiceDescriptor, len(r.services))
 	clone.messages = make(map[string]*desc.MessageDescriptor, len(r.messages))
+	clone.extensions = make(map[string]*desc.FieldDescriptor, len(r.extensions))
+	clone.extensionNumbers = make(map[extensionNumber]*desc.FieldDescriptor, len(r.extensionNumbers))
+	clone.complexity = make(map[string]MessageComplexity, len(r.complexity))
 
 	for k, v := range r.files {
 		clone.files[k] = v
@@ -439,6 +976,15 @@ func (r *Registry) Clone() *Registry {
 	for k, v := range r.messages {
 		clone.messages[k] = v
 	}
+	for k, v := range r.extensions {
+		clone.extensions[k] = v
+	}
+	for k, v := range r.extensionNumbers {
+		clone.extensionNumbers[k] = v
+	}
+	for k, v := range r.complexity {
+		clone.complexity[k] = v
+	}
 
 	return clone
 }
@@ -448,15 +994,48 @@ func (r *Registry) MarshalBinary() ([]byte, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	fds := &descriptorpb.FileDescriptorSet{
-		File: make([]*descriptorpb.FileDescriptorProto, 0, len(r.files)),
+	return proto.Marshal(r.descriptorSet())
+}
+
+// EncodedSize returns the size in bytes of the registry's binary descriptor
+// set, as produced by MarshalBinary. It approximates the memory held by the
+// registry and is cached until the registry changes.
+func (r *Registry) EncodedSize() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if cached := r.encodedSize.Load(); cached > 0 {
+		return cached - 1
 	}
+	size := int64(proto.Size(r.descriptorSet()))
+	r.encodedSize.Store(size + 1)
+	return size
+}
 
-	for _, fd := range r.files {
-		fds.File = append(fds.File, fd.AsFileDescriptorProto())
+// MarshalJSON serializes the registry's descriptor set as indented protojson.
+// Files are ordered so that dependencies precede their dependents.
+func (r *Registry) MarshalJSON() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(r.descriptorSet())
+}
+
+// descriptorSet returns the registered files in dependency order, breaking
+// ties by name. The caller must hold r.mu.
+func (r *Registry) descriptorSet() *descriptorpb.FileDescriptorSet {
+	names := make([]string, 0, len(r.files))
+	for name := range r.files {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	return proto.Marshal(fds)
+	files := make([]*descriptorpb.FileDescriptorProto, 0, len(names))
+	for _, name := range names {
+		files = append(files, r.files[name].AsFileDescriptorProto())
+	}
+
+	return &descriptorpb.FileDescriptorSet{File: dependencyOrder(files)}
 }
 
 // UnmarshalBinary deserializes a registry from binary format
@@ -468,3 +1047,14 @@ func (r *Registry) UnmarshalBinary(data []byte) error {
 
 	return r.Register(fds)
 }
+
+// UnmarshalJSON registers the descriptors of a protojson-encoded
+// FileDescriptorSet, as produced by MarshalJSON
+func (r *Registry) UnmarshalJSON(data []byte) error {
+	fds := &descriptorpb.FileDescriptorSet{}
+	if err := protojson.Unmarshal(data, fds); err != nil {
+		return fmt.Errorf("failed to unmarshal descriptor set JSON: %w", err)
+	}
+
+	return r.Register(fds)
+}
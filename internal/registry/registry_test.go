@@ -1,9 +1,17 @@
 package registry
 
 import (
+	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 // createTestFileDescriptorSet creates a minimal FileDescriptorSet for testing
@@ -480,6 +488,71 @@ func TestClear(t *testing.T) {
 	}
 }
 
+// TestRemoveFiles tests that removing a file drops only its definitions
+func TestRemoveFiles(t *testing.T) {
+	registry := New()
+	if err := registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := registry.Register(createMultiServiceTestData()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	registry.RemoveFiles("multi.proto", "missing.proto")
+
+	if registry.HasService("multi.v1.UserService") || registry.HasService("multi.v1.OrderService") {
+		t.Error("Expected services of the removed file to be gone")
+	}
+	if _, err := registry.GetMessageDescriptor("multi.v1.GetUserRequest"); err == nil {
+		t.Error("Expected messages of the removed file to be gone")
+	}
+	if !registry.HasService("test.v1.TestService") {
+		t.Error("Expected services of other files to remain")
+	}
+	if stats := registry.GetStats(); stats.FileCount != 1 {
+		t.Errorf("Expected 1 file, got %d", stats.FileCount)
+	}
+}
+
+// TestRemoveFiles_Imported tests that a file another registered file
+// imports stays until its importer is removed too
+func TestRemoveFiles_Imported(t *testing.T) {
+	registry := New()
+	if err := registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	importer := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:       proto.String("importer.proto"),
+		Package:    proto.String("importer.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"test.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("ImporterService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Call"),
+				InputType:  proto.String(".test.v1.TestRequest"),
+				OutputType: proto.String(".test.v1.TestResponse"),
+			}},
+		}},
+	}}}
+	if fileErrs := registry.RegisterBestEffort(importer); len(fileErrs) > 0 {
+		t.Fatalf("RegisterBestEffort failed: %v", fileErrs)
+	}
+
+	registry.RemoveFiles("test.proto")
+	if !registry.HasService("test.v1.TestService") {
+		t.Error("Expected an imported file to stay registered")
+	}
+	if _, err := registry.GetMessageDescriptor("test.v1.TestRequest"); err != nil {
+		t.Errorf("Expected messages of an imported file to remain: %v", err)
+	}
+
+	registry.RemoveFiles("importer.proto", "test.proto")
+	if stats := registry.GetStats(); stats.FileCount != 0 {
+		t.Errorf("Expected 0 files once the importer is removed too, got %d", stats.FileCount)
+	}
+}
+
 // TestRegister_Duplicate tests duplicate registration handling
 func TestRegister_Duplicate(t *testing.T) {
 	registry := New()
@@ -590,6 +663,132 @@ func TestGetMethodDescriptor_MethodNotFound(t *testing.T) {
 	}
 }
 
+// TestListMethods tests listing method summaries for a service
+func TestListMethods(t *testing.T) {
+	registry := New()
+	fds := createTestFileDescriptorSet()
+
+	if err := registry.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	methods, err := registry.ListMethods("test.v1.TestService")
+	if err != nil {
+		t.Fatalf("ListMethods failed: %v", err)
+	}
+
+	if len(methods) != 1 {
+		t.Fatalf("Expected 1 method, got %d", len(methods))
+	}
+
+	method := methods[0]
+	if method.Name != "TestMethod" {
+		t.Errorf("Expected method name 'TestMethod', got '%s'", method.Name)
+	}
+	if method.InputType != "test.v1.TestRequest" || method.OutputType != "test.v1.TestResponse" {
+		t.Errorf("Unexpected types: %s -> %s", method.InputType, method.OutputType)
+	}
+	if method.ClientStreaming || method.ServerStreaming {
+		t.Error("Expected unary method")
+	}
+}
+
+// TestListMethods_NotFound tests error when service doesn't exist
+func TestListMethods_NotFound(t *testing.T) {
+	registry := New()
+
+	if _, err := registry.ListMethods("nonexistent.Service"); err == nil {
+		t.Error("Expected error for non-existent service, got nil")
+	}
+}
+
+// TestListAllMethods tests the flat method index across services
+func TestListAllMethods(t *testing.T) {
+	registry := New()
+	if err := registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	// A second service whose name sorts first, with a streaming method
+	ping := ".alpha.v1.Ping"
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:        proto.String("alpha/v1/alpha.proto"),
+		Package:     proto.String("alpha.v1"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Ping")}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("AlphaService"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Watch"), InputType: &ping, OutputType: &ping, ServerStreaming: proto.Bool(true)},
+				{Name: proto.String("Get"), InputType: &ping, OutputType: &ping},
+			},
+		}},
+	}}}
+	if err := registry.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	want := []MethodRef{
+		{Service: "alpha.v1.AlphaService", Method: "Get"},
+		{Service: "alpha.v1.AlphaService", Method: "Watch", ServerStreaming: true},
+		{Service: "test.v1.TestService", Method: "TestMethod"},
+	}
+	if got := registry.ListAllMethods(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if got := New().ListAllMethods(); len(got) != 0 {
+		t.Errorf("Expected no methods in an empty registry, got %v", got)
+	}
+}
+
+// TestSplitMethodPath tests splitting full method paths into service and method
+func TestSplitMethodPath(t *testing.T) {
+	tests := []struct {
+		path        string
+		wantService string
+		wantMethod  string
+		wantErr     bool
+	}{
+		{path: "test.v1.TestService/TestMethod", wantService: "test.v1.TestService", wantMethod: "TestMethod"},
+		{path: "/test.v1.TestService/TestMethod", wantService: "test.v1.TestService", wantMethod: "TestMethod"},
+		{path: " /test.v1.TestService/TestMethod ", wantService: "test.v1.TestService", wantMethod: "TestMethod"},
+		{path: "TestMethod", wantErr: true},
+		{path: "/TestMethod", wantErr: true},
+		{path: "test.v1.TestService/", wantErr: true},
+		{path: "//TestMethod", wantErr: true},
+		{path: "/test.v1.TestService/TestMethod/extra", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		service, method, err := SplitMethodPath(tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("SplitMethodPath(%q): expected error, got %q, %q", tt.path, service, method)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("SplitMethodPath(%q) failed: %v", tt.path, err)
+			continue
+		}
+		if service != tt.wantService || method != tt.wantMethod {
+			t.Errorf("SplitMethodPath(%q) = %q, %q; want %q, %q", tt.path, service, method, tt.wantService, tt.wantMethod)
+		}
+	}
+
+	reg := New()
+	if err := reg.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if _, err := reg.GetMethodDescriptorByPath("/test.v1.TestService/TestMethod"); err != nil {
+		t.Errorf("GetMethodDescriptorByPath failed: %v", err)
+	}
+	if _, err := reg.GetMethodDescriptorByPath("/test.v1.TestService/Missing"); err == nil {
+		t.Error("Expected error for unknown method path")
+	}
+}
+
 // TestGetMessageDescriptor tests retrieving message descriptors
 func TestGetMessageDescriptor(t *testing.T) {
 	registry := New()
@@ -678,6 +877,82 @@ func TestGetServiceSchema_NotFound(t *testing.T) {
 	}
 }
 
+// TestStripSourceInfo tests that stripped descriptors register without docs
+func TestStripSourceInfo(t *testing.T) {
+	const source = `syntax = "proto3";
+package docs.v1;
+
+// Documented service.
+service DocService {
+  rpc Get(GetRequest) returns (GetRequest);
+}
+
+message GetRequest { string id = 1; }
+`
+	fds := parseTestProtos(t, map[string]string{"docs/v1/docs.proto": source}, "docs/v1/docs.proto")
+	StripSourceInfo(fds)
+
+	for _, fdpb := range fds.File {
+		if fdpb.SourceCodeInfo != nil {
+			t.Errorf("Expected source info to be stripped from %s", fdpb.GetName())
+		}
+	}
+
+	reg := New()
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	svc, _, err := reg.GetServiceSchema("docs.v1.DocService")
+	if err != nil {
+		t.Fatalf("GetServiceSchema failed: %v", err)
+	}
+	if svc.Documentation != "" {
+		t.Errorf("Expected empty documentation, got %q", svc.Documentation)
+	}
+}
+
+// TestDocumentationAvailable tests that services and methods report whether
+// their documentation could be known, with and without source info
+func TestDocumentationAvailable(t *testing.T) {
+	const source = `syntax = "proto3";
+package docs.v1;
+
+// Documented service.
+service DocService {
+  rpc Get(GetRequest) returns (GetRequest);
+}
+
+message GetRequest { string id = 1; }
+`
+	for _, strip := range []bool{false, true} {
+		fds := parseTestProtos(t, map[string]string{"docs/v1/docs.proto": source}, "docs/v1/docs.proto")
+		if strip {
+			StripSourceInfo(fds)
+		}
+
+		reg := New()
+		if err := reg.Register(fds); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+		svc, _, err := reg.GetServiceSchema("docs.v1.DocService")
+		if err != nil {
+			t.Fatalf("GetServiceSchema failed: %v", err)
+		}
+
+		if svc.DocumentationAvailable == strip {
+			t.Errorf("stripped %v: expected service DocumentationAvailable %v", strip, !strip)
+		}
+		if len(svc.Methods) != 1 || svc.Methods[0].DocumentationAvailable == strip {
+			t.Errorf("stripped %v: expected method DocumentationAvailable %v", strip, !strip)
+		}
+		// The undocumented method is empty either way; only the flag tells
+		// whether that's because it has no comment
+		if svc.Methods[0].Documentation != "" {
+			t.Errorf("Expected empty method documentation, got %q", svc.Methods[0].Documentation)
+		}
+	}
+}
+
 // TestValidateDescriptors tests descriptor validation
 func TestValidateDescriptors(t *testing.T) {
 	tests := []struct {
@@ -757,6 +1032,71 @@ func TestClone(t *testing.T) {
 	}
 }
 
+// TestMarshalJSON tests protojson export and import of the descriptor set
+func TestMarshalJSON(t *testing.T) {
+	registry := New()
+	if err := registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	data, err := registry.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+
+	fds := &descriptorpb.FileDescriptorSet{}
+	if err := protojson.Unmarshal(data, fds); err != nil {
+		t.Fatalf("Output is not a protojson FileDescriptorSet: %v", err)
+	}
+	if len(fds.File) != 1 || fds.File[0].GetName() != "test.proto" {
+		t.Errorf("Unexpected files in export: %v", fds.File)
+	}
+
+	again, err := registry.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Error("Expected deterministic output")
+	}
+
+	registry2 := New()
+	if err := registry2.UnmarshalJSON(data); err != nil {
+		t.Fatalf("UnmarshalJSON failed: %v", err)
+	}
+	if !registry2.HasService("test.v1.TestService") {
+		t.Error("Expected exported descriptors to round-trip")
+	}
+
+	if err := New().UnmarshalJSON([]byte(`{"file": "not a list"}`)); err == nil {
+		t.Error("Expected error for malformed JSON")
+	}
+}
+
+// TestEncodedSize tests that the cached size tracks registry changes
+func TestEncodedSize(t *testing.T) {
+	registry := New()
+	if size := registry.EncodedSize(); size != 0 {
+		t.Errorf("Expected 0 bytes for empty registry, got %d", size)
+	}
+
+	if err := registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	data, err := registry.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	if size := registry.EncodedSize(); size != int64(len(data)) {
+		t.Errorf("Expected %d bytes, got %d", len(data), size)
+	}
+
+	registry.Clear()
+	if size := registry.EncodedSize(); size != 0 {
+		t.Errorf("Expected 0 bytes after Clear, got %d", size)
+	}
+}
+
 // TestMarshalUnmarshalBinary tests binary serialization
 func TestMarshalUnmarshalBinary(t *testing.T) {
 	registry := New()
@@ -1133,3 +1473,438 @@ func TestMethodStreaming(t *testing.T) {
 		}
 	}
 }
+
+// TestAnyResolver tests resolving Any type URLs against registered messages
+func TestAnyResolver(t *testing.T) {
+	fds := parseTestProtos(t, map[string]string{
+		"payload/v1/payload.proto": `syntax = "proto3";
+package payload.v1;
+message Payload { string text = 1; }
+`,
+	}, "payload/v1/payload.proto")
+
+	reg := New()
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	msg, err := reg.AnyResolver().Resolve("type.googleapis.com/payload.v1.Payload")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if dm, ok := msg.(*dynamic.Message); !ok || dm.GetMessageDescriptor().GetFullyQualifiedName() != "payload.v1.Payload" {
+		t.Errorf("Expected dynamic payload.v1.Payload, got %T", msg)
+	}
+
+	if _, err := reg.AnyResolver().Resolve("type.googleapis.com/unknown.v1.Missing"); err == nil {
+		t.Error("Expected error for unregistered type")
+	}
+}
+
+// TestAnyResolver_MarshalPacked tests expanding a registered message packed
+// into an Any, including types registered after the resolver was created
+func TestAnyResolver_MarshalPacked(t *testing.T) {
+	fds := parseTestProtos(t, map[string]string{
+		"wrap/v1/wrap.proto": `syntax = "proto3";
+package wrap.v1;
+import "google/protobuf/any.proto";
+message Envelope { google.protobuf.Any detail = 1; }
+`,
+		"payload/v1/payload.proto": `syntax = "proto3";
+package payload.v1;
+message Payload { message Inner { int32 count = 1; } string text = 1; Inner inner = 2; }
+`,
+	}, "wrap/v1/wrap.proto", "payload/v1/payload.proto")
+
+	reg := New()
+	resolver := reg.AnyResolver()
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	payloadDesc, err := reg.GetMessageDescriptor("payload.v1.Payload")
+	if err != nil {
+		t.Fatalf("GetMessageDescriptor failed: %v", err)
+	}
+	payload := dynamic.NewMessage(payloadDesc)
+	if err := payload.UnmarshalJSON([]byte(`{"text":"hello","inner":{"count":3}}`)); err != nil {
+		t.Fatalf("Failed to build payload: %v", err)
+	}
+	value, err := payload.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to encode payload: %v", err)
+	}
+
+	envelopeDesc, err := reg.GetMessageDescriptor("wrap.v1.Envelope")
+	if err != nil {
+		t.Fatalf("GetMessageDescriptor failed: %v", err)
+	}
+	envelope := dynamic.NewMessage(envelopeDesc)
+	envelope.SetFieldByName("detail", &anypb.Any{
+		TypeUrl: "type.googleapis.com/payload.v1.Payload",
+		Value:   value,
+	})
+
+	data, err := envelope.MarshalJSONPB(&jsonpb.Marshaler{AnyResolver: resolver})
+	if err != nil {
+		t.Fatalf("MarshalJSONPB failed: %v", err)
+	}
+
+	var got map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", data, err)
+	}
+	detail := got["detail"]
+	if detail["@type"] != "type.googleapis.com/payload.v1.Payload" {
+		t.Errorf("Expected @type for payload.v1.Payload, got %v", detail["@type"])
+	}
+	if detail["text"] != "hello" {
+		t.Errorf("Expected expanded text field, got %s", data)
+	}
+	if inner, ok := detail["inner"].(map[string]interface{}); !ok || inner["count"] != float64(3) {
+		t.Errorf("Expected expanded nested message, got %s", data)
+	}
+
+	// Nested and well-known types resolve too
+	for _, typeURL := range []string{
+		"type.googleapis.com/payload.v1.Payload.Inner",
+		"type.googleapis.com/google.protobuf.Timestamp",
+	} {
+		if _, err := resolver.Resolve(typeURL); err != nil {
+			t.Errorf("Resolve(%s) failed: %v", typeURL, err)
+		}
+	}
+}
+
+// TestGetServiceSchema_Deterministic tests that schemas are identical across runs and declaration orders
+func TestGetServiceSchema_Deterministic(t *testing.T) {
+	const source = `syntax = "proto3";
+package stable.v1;
+
+service StableService {
+  rpc Do(Request) returns (Response);
+}
+
+message Request {
+  string name = 1;
+  Nested nested = 3;
+  int32 count = 2;
+}
+
+message Nested { bool flag = 1; }
+
+message Response { repeated Nested items = 1; }
+`
+	const reordered = `syntax = "proto3";
+package stable.v1;
+
+service StableService {
+  rpc Do(Request) returns (Response);
+}
+
+message Response { repeated Nested items = 1; }
+
+message Nested { bool flag = 1; }
+
+message Request {
+  int32 count = 2;
+  string name = 1;
+  Nested nested = 3;
+}
+`
+
+	schemasFor := func(src string) map[string]string {
+		reg := New()
+		fds := parseTestProtos(t, map[string]string{"stable/v1/stable.proto": src}, "stable/v1/stable.proto")
+		if err := reg.Register(fds); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+		_, schemas, err := reg.GetServiceSchema("stable.v1.StableService")
+		if err != nil {
+			t.Fatalf("GetServiceSchema failed: %v", err)
+		}
+		return schemas
+	}
+
+	first := schemasFor(source)
+	for i := 0; i < 5; i++ {
+		if got := schemasFor(source); !reflect.DeepEqual(got, first) {
+			t.Fatalf("Schemas differ between runs:\n%v\n%v", first, got)
+		}
+	}
+	if got := schemasFor(reordered); !reflect.DeepEqual(got, first) {
+		t.Errorf("Schemas differ after reordering declarations:\n%v\n%v", first, got)
+	}
+
+	request := first["stable.v1.Request"]
+	if !json.Valid([]byte(request)) {
+		t.Fatalf("Schema is not valid JSON: %s", request)
+	}
+	name, count, nested := strings.Index(request, `"name"`), strings.Index(request, `"count"`), strings.Index(request, `"nested"`)
+	if !(name < count && count < nested) {
+		t.Errorf("Expected properties in field number order, got %s", request)
+	}
+}
+
+// TestGetServiceSchema_Recursive tests that self-referential messages and
+// deep nesting terminate with $ref placeholders
+func TestGetServiceSchema_Recursive(t *testing.T) {
+	const source = `syntax = "proto3";
+package tree.v1;
+
+service TreeService {
+  rpc Get(TreeNode) returns (Chain);
+}
+
+message TreeNode {
+  string name = 1;
+  TreeNode parent = 2;
+  repeated TreeNode children = 3;
+}
+
+message Chain { Link1 next = 1; }
+message Link1 { Link2 next = 1; }
+message Link2 { Link3 next = 1; }
+message Link3 { string value = 1; }
+`
+	reg := New()
+	fds := parseTestProtos(t, map[string]string{"tree/v1/tree.proto": source}, "tree/v1/tree.proto")
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	_, schemas, err := reg.GetServiceSchema("tree.v1.TreeService")
+	if err != nil {
+		t.Fatalf("GetServiceSchema failed: %v", err)
+	}
+	if len(schemas) != 5 {
+		t.Errorf("Expected 5 schemas, got %d", len(schemas))
+	}
+	if !strings.Contains(schemas["tree.v1.TreeNode"], `"$ref": "#/definitions/tree.v1.TreeNode"`) {
+		t.Errorf("Expected self reference in TreeNode schema:\n%s", schemas["tree.v1.TreeNode"])
+	}
+
+	reg.SetMaxSchemaDepth(2)
+	_, schemas, err = reg.GetServiceSchema("tree.v1.TreeService")
+	if err != nil {
+		t.Fatalf("GetServiceSchema failed: %v", err)
+	}
+	if !strings.Contains(schemas["tree.v1.Link1"], `"$ref": "#/definitions/tree.v1.Link2"`) {
+		t.Errorf("Expected Link1 to be expanded:\n%s", schemas["tree.v1.Link1"])
+	}
+	if !strings.Contains(schemas["tree.v1.Link2"], "depth limit reached") {
+		t.Errorf("Expected Link2 placeholder:\n%s", schemas["tree.v1.Link2"])
+	}
+	if _, ok := schemas["tree.v1.Link3"]; ok {
+		t.Error("Expected Link3 beyond the placeholder to be omitted")
+	}
+	for name, schema := range schemas {
+		var parsed map[string]any
+		if err := json.Unmarshal([]byte(schema), &parsed); err != nil {
+			t.Errorf("Schema for %s is not valid JSON: %v", name, err)
+		}
+	}
+
+	example, err := reg.GenerateExampleJSON("tree.v1.TreeNode")
+	if err != nil {
+		t.Fatalf("GenerateExampleJSON failed: %v", err)
+	}
+	if !strings.Contains(example, `"name": ""`) {
+		t.Errorf("Unexpected example: %s", example)
+	}
+}
+
+// TestRegisterBestEffort tests that files with unresolved imports don't block the rest of the set
+func TestRegisterBestEffort(t *testing.T) {
+	fds := parseTestProtos(t, map[string]string{
+		"good/v1/good.proto": `syntax = "proto3";
+package good.v1;
+import "shared/v1/shared.proto";
+service GoodService { rpc Do(shared.v1.Msg) returns (shared.v1.Msg); }
+`,
+		"shared/v1/shared.proto": `syntax = "proto3";
+package shared.v1;
+message Msg { string id = 1; }
+`,
+		"bad/v1/bad.proto": `syntax = "proto3";
+package bad.v1;
+import "missing/v1/missing.proto";
+service BadService { rpc Do(missing.v1.Msg) returns (missing.v1.Msg); }
+`,
+		"missing/v1/missing.proto": `syntax = "proto3";
+package missing.v1;
+message Msg {}
+`,
+		"dependent/v1/dependent.proto": `syntax = "proto3";
+package dependent.v1;
+import "bad/v1/bad.proto";
+service DependentService { rpc Do(Req) returns (Req); }
+message Req {}
+`,
+	}, "good/v1/good.proto", "dependent/v1/dependent.proto")
+
+	// Drop the import that bad.proto needs, and put a dependent before its dependency
+	var files []*descriptorpb.FileDescriptorProto
+	for _, file := range fds.File {
+		if file.GetName() != "missing/v1/missing.proto" {
+			files = append([]*descriptorpb.FileDescriptorProto{file}, files...)
+		}
+	}
+	fds.File = files
+
+	reg := New()
+	if err := reg.Register(fds); err == nil {
+		t.Fatal("Expected Register to reject the set")
+	}
+
+	fileErrs := reg.RegisterBestEffort(fds)
+
+	failed := make(map[string]bool)
+	for _, fileErr := range fileErrs {
+		failed[fileErr.File] = true
+		if fileErr.Err == nil || fileErr.Error() == "" {
+			t.Errorf("Expected error detail for %s", fileErr.File)
+		}
+	}
+	if len(failed) != 2 || !failed["bad/v1/bad.proto"] || !failed["dependent/v1/dependent.proto"] {
+		t.Errorf("Expected bad and dependent files to fail, got %v", fileErrs)
+	}
+
+	if !reg.HasService("good.v1.GoodService") {
+		t.Error("Expected good service to be registered")
+	}
+	if reg.HasService("bad.v1.BadService") || reg.HasService("dependent.v1.DependentService") {
+		t.Error("Expected failed files not to be registered")
+	}
+	if stats := reg.GetStats(); stats.FileCount != 2 {
+		t.Errorf("Expected 2 registered files, got %d", stats.FileCount)
+	}
+}
+
+// TestRegisterBestEffort_Incremental tests that a later load can import files
+// registered earlier, and that symbols defined twice within a set are
+// rejected as Register rejects them
+func TestRegisterBestEffort_Incremental(t *testing.T) {
+	sources := map[string]string{
+		"shared/v1/shared.proto": `syntax = "proto3";
+package shared.v1;
+message Msg { string id = 1; }
+`,
+		"good/v1/good.proto": `syntax = "proto3";
+package good.v1;
+import "shared/v1/shared.proto";
+service GoodService { rpc Do(shared.v1.Msg) returns (shared.v1.Msg); }
+`,
+		"dup/v1/dup.proto": `syntax = "proto3";
+package shared.v1;
+message Msg {}
+`,
+	}
+
+	reg := New()
+	if fileErrs := reg.RegisterBestEffort(parseTestProtos(t, sources, "shared/v1/shared.proto")); len(fileErrs) != 0 {
+		t.Fatalf("Expected shared.proto to register, got %v", fileErrs)
+	}
+
+	// Only good.proto, relying on the registered import
+	fds := parseTestProtos(t, sources, "good/v1/good.proto")
+	var files []*descriptorpb.FileDescriptorProto
+	for _, file := range fds.File {
+		if file.GetName() == "good/v1/good.proto" {
+			files = append(files, file)
+		}
+	}
+	fds.File = files
+	if fileErrs := reg.RegisterBestEffort(fds); len(fileErrs) != 0 {
+		t.Fatalf("Expected good.proto to link against the registered import, got %v", fileErrs)
+	}
+	if !reg.HasService("good.v1.GoodService") {
+		t.Error("Expected good service to be registered")
+	}
+
+	// Two files of one set defining shared.v1.Msg conflict
+	conflicting := parseTestProtos(t, sources, "shared/v1/shared.proto")
+	conflicting.File = append(conflicting.File, parseTestProtos(t, sources, "dup/v1/dup.proto").File...)
+	if err := New().Register(conflicting); err == nil {
+		t.Fatal("Expected Register to reject the conflicting set")
+	}
+	fileErrs := New().RegisterBestEffort(conflicting)
+	if len(fileErrs) != 1 || !strings.Contains(fileErrs[0].Error(), "shared.v1.Msg") {
+		t.Errorf("Expected a conflict error for shared.v1.Msg, got %v", fileErrs)
+	}
+
+	// A file given twice with different contents is rejected
+	changed := parseTestProtos(t, sources, "shared/v1/shared.proto")
+	changed.File = append(changed.File, proto.Clone(changed.File[0]).(*descriptorpb.FileDescriptorProto))
+	changed.File[1].Package = proto.String("other.v1")
+	if fileErrs := New().RegisterBestEffort(changed); len(fileErrs) != 1 {
+		t.Errorf("Expected a duplicate file error, got %v", fileErrs)
+	}
+}
+
+// TestCheckDescriptors tests reporting unresolved references without registering anything
+func TestCheckDescriptors(t *testing.T) {
+	if fileErrs := CheckDescriptors(createTestFileDescriptorSet()); len(fileErrs) != 0 {
+		t.Errorf("Expected no errors for a valid set, got %v", fileErrs)
+	}
+
+	fds := createTestFileDescriptorSet()
+	fds.File[0].MessageType[0].Field[0].TypeName = proto.String(".test.v1.Missing")
+	fds.File[0].MessageType[0].Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+
+	fileErrs := CheckDescriptors(fds)
+	if len(fileErrs) != 1 || !strings.Contains(fileErrs[0].Error(), "test.v1.Missing") {
+		t.Errorf("Expected unresolved symbol error, got %v", fileErrs)
+	}
+}
+
+// TestGetPackageStats tests grouping registry counts by package
+func TestGetPackageStats(t *testing.T) {
+	sources := map[string]string{
+		"alpha/v1/alpha.proto": `syntax = "proto3";
+package alpha.v1;
+import "alpha/v1/types.proto";
+service AlphaService { rpc Get(Thing) returns (Thing); }
+`,
+		"alpha/v1/types.proto": `syntax = "proto3";
+package alpha.v1;
+message Thing {
+  message Part { Kind kind = 1; }
+  enum Kind { KIND_UNSPECIFIED = 0; }
+  repeated Part parts = 1;
+}
+enum Color { COLOR_UNSPECIFIED = 0; }
+`,
+		"beta/v1/beta.proto": `syntax = "proto3";
+package beta.v1;
+service BetaService { rpc Send(Ping) returns (Ping); }
+service BetaAdminService { rpc Reset(Ping) returns (Ping); }
+message Ping {}
+`,
+	}
+	fds := parseTestProtos(t, sources, "alpha/v1/alpha.proto", "beta/v1/beta.proto")
+
+	registry := New()
+	if err := registry.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	want := map[string]Stats{
+		"alpha.v1": {FileCount: 2, ServiceCount: 1, MessageCount: 2, EnumCount: 2},
+		"beta.v1":  {FileCount: 1, ServiceCount: 2, MessageCount: 1, EnumCount: 0},
+	}
+	got := registry.GetPackageStats()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d packages, got %v", len(want), got)
+	}
+	for pkg, stats := range want {
+		if got[pkg] != stats {
+			t.Errorf("Package %s: expected %+v, got %+v", pkg, stats, got[pkg])
+		}
+	}
+
+	total := registry.GetStats()
+	if total.MessageCount != 3 || total.EnumCount != 2 {
+		t.Errorf("Expected totals to match the packages, got %+v", total)
+	}
+}
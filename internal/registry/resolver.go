@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// TypeResolver resolves message and extension types, as expected by the
+// Resolver options of protojson and proto (un)marshaling
+type TypeResolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// Resolver returns a type resolver backed by the registry's messages and
+// extensions, so dynamic marshaling resolves Any payloads and extension
+// fields the same way everywhere. Types are looked up on each call, so
+// later registrations are visible; types the registry doesn't hold fall back
+// to those linked into the binary.
+func (r *Registry) Resolver() TypeResolver {
+	return &typeResolver{registry: r, fallback: protoregistry.GlobalTypes}
+}
+
+// ExtensionResolver returns the registry's resolver for extension types
+// alone; it is the same resolver as Resolver
+func (r *Registry) ExtensionResolver() protoregistry.ExtensionTypeResolver {
+	return r.Resolver()
+}
+
+// lockedResolver returns a resolver for use while the caller holds r.mu
+func (r *Registry) lockedResolver() TypeResolver {
+	return &typeResolver{registry: r, fallback: protoregistry.GlobalTypes, locked: true}
+}
+
+// typeResolver implements TypeResolver over a registry
+type typeResolver struct {
+	registry *Registry
+	fallback *protoregistry.Types
+	// locked is set when the caller already holds registry.mu
+	locked bool
+}
+
+// rlock read-locks the registry unless the caller holds its lock
+func (t *typeResolver) rlock() func() {
+	if t.locked {
+		return func() {}
+	}
+	t.registry.mu.RLock()
+	return t.registry.mu.RUnlock
+}
+
+// message returns the registered message type with the given name
+func (t *typeResolver) message(name string) (*desc.MessageDescriptor, bool) {
+	defer t.rlock()()
+	md, ok := t.registry.messages[name]
+	return md, ok
+}
+
+// FindMessageByName implements protoregistry.MessageTypeResolver
+func (t *typeResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if md, ok := t.message(string(name)); ok {
+		return dynamicpb.NewMessageType(md.UnwrapMessage()), nil
+	}
+	return t.fallback.FindMessageByName(name)
+}
+
+// FindMessageByURL implements protoregistry.MessageTypeResolver
+func (t *typeResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	return t.FindMessageByName(protoreflect.FullName(messageNameFromURL(url)))
+}
+
+// FindExtensionByName implements protoregistry.ExtensionTypeResolver
+func (t *typeResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	unlock := t.rlock()
+	ext, ok := t.registry.extensions[string(field)]
+	unlock()
+	if ok {
+		return dynamicpb.NewExtensionType(ext.UnwrapField()), nil
+	}
+	return t.fallback.FindExtensionByName(field)
+}
+
+// FindExtensionByNumber implements protoregistry.ExtensionTypeResolver
+func (t *typeResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	unlock := t.rlock()
+	ext, ok := t.registry.extensionNumbers[extensionNumber{string(message), int32(field)}]
+	unlock()
+	if ok {
+		return dynamicpb.NewExtensionType(ext.UnwrapField()), nil
+	}
+	return t.fallback.FindExtensionByNumber(message, field)
+}
+
+// messageNameFromURL returns the message name of a type URL: as with Any,
+// whatever follows the last slash
+func messageNameFromURL(url string) string {
+	if slash := strings.LastIndex(url, "/"); slash >= 0 {
+		return url[slash+1:]
+	}
+	return url
+}
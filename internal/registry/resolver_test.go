@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// TestResolver tests resolving registered message types by name and URL,
+// registered extensions, and falling back to linked-in types
+func TestResolver(t *testing.T) {
+	fds := parseTestProtos(t, map[string]string{
+		"payload/v1/payload.proto": `syntax = "proto2";
+package payload.v1;
+message Payload {
+  optional string text = 1;
+  extensions 100 to 199;
+}
+extend Payload { optional int32 priority = 100; }
+`,
+	}, "payload/v1/payload.proto")
+
+	reg := New()
+	resolver := reg.Resolver()
+	// Registered after the resolver was created, which still sees it
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	mt, err := resolver.FindMessageByURL("type.googleapis.com/payload.v1.Payload")
+	if err != nil {
+		t.Fatalf("FindMessageByURL failed: %v", err)
+	}
+	if got := mt.Descriptor().FullName(); got != "payload.v1.Payload" {
+		t.Errorf("Expected payload.v1.Payload, got %s", got)
+	}
+
+	// Any payloads round-trip through protojson with the resolver
+	var packed anypb.Any
+	in := `{"@type":"type.googleapis.com/payload.v1.Payload","text":"hello","[payload.v1.priority]":3}`
+	if err := (protojson.UnmarshalOptions{Resolver: resolver}).Unmarshal([]byte(in), &packed); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	out, err := protojson.MarshalOptions{Resolver: resolver}.Marshal(&packed)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, want := range []string{`"text":"hello"`, `"[payload.v1.priority]":3`} {
+		if !strings.Contains(strings.ReplaceAll(string(out), " ", ""), want) {
+			t.Errorf("Expected %s in %s", want, out)
+		}
+	}
+
+	ext, err := reg.ExtensionResolver().FindExtensionByName("payload.v1.priority")
+	if err != nil {
+		t.Fatalf("FindExtensionByName failed: %v", err)
+	}
+	if ext.TypeDescriptor().Number() != 100 {
+		t.Errorf("Expected extension number 100, got %d", ext.TypeDescriptor().Number())
+	}
+	if _, err := resolver.FindExtensionByNumber("payload.v1.Payload", 100); err != nil {
+		t.Errorf("FindExtensionByNumber failed: %v", err)
+	}
+
+	// Well-known types come from the binary when not registered
+	if _, err := resolver.FindMessageByName("google.protobuf.Duration"); err != nil {
+		t.Errorf("Expected google.protobuf.Duration from the linked types, got %v", err)
+	}
+	if _, err := resolver.FindMessageByURL("type.googleapis.com/unknown.v1.Missing"); !errors.Is(err, protoregistry.NotFound) {
+		t.Errorf("Expected NotFound for an unregistered type, got %v", err)
+	}
+	if _, err := resolver.FindExtensionByNumber("payload.v1.Payload", 101); !errors.Is(err, protoregistry.NotFound) {
+		t.Errorf("Expected NotFound for an unregistered extension, got %v", err)
+	}
+
+	// AnyResolver resolves through the same lookup
+	if msg, err := reg.AnyResolver().Resolve("type.googleapis.com/payload.v1.Payload"); err != nil || msg == nil {
+		t.Errorf("Expected AnyResolver to resolve payload.v1.Payload, got %v", err)
+	}
+
+	// Removed files drop out of the number index too
+	reg.RemoveFiles("payload/v1/payload.proto")
+	if _, err := resolver.FindExtensionByNumber("payload.v1.Payload", 100); !errors.Is(err, protoregistry.NotFound) {
+		t.Errorf("Expected NotFound for a removed extension, got %v", err)
+	}
+}
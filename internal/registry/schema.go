@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// MessageSchema is the structured description of a single message type
+type MessageSchema struct {
+	Name          string
+	FullName      string
+	Documentation string
+	Fields        []FieldSchema
+	// ReferencedMessages and ReferencedEnums list the fully qualified names of
+	// the types the message's fields refer to directly, sorted
+	ReferencedMessages []string
+	ReferencedEnums    []string
+	// ReservedRanges and ReservedNames are the field numbers and names the
+	// message reserves against reuse, in declaration order
+	ReservedRanges []ReservedRange
+	ReservedNames  []string
+}
+
+// ReservedRange is a reserved range of field numbers, both ends inclusive
+type ReservedRange struct {
+	Start int32
+	End   int32
+}
+
+// FieldSchema describes a message field. Map fields report the value type in
+// Type and TypeName and the key type in MapKeyType.
+type FieldSchema struct {
+	Name          string
+	JSONName      string
+	Number        int32
+	Type          string // proto type without the TYPE_ prefix, e.g. "string" or "message"
+	TypeName      string // fully qualified message or enum name, if any
+	Repeated      bool
+	Map           bool
+	MapKeyType    string
+	Optional      bool   // explicit presence (proto2 optional or proto3 optional)
+	OneOf         string // containing oneof, excluding synthetic proto3 optional oneofs
+	Documentation string
+}
+
+// DescribeMessage returns the structured schema of a registered message
+func (r *Registry) DescribeMessage(messageName string) (*MessageSchema, error) {
+	md, err := r.GetMessageDescriptor(messageName)
+	if err != nil {
+		return nil, err
+	}
+	return newMessageSchema(md), nil
+}
+
+// newMessageSchema builds the structured schema for md, with fields in
+// number order
+func newMessageSchema(md *desc.MessageDescriptor) *MessageSchema {
+	schema := &MessageSchema{
+		Name:          md.GetName(),
+		FullName:      md.GetFullyQualifiedName(),
+		Documentation: extractComments(md.GetSourceInfo()),
+		ReservedNames: md.AsDescriptorProto().GetReservedName(),
+	}
+	// Descriptor ranges are end-exclusive
+	for _, r := range md.AsDescriptorProto().GetReservedRange() {
+		schema.ReservedRanges = append(schema.ReservedRanges, ReservedRange{Start: r.GetStart(), End: r.GetEnd() - 1})
+	}
+
+	messages := make(map[string]bool)
+	enums := make(map[string]bool)
+	for _, field := range md.GetFields() {
+		fs := FieldSchema{
+			Name:          field.GetName(),
+			JSONName:      field.GetJSONName(),
+			Number:        field.GetNumber(),
+			Repeated:      field.IsRepeated() && !field.IsMap(),
+			Map:           field.IsMap(),
+			Optional:      field.IsProto3Optional() || (field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL && !md.IsProto3()),
+			Documentation: extractComments(field.GetSourceInfo()),
+		}
+		if oneOf := field.GetOneOf(); oneOf != nil && !oneOf.IsSynthetic() {
+			fs.OneOf = oneOf.GetName()
+		}
+
+		typeField := field
+		if field.IsMap() {
+			fs.MapKeyType = protoTypeName(field.GetMapKeyType())
+			typeField = field.GetMapValueType()
+		}
+		fs.Type = protoTypeName(typeField)
+		if msg := typeField.GetMessageType(); msg != nil {
+			fs.TypeName = msg.GetFullyQualifiedName()
+			messages[fs.TypeName] = true
+		} else if enum := typeField.GetEnumType(); enum != nil {
+			fs.TypeName = enum.GetFullyQualifiedName()
+			enums[fs.TypeName] = true
+		}
+
+		schema.Fields = append(schema.Fields, fs)
+	}
+
+	sort.Slice(schema.Fields, func(i, j int) bool {
+		return schema.Fields[i].Number < schema.Fields[j].Number
+	})
+	schema.ReferencedMessages = sortedNames(messages)
+	schema.ReferencedEnums = sortedNames(enums)
+	return schema
+}
+
+// protoTypeName returns a field's proto type without the TYPE_ prefix
+func protoTypeName(field *desc.FieldDescriptor) string {
+	return strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+}
+
+// sortedNames returns the keys of a set in sorted order
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
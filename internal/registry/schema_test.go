@@ -0,0 +1,115 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDescribeMessage tests building the structured schema of a message
+func TestDescribeMessage(t *testing.T) {
+	const source = `syntax = "proto3";
+package schema.v1;
+
+// A user account.
+message User {
+  // Display name.
+  string name = 1;
+  Status status = 3;
+  repeated Address addresses = 2;
+  map<string, Address> labeled = 4;
+  optional int32 age = 5;
+  oneof contact {
+    string email = 6;
+    string phone = 7;
+  }
+  User manager = 8;
+}
+
+message Address { string city = 1; }
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+}
+`
+	reg := New()
+	fds := parseTestProtos(t, map[string]string{"schema/v1/schema.proto": source}, "schema/v1/schema.proto")
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	schema, err := reg.DescribeMessage("schema.v1.User")
+	if err != nil {
+		t.Fatalf("DescribeMessage failed: %v", err)
+	}
+
+	if schema.Name != "User" || schema.FullName != "schema.v1.User" {
+		t.Errorf("Unexpected names: %s, %s", schema.Name, schema.FullName)
+	}
+	if schema.Documentation != " A user account.\n" {
+		t.Errorf("Unexpected documentation: %q", schema.Documentation)
+	}
+
+	want := []FieldSchema{
+		{Name: "name", JSONName: "name", Number: 1, Type: "string", Documentation: " Display name.\n"},
+		{Name: "addresses", JSONName: "addresses", Number: 2, Type: "message", TypeName: "schema.v1.Address", Repeated: true},
+		{Name: "status", JSONName: "status", Number: 3, Type: "enum", TypeName: "schema.v1.Status"},
+		{Name: "labeled", JSONName: "labeled", Number: 4, Type: "message", TypeName: "schema.v1.Address", Map: true, MapKeyType: "string"},
+		{Name: "age", JSONName: "age", Number: 5, Type: "int32", Optional: true},
+		{Name: "email", JSONName: "email", Number: 6, Type: "string", OneOf: "contact"},
+		{Name: "phone", JSONName: "phone", Number: 7, Type: "string", OneOf: "contact"},
+		{Name: "manager", JSONName: "manager", Number: 8, Type: "message", TypeName: "schema.v1.User"},
+	}
+	if !reflect.DeepEqual(schema.Fields, want) {
+		t.Errorf("Unexpected fields:\n got: %+v\nwant: %+v", schema.Fields, want)
+	}
+
+	if !reflect.DeepEqual(schema.ReferencedMessages, []string{"schema.v1.Address", "schema.v1.User"}) {
+		t.Errorf("Unexpected referenced messages: %v", schema.ReferencedMessages)
+	}
+	if !reflect.DeepEqual(schema.ReferencedEnums, []string{"schema.v1.Status"}) {
+		t.Errorf("Unexpected referenced enums: %v", schema.ReferencedEnums)
+	}
+
+	if _, err := reg.DescribeMessage("schema.v1.Missing"); err == nil {
+		t.Error("Expected error for non-existent message")
+	}
+}
+
+// TestDescribeMessage_Reserved tests reporting reserved field numbers, as
+// inclusive ranges, and reserved names
+func TestDescribeMessage_Reserved(t *testing.T) {
+	const source = `syntax = "proto3";
+package schema.v1;
+message Legacy {
+  reserved 2, 5 to 7, 100 to max;
+  reserved "old_name", "removed";
+  string name = 1;
+}
+message Plain { string name = 1; }
+`
+	reg := New()
+	fds := parseTestProtos(t, map[string]string{"schema/v1/reserved.proto": source}, "schema/v1/reserved.proto")
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	schema, err := reg.DescribeMessage("schema.v1.Legacy")
+	if err != nil {
+		t.Fatalf("DescribeMessage failed: %v", err)
+	}
+	wantRanges := []ReservedRange{{Start: 2, End: 2}, {Start: 5, End: 7}, {Start: 100, End: 536870911}}
+	if !reflect.DeepEqual(schema.ReservedRanges, wantRanges) {
+		t.Errorf("Expected reserved ranges %v, got %v", wantRanges, schema.ReservedRanges)
+	}
+	if !reflect.DeepEqual(schema.ReservedNames, []string{"old_name", "removed"}) {
+		t.Errorf("Unexpected reserved names: %v", schema.ReservedNames)
+	}
+
+	plain, err := reg.DescribeMessage("schema.v1.Plain")
+	if err != nil {
+		t.Fatalf("DescribeMessage failed: %v", err)
+	}
+	if len(plain.ReservedRanges) != 0 || len(plain.ReservedNames) != 0 {
+		t.Errorf("Expected nothing reserved, got %v and %v", plain.ReservedRanges, plain.ReservedNames)
+	}
+}
@@ -0,0 +1,38 @@
+package registry
+
+import "fmt"
+
+// Snapshot is an opaque point-in-time copy of a registry's contents,
+// used to roll back registrations that should not be kept
+type Snapshot struct {
+	registry *Registry
+}
+
+// Snapshot captures the current registry contents. Descriptors are immutable,
+// so the snapshot shares them and only copies the indexes.
+func (r *Registry) Snapshot() *Snapshot {
+	return &Snapshot{registry: r.Clone()}
+}
+
+// Restore replaces the registry contents with those captured by snapshot,
+// discarding everything registered since
+func (r *Registry) Restore(snapshot *Snapshot) error {
+	if snapshot == nil || snapshot.registry == nil {
+		return fmt.Errorf("nil registry snapshot")
+	}
+
+	// Copy again so the snapshot stays valid for repeated restores
+	saved := snapshot.registry.Clone()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.files = saved.files
+	r.services = saved.services
+	r.messages = saved.messages
+	r.extensions = saved.extensions
+	r.extensionNumbers = saved.extensionNumbers
+	r.complexity = saved.complexity
+	r.encodedSize.Store(0)
+	return nil
+}
@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"testing"
+)
+
+// TestSnapshotRestore tests rolling back registrations made after a snapshot
+func TestSnapshotRestore(t *testing.T) {
+	reg := New()
+	if err := reg.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	snapshot := reg.Snapshot()
+
+	fds := parseTestProtos(t, map[string]string{
+		"extra/v1/extra.proto": `syntax = "proto3";
+package extra.v1;
+service ExtraService { rpc Do(Msg) returns (Msg); }
+message Msg {}
+`,
+	}, "extra/v1/extra.proto")
+	if err := reg.Register(fds); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if !reg.HasService("extra.v1.ExtraService") {
+		t.Fatal("Expected extra service after registration")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := reg.Restore(snapshot); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if reg.HasService("extra.v1.ExtraService") {
+			t.Error("Expected extra service to be rolled back")
+		}
+		if !reg.HasService("test.v1.TestService") {
+			t.Error("Expected original service to survive restore")
+		}
+		if stats := reg.GetStats(); stats.FileCount != 1 || stats.ServiceCount != 1 {
+			t.Errorf("Expected original stats, got %+v", stats)
+		}
+
+		// Registering again must not leak into the snapshot
+		if err := reg.Register(fds); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+}
+
+// TestRestore_Nil tests that restoring a nil snapshot fails
+func TestRestore_Nil(t *testing.T) {
+	if err := New().Restore(nil); err == nil {
+		t.Error("Expected error for nil snapshot, got nil")
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"connectrpc.com/connect"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+)
+
+// LoadProtosBatch implements the LoadProtosBatch RPC handler. Sources load in
+// order while holding the session's load lock; in transactional mode the
+// registry is snapshotted first and restored if any source fails.
+func (s *CatalogServer) LoadProtosBatch(
+	ctx context.Context,
+	req *connect.Request[catalogv1.LoadProtosBatchRequest],
+) (*connect.Response[catalogv1.LoadProtosBatchResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if err := checkWritable(state); err != nil {
+		return nil, err
+	}
+
+	if len(req.Msg.Sources) == 0 {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("at least one source is required"),
+		)
+	}
+	for i, source := range req.Msg.Sources {
+		if source.GetSource() == nil {
+			return nil, connect.NewError(
+				connect.CodeInvalidArgument,
+				fmt.Errorf("no source specified in sources[%d]", i),
+			)
+		}
+		if refOpts := source.GetReflectionOptions(); refOpts != nil {
+			if err := validateTLSSettings(refOpts.GetUseTls(), refOpts.GetServerName()); err != nil {
+				return nil, connect.NewError(
+					connect.CodeInvalidArgument,
+					fmt.Errorf("sources[%d]: %w", i, errors.Unwrap(err)),
+				)
+			}
+		}
+	}
+
+	state.LoadMu.Lock()
+	defer state.LoadMu.Unlock()
+
+	snapshot := state.Registry.Snapshot()
+
+	msg := &catalogv1.LoadProtosBatchResponse{Success: true}
+	for i, source := range req.Msg.Sources {
+		result := s.loadSource(state, source, nil)
+		msg.Results = append(msg.Results, result)
+
+		// Skipped files count as failures when the batch must be all-or-nothing
+		failed := !result.Success || (req.Msg.Transactional && len(result.FileErrors) > 0)
+		if !failed {
+			continue
+		}
+
+		if msg.Success {
+			msg.Success = false
+			msg.Error = fmt.Sprintf("sources[%d]: %s", i, result.Error)
+			if result.Success {
+				msg.Error = fmt.Sprintf("sources[%d]: %d files failed to register", i, len(result.FileErrors))
+			}
+		}
+		if req.Msg.Transactional {
+			if err := state.Registry.Restore(snapshot); err != nil {
+				return nil, connect.NewError(connect.CodeInternal, err)
+			}
+			msg.RolledBack = true
+			break
+		}
+	}
+
+	resp := connect.NewResponse(msg)
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
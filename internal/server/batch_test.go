@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"connectrpc.com/connect"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// writeTestDescriptorSet writes the shared test descriptors to a temporary file
+func writeTestDescriptorSet(t *testing.T) string {
+	t.Helper()
+
+	return writeDescriptorSet(t, createTestFileDescriptorSet())
+}
+
+// writeDescriptorSet writes a descriptor set to a temporary file
+func writeDescriptorSet(t *testing.T, fds *descriptorpb.FileDescriptorSet) string {
+	t.Helper()
+
+	data, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "descriptors.binpb")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write descriptor set: %v", err)
+	}
+	return path
+}
+
+// descriptorSetSource builds a single-source load request for a descriptor set file
+func descriptorSetSource(path string) *catalogv1.LoadProtosRequest {
+	return &catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_DescriptorSetPath{DescriptorSetPath: path},
+	}
+}
+
+// TestLoadProtosBatch tests transactional and non-transactional batch loads
+func TestLoadProtosBatch(t *testing.T) {
+	valid := writeTestDescriptorSet(t)
+	missing := filepath.Join(t.TempDir(), "missing.binpb")
+
+	tests := []struct {
+		name          string
+		transactional bool
+		wantResults   int
+		wantRollback  bool
+		wantService   bool
+	}{
+		{
+			name:          "transactional failure rolls back",
+			transactional: true,
+			wantResults:   2,
+			wantRollback:  true,
+			wantService:   false,
+		},
+		{
+			name:          "non-transactional failure keeps earlier sources",
+			transactional: false,
+			wantResults:   3,
+			wantRollback:  false,
+			wantService:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New()
+			defer server.Close()
+
+			req := connect.NewRequest(&catalogv1.LoadProtosBatchRequest{
+				Sources: []*catalogv1.LoadProtosRequest{
+					descriptorSetSource(valid),
+					descriptorSetSource(missing),
+					descriptorSetSource(valid),
+				},
+				Transactional: tt.transactional,
+			})
+
+			resp, err := server.LoadProtosBatch(context.Background(), req)
+			if err != nil {
+				t.Fatalf("LoadProtosBatch failed: %v", err)
+			}
+			if resp.Msg.Success || resp.Msg.Error == "" {
+				t.Errorf("Expected batch failure, got %v", resp.Msg)
+			}
+			if len(resp.Msg.Results) != tt.wantResults {
+				t.Errorf("Expected %d results, got %d", tt.wantResults, len(resp.Msg.Results))
+			}
+			if resp.Msg.RolledBack != tt.wantRollback {
+				t.Errorf("Expected rolled_back=%v, got %v", tt.wantRollback, resp.Msg.RolledBack)
+			}
+
+			state := server.sessionManager.Get(resp.Header().Get("X-Session-ID"))
+			if state == nil {
+				t.Fatal("Expected session to exist")
+			}
+			if got := state.Registry.HasService("test.v1.TestService"); got != tt.wantService {
+				t.Errorf("Expected service registered=%v, got %v", tt.wantService, got)
+			}
+		})
+	}
+}
+
+// TestLoadProtosBatch_InvalidArgument tests validation of batch requests
+func TestLoadProtosBatch_InvalidArgument(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	requests := []*catalogv1.LoadProtosBatchRequest{
+		{},
+		{Sources: []*catalogv1.LoadProtosRequest{{}}},
+	}
+	for _, msg := range requests {
+		_, err := server.LoadProtosBatch(context.Background(), connect.NewRequest(msg))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+		}
+	}
+}
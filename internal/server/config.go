@@ -0,0 +1,216 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	"github.com/opentdf/connectrpc-catalog/internal/invoker"
+	"github.com/opentdf/connectrpc-catalog/internal/loader"
+	"github.com/opentdf/connectrpc-catalog/internal/session"
+)
+
+// DefaultMaxConcurrentInvocations is the default server-wide limit on
+// in-flight invocations. It is generous enough that interactive use never
+// reaches it.
+const DefaultMaxConcurrentInvocations = 256
+
+// Config holds the effective server settings
+type Config struct {
+	// Maximum number of cached connections per session invoker
+	MaxConnections int
+	// Time-to-live for cached connections
+	ConnectionTTL time.Duration
+	// Time after which an unused cached connection is closed
+	ConnectionIdleTimeout time.Duration
+	// Maximum number of invocations in flight across all sessions
+	MaxConcurrentInvocations int
+	// Time-to-live for idle sessions
+	SessionTTL time.Duration
+	// How often expired sessions are removed and idle ones compacted
+	SessionCleanupInterval time.Duration
+	// Whether ValidateSetup checks for a buf installation
+	CheckBuf bool
+	// Maximum size in bytes of an uploaded descriptor set
+	MaxDescriptorSetSize int
+	// Metadata added to every invocation unless the request sets the same key.
+	// Never returned to clients.
+	DefaultInvokeMetadata map[string]string
+	// Directory idle session registries are spilled to; empty disables compaction
+	CompactionDir string
+	// Idle period after which the cleanup loop compacts a session; zero
+	// limits compaction to the CompactSession RPC
+	CompactIdleAfter time.Duration
+	// Connect-Protocol-Version sent unless a request sets its own; empty
+	// omits the header
+	ConnectProtocolVersion string
+	// ID of the session given to requests without a session ID; empty gives
+	// each such request a new session
+	SharedSessionID string
+	// Metadata keys redacted from recorded invocations, in addition to
+	// invoker.DefaultRedactedMetadataKeys
+	RedactMetadataKeys []string
+	// Custom bool field options, by full name, that mark request fields to
+	// redact from recorded invocations; debug_redact always does
+	RedactFieldOptions []string
+}
+
+// DefaultConfig returns the settings used when no options are given
+func DefaultConfig() Config {
+	return Config{
+		MaxConnections: invoker.DefaultMaxConnections,
+		ConnectionTTL:  invoker.DefaultConnectionTTL,
+		SessionTTL:     session.DefaultSessionTTL,
+		CheckBuf:       true,
+
+		ConnectionIdleTimeout:    invoker.DefaultConnectionIdleTimeout,
+		SessionCleanupInterval:   session.CleanupInterval,
+		MaxConcurrentInvocations: DefaultMaxConcurrentInvocations,
+		MaxDescriptorSetSize:     loader.DefaultMaxDescriptorSetSize,
+		ConnectProtocolVersion:   invoker.DefaultConnectProtocolVersion,
+	}
+}
+
+// Option configures a CatalogServer
+type Option func(*Config)
+
+// WithConnectionPool sets the per-session connection pool size and TTL.
+// Non-positive values keep the defaults.
+func WithConnectionPool(maxConnections int, ttl time.Duration) Option {
+	return func(cfg *Config) {
+		if maxConnections > 0 {
+			cfg.MaxConnections = maxConnections
+		}
+		if ttl > 0 {
+			cfg.ConnectionTTL = ttl
+		}
+	}
+}
+
+// WithConnectionIdleTimeout closes cached connections unused for the given
+// duration, independently of the TTL. Non-positive values keep the default.
+func WithConnectionIdleTimeout(timeout time.Duration) Option {
+	return func(cfg *Config) {
+		if timeout > 0 {
+			cfg.ConnectionIdleTimeout = timeout
+		}
+	}
+}
+
+// WithMaxConcurrentInvocations limits the number of invocations in flight
+// across all sessions; calls beyond it fail with ResourceExhausted.
+// Non-positive values keep the default.
+func WithMaxConcurrentInvocations(limit int) Option {
+	return func(cfg *Config) {
+		if limit > 0 {
+			cfg.MaxConcurrentInvocations = limit
+		}
+	}
+}
+
+// WithBufCheck enables or disables the buf installation check in ValidateSetup
+func WithBufCheck(enabled bool) Option {
+	return func(cfg *Config) {
+		cfg.CheckBuf = enabled
+	}
+}
+
+// WithMaxDescriptorSetSize limits the size of uploaded descriptor sets.
+// Non-positive values keep the default.
+func WithMaxDescriptorSetSize(maxBytes int) Option {
+	return func(cfg *Config) {
+		if maxBytes > 0 {
+			cfg.MaxDescriptorSetSize = maxBytes
+		}
+	}
+}
+
+// WithDefaultInvokeMetadata adds metadata, such as service-to-service
+// credentials, to every InvokeGRPC call. Request metadata takes precedence.
+func WithDefaultInvokeMetadata(md map[string]string) Option {
+	return func(cfg *Config) {
+		if len(md) == 0 {
+			return
+		}
+		if cfg.DefaultInvokeMetadata == nil {
+			cfg.DefaultInvokeMetadata = make(map[string]string, len(md))
+		}
+		for k, v := range md {
+			cfg.DefaultInvokeMetadata[k] = v
+		}
+	}
+}
+
+// WithSessions sets how long idle sessions live and how often expired ones
+// are removed. Non-positive values keep the defaults.
+func WithSessions(ttl, cleanupInterval time.Duration) Option {
+	return func(cfg *Config) {
+		if ttl > 0 {
+			cfg.SessionTTL = ttl
+		}
+		if cleanupInterval > 0 {
+			cfg.SessionCleanupInterval = cleanupInterval
+		}
+	}
+}
+
+// WithSessionCompaction lets idle sessions spill their registries to dir,
+// restoring them on next use. Sessions unused for idleAfter are compacted
+// automatically; a non-positive idleAfter leaves only the CompactSession RPC.
+func WithSessionCompaction(dir string, idleAfter time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.CompactionDir = dir
+		cfg.CompactIdleAfter = idleAfter
+	}
+}
+
+// WithConnectProtocolVersion sets the Connect-Protocol-Version sent on the
+// Connect transport, e.g. for gateways that expect a different value. Requests
+// that set connect_protocol_version take precedence; "" omits the header.
+func WithConnectProtocolVersion(version string) Option {
+	return func(cfg *Config) {
+		cfg.ConnectProtocolVersion = version
+	}
+}
+
+// WithSharedSession creates a session with the given ID that requests
+// without a session ID attach to, e.g. for a pre-loaded public demo. Load
+// it through LoadProtos with the ID, then call SealSharedSession.
+func WithSharedSession(sessionID string) Option {
+	return func(cfg *Config) {
+		cfg.SharedSessionID = sessionID
+	}
+}
+
+// WithRedaction adds metadata keys and custom field options, such as
+// "acme.v1.sensitive", whose values are hidden from recorded invocations
+func WithRedaction(metadataKeys, fieldOptions []string) Option {
+	return func(cfg *Config) {
+		cfg.RedactMetadataKeys = append(cfg.RedactMetadataKeys, metadataKeys...)
+		cfg.RedactFieldOptions = append(cfg.RedactFieldOptions, fieldOptions...)
+	}
+}
+
+// mergeDefaultMetadata returns the request metadata with defaults added for
+// keys it doesn't set. Keys are compared case-insensitively, as headers are.
+func mergeDefaultMetadata(defaults, requested map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return requested
+	}
+
+	merged := make(map[string]string, len(defaults)+len(requested))
+	for k, v := range defaults {
+		merged[strings.ToLower(k)] = v
+	}
+	for k, v := range requested {
+		delete(merged, strings.ToLower(k))
+		merged[k] = v
+	}
+	return merged
+}
+
+// newInvokerFactory returns a session invoker factory using the configured pool limits
+func newInvokerFactory(cfg Config) session.InvokerFactory {
+	return func() *invoker.Invoker {
+		return invoker.NewWithLimits(cfg.MaxConnections, cfg.ConnectionTTL, invoker.WithIdleTimeout(cfg.ConnectionIdleTimeout))
+	}
+}
@@ -0,0 +1,522 @@
+package server
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jhump/protoreflect/desc"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"github.com/opentdf/connectrpc-catalog/internal/invoker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestNew_DefaultConfig tests that a server without options uses the defaults
+func TestNew_DefaultConfig(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	if cfg := server.GetConfig(); !reflect.DeepEqual(cfg, DefaultConfig()) {
+		t.Errorf("Expected default config, got %+v", cfg)
+	}
+}
+
+// TestWithConnectionPool tests that pool limits reach session invokers
+func TestWithConnectionPool(t *testing.T) {
+	server := New(WithConnectionPool(7, 90*time.Second))
+	defer server.Close()
+
+	state, _, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	stats := state.Invoker.GetConnectionStats()
+	if stats.MaxConnections != 7 {
+		t.Errorf("Expected MaxConnections 7, got %d", stats.MaxConnections)
+	}
+	if stats.ConnectionTTL != 90*time.Second {
+		t.Errorf("Expected ConnectionTTL 90s, got %v", stats.ConnectionTTL)
+	}
+
+	resp, err := server.GetServerConfig(context.Background(), connect.NewRequest(&catalogv1.GetServerConfigRequest{}))
+	if err != nil {
+		t.Fatalf("GetServerConfig failed: %v", err)
+	}
+	if resp.Msg.MaxConnections != 7 {
+		t.Errorf("Expected max_connections 7, got %d", resp.Msg.MaxConnections)
+	}
+	if resp.Msg.ConnectionTtlSeconds != 90 {
+		t.Errorf("Expected connection_ttl_seconds 90, got %d", resp.Msg.ConnectionTtlSeconds)
+	}
+}
+
+// TestWithConnectionPool_NonPositive tests that non-positive values keep the defaults
+func TestWithConnectionPool_NonPositive(t *testing.T) {
+	server := New(WithConnectionPool(0, -time.Second))
+	defer server.Close()
+
+	cfg := server.GetConfig()
+	if cfg.MaxConnections != invoker.DefaultMaxConnections {
+		t.Errorf("Expected default MaxConnections, got %d", cfg.MaxConnections)
+	}
+	if cfg.ConnectionTTL != invoker.DefaultConnectionTTL {
+		t.Errorf("Expected default ConnectionTTL, got %v", cfg.ConnectionTTL)
+	}
+}
+
+// TestWithDefaultInvokeMetadata tests that server-configured metadata reaches the backend
+func TestWithDefaultInvokeMetadata(t *testing.T) {
+	received := make(chan metadata.MD, 1)
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		received <- md
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	server := New(WithDefaultInvokeMetadata(map[string]string{
+		"X-Service-Token": "s2s-secret",
+		"Authorization":   "Bearer default",
+	}))
+	defer server.Close()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	healthFile, err := desc.LoadFileDescriptor("grpc/health/v1/health.proto")
+	if err != nil {
+		t.Fatalf("Failed to load health descriptor: %v", err)
+	}
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{healthFile.AsFileDescriptorProto()}}
+	if err := state.Registry.Register(fds); err != nil {
+		t.Fatalf("Failed to register health descriptors: %v", err)
+	}
+
+	req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:  lis.Addr().String(),
+		Service:   "grpc.health.v1.Health",
+		Method:    "Check",
+		Transport: catalogv1.Transport_TRANSPORT_GRPC,
+		Metadata:  map[string]string{"authorization": "Bearer user"},
+	})
+	req.Header().Set("X-Session-ID", sessionID)
+
+	resp, err := server.InvokeGRPC(context.Background(), req)
+	if err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+	if !resp.Msg.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Msg.Error)
+	}
+
+	md := <-received
+	if got := md.Get("x-service-token"); len(got) != 1 || got[0] != "s2s-secret" {
+		t.Errorf("Expected default x-service-token, got %v", got)
+	}
+	if got := md.Get("authorization"); len(got) != 1 || got[0] != "Bearer user" {
+		t.Errorf("Expected request authorization to take precedence, got %v", got)
+	}
+
+	// Defaults stay server-side: descriptions sent back to clients omit them
+	describeReq := connect.NewRequest(req.Msg)
+	describeReq.Header().Set("X-Session-ID", sessionID)
+	describeResp, err := server.DescribeInvocation(context.Background(), describeReq)
+	if err != nil {
+		t.Fatalf("DescribeInvocation failed: %v", err)
+	}
+	if _, ok := describeResp.Msg.Headers["x-service-token"]; ok {
+		t.Error("Expected default metadata to be omitted from DescribeInvocation")
+	}
+}
+
+// TestWithConnectProtocolVersion tests that the server-wide Connect protocol
+// version applies unless a request sets its own
+func TestWithConnectProtocolVersion(t *testing.T) {
+	server := New(WithConnectProtocolVersion("2"))
+	defer server.Close()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	describe := func(version *string) map[string]string {
+		t.Helper()
+		req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+			Endpoint:               "localhost:9999",
+			Service:                "test.v1.TestService",
+			Method:                 "TestMethod",
+			ConnectProtocolVersion: version,
+		})
+		req.Header().Set("X-Session-ID", sessionID)
+		resp, err := server.DescribeInvocation(context.Background(), req)
+		if err != nil {
+			t.Fatalf("DescribeInvocation failed: %v", err)
+		}
+		if resp.Msg.Error != "" {
+			t.Fatalf("Unexpected error: %s", resp.Msg.Error)
+		}
+		return resp.Msg.Headers
+	}
+
+	if got := describe(nil)[invoker.ConnectProtocolVersionHeader]; got != "2" {
+		t.Errorf("Expected the server version 2, got %q", got)
+	}
+	version := "1"
+	if got := describe(&version)[invoker.ConnectProtocolVersionHeader]; got != "1" {
+		t.Errorf("Expected the request version to take precedence, got %q", got)
+	}
+}
+
+// TestSetEndpointDefaults tests that per-session endpoint metadata is merged
+// between the server defaults and the request metadata
+func TestSetEndpointDefaults(t *testing.T) {
+	received := make(chan metadata.MD, 1)
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		received <- md
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	server := New(WithDefaultInvokeMetadata(map[string]string{
+		"x-tenant-id":   "server-tenant",
+		"x-server-only": "yes",
+	}))
+	defer server.Close()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	healthFile, err := desc.LoadFileDescriptor("grpc/health/v1/health.proto")
+	if err != nil {
+		t.Fatalf("Failed to load health descriptor: %v", err)
+	}
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{healthFile.AsFileDescriptorProto()}}
+	if err := state.Registry.Register(fds); err != nil {
+		t.Fatalf("Failed to register health descriptors: %v", err)
+	}
+
+	endpoint := lis.Addr().String()
+	setReq := connect.NewRequest(&catalogv1.SetEndpointDefaultsRequest{
+		Endpoint: strings.ToUpper(endpoint),
+		Metadata: map[string]string{
+			"authorization": "Bearer default",
+			"x-tenant-id":   "endpoint-tenant",
+		},
+	})
+	setReq.Header().Set("X-Session-ID", sessionID)
+	setResp, err := server.SetEndpointDefaults(context.Background(), setReq)
+	if err != nil {
+		t.Fatalf("SetEndpointDefaults failed: %v", err)
+	}
+	if !reflect.DeepEqual(setResp.Msg.Endpoints, []string{strings.ToLower(endpoint)}) {
+		t.Errorf("Expected endpoint to be listed, got %v", setResp.Msg.Endpoints)
+	}
+
+	req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:  endpoint,
+		Service:   "grpc.health.v1.Health",
+		Method:    "Check",
+		Transport: catalogv1.Transport_TRANSPORT_GRPC,
+		Metadata:  map[string]string{"Authorization": "Bearer user"},
+	})
+	req.Header().Set("X-Session-ID", sessionID)
+	resp, err := server.InvokeGRPC(context.Background(), req)
+	if err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+	if !resp.Msg.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Msg.Error)
+	}
+
+	md := <-received
+	if got := md.Get("authorization"); len(got) != 1 || got[0] != "Bearer user" {
+		t.Errorf("Expected request authorization to take precedence, got %v", got)
+	}
+	if got := md.Get("x-tenant-id"); len(got) != 1 || got[0] != "endpoint-tenant" {
+		t.Errorf("Expected endpoint x-tenant-id to override the server default, got %v", got)
+	}
+	if got := md.Get("x-server-only"); len(got) != 1 || got[0] != "yes" {
+		t.Errorf("Expected server default to remain, got %v", got)
+	}
+
+	// Empty metadata clears the endpoint's defaults
+	clearReq := connect.NewRequest(&catalogv1.SetEndpointDefaultsRequest{Endpoint: endpoint})
+	clearReq.Header().Set("X-Session-ID", sessionID)
+	clearResp, err := server.SetEndpointDefaults(context.Background(), clearReq)
+	if err != nil {
+		t.Fatalf("SetEndpointDefaults failed: %v", err)
+	}
+	if len(clearResp.Msg.Endpoints) != 0 {
+		t.Errorf("Expected defaults to be cleared, got %v", clearResp.Msg.Endpoints)
+	}
+
+	if _, err := server.SetEndpointDefaults(context.Background(), connect.NewRequest(&catalogv1.SetEndpointDefaultsRequest{})); connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument for missing endpoint, got %v", err)
+	}
+}
+
+// TestWithConnectionIdleTimeout tests that the idle timeout reaches session
+// invokers and is reported by GetServerConfig
+func TestWithConnectionIdleTimeout(t *testing.T) {
+	server := New(WithConnectionIdleTimeout(45 * time.Second))
+	defer server.Close()
+
+	state, _, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if got := state.Invoker.GetConnectionStats().IdleTimeout; got != 45*time.Second {
+		t.Errorf("Expected IdleTimeout 45s, got %v", got)
+	}
+
+	resp, err := server.GetServerConfig(context.Background(), connect.NewRequest(&catalogv1.GetServerConfigRequest{}))
+	if err != nil {
+		t.Fatalf("GetServerConfig failed: %v", err)
+	}
+	if resp.Msg.ConnectionIdleTimeoutSeconds != 45 {
+		t.Errorf("Expected connection_idle_timeout_seconds 45, got %d", resp.Msg.ConnectionIdleTimeoutSeconds)
+	}
+
+	if got := New(WithConnectionIdleTimeout(0)).config.ConnectionIdleTimeout; got != invoker.DefaultConnectionIdleTimeout {
+		t.Errorf("Expected the default idle timeout, got %v", got)
+	}
+}
+
+// TestWithMaxConcurrentInvocations tests that invocations beyond the limit
+// are rejected while dry runs still go through
+func TestWithMaxConcurrentInvocations(t *testing.T) {
+	server := New(WithMaxConcurrentInvocations(1))
+	defer server.Close()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	// Hold the only slot, as a slow invocation would
+	release, err := server.acquireInvocation()
+	if err != nil {
+		t.Fatalf("Failed to acquire the first slot: %v", err)
+	}
+	if stats := server.GetStats(); stats.InFlightInvocations != 1 || stats.MaxConcurrentInvocations != 1 {
+		t.Errorf("Expected 1 of 1 invocations in flight, got %d of %d", stats.InFlightInvocations, stats.MaxConcurrentInvocations)
+	}
+
+	invoke := func(dryRun bool) (*connect.Response[catalogv1.InvokeGRPCResponse], error) {
+		req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+			Endpoint:    "localhost:9999",
+			Service:     "test.v1.TestService",
+			Method:      "TestMethod",
+			RequestJson: `{"name": "test"}`,
+			DryRun:      dryRun,
+		})
+		req.Header().Set("X-Session-ID", sessionID)
+		return server.InvokeGRPC(context.Background(), req)
+	}
+
+	if _, err := invoke(false); connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Errorf("Expected ResourceExhausted, got %v", err)
+	}
+	if resp, err := invoke(true); err != nil || !resp.Msg.Success {
+		t.Errorf("Expected the dry run to succeed, got %v, %v", resp, err)
+	}
+
+	release()
+	if stats := server.GetStats(); stats.InFlightInvocations != 0 {
+		t.Errorf("Expected no invocations in flight, got %d", stats.InFlightInvocations)
+	}
+	if _, err := invoke(false); err != nil {
+		t.Errorf("Expected the invocation to be attempted once the slot is free, got %v", err)
+	}
+	if got := server.GetStats().InFlightInvocations; got != 0 {
+		t.Errorf("Expected the slot to be released after the call, got %d in flight", got)
+	}
+
+	if got := New(WithMaxConcurrentInvocations(0)).config.MaxConcurrentInvocations; got != DefaultMaxConcurrentInvocations {
+		t.Errorf("Expected the default limit, got %d", got)
+	}
+}
+
+// TestWithSharedSession tests that requests without a session ID attach to
+// the shared session, which can be read and invoked but not changed once
+// sealed
+func TestWithSharedSession(t *testing.T) {
+	server := New(WithSharedSession("demo"))
+	defer server.Close()
+
+	shared := server.sessionManager.Get("demo")
+	if shared == nil {
+		t.Fatal("Expected the shared session to be created")
+	}
+	if err := shared.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+	server.SealSharedSession()
+
+	listResp, err := server.ListServices(context.Background(), connect.NewRequest(&catalogv1.ListServicesRequest{}))
+	if err != nil {
+		t.Fatalf("ListServices failed: %v", err)
+	}
+	if got := listResp.Header().Get("X-Session-ID"); got != "demo" {
+		t.Errorf("Expected the shared session, got %q", got)
+	}
+	if len(listResp.Msg.Services) != 1 {
+		t.Errorf("Expected the pre-loaded service, got %d services", len(listResp.Msg.Services))
+	}
+
+	invokeResp, err := server.InvokeGRPC(context.Background(), connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:    "localhost:9999",
+		Service:     "test.v1.TestService",
+		Method:      "TestMethod",
+		RequestJson: `{"name": "test"}`,
+		DryRun:      true,
+	}))
+	if err != nil || !invokeResp.Msg.Success {
+		t.Errorf("Expected the shared session to be invokable, got %v, %v", invokeResp, err)
+	}
+
+	// Calls through the shared session are not counted against it
+	_, err = server.InvokeGRPC(context.Background(), connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:       "localhost:9999",
+		Service:        "test.v1.TestService",
+		Method:         "TestMethod",
+		RequestJson:    `{"name": "test"}`,
+		TimeoutSeconds: 1,
+	}))
+	if err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+	if stats := shared.MethodStats(); len(stats) != 0 {
+		t.Errorf("Expected no method stats on the shared session, got %v", stats)
+	}
+
+	_, err = server.LoadProtos(context.Background(), connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_ProtoPath{ProtoPath: t.TempDir()},
+	}))
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("Expected LoadProtos to be denied, got %v", err)
+	}
+	_, err = server.SetEndpointDefaults(context.Background(), connect.NewRequest(&catalogv1.SetEndpointDefaultsRequest{
+		Endpoint: "localhost:9999",
+		Metadata: map[string]string{"authorization": "Bearer secret"},
+	}))
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("Expected SetEndpointDefaults to be denied, got %v", err)
+	}
+
+	// Other sessions are unaffected
+	req := connect.NewRequest(&catalogv1.SetEndpointDefaultsRequest{Endpoint: "localhost:9999"})
+	req.Header().Set("X-Session-ID", "private")
+	if _, err := server.SetEndpointDefaults(context.Background(), req); err != nil {
+		t.Errorf("Expected a private session to be writable, got %v", err)
+	}
+}
+
+// TestWithRedaction tests that credentials and marked request fields echoed
+// by an upstream error are redacted before the error is recorded
+func TestWithRedaction(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return nil, status.Errorf(codes.PermissionDenied, "token %v of tenant %v may not check %q",
+			md.Get("authorization"), md.Get("x-tenant-secret"), req.(*healthpb.HealthCheckRequest).GetService())
+	}))
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	server := New(WithRedaction([]string{"X-Tenant-Secret"}, nil))
+	defer server.Close()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	healthFile, err := desc.LoadFileDescriptor("grpc/health/v1/health.proto")
+	if err != nil {
+		t.Fatalf("Failed to load health descriptor: %v", err)
+	}
+	// Mark the checked service name as sensitive
+	fdpb := healthFile.AsFileDescriptorProto()
+	for _, msg := range fdpb.MessageType {
+		if msg.GetName() == "HealthCheckRequest" {
+			msg.Field[0].Options = &descriptorpb.FieldOptions{DebugRedact: proto.Bool(true)}
+		}
+	}
+	if err := state.Registry.Register(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdpb}}); err != nil {
+		t.Fatalf("Failed to register health descriptors: %v", err)
+	}
+
+	req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:    lis.Addr().String(),
+		Service:     "grpc.health.v1.Health",
+		Method:      "Check",
+		RequestJson: `{"service":"billing-internal"}`,
+		Transport:   catalogv1.Transport_TRANSPORT_GRPC,
+		Metadata:    map[string]string{"authorization": "Bearer user-token", "x-tenant-secret": "tenant-7"},
+	})
+	req.Header().Set("X-Session-ID", sessionID)
+	resp, err := server.InvokeGRPC(context.Background(), req)
+	if err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+	if resp.Msg.Success || !strings.Contains(resp.Msg.Error, "billing-internal") {
+		t.Fatalf("Expected the upstream error in the response, got %v", resp.Msg)
+	}
+
+	statsReq := connect.NewRequest(&catalogv1.GetMethodStatsRequest{})
+	statsReq.Header().Set("X-Session-ID", sessionID)
+	statsResp, err := server.GetMethodStats(context.Background(), statsReq)
+	if err != nil {
+		t.Fatalf("GetMethodStats failed: %v", err)
+	}
+	stats := statsResp.Msg.Methods["/grpc.health.v1.Health/Check"]
+	if stats == nil {
+		t.Fatalf("Expected stats for Health/Check, got %v", statsResp.Msg.Methods)
+	}
+	for _, secret := range []string{"user-token", "tenant-7", "billing-internal"} {
+		if strings.Contains(stats.LastError, secret) {
+			t.Errorf("Expected %q to be redacted, got %q", secret, stats.LastError)
+		}
+	}
+	if !strings.Contains(stats.LastError, invoker.RedactedValue) {
+		t.Errorf("Expected redacted values in %q", stats.LastError)
+	}
+}
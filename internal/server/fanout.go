@@ -0,0 +1,87 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"connectrpc.com/connect"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"github.com/opentdf/connectrpc-catalog/internal/invoker"
+)
+
+// MaxFanOutRequests is the maximum number of elements in a JSON array request body
+const MaxFanOutRequests = 25
+
+// isJSONArray reports whether a request payload is a JSON array
+func isJSONArray(requestJSON json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(requestJSON)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// invokeFanOut invokes the method once per element of a JSON array payload,
+// sequentially against the same endpoint, and aggregates the results
+func invokeFanOut(invokeReq invoker.InvokeRequest, invoke func(invoker.InvokeRequest) *catalogv1.InvokeGRPCResponse) (*catalogv1.InvokeGRPCResponse, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(invokeReq.RequestJSON, &elements); err != nil {
+		return &catalogv1.InvokeGRPCResponse{
+			Success:   false,
+			Error:     fmt.Sprintf("invalid request JSON array: %v", err),
+			ErrorKind: catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST,
+		}, nil
+	}
+
+	if len(elements) == 0 {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("request JSON array is empty"),
+		)
+	}
+	if len(elements) > MaxFanOutRequests {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("request JSON array has %d elements, maximum is %d", len(elements), MaxFanOutRequests),
+		)
+	}
+
+	results := make([]*catalogv1.InvokeGRPCResponse, len(elements))
+	responses := make([]json.RawMessage, len(elements))
+	failed := 0
+	var requestBytes, responseBytes int64
+
+	for i, element := range elements {
+		elementReq := invokeReq
+		elementReq.RequestJSON = invoker.NormalizeRequestJSON(element)
+
+		results[i] = invoke(elementReq)
+		requestBytes += results[i].RequestBytes
+		responseBytes += results[i].ResponseBytes
+		switch {
+		case !results[i].Success:
+			failed++
+			responses[i] = json.RawMessage("null")
+		case results[i].ResponseJson == "":
+			// Dry runs have no response
+			responses[i] = json.RawMessage("null")
+		default:
+			responses[i] = json.RawMessage(results[i].ResponseJson)
+		}
+	}
+
+	responseJSON, err := json.Marshal(responses)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("failed to aggregate responses: %w", err))
+	}
+
+	resp := &catalogv1.InvokeGRPCResponse{
+		Success:       failed == 0,
+		ResponseJson:  string(responseJSON),
+		Results:       results,
+		RequestBytes:  requestBytes,
+		ResponseBytes: responseBytes,
+	}
+	if failed > 0 {
+		resp.Error = fmt.Sprintf("%d of %d requests failed", failed, len(elements))
+	}
+	return resp, nil
+}
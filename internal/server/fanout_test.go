@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+)
+
+// newFanOutTestServer creates a server with a session holding the test descriptors
+func newFanOutTestServer(t *testing.T) (*CatalogServer, string) {
+	t.Helper()
+
+	server := New()
+	t.Cleanup(func() { server.Close() })
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+	return server, sessionID
+}
+
+// TestInvokeGRPC_FanOut tests that a JSON array body is invoked once per element
+func TestInvokeGRPC_FanOut(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name string `json:"name"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Name == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": "invalid_argument", "message": "bad name"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "hello " + req.Name})
+	}))
+	defer backend.Close()
+
+	server, sessionID := newFanOutTestServer(t)
+
+	invokeReq := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:    strings.TrimPrefix(backend.URL, "http://"),
+		Service:     "test.v1.TestService",
+		Method:      "TestMethod",
+		RequestJson: `[{"name": "a"}, {"name": "fail"}, {"name": "b"}]`,
+	})
+	invokeReq.Header().Set("X-Session-ID", sessionID)
+
+	resp, err := server.InvokeGRPC(context.Background(), invokeReq)
+	if err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+
+	if len(resp.Msg.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(resp.Msg.Results))
+	}
+	if resp.Msg.Success {
+		t.Error("Expected success=false when an element fails")
+	}
+	if resp.Msg.Error != "1 of 3 requests failed" {
+		t.Errorf("Unexpected error: %s", resp.Msg.Error)
+	}
+	if !resp.Msg.Results[0].Success || resp.Msg.Results[1].Success || !resp.Msg.Results[2].Success {
+		t.Errorf("Unexpected per-element success values")
+	}
+
+	var responses []map[string]string
+	if err := json.Unmarshal([]byte(resp.Msg.ResponseJson), &responses); err != nil {
+		t.Fatalf("Aggregated response is not a JSON array: %v", err)
+	}
+	if responses[0]["message"] != "hello a" || responses[1] != nil || responses[2]["message"] != "hello b" {
+		t.Errorf("Unexpected aggregated responses: %s", resp.Msg.ResponseJson)
+	}
+}
+
+// TestInvokeGRPC_FanOutLimit tests that oversized and empty arrays are rejected
+func TestInvokeGRPC_FanOutLimit(t *testing.T) {
+	server, sessionID := newFanOutTestServer(t)
+
+	elements := make([]string, MaxFanOutRequests+1)
+	for i := range elements {
+		elements[i] = "{}"
+	}
+
+	for _, body := range []string{"[" + strings.Join(elements, ",") + "]", "[]"} {
+		invokeReq := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+			Endpoint:    "localhost:9999",
+			Service:     "test.v1.TestService",
+			Method:      "TestMethod",
+			RequestJson: body,
+		})
+		invokeReq.Header().Set("X-Session-ID", sessionID)
+
+		_, err := server.InvokeGRPC(context.Background(), invokeReq)
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"connectrpc.com/connect"
@@ -477,6 +478,68 @@ func TestIntegrationMultipleLoadProtos(t *testing.T) {
 	t.Logf("   - Second load: %d services", resp2.Msg.ServiceCount)
 }
 
+// TestIntegrationCatalogService tests loading the catalog's own service and
+// invoking it through InvokeGRPC against the running catalog
+func TestIntegrationCatalogService(t *testing.T) {
+	catalogServer := server.New()
+	defer catalogServer.Close()
+
+	mux := http.NewServeMux()
+	path, handler := catalogv1connect.NewCatalogServiceHandler(catalogServer)
+	mux.Handle(path, handler)
+
+	testServer := httptest.NewServer(mux)
+	defer testServer.Close()
+
+	client := catalogv1connect.NewCatalogServiceClient(
+		http.DefaultClient,
+		testServer.URL,
+	)
+
+	ctx := context.Background()
+	loadResp, err := client.LoadProtos(ctx, connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_CatalogService{CatalogService: true},
+	}))
+	if err != nil {
+		t.Fatalf("LoadProtos failed: %v", err)
+	}
+	if !loadResp.Msg.Success {
+		t.Fatalf("LoadProtos returned error: %s", loadResp.Msg.Error)
+	}
+	sessionID := loadResp.Header().Get("X-Session-ID")
+
+	listReq := connect.NewRequest(&catalogv1.ListServicesRequest{})
+	listReq.Header().Set("X-Session-ID", sessionID)
+	listResp, err := client.ListServices(ctx, listReq)
+	if err != nil {
+		t.Fatalf("ListServices failed: %v", err)
+	}
+	if len(listResp.Msg.Services) != 1 || listResp.Msg.Services[0].Name != "catalog.v1.CatalogService" {
+		t.Fatalf("Expected only catalog.v1.CatalogService, got %v", listResp.Msg.Services)
+	}
+
+	// The catalog lists its own services when asked through itself
+	invokeReq := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:    strings.TrimPrefix(testServer.URL, "http://"),
+		Service:     "catalog.v1.CatalogService",
+		Method:      "ListServices",
+		RequestJson: "{}",
+		Transport:   catalogv1.Transport_TRANSPORT_CONNECT,
+		Metadata:    map[string]string{"X-Session-ID": sessionID},
+	})
+	invokeReq.Header().Set("X-Session-ID", sessionID)
+	invokeResp, err := client.InvokeGRPC(ctx, invokeReq)
+	if err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+	if !invokeResp.Msg.Success {
+		t.Fatalf("InvokeGRPC returned error: %s", invokeResp.Msg.Error)
+	}
+	if !strings.Contains(invokeResp.Msg.ResponseJson, "catalog.v1.CatalogService") {
+		t.Errorf("Expected the catalog service in the response, got %s", invokeResp.Msg.ResponseJson)
+	}
+}
+
 // Helper function to get test proto path
 func getTestProtoPath(t *testing.T) string {
 	t.Helper()
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"connectrpc.com/connect"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"github.com/opentdf/connectrpc-catalog/internal/loader"
+)
+
+// loadProgressFunc receives the stages of a load as it runs
+type loadProgressFunc func(stage catalogv1.LoadStage, message string)
+
+// report calls f if it is set
+func (f loadProgressFunc) report(stage catalogv1.LoadStage, message string) {
+	if f != nil {
+		f(stage, message)
+	}
+}
+
+// loaderProgress adapts f to the stages reported by loaders, or returns nil
+// if f is nil
+func (f loadProgressFunc) loaderProgress() loader.ProgressFunc {
+	if f == nil {
+		return nil
+	}
+	return func(stage loader.LoadStage, message string) {
+		f(loaderStages[stage], message)
+	}
+}
+
+// loaderStages maps loader stages to their proto form
+var loaderStages = map[loader.LoadStage]catalogv1.LoadStage{
+	loader.StageCloning:   catalogv1.LoadStage_LOAD_STAGE_CLONING,
+	loader.StageExporting: catalogv1.LoadStage_LOAD_STAGE_EXPORTING,
+	loader.StageBuilding:  catalogv1.LoadStage_LOAD_STAGE_BUILDING,
+}
+
+// sourceDescription names the source of a load request for progress messages
+func sourceDescription(msg *catalogv1.LoadProtosRequest) string {
+	switch source := msg.Source.(type) {
+	case *catalogv1.LoadProtosRequest_ProtoPath:
+		return source.ProtoPath
+	case *catalogv1.LoadProtosRequest_ProtoRepo:
+		return source.ProtoRepo
+	case *catalogv1.LoadProtosRequest_BufModule:
+		return source.BufModule
+	case *catalogv1.LoadProtosRequest_ReflectionEndpoint:
+		return "reflection endpoint " + source.ReflectionEndpoint
+	case *catalogv1.LoadProtosRequest_ReflectionEndpoints:
+		return fmt.Sprintf("%d reflection endpoints", len(source.ReflectionEndpoints.GetEndpoints()))
+	case *catalogv1.LoadProtosRequest_Git:
+		return source.Git.GetRemoteUrl()
+	case *catalogv1.LoadProtosRequest_AccessorUrl:
+		return source.AccessorUrl
+	case *catalogv1.LoadProtosRequest_Oci:
+		return source.Oci.GetReference()
+	case *catalogv1.LoadProtosRequest_CatalogService:
+		return "the catalog service"
+	case *catalogv1.LoadProtosRequest_DescriptorSetPath:
+		return source.DescriptorSetPath
+	case *catalogv1.LoadProtosRequest_DescriptorSetUrl:
+		return source.DescriptorSetUrl
+	case *catalogv1.LoadProtosRequest_DescriptorSet, *catalogv1.LoadProtosRequest_DescriptorSetJson:
+		return "uploaded descriptor set"
+	case *catalogv1.LoadProtosRequest_DescriptorSetDir:
+		return source.DescriptorSetDir
+	default:
+		return "unknown source"
+	}
+}
+
+// LoadProtosStream implements the LoadProtosStream RPC handler. Progress
+// events go only to this caller, so unlike LoadProtos the load is never
+// shared with identical concurrent ones.
+func (s *CatalogServer) LoadProtosStream(
+	ctx context.Context,
+	req *connect.Request[catalogv1.LoadProtosRequest],
+	stream *connect.ServerStream[catalogv1.LoadProgress],
+) error {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if err := checkWritable(state); err != nil {
+		return err
+	}
+
+	if req.Msg.Source == nil {
+		return connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("no source specified in request"),
+		)
+	}
+	if refOpts := req.Msg.GetReflectionOptions(); refOpts != nil {
+		if err := validateTLSSettings(refOpts.GetUseTls(), refOpts.GetServerName()); err != nil {
+			return err
+		}
+	}
+	stream.ResponseHeader().Set("X-Session-ID", newSessionID)
+
+	// Events are sent from this goroutine, outside the session's load lock,
+	// so a slow client never holds up other loads into the session. Each
+	// stage is reported at most once, so the buffer never fills.
+	events := make(chan *catalogv1.LoadProgress, len(catalogv1.LoadStage_name))
+	var result *catalogv1.LoadProtosResponse
+	go func() {
+		defer close(events)
+		// Loads into one session queue so registrations never interleave
+		state.LoadMu.Lock()
+		defer state.LoadMu.Unlock()
+		result = s.loadSource(state, req.Msg, func(stage catalogv1.LoadStage, message string) {
+			events <- &catalogv1.LoadProgress{Stage: stage, Message: message}
+		})
+	}()
+
+	// A client that goes away stops receiving events; the load still
+	// completes so the session is left consistent
+	var sendErr error
+	for event := range events {
+		if sendErr == nil {
+			sendErr = stream.Send(event)
+		}
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	message := fmt.Sprintf("loaded %d services from %d files", result.ServiceCount, result.FileCount)
+	if !result.Success {
+		message = "load failed"
+	}
+	return stream.Send(&catalogv1.LoadProgress{
+		Stage:   catalogv1.LoadStage_LOAD_STAGE_DONE,
+		Message: message,
+		Result:  result,
+	})
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"connectrpc.com/connect"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"github.com/opentdf/connectrpc-catalog/gen/catalog/v1/catalogv1connect"
+)
+
+// TestLoadProtosStream tests the progress events of successful and failed loads
+func TestLoadProtosStream(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle(catalogv1connect.NewCatalogServiceHandler(server))
+	testServer := httptest.NewServer(mux)
+	defer testServer.Close()
+	client := catalogv1connect.NewCatalogServiceClient(testServer.Client(), testServer.URL)
+
+	load := func(sessionID, path string) (string, []*catalogv1.LoadProgress) {
+		t.Helper()
+		req := connect.NewRequest(descriptorSetSource(path))
+		req.Header().Set("X-Session-ID", sessionID)
+		stream, err := client.LoadProtosStream(context.Background(), req)
+		if err != nil {
+			t.Fatalf("LoadProtosStream failed: %v", err)
+		}
+		defer stream.Close()
+
+		var events []*catalogv1.LoadProgress
+		for stream.Receive() {
+			events = append(events, stream.Msg())
+		}
+		if err := stream.Err(); err != nil {
+			t.Fatalf("Stream failed: %v", err)
+		}
+		return stream.ResponseHeader().Get("X-Session-ID"), events
+	}
+
+	sessionID, events := load("", writeTestDescriptorSet(t))
+	if sessionID == "" {
+		t.Fatal("Expected a session ID header")
+	}
+	wantStages := []catalogv1.LoadStage{
+		catalogv1.LoadStage_LOAD_STAGE_FETCHING,
+		catalogv1.LoadStage_LOAD_STAGE_REGISTERING,
+		catalogv1.LoadStage_LOAD_STAGE_DONE,
+	}
+	if len(events) != len(wantStages) {
+		t.Fatalf("Expected %d events, got %v", len(wantStages), events)
+	}
+	for i, event := range events {
+		if event.Stage != wantStages[i] {
+			t.Errorf("Event %d: expected %v, got %v", i, wantStages[i], event.Stage)
+		}
+	}
+	result := events[len(events)-1].Result
+	if !result.GetSuccess() || result.GetServiceCount() != 1 {
+		t.Errorf("Expected a successful result with 1 service, got %v", result)
+	}
+
+	state, _, err := server.sessionManager.GetOrCreate(sessionID)
+	if err != nil {
+		t.Fatalf("Failed to get session: %v", err)
+	}
+	if !state.Registry.HasService("test.v1.TestService") {
+		t.Error("Expected the service to be registered in the session")
+	}
+
+	// A failed load ends with a done event carrying the error
+	_, events = load(sessionID, filepath.Join(t.TempDir(), "missing.binpb"))
+	last := events[len(events)-1]
+	if last.Stage != catalogv1.LoadStage_LOAD_STAGE_DONE || last.Result.GetSuccess() || last.Result.GetError() == "" {
+		t.Errorf("Expected a failed done event, got %v", last)
+	}
+}
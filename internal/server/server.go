@@ -3,25 +3,94 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"connectrpc.com/connect"
+	"github.com/jhump/protoreflect/desc"
 	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"github.com/opentdf/connectrpc-catalog/internal/codegen"
 	"github.com/opentdf/connectrpc-catalog/internal/invoker"
 	"github.com/opentdf/connectrpc-catalog/internal/loader"
+	"github.com/opentdf/connectrpc-catalog/internal/registry"
 	"github.com/opentdf/connectrpc-catalog/internal/session"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
+const (
+	// maxConcurrentWarmups bounds the number of endpoints dialed at once by WarmEndpoints
+	maxConcurrentWarmups = 8
+	// maxWarmEndpoints bounds the number of endpoints in one WarmEndpoints request
+	maxWarmEndpoints = 256
+	// defaultWarmTimeoutSeconds is the per-endpoint timeout when none is specified
+	defaultWarmTimeoutSeconds = 5
+	// defaultInspectTLSTimeoutSeconds is the handshake timeout when none is specified
+	defaultInspectTLSTimeoutSeconds = 10
+	// defaultCompileFilename names inline proto source submitted without a file name
+	defaultCompileFilename = "input.proto"
+	// defaultProbeTimeoutSeconds bounds transport probing when no timeout is specified
+	defaultProbeTimeoutSeconds = 5
+	// defaultDescribeEndpoint is the target used in generated commands when none is given
+	defaultDescribeEndpoint = "localhost:8080"
+	// largeResponseMaxRecvMsgSize is the gRPC receive limit for methods whose
+	// response type looks large
+	largeResponseMaxRecvMsgSize = 64 << 20
+)
+
 // CatalogServer implements the CatalogService ConnectRPC handlers
 type CatalogServer struct {
 	sessionManager *session.Manager
+	config         Config
+	loads          singleflight.Group
+	startedAt      time.Time
+	// invocations holds a slot for each invocation in flight
+	invocations chan struct{}
+	// redactor hides sensitive values of recorded invocations
+	redactor *invoker.Redactor
 }
 
 // New creates a new CatalogServer instance
-func New() *CatalogServer {
+func New(opts ...Option) *CatalogServer {
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	sessionManager := session.NewManagerWithInvokerFactory(cfg.SessionTTL, newInvokerFactory(cfg),
+		session.WithCleanupInterval(cfg.SessionCleanupInterval))
+	sessionManager.SetCompaction(cfg.CompactionDir, cfg.CompactIdleAfter)
+	if cfg.SharedSessionID != "" {
+		sessionManager.CreateShared(cfg.SharedSessionID)
+	}
+
 	return &CatalogServer{
-		sessionManager: session.NewManager(session.DefaultSessionTTL),
+		sessionManager: sessionManager,
+		config:         cfg,
+		startedAt:      time.Now(),
+		invocations:    make(chan struct{}, cfg.MaxConcurrentInvocations),
+		redactor:       invoker.NewRedactor(cfg.RedactMetadataKeys, cfg.RedactFieldOptions),
+	}
+}
+
+// acquireInvocation takes an in-flight invocation slot, failing with
+// ResourceExhausted when all are in use. The returned func releases it.
+func (s *CatalogServer) acquireInvocation() (func(), error) {
+	select {
+	case s.invocations <- struct{}{}:
+		return func() { <-s.invocations }, nil
+	default:
+		return nil, connect.NewError(
+			connect.CodeResourceExhausted,
+			fmt.Errorf("too many concurrent invocations (limit %d), retry later", cap(s.invocations)),
+		)
 	}
 }
 
@@ -32,99 +101,408 @@ func (s *CatalogServer) LoadProtos(
 ) (*connect.Response[catalogv1.LoadProtosResponse], error) {
 	// Get or create session
 	sessionID := req.Header().Get("X-Session-ID")
-	state, newSessionID, err := s.sessionManager.GetOrCreate(sessionID)
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if err := checkWritable(state); err != nil {
+		return nil, err
+	}
+
+	if req.Msg.Source == nil {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("no source specified in request"),
+		)
+	}
+	if refOpts := req.Msg.GetReflectionOptions(); refOpts != nil {
+		if err := validateTLSSettings(refOpts.GetUseTls(), refOpts.GetServerName()); err != nil {
+			return nil, err
+		}
+	}
+
+	// Identical concurrent loads into the same session share one result
+	key, err := loadKey(newSessionID, req.Msg)
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
+	result, _, _ := s.loads.Do(key, func() (any, error) {
+		// Loads into one session queue so registrations never interleave
+		state.LoadMu.Lock()
+		defer state.LoadMu.Unlock()
+		return s.loadSource(state, req.Msg, nil), nil
+	})
+
+	loaded := result.(*catalogv1.LoadProtosResponse)
+	resp := connect.NewResponse(proto.Clone(loaded).(*catalogv1.LoadProtosResponse))
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// checkWritable rejects requests that would change what a read-only
+// session, such as the shared session, holds
+func checkWritable(state *session.State) error {
+	if state.ReadOnly() {
+		return connect.NewError(
+			connect.CodePermissionDenied,
+			fmt.Errorf("session is read-only"),
+		)
+	}
+	return nil
+}
+
+// loadKey identifies a load request within a session for deduplication
+func loadKey(sessionID string, msg *catalogv1.LoadProtosRequest) (string, error) {
+	encoded, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode load request: %w", err)
+	}
+	return sessionID + "/" + string(encoded), nil
+}
 
+// loadSource loads descriptors from the requested source and registers them
+// into the session registry, reporting its stages to progress if set
+func (s *CatalogServer) loadSource(state *session.State, msg *catalogv1.LoadProtosRequest, progress loadProgressFunc) *catalogv1.LoadProtosResponse {
 	// Determine the source type and load descriptors
 	var fds *descriptorpb.FileDescriptorSet
+	var warnings []string
+	var err error
+	// Reflection sources record where each service came from and how to
+	// refresh them
+	var origins map[string][]string
+	var failedEndpoints []*catalogv1.EndpointError
+	var refSource *session.ReflectionSource
 
-	switch source := req.Msg.Source.(type) {
+	progress.report(catalogv1.LoadStage_LOAD_STAGE_FETCHING, fmt.Sprintf("loading %s", sourceDescription(msg)))
+	switch source := msg.Source.(type) {
 	case *catalogv1.LoadProtosRequest_ProtoPath:
-		fds, err = loader.LoadFromPath(source.ProtoPath)
+		fds, err = loader.LoadFromPathWithProgress(source.ProtoPath, progress.loaderProgress())
 		if err != nil {
-			resp := connect.NewResponse(&catalogv1.LoadProtosResponse{
+			return &catalogv1.LoadProtosResponse{
 				Success: false,
 				Error:   fmt.Sprintf("failed to load from path: %v", err),
-			})
-			resp.Header().Set("X-Session-ID", newSessionID)
-			return resp, nil
+			}
 		}
 
 	case *catalogv1.LoadProtosRequest_ProtoRepo:
-		fds, err = loader.LoadFromGitHub(source.ProtoRepo)
+		fds, err = loader.LoadFromGitHubWithProgress(source.ProtoRepo, progress.loaderProgress())
 		if err != nil {
-			resp := connect.NewResponse(&catalogv1.LoadProtosResponse{
+			return &catalogv1.LoadProtosResponse{
 				Success: false,
 				Error:   fmt.Sprintf("failed to load from GitHub: %v", err),
-			})
-			resp.Header().Set("X-Session-ID", newSessionID)
-			return resp, nil
+			}
 		}
 
 	case *catalogv1.LoadProtosRequest_BufModule:
-		fds, err = loader.LoadFromBufModule(source.BufModule)
+		fds, err = loader.LoadFromBufModuleWithProgress(source.BufModule, progress.loaderProgress())
 		if err != nil {
-			resp := connect.NewResponse(&catalogv1.LoadProtosResponse{
+			return &catalogv1.LoadProtosResponse{
 				Success: false,
 				Error:   fmt.Sprintf("failed to load from Buf module: %v", err),
-			})
-			resp.Header().Set("X-Session-ID", newSessionID)
-			return resp, nil
+			}
 		}
 
 	case *catalogv1.LoadProtosRequest_ReflectionEndpoint:
-		// Build reflection options from request
-		opts := loader.ReflectionOptions{
-			UseTLS:         true, // Default to TLS
-			TimeoutSeconds: 10,   // Default timeout
-		}
-		if refOpts := req.Msg.GetReflectionOptions(); refOpts != nil {
-			opts.UseTLS = refOpts.GetUseTls()
-			opts.ServerName = refOpts.GetServerName()
-			if refOpts.GetTimeoutSeconds() > 0 {
-				opts.TimeoutSeconds = refOpts.GetTimeoutSeconds()
+		result, err := loader.LoadFromReflectionWithWarnings(source.ReflectionEndpoint, reflectionOptions(msg))
+		if err != nil {
+			return &catalogv1.LoadProtosResponse{
+				Success: false,
+				Error:   fmt.Sprintf("failed to load from reflection: %v", err),
 			}
 		}
+		fds = result.Descriptors
+		warnings = result.Warnings
+		origins = make(map[string][]string, len(result.Services))
+		for _, svc := range result.Services {
+			origins[svc] = []string{source.ReflectionEndpoint}
+		}
+		refSource = &session.ReflectionSource{
+			Endpoints: []string{source.ReflectionEndpoint},
+			Options:   reflectionOptions(msg),
+			Services:  result.Services,
+		}
 
-		fds, err = loader.LoadFromReflection(source.ReflectionEndpoint, opts)
+	case *catalogv1.LoadProtosRequest_ReflectionEndpoints:
+		result, err := loader.LoadFromReflectionEndpoints(source.ReflectionEndpoints.GetEndpoints(), reflectionOptions(msg))
 		if err != nil {
-			resp := connect.NewResponse(&catalogv1.LoadProtosResponse{
+			return &catalogv1.LoadProtosResponse{
 				Success: false,
 				Error:   fmt.Sprintf("failed to load from reflection: %v", err),
-			})
-			resp.Header().Set("X-Session-ID", newSessionID)
-			return resp, nil
+			}
+		}
+		fds = result.Descriptors
+		warnings = result.Warnings
+		origins = result.ServiceOrigins
+		failedEndpoints = toProtoEndpointErrors(result.Failures)
+		refSource = &session.ReflectionSource{
+			Endpoints: source.ReflectionEndpoints.GetEndpoints(),
+			Options:   reflectionOptions(msg),
+			Services:  sortedKeys(result.ServiceOrigins),
 		}
 
-	default:
-		return nil, connect.NewError(
-			connect.CodeInvalidArgument,
-			fmt.Errorf("no source specified in request"),
-		)
+	case *catalogv1.LoadProtosRequest_Git:
+		fds, err = loader.LoadFromGitWithProgress(source.Git.GetRemoteUrl(), source.Git.GetRef(), source.Git.GetSubdir(), progress.loaderProgress())
+		if err != nil {
+			return &catalogv1.LoadProtosResponse{
+				Success: false,
+				Error:   fmt.Sprintf("failed to load from git: %v", err),
+			}
+		}
+
+	case *catalogv1.LoadProtosRequest_AccessorUrl:
+		fds, err = loader.LoadFromAccessor(source.AccessorUrl)
+		if err != nil {
+			return &catalogv1.LoadProtosResponse{
+				Success: false,
+				Error:   fmt.Sprintf("failed to load from accessor: %v", err),
+			}
+		}
+
+	case *catalogv1.LoadProtosRequest_Oci:
+		fds, err = loader.LoadFromOCI(source.Oci.GetReference(), source.Oci.GetToken(), s.config.MaxDescriptorSetSize)
+		if err != nil {
+			return &catalogv1.LoadProtosResponse{
+				Success: false,
+				Error:   fmt.Sprintf("failed to load from OCI: %v", err),
+			}
+		}
+
+	case *catalogv1.LoadProtosRequest_CatalogService:
+		fds, err = loader.LoadFromGlobalRegistry(catalogv1.File_catalog_v1_catalog_proto.Path())
+		if err != nil {
+			return &catalogv1.LoadProtosResponse{
+				Success: false,
+				Error:   fmt.Sprintf("failed to load the catalog service: %v", err),
+			}
+		}
+
+	case *catalogv1.LoadProtosRequest_DescriptorSetPath:
+		fds, err = loader.LoadFromDescriptorSet(source.DescriptorSetPath)
+		if err != nil {
+			return &catalogv1.LoadProtosResponse{
+				Success: false,
+				Error:   fmt.Sprintf("failed to load descriptor set: %v", err),
+			}
+		}
+
+	case *catalogv1.LoadProtosRequest_DescriptorSetUrl:
+		fds, err = loader.LoadFromURL(source.DescriptorSetUrl)
+		if err != nil {
+			return &catalogv1.LoadProtosResponse{
+				Success: false,
+				Error:   fmt.Sprintf("failed to load descriptor set from URL: %v", err),
+			}
+		}
+
+	case *catalogv1.LoadProtosRequest_DescriptorSet:
+		var problems []*catalogv1.DescriptorSetProblem
+		fds, problems = validateDescriptorSetUpload(source.DescriptorSet, s.config.MaxDescriptorSetSize)
+		if len(problems) > 0 {
+			return &catalogv1.LoadProtosResponse{
+				Success:  false,
+				Error:    fmt.Sprintf("invalid descriptor set upload: %s", problems[0].Message),
+				Problems: problems,
+			}
+		}
+
+	case *catalogv1.LoadProtosRequest_DescriptorSetJson:
+		fds, err = loader.DecodeDescriptorSetJSON([]byte(source.DescriptorSetJson), s.config.MaxDescriptorSetSize)
+		if err != nil {
+			return &catalogv1.LoadProtosResponse{
+				Success: false,
+				Error:   fmt.Sprintf("failed to decode descriptor set JSON: %v", err),
+			}
+		}
+
+	case *catalogv1.LoadProtosRequest_DescriptorSetDir:
+		fds, err = loader.LoadFromDescriptorSetDir(source.DescriptorSetDir)
+		if err != nil {
+			return &catalogv1.LoadProtosResponse{
+				Success: false,
+				Error:   fmt.Sprintf("failed to load descriptor set directory: %v", err),
+			}
+		}
 	}
 
-	// Register the loaded descriptors using session registry
-	if err := state.Registry.Register(fds); err != nil {
-		resp := connect.NewResponse(&catalogv1.LoadProtosResponse{
-			Success: false,
-			Error:   fmt.Sprintf("failed to register descriptors: %v", err),
-		})
-		resp.Header().Set("X-Session-ID", newSessionID)
-		return resp, nil
+	if msg.StripSourceInfo {
+		registry.StripSourceInfo(fds)
+	}
+	if len(msg.ServiceFilter) > 0 {
+		fds, err = registry.PruneToServices(fds, msg.ServiceFilter)
+		if err != nil {
+			return &catalogv1.LoadProtosResponse{
+				Success:         false,
+				Error:           fmt.Sprintf("failed to apply service filter: %v", err),
+				FailedEndpoints: failedEndpoints,
+			}
+		}
+		origins = filterServiceOrigins(origins, msg.ServiceFilter)
+		if refSource != nil {
+			refSource.Services = sortedKeys(origins)
+		}
+	}
+
+	// Register the loaded descriptors using session registry. A bad file only
+	// drops itself and its dependents, so the rest of the catalog stays usable.
+	progress.report(catalogv1.LoadStage_LOAD_STAGE_REGISTERING, fmt.Sprintf("registering %d files", len(fds.GetFile())))
+	fileErrs := state.Registry.RegisterBestEffort(fds)
+	if len(fileErrs) > 0 && len(fileErrs) == len(fds.GetFile()) {
+		return &catalogv1.LoadProtosResponse{
+			Success:         false,
+			Error:           fmt.Sprintf("failed to register descriptors: %v", fileErrs[0]),
+			FileErrors:      toProtoFileErrors(fileErrs),
+			FailedEndpoints: failedEndpoints,
+		}
+	}
+	state.AddServiceOrigins(origins)
+
+	// Get statistics for the files that were registered
+	registered := withoutFailedFiles(fds, fileErrs)
+	if refSource != nil {
+		refSource.StripSourceInfo = msg.StripSourceInfo
+		refSource.Files = fileNames(registered)
+		refSource.LoadedAt = time.Now()
+		state.SetReflectionSource(*refSource)
+	}
+	info := loader.GetDescriptorInfo(registered)
+	if len(fileErrs) > 0 {
+		warnings = append(warnings, fmt.Sprintf("loaded %d of %d files", len(registered.File), len(fds.File)))
+	}
+
+	return &catalogv1.LoadProtosResponse{
+		Success:         true,
+		ServiceCount:    int32(len(info.Services)),
+		FileCount:       int32(info.Files),
+		Warnings:        warnings,
+		FileErrors:      toProtoFileErrors(fileErrs),
+		FailedEndpoints: failedEndpoints,
+	}
+}
+
+// reflectionOptions builds loader options from a load request, defaulting to
+// TLS and a 10 second timeout
+func reflectionOptions(msg *catalogv1.LoadProtosRequest) loader.ReflectionOptions {
+	opts := loader.ReflectionOptions{
+		UseTLS:         true, // Default to TLS
+		TimeoutSeconds: 10,   // Default timeout
+	}
+	if refOpts := msg.GetReflectionOptions(); refOpts != nil {
+		opts.UseTLS = refOpts.GetUseTls()
+		opts.ServerName = refOpts.GetServerName()
+		opts.Authority = refOpts.GetAuthority()
+		opts.IncludeServices = refOpts.GetIncludeServices()
+		opts.ExcludeServices = refOpts.GetExcludeServices()
+		opts.DisableDefaultExcludes = refOpts.GetDisableDefaultExcludes()
+		if refOpts.GetTimeoutSeconds() > 0 {
+			opts.TimeoutSeconds = refOpts.GetTimeoutSeconds()
+		}
+	}
+	// Only fetch the filtered services rather than pruning the rest later
+	if len(opts.IncludeServices) == 0 {
+		opts.IncludeServices = msg.GetServiceFilter()
 	}
+	return opts
+}
 
-	// Get statistics
-	info := loader.GetDescriptorInfo(fds)
+// toProtoEndpointErrors converts per-endpoint failures to their proto form,
+// sorted by endpoint
+func toProtoEndpointErrors(failures map[string]error) []*catalogv1.EndpointError {
+	if len(failures) == 0 {
+		return nil
+	}
 
-	resp := connect.NewResponse(&catalogv1.LoadProtosResponse{
-		Success:      true,
-		ServiceCount: int32(len(info.Services)),
-		FileCount:    int32(info.Files),
+	protoErrs := make([]*catalogv1.EndpointError, 0, len(failures))
+	for endpoint, err := range failures {
+		protoErrs = append(protoErrs, &catalogv1.EndpointError{
+			Endpoint: endpoint,
+			Error:    err.Error(),
+		})
+	}
+	sort.Slice(protoErrs, func(i, j int) bool {
+		return protoErrs[i].Endpoint < protoErrs[j].Endpoint
 	})
-	resp.Header().Set("X-Session-ID", newSessionID)
-	return resp, nil
+	return protoErrs
+}
+
+// filterServiceOrigins returns the origins of the given services only
+func filterServiceOrigins(origins map[string][]string, services []string) map[string][]string {
+	if origins == nil {
+		return nil
+	}
+	filtered := make(map[string][]string, len(services))
+	for _, svc := range services {
+		if endpoints, ok := origins[svc]; ok {
+			filtered[svc] = endpoints
+		}
+	}
+	return filtered
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fileNames returns the names of the files of fds
+func fileNames(fds *descriptorpb.FileDescriptorSet) []string {
+	names := make([]string, 0, len(fds.GetFile()))
+	for _, fdpb := range fds.GetFile() {
+		names = append(names, fdpb.GetName())
+	}
+	return names
+}
+
+// withoutFailedFiles returns the files of fds that registered successfully
+func withoutFailedFiles(fds *descriptorpb.FileDescriptorSet, fileErrs []registry.FileError) *descriptorpb.FileDescriptorSet {
+	if len(fileErrs) == 0 {
+		return fds
+	}
+
+	failed := make(map[string]bool, len(fileErrs))
+	for _, fileErr := range fileErrs {
+		failed[fileErr.File] = true
+	}
+
+	registered := &descriptorpb.FileDescriptorSet{}
+	for _, file := range fds.File {
+		if !failed[file.GetName()] {
+			registered.File = append(registered.File, file)
+		}
+	}
+	return registered
+}
+
+// toProtoFileErrors converts registry file errors to their proto form
+func toProtoFileErrors(fileErrs []registry.FileError) []*catalogv1.FileLoadError {
+	if len(fileErrs) == 0 {
+		return nil
+	}
+
+	protoErrs := make([]*catalogv1.FileLoadError, len(fileErrs))
+	for i, fileErr := range fileErrs {
+		protoErrs[i] = &catalogv1.FileLoadError{
+			File:  fileErr.File,
+			Error: fileErr.Err.Error(),
+		}
+	}
+	return protoErrs
 }
 
 // ListServices implements the ListServices RPC handler
@@ -134,10 +512,11 @@ func (s *CatalogServer) ListServices(
 ) (*connect.Response[catalogv1.ListServicesResponse], error) {
 	// Get or create session
 	sessionID := req.Header().Get("X-Session-ID")
-	state, newSessionID, err := s.sessionManager.GetOrCreate(sessionID)
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
+	defer state.Release()
 
 	// Get all services from session registry
 	services := state.Registry.ListServices()
@@ -145,24 +524,14 @@ func (s *CatalogServer) ListServices(
 	// Convert to proto response format
 	protoServices := make([]*catalogv1.ServiceInfo, len(services))
 	for i, svc := range services {
-		methods := make([]*catalogv1.MethodInfo, len(svc.Methods))
-		for j, method := range svc.Methods {
-			methods[j] = &catalogv1.MethodInfo{
-				Name:            method.Name,
-				InputType:       method.InputType,
-				OutputType:      method.OutputType,
-				Documentation:   method.Documentation,
-				ClientStreaming: method.ClientStreaming,
-				ServerStreaming: method.ServerStreaming,
+		protoServices[i] = toProtoServiceInfo(svc)
+		protoServices[i].OriginEndpoints = state.ServiceOrigins(svc.Name)
+		if req.Msg.IncludeFields {
+			for _, method := range protoServices[i].Methods {
+				method.InputFields = describeFields(state.Registry, method.InputType)
+				method.OutputFields = describeFields(state.Registry, method.OutputType)
 			}
 		}
-
-		protoServices[i] = &catalogv1.ServiceInfo{
-			Name:          svc.Name,
-			Package:       svc.Package,
-			Methods:       methods,
-			Documentation: svc.Documentation,
-		}
 	}
 
 	resp := connect.NewResponse(&catalogv1.ListServicesResponse{
@@ -179,10 +548,11 @@ func (s *CatalogServer) GetServiceSchema(
 ) (*connect.Response[catalogv1.GetServiceSchemaResponse], error) {
 	// Get or create session
 	sessionID := req.Header().Get("X-Session-ID")
-	state, newSessionID, err := s.sessionManager.GetOrCreate(sessionID)
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
+	defer state.Release()
 
 	serviceName := req.Msg.ServiceName
 
@@ -193,8 +563,7 @@ func (s *CatalogServer) GetServiceSchema(
 		)
 	}
 
-	// Get service schema from session registry
-	serviceInfo, messageSchemas, err := state.Registry.GetServiceSchema(serviceName)
+	hash, err := state.Registry.ServiceSchemaHash(serviceName)
 	if err != nil {
 		resp := connect.NewResponse(&catalogv1.GetServiceSchemaResponse{
 			Error: fmt.Sprintf("failed to get service schema: %v", err),
@@ -203,140 +572,1788 @@ func (s *CatalogServer) GetServiceSchema(
 		return resp, nil
 	}
 
-	// Convert service info to proto format
-	methods := make([]*catalogv1.MethodInfo, len(serviceInfo.Methods))
-	for i, method := range serviceInfo.Methods {
-		methods[i] = &catalogv1.MethodInfo{
-			Name:            method.Name,
-			InputType:       method.InputType,
-			OutputType:      method.OutputType,
-			Documentation:   method.Documentation,
-			ClientStreaming: method.ClientStreaming,
-			ServerStreaming: method.ServerStreaming,
-		}
+	// Skip building the schema when the caller already has the current version
+	if req.Msg.IfNoneMatch != "" && req.Msg.IfNoneMatch == hash {
+		resp := connect.NewResponse(&catalogv1.GetServiceSchemaResponse{
+			Hash:        hash,
+			NotModified: true,
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	// Get service schema from session registry, skipping message schemas
+	// when the caller only wants service and method metadata
+	var serviceInfo *registry.ServiceInfo
+	var messageSchemas map[string]string
+	if req.Msg.IncludeMessageSchemas == nil || req.Msg.GetIncludeMessageSchemas() {
+		serviceInfo, messageSchemas, err = state.Registry.GetServiceSchema(serviceName)
+	} else {
+		serviceInfo, err = state.Registry.GetServiceInfo(serviceName)
+	}
+	if err != nil {
+		resp := connect.NewResponse(&catalogv1.GetServiceSchemaResponse{
+			Error: fmt.Sprintf("failed to get service schema: %v", err),
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
 	}
 
-	protoServiceInfo := &catalogv1.ServiceInfo{
-		Name:          serviceInfo.Name,
-		Package:       serviceInfo.Package,
-		Methods:       methods,
-		Documentation: serviceInfo.Documentation,
+	protoService := toProtoServiceInfo(*serviceInfo)
+	protoService.OriginEndpoints = state.ServiceOrigins(serviceInfo.Name)
+
+	var enumValues map[string]*catalogv1.EnumValues
+	if req.Msg.IncludeEnumValues {
+		if svc, err := state.Registry.GetService(serviceName); err == nil {
+			var roots []*desc.MessageDescriptor
+			for _, method := range svc.GetMethods() {
+				roots = append(roots, method.GetInputType(), method.GetOutputType())
+			}
+			enumValues = toProtoEnumValues(registry.CollectEnumValues(roots...))
+		}
 	}
 
 	resp := connect.NewResponse(&catalogv1.GetServiceSchemaResponse{
-		Service:        protoServiceInfo,
+		Service:        protoService,
 		MessageSchemas: messageSchemas,
+		Hash:           hash,
+		EnumValues:     enumValues,
 	})
 	resp.Header().Set("X-Session-ID", newSessionID)
 	return resp, nil
 }
 
-// InvokeGRPC implements the InvokeGRPC RPC handler
-func (s *CatalogServer) InvokeGRPC(
+// ListMethods implements the ListMethods RPC handler
+func (s *CatalogServer) ListMethods(
 	ctx context.Context,
-	req *connect.Request[catalogv1.InvokeGRPCRequest],
-) (*connect.Response[catalogv1.InvokeGRPCResponse], error) {
+	req *connect.Request[catalogv1.ListMethodsRequest],
+) (*connect.Response[catalogv1.ListMethodsResponse], error) {
 	// Get or create session
 	sessionID := req.Header().Get("X-Session-ID")
-	state, newSessionID, err := s.sessionManager.GetOrCreate(sessionID)
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
+	defer state.Release()
 
-	// Validate required fields
-	if req.Msg.Endpoint == "" {
-		return nil, connect.NewError(
-			connect.CodeInvalidArgument,
-			fmt.Errorf("endpoint is required"),
-		)
-	}
-	if req.Msg.Service == "" {
-		return nil, connect.NewError(
-			connect.CodeInvalidArgument,
-			fmt.Errorf("service is required"),
-		)
-	}
-	if req.Msg.Method == "" {
+	if req.Msg.ServiceName == "" {
 		return nil, connect.NewError(
 			connect.CodeInvalidArgument,
-			fmt.Errorf("method is required"),
+			fmt.Errorf("service_name is required"),
 		)
 	}
 
-	// Get method descriptor from session registry
-	methodDesc, err := state.Registry.GetMethodDescriptor(req.Msg.Service, req.Msg.Method)
+	methods, err := state.Registry.ListMethods(req.Msg.ServiceName)
 	if err != nil {
-		resp := connect.NewResponse(&catalogv1.InvokeGRPCResponse{
-			Success: false,
-			Error:   fmt.Sprintf("method not found: %v", err),
-		})
-		resp.Header().Set("X-Session-ID", newSessionID)
-		return resp, nil
-	}
-
-	// Check for streaming methods (not supported in MVP)
-	if methodDesc.IsClientStreaming() || methodDesc.IsServerStreaming() {
-		resp := connect.NewResponse(&catalogv1.InvokeGRPCResponse{
-			Success: false,
-			Error:   "streaming methods are not supported in MVP (unary only)",
+		resp := connect.NewResponse(&catalogv1.ListMethodsResponse{
+			Error: fmt.Sprintf("failed to list methods: %v", err),
 		})
 		resp.Header().Set("X-Session-ID", newSessionID)
 		return resp, nil
 	}
 
-	// Parse request JSON
-	var requestJSON json.RawMessage
-	if req.Msg.RequestJson != "" {
-		requestJSON = json.RawMessage(req.Msg.RequestJson)
-	} else {
-		requestJSON = json.RawMessage("{}")
+	protoMethods := make([]*catalogv1.MethodSummary, len(methods))
+	for i, method := range methods {
+		protoMethods[i] = &catalogv1.MethodSummary{
+			Name:            method.Name,
+			InputType:       method.InputType,
+			OutputType:      method.OutputType,
+			ClientStreaming: method.ClientStreaming,
+			ServerStreaming: method.ServerStreaming,
+		}
 	}
 
-	// Set default timeout if not specified
-	timeoutSeconds := req.Msg.TimeoutSeconds
-	if timeoutSeconds <= 0 {
-		timeoutSeconds = 30
-	}
+	resp := connect.NewResponse(&catalogv1.ListMethodsResponse{
+		Methods: protoMethods,
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
 
-	// Build invocation request
-	invokeReq := invoker.InvokeRequest{
-		Endpoint:       req.Msg.Endpoint,
-		ServiceName:    req.Msg.Service,
-		MethodName:     req.Msg.Method,
-		RequestJSON:    requestJSON,
-		UseTLS:         req.Msg.UseTls,
-		ServerName:     req.Msg.ServerName,
-		TimeoutSeconds: timeoutSeconds,
-		Metadata:       req.Msg.Metadata,
-		MethodDesc:     methodDesc,
-		Transport:      req.Msg.Transport,
+// ListAllMethods implements the ListAllMethods RPC handler
+func (s *CatalogServer) ListAllMethods(
+	ctx context.Context,
+	req *connect.Request[catalogv1.ListAllMethodsRequest],
+) (*connect.Response[catalogv1.ListAllMethodsResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
 	}
+	defer state.Release()
 
-	// Perform invocation using session invoker
-	invokeResp, err := state.Invoker.InvokeUnary(ctx, invokeReq)
-	if err != nil {
-		resp := connect.NewResponse(&catalogv1.InvokeGRPCResponse{
-			Success: false,
-			Error:   fmt.Sprintf("invocation error: %v", err),
-		})
-		resp.Header().Set("X-Session-ID", newSessionID)
-		return resp, nil
+	methods := state.Registry.ListAllMethods()
+	protoMethods := make([]*catalogv1.MethodRef, len(methods))
+	for i, method := range methods {
+		protoMethods[i] = &catalogv1.MethodRef{
+			Service:       method.Service,
+			Method:        method.Method,
+			StreamingKind: streamingKind(method.ClientStreaming, method.ServerStreaming),
+		}
 	}
 
-	// Convert response
-	resp := connect.NewResponse(&catalogv1.InvokeGRPCResponse{
-		Success:       invokeResp.Success,
-		ResponseJson:  string(invokeResp.ResponseJSON),
-		Error:         invokeResp.Error,
-		Metadata:      invokeResp.Metadata,
-		StatusCode:    invokeResp.StatusCode,
-		StatusMessage: invokeResp.StatusMessage,
+	resp := connect.NewResponse(&catalogv1.ListAllMethodsResponse{
+		Methods: protoMethods,
 	})
 	resp.Header().Set("X-Session-ID", newSessionID)
 	return resp, nil
 }
 
-// Close releases all resources held by the server
+// GetServiceSchemaHash returns a stable hash of a service's schema
+func (s *CatalogServer) GetServiceSchemaHash(
+	ctx context.Context,
+	req *connect.Request[catalogv1.GetServiceSchemaHashRequest],
+) (*connect.Response[catalogv1.GetServiceSchemaHashResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if req.Msg.ServiceName == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("service_name is required"),
+		)
+	}
+
+	hash, err := state.Registry.ServiceSchemaHash(req.Msg.ServiceName)
+	if err != nil {
+		resp := connect.NewResponse(&catalogv1.GetServiceSchemaHashResponse{
+			Error: fmt.Sprintf("failed to hash service schema: %v", err),
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	resp := connect.NewResponse(&catalogv1.GetServiceSchemaHashResponse{
+		Hash: hash,
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// streamingKind classifies a method by its client and server streaming flags
+func streamingKind(clientStreaming, serverStreaming bool) catalogv1.StreamingKind {
+	switch {
+	case clientStreaming && serverStreaming:
+		return catalogv1.StreamingKind_STREAMING_KIND_BIDI_STREAM
+	case clientStreaming:
+		return catalogv1.StreamingKind_STREAMING_KIND_CLIENT_STREAM
+	case serverStreaming:
+		return catalogv1.StreamingKind_STREAMING_KIND_SERVER_STREAM
+	default:
+		return catalogv1.StreamingKind_STREAMING_KIND_UNARY
+	}
+}
+
+// toProtoServiceInfo converts registry service metadata to its proto form
+func toProtoServiceInfo(svc registry.ServiceInfo) *catalogv1.ServiceInfo {
+	methods := make([]*catalogv1.MethodInfo, len(svc.Methods))
+	for i, method := range svc.Methods {
+		methods[i] = &catalogv1.MethodInfo{
+			Name:             method.Name,
+			InputType:        method.InputType,
+			OutputType:       method.OutputType,
+			Documentation:    method.Documentation,
+			ClientStreaming:  method.ClientStreaming,
+			ServerStreaming:  method.ServerStreaming,
+			Options:          method.Options,
+			InputComplexity:  toProtoComplexity(method.InputComplexity),
+			OutputComplexity: toProtoComplexity(method.OutputComplexity),
+			StreamingKind:    streamingKind(method.ClientStreaming, method.ServerStreaming),
+
+			DocumentationAvailable: method.DocumentationAvailable,
+		}
+	}
+
+	return &catalogv1.ServiceInfo{
+		Name:                   svc.Name,
+		Package:                svc.Package,
+		Methods:                methods,
+		Documentation:          svc.Documentation,
+		DocumentationAvailable: svc.DocumentationAvailable,
+		Options:                svc.Options,
+	}
+}
+
+// toProtoComplexity converts a message complexity estimate to its proto form
+func toProtoComplexity(c registry.MessageComplexity) *catalogv1.MessageComplexity {
+	return &catalogv1.MessageComplexity{
+		FieldCount:  int32(c.FieldCount),
+		MaxDepth:    int32(c.MaxDepth),
+		HasRepeated: c.HasRepeated,
+		HasMap:      c.HasMap,
+		Recursive:   c.Recursive,
+		Large:       c.Large,
+	}
+}
+
+// InvokeGRPC implements the InvokeGRPC RPC handler
+func (s *CatalogServer) InvokeGRPC(
+	ctx context.Context,
+	req *connect.Request[catalogv1.InvokeGRPCRequest],
+) (*connect.Response[catalogv1.InvokeGRPCResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	// Validate required fields
+	service, method, err := validateInvokeGRPCRequest(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fan-outs invoke sequentially, so one slot covers the whole request.
+	// Inline descriptor sets are decoded for every call, so they take the
+	// slot even on dry runs.
+	if !req.Msg.DryRun || len(req.Msg.FileDescriptorSet) > 0 {
+		release, err := s.acquireInvocation()
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	// Get method descriptor from session registry, or the request's own
+	// descriptors
+	reg, err := s.methodRegistry(state, req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	methodDesc, err := reg.GetMethodDescriptor(service, method)
+	if err != nil {
+		resp := connect.NewResponse(&catalogv1.InvokeGRPCResponse{
+			Success: false,
+			Error:   fmt.Sprintf("method not found: %v", err),
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	// Check for streaming methods (not supported in MVP)
+	if methodDesc.IsClientStreaming() || methodDesc.IsServerStreaming() {
+		resp := connect.NewResponse(&catalogv1.InvokeGRPCResponse{
+			Success:   false,
+			Error:     "streaming methods are not supported in MVP (unary only)",
+			ErrorKind: catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST,
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	// Build invocation request
+	invokeReq := s.newInvokeRequest(reg, req.Msg, methodDesc)
+	invokeReq.AnyResolver = reg.AnyResolver()
+	// Request metadata overrides the session's endpoint defaults, which
+	// override the server-wide defaults
+	invokeReq.Metadata = mergeDefaultMetadata(state.EndpointDefaults(req.Msg.Endpoint), invokeReq.Metadata)
+
+	// Dry runs report what they would send, so they leave out the server-wide
+	// defaults and never contact the endpoint
+	invoke := dryRunOne
+	if !req.Msg.DryRun {
+		invokeReq.Metadata = mergeDefaultMetadata(s.config.DefaultInvokeMetadata, invokeReq.Metadata)
+		if req.Msg.AutoTransport {
+			probeCtx, cancel := context.WithTimeout(ctx, defaultProbeTimeoutSeconds*time.Second)
+			invokeReq.Transport = state.Invoker.AutoTransport(probeCtx, req.Msg.Endpoint, req.Msg.UseTls, req.Msg.ServerName)
+			cancel()
+		}
+		methodPath := "/" + methodDesc.GetService().GetFullyQualifiedName() + "/" + methodDesc.GetName()
+		invoke = func(call invoker.InvokeRequest) *catalogv1.InvokeGRPCResponse {
+			result := invokeOne(ctx, state.Invoker, call)
+			// The shared session serves every client, so its stats would
+			// mix their calls. Methods from an inline descriptor set are not
+			// part of the session and go unrecorded too.
+			if !state.Shared() && len(req.Msg.FileDescriptorSet) == 0 {
+				// Upstream errors may echo credentials or request values
+				lastError := s.redactor.Error(result.Error, call.Metadata, methodDesc.GetInputType(), call.RequestJSON, reg.Resolver())
+				state.RecordInvocation(methodPath, result.Success, result.StatusCode, lastError)
+			}
+			return result
+		}
+	}
+	if req.Msg.ValidateSchema {
+		invoke = validateSchema(invoke, req.Msg.DryRun)
+	}
+
+	// A JSON array body fans out into one invocation per element
+	if isJSONArray(invokeReq.RequestJSON) {
+		fanOutResp, err := invokeFanOut(invokeReq, invoke)
+		if err != nil {
+			return nil, err
+		}
+		fanOutResp.DryRun = req.Msg.DryRun
+		resp := connect.NewResponse(fanOutResp)
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	// Perform invocation using session invoker
+	resp := connect.NewResponse(invoke(invokeReq))
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// validateSchema wraps invoke so that request JSON which does not match the
+// method's input message is rejected with the offending field before sending
+func validateSchema(invoke func(invoker.InvokeRequest) *catalogv1.InvokeGRPCResponse, dryRun bool) func(invoker.InvokeRequest) *catalogv1.InvokeGRPCResponse {
+	return func(call invoker.InvokeRequest) *catalogv1.InvokeGRPCResponse {
+		err := invoker.ValidateRequestSchema(call)
+		if err == nil {
+			return invoke(call)
+		}
+		resp := &catalogv1.InvokeGRPCResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorKind: catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST,
+			DryRun:    dryRun,
+		}
+		var schemaErr *invoker.SchemaError
+		if errors.As(err, &schemaErr) {
+			resp.ErrorFieldPath = schemaErr.FieldPath
+		}
+		return resp
+	}
+}
+
+// invokeOne performs a single invocation and converts the result to its proto form
+func invokeOne(ctx context.Context, inv *invoker.Invoker, invokeReq invoker.InvokeRequest) *catalogv1.InvokeGRPCResponse {
+	invokeResp, err := inv.InvokeUnary(ctx, invokeReq)
+	if err != nil {
+		return &catalogv1.InvokeGRPCResponse{
+			Success:   false,
+			Error:     fmt.Sprintf("invocation error: %v", err),
+			ErrorKind: catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST,
+		}
+	}
+
+	return &catalogv1.InvokeGRPCResponse{
+		Success:        invokeResp.Success,
+		ResponseJson:   string(invokeResp.ResponseJSON),
+		Error:          invokeResp.Error,
+		Metadata:       invokeResp.Metadata,
+		Headers:        invokeResp.Headers,
+		Trailers:       invokeResp.Trailers,
+		StatusCode:     invokeResp.StatusCode,
+		StatusMessage:  invokeResp.StatusMessage,
+		RequestBytes:   invokeResp.RequestBytes,
+		ResponseBytes:  invokeResp.ResponseBytes,
+		ErrorKind:      invokeResp.ErrorKind,
+		ErrorFieldPath: invokeResp.ErrorFieldPath,
+		TlsVersion:     invokeResp.TLS.Version,
+		TlsCipherSuite: invokeResp.TLS.CipherSuite,
+	}
+}
+
+// dryRunOne builds an invocation without sending it and describes what
+// would be sent
+func dryRunOne(invokeReq invoker.InvokeRequest) *catalogv1.InvokeGRPCResponse {
+	description, err := invoker.Describe(invokeReq)
+	if err != nil {
+		return &catalogv1.InvokeGRPCResponse{
+			Success:   false,
+			Error:     err.Error(),
+			ErrorKind: catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST,
+			DryRun:    true,
+		}
+	}
+
+	return &catalogv1.InvokeGRPCResponse{
+		Success:       true,
+		StatusMessage: "dry run: request not sent",
+		RequestBytes:  description.RequestBytes,
+		DryRun:        true,
+		DryRunRequest: toProtoInvocationDescription(description),
+	}
+}
+
+// toProtoInvocationDescription converts an invocation description to its proto form
+func toProtoInvocationDescription(description *invoker.InvocationDescription) *catalogv1.DescribeInvocationResponse {
+	return &catalogv1.DescribeInvocationResponse{
+		Transport:    description.Transport,
+		HttpMethod:   description.HTTPMethod,
+		Url:          description.URL,
+		FullMethod:   description.FullMethod,
+		Headers:      description.Headers,
+		RequestJson:  string(description.RequestJSON),
+		RequestBytes: description.RequestBytes,
+	}
+}
+
+// DescribeInvocation implements the DescribeInvocation RPC handler
+func (s *CatalogServer) DescribeInvocation(
+	ctx context.Context,
+	req *connect.Request[catalogv1.InvokeGRPCRequest],
+) (*connect.Response[catalogv1.DescribeInvocationResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	// Validate required fields
+	service, method, err := validateInvokeGRPCRequest(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fan-outs invoke sequentially, so one slot covers the whole request.
+	// Inline descriptor sets are decoded for every call, so they take the
+	// slot even on dry runs.
+	if !req.Msg.DryRun || len(req.Msg.FileDescriptorSet) > 0 {
+		release, err := s.acquireInvocation()
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	// Get method descriptor from session registry, or the request's own
+	// descriptors
+	reg, err := s.methodRegistry(state, req.Msg)
+	if err != nil {
+		return nil, err
+	}
+	methodDesc, err := reg.GetMethodDescriptor(service, method)
+	if err != nil {
+		resp := connect.NewResponse(&catalogv1.DescribeInvocationResponse{
+			Error: fmt.Sprintf("method not found: %v", err),
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	describeReq := s.newInvokeRequest(reg, req.Msg, methodDesc)
+	describeReq.AnyResolver = reg.AnyResolver()
+
+	description, err := invoker.Describe(describeReq)
+	if err != nil {
+		resp := connect.NewResponse(&catalogv1.DescribeInvocationResponse{
+			Error: err.Error(),
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	resp := connect.NewResponse(toProtoInvocationDescription(description))
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// DescribeService implements the DescribeService RPC handler
+func (s *CatalogServer) DescribeService(
+	ctx context.Context,
+	req *connect.Request[catalogv1.DescribeServiceRequest],
+) (*connect.Response[catalogv1.DescribeServiceResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if req.Msg.ServiceName == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("service_name is required"),
+		)
+	}
+
+	svc, err := state.Registry.GetService(req.Msg.ServiceName)
+	if err != nil {
+		resp := connect.NewResponse(&catalogv1.DescribeServiceResponse{
+			Error: fmt.Sprintf("failed to describe service: %v", err),
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	endpoint := req.Msg.Endpoint
+	if endpoint == "" {
+		endpoint = defaultDescribeEndpoint
+	}
+	anyResolver := state.Registry.AnyResolver()
+
+	methods := make([]*catalogv1.MethodExample, 0, len(svc.GetMethods()))
+	for _, method := range svc.GetMethods() {
+		example, err := describeMethod(state.Registry, invoker.InvokeRequest{
+			Endpoint:    endpoint,
+			ServiceName: svc.GetFullyQualifiedName(),
+			MethodName:  method.GetName(),
+			UseTLS:      req.Msg.UseTls,
+			MethodDesc:  method,
+			AnyResolver: anyResolver,
+		})
+		if err != nil {
+			resp := connect.NewResponse(&catalogv1.DescribeServiceResponse{
+				Error: fmt.Sprintf("failed to describe method %s: %v", method.GetName(), err),
+			})
+			resp.Header().Set("X-Session-ID", newSessionID)
+			return resp, nil
+		}
+		if req.Msg.IncludeEnumValues {
+			example.EnumValues = toProtoEnumValues(registry.CollectEnumValues(method.GetInputType()))
+		}
+		methods = append(methods, example)
+	}
+
+	resp := connect.NewResponse(&catalogv1.DescribeServiceResponse{
+		Methods: methods,
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// toProtoEnumValues converts registry enum value mappings to their proto form
+func toProtoEnumValues(enums map[string]registry.EnumValues) map[string]*catalogv1.EnumValues {
+	values := make(map[string]*catalogv1.EnumValues, len(enums))
+	for name, enum := range enums {
+		values[name] = &catalogv1.EnumValues{Numbers: enum.Numbers, Names: enum.Names}
+	}
+	return values
+}
+
+// describeMethod builds the example payload and commands for one method
+func describeMethod(reg *registry.Registry, invokeReq invoker.InvokeRequest) (*catalogv1.MethodExample, error) {
+	example, err := reg.GenerateExampleJSON(invokeReq.MethodDesc.GetInputType().GetFullyQualifiedName())
+	if err != nil {
+		return nil, err
+	}
+	invokeReq.RequestJSON = json.RawMessage(example)
+
+	curl, err := invoker.CurlCommand(invokeReq)
+	if err != nil {
+		return nil, err
+	}
+	grpcurl, err := invoker.GrpcurlCommand(invokeReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &catalogv1.MethodExample{
+		Name:               invokeReq.MethodName,
+		FullMethod:         fmt.Sprintf("/%s/%s", invokeReq.ServiceName, invokeReq.MethodName),
+		ExampleRequestJson: example,
+		CurlCommand:        curl,
+		GrpcurlCommand:     grpcurl,
+	}, nil
+}
+
+// GenerateClientCode implements the GenerateClientCode RPC handler
+func (s *CatalogServer) GenerateClientCode(
+	ctx context.Context,
+	req *connect.Request[catalogv1.GenerateClientCodeRequest],
+) (*connect.Response[catalogv1.GenerateClientCodeResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if req.Msg.ServiceName == "" || req.Msg.MethodName == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("service_name and method_name are required"),
+		)
+	}
+	if !codegen.SupportsLanguage(req.Msg.Language) {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("unsupported language: %v", req.Msg.Language),
+		)
+	}
+
+	endpoint := req.Msg.Endpoint
+	if endpoint == "" {
+		endpoint = defaultDescribeEndpoint
+	}
+
+	var code string
+	methodDesc, err := state.Registry.GetMethodDescriptor(req.Msg.ServiceName, req.Msg.MethodName)
+	if err == nil {
+		code, err = codegen.Generate(req.Msg.Language, codegen.Request{
+			Method:   methodDesc,
+			Endpoint: endpoint,
+			UseTLS:   req.Msg.UseTls,
+		})
+	}
+	if err != nil {
+		resp := connect.NewResponse(&catalogv1.GenerateClientCodeResponse{
+			Error: fmt.Sprintf("failed to generate client code: %v", err),
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	resp := connect.NewResponse(&catalogv1.GenerateClientCodeResponse{
+		Code: code,
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// ExportDescriptorsJSON implements the ExportDescriptorsJSON RPC handler
+func (s *CatalogServer) ExportDescriptorsJSON(
+	ctx context.Context,
+	req *connect.Request[catalogv1.ExportDescriptorsJSONRequest],
+) (*connect.Response[catalogv1.ExportDescriptorsJSONResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	data, err := state.Registry.MarshalJSON()
+	if err != nil {
+		resp := connect.NewResponse(&catalogv1.ExportDescriptorsJSONResponse{
+			Error: fmt.Sprintf("failed to export descriptors: %v", err),
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	resp := connect.NewResponse(&catalogv1.ExportDescriptorsJSONResponse{
+		DescriptorSetJson: string(data),
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// DescribeMessage implements the DescribeMessage RPC handler
+func (s *CatalogServer) DescribeMessage(
+	ctx context.Context,
+	req *connect.Request[catalogv1.DescribeMessageRequest],
+) (*connect.Response[catalogv1.DescribeMessageResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if req.Msg.MessageName == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("message_name is required"),
+		)
+	}
+
+	schema, err := state.Registry.DescribeMessage(req.Msg.MessageName)
+	if err != nil {
+		resp := connect.NewResponse(&catalogv1.DescribeMessageResponse{
+			Error: fmt.Sprintf("failed to describe message: %v", err),
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	resp := connect.NewResponse(&catalogv1.DescribeMessageResponse{
+		Schema:             toProtoMessageSchema(schema),
+		ReferencedMessages: schema.ReferencedMessages,
+		ReferencedEnums:    schema.ReferencedEnums,
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// toProtoMessageSchema converts a registry message schema to its proto form
+func toProtoMessageSchema(schema *registry.MessageSchema) *catalogv1.MessageSchema {
+	reservedRanges := make([]*catalogv1.ReservedRange, len(schema.ReservedRanges))
+	for i, r := range schema.ReservedRanges {
+		reservedRanges[i] = &catalogv1.ReservedRange{Start: r.Start, End: r.End}
+	}
+
+	return &catalogv1.MessageSchema{
+		Name:           schema.Name,
+		FullName:       schema.FullName,
+		Documentation:  schema.Documentation,
+		Fields:         toProtoFieldSchemas(schema.Fields),
+		ReservedRanges: reservedRanges,
+		ReservedNames:  schema.ReservedNames,
+	}
+}
+
+// describeFields returns the proto field schemas of a registered message, or
+// none if it can't be described
+func describeFields(reg *registry.Registry, messageName string) []*catalogv1.FieldSchema {
+	schema, err := reg.DescribeMessage(messageName)
+	if err != nil {
+		return nil
+	}
+	return toProtoFieldSchemas(schema.Fields)
+}
+
+// toProtoFieldSchemas converts registry field schemas to their proto form
+func toProtoFieldSchemas(schemaFields []registry.FieldSchema) []*catalogv1.FieldSchema {
+	fields := make([]*catalogv1.FieldSchema, len(schemaFields))
+	for i, field := range schemaFields {
+		fields[i] = &catalogv1.FieldSchema{
+			Name:          field.Name,
+			JsonName:      field.JSONName,
+			Number:        field.Number,
+			Type:          field.Type,
+			TypeName:      field.TypeName,
+			Repeated:      field.Repeated,
+			Map:           field.Map,
+			MapKeyType:    field.MapKeyType,
+			Optional:      field.Optional,
+			Oneof:         field.OneOf,
+			Documentation: field.Documentation,
+		}
+	}
+	return fields
+}
+
+// GetProtoSource implements the GetProtoSource RPC handler
+func (s *CatalogServer) GetProtoSource(
+	ctx context.Context,
+	req *connect.Request[catalogv1.GetProtoSourceRequest],
+) (*connect.Response[catalogv1.GetProtoSourceResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if req.Msg.Service == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("service is required"))
+	}
+
+	respMsg := &catalogv1.GetProtoSourceResponse{}
+	files, err := protoSourceFiles(state.Registry, req.Msg.Service, req.Msg.IncludeImports)
+	if err != nil {
+		respMsg.Error = err.Error()
+	} else {
+		respMsg.Files = files
+	}
+
+	resp := connect.NewResponse(respMsg)
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// protoSourceFiles renders the file declaring a service and, if requested,
+// its imports
+func protoSourceFiles(reg *registry.Registry, service string, includeImports bool) ([]*catalogv1.ProtoSourceFile, error) {
+	svc, err := reg.GetService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{svc.GetFile().GetName()}
+	if includeImports {
+		imports, err := reg.FileImports(names[0])
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, imports...)
+	}
+
+	files := make([]*catalogv1.ProtoSourceFile, 0, len(names))
+	for _, name := range names {
+		source, err := reg.ExportProto(name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &catalogv1.ProtoSourceFile{Name: name, Source: source})
+	}
+	return files, nil
+}
+
+// WarmEndpoints implements the WarmEndpoints RPC handler
+func (s *CatalogServer) WarmEndpoints(
+	ctx context.Context,
+	req *connect.Request[catalogv1.WarmEndpointsRequest],
+) (*connect.Response[catalogv1.WarmEndpointsResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if len(req.Msg.Endpoints) > maxWarmEndpoints {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("too many endpoints: %d (limit %d)", len(req.Msg.Endpoints), maxWarmEndpoints),
+		)
+	}
+	for i, endpoint := range req.Msg.Endpoints {
+		if endpoint.GetEndpoint() == "" {
+			return nil, connect.NewError(
+				connect.CodeInvalidArgument,
+				fmt.Errorf("endpoints[%d]: endpoint is required", i),
+			)
+		}
+		if err := validateTLSSettings(endpoint.GetUseTls(), endpoint.GetServerName()); err != nil {
+			return nil, connect.NewError(
+				connect.CodeInvalidArgument,
+				fmt.Errorf("endpoints[%d]: %w", i, errors.Unwrap(err)),
+			)
+		}
+	}
+
+	timeout := time.Duration(req.Msg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWarmTimeoutSeconds * time.Second
+	}
+
+	// Warm endpoints concurrently; each failure is reported without
+	// affecting the others
+	results := make([]*catalogv1.EndpointWarmResult, len(req.Msg.Endpoints))
+	sem := make(chan struct{}, maxConcurrentWarmups)
+	var wg sync.WaitGroup
+
+	for i, endpoint := range req.Msg.Endpoints {
+		// Acquire before starting the goroutine so at most
+		// maxConcurrentWarmups exist at once
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, endpoint *catalogv1.EndpointConfig) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			release, err := s.acquireInvocation()
+			if err != nil {
+				results[i] = &catalogv1.EndpointWarmResult{Endpoint: endpoint.GetEndpoint(), Error: err.Error()}
+				return
+			}
+			defer release()
+
+			results[i] = warmEndpoint(ctx, state.Invoker, endpoint, timeout)
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	resp := connect.NewResponse(&catalogv1.WarmEndpointsResponse{
+		Results: results,
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// warmEndpoint dials a single endpoint into the invoker's pool and waits for it to be ready
+func warmEndpoint(ctx context.Context, inv *invoker.Invoker, endpoint *catalogv1.EndpointConfig, timeout time.Duration) *catalogv1.EndpointWarmResult {
+	warmCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := inv.WaitForReady(warmCtx, endpoint.GetEndpoint(), endpoint.GetUseTls(), endpoint.GetServerName())
+
+	result := &catalogv1.EndpointWarmResult{
+		Endpoint:  endpoint.GetEndpoint(),
+		Reachable: err == nil,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// InspectTLS implements the InspectTLS RPC handler
+func (s *CatalogServer) InspectTLS(
+	ctx context.Context,
+	req *connect.Request[catalogv1.InspectTLSRequest],
+) (*connect.Response[catalogv1.InspectTLSResponse], error) {
+	if req.Msg.Endpoint == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("endpoint is required"),
+		)
+	}
+
+	timeout := time.Duration(req.Msg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultInspectTLSTimeoutSeconds * time.Second
+	}
+	inspectCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	info, err := invoker.InspectTLS(inspectCtx, req.Msg.Endpoint, req.Msg.ServerName)
+	if err != nil {
+		return connect.NewResponse(&catalogv1.InspectTLSResponse{
+			Error: err.Error(),
+		}), nil
+	}
+
+	certificates := make([]*catalogv1.CertificateInfo, len(info.Certificates))
+	for i, cert := range info.Certificates {
+		certificates[i] = &catalogv1.CertificateInfo{
+			Subject:     cert.Subject,
+			Issuer:      cert.Issuer,
+			DnsNames:    cert.DNSNames,
+			IpAddresses: cert.IPAddresses,
+			NotBefore:   cert.NotBefore.Format(time.RFC3339),
+			NotAfter:    cert.NotAfter.Format(time.RFC3339),
+		}
+	}
+
+	return connect.NewResponse(&catalogv1.InspectTLSResponse{
+		Certificates:      certificates,
+		Verified:          info.Verified,
+		VerificationError: info.VerificationError,
+	}), nil
+}
+
+// ProbeTransports implements the ProbeTransports RPC handler
+func (s *CatalogServer) ProbeTransports(
+	ctx context.Context,
+	req *connect.Request[catalogv1.ProbeTransportsRequest],
+) (*connect.Response[catalogv1.ProbeTransportsResponse], error) {
+	if req.Msg.Endpoint == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("endpoint is required"),
+		)
+	}
+	if err := validateTLSSettings(req.Msg.UseTls, req.Msg.ServerName); err != nil {
+		return nil, err
+	}
+
+	probe := probeTransports(ctx, req.Msg.Endpoint, req.Msg.UseTls, req.Msg.ServerName, req.Msg.TimeoutSeconds)
+
+	results := make([]*catalogv1.TransportProbeResult, len(probe.Results))
+	for i, result := range probe.Results {
+		results[i] = &catalogv1.TransportProbeResult{
+			Transport: result.Transport,
+			Supported: result.Supported,
+			Detail:    result.Detail,
+		}
+	}
+
+	return connect.NewResponse(&catalogv1.ProbeTransportsResponse{
+		Results:     results,
+		Recommended: probe.Recommended,
+	}), nil
+}
+
+// probeTransports runs the transport probes with the given or default timeout
+func probeTransports(ctx context.Context, endpoint string, useTLS bool, serverName string, timeoutSeconds int32) *invoker.TransportProbe {
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultProbeTimeoutSeconds * time.Second
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return invoker.ProbeTransports(probeCtx, endpoint, useTLS, serverName)
+}
+
+// CheckEndpoint implements the CheckEndpoint RPC handler
+func (s *CatalogServer) CheckEndpoint(
+	ctx context.Context,
+	req *connect.Request[catalogv1.CheckEndpointRequest],
+) (*connect.Response[catalogv1.CheckEndpointResponse], error) {
+	if req.Msg.Endpoint == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("endpoint is required"),
+		)
+	}
+	if err := validateTLSSettings(req.Msg.UseTls, req.Msg.ServerName); err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(req.Msg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultProbeTimeoutSeconds * time.Second
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	diagnosis := invoker.DiagnoseEndpoint(checkCtx, req.Msg.Endpoint, req.Msg.UseTls, req.Msg.ServerName, req.Msg.Transport)
+
+	stages := make([]*catalogv1.ConnectivityStageResult, len(diagnosis.Stages))
+	for i, stage := range diagnosis.Stages {
+		stages[i] = &catalogv1.ConnectivityStageResult{
+			Stage:      stage.Stage,
+			Ok:         stage.OK,
+			Detail:     stage.Detail,
+			DurationMs: stage.Duration.Milliseconds(),
+		}
+	}
+
+	return connect.NewResponse(&catalogv1.CheckEndpointResponse{
+		Reachable:   diagnosis.Reachable(),
+		FailedStage: diagnosis.FailedStage,
+		Stages:      stages,
+	}), nil
+}
+
+// GetServerConfig implements the GetServerConfig RPC handler
+func (s *CatalogServer) GetServerConfig(
+	ctx context.Context,
+	req *connect.Request[catalogv1.GetServerConfigRequest],
+) (*connect.Response[catalogv1.GetServerConfigResponse], error) {
+	return connect.NewResponse(&catalogv1.GetServerConfigResponse{
+		MaxConnections:               int32(s.config.MaxConnections),
+		ConnectionTtlSeconds:         int64(s.config.ConnectionTTL / time.Second),
+		SessionTtlSeconds:            int64(s.config.SessionTTL / time.Second),
+		ConnectionIdleTimeoutSeconds: int64(s.config.ConnectionIdleTimeout / time.Second),
+	}), nil
+}
+
+// GetResourceReport implements the GetResourceReport RPC handler
+func (s *CatalogServer) GetResourceReport(
+	ctx context.Context,
+	req *connect.Request[catalogv1.GetResourceReportRequest],
+) (*connect.Response[catalogv1.GetResourceReportResponse], error) {
+	report := &catalogv1.GetResourceReportResponse{
+		UptimeSeconds: int64(time.Since(s.startedAt) / time.Second),
+	}
+
+	for _, state := range s.sessionManager.States() {
+		report.SessionCount++
+		if state.Registry != nil {
+			stats := state.Registry.GetStats()
+			report.FileCount += int32(stats.FileCount)
+			report.ServiceCount += int32(stats.ServiceCount)
+			report.MessageCount += int32(stats.MessageCount)
+			report.RegistryBytes += state.Registry.EncodedSize()
+		}
+		if state.Invoker != nil {
+			report.PooledConnections += int32(state.Invoker.GetConnectionStats().TotalConnections)
+		}
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	report.Memory = &catalogv1.RuntimeMemory{
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapInuseBytes: mem.HeapInuse,
+		SysBytes:       mem.Sys,
+		NumGc:          mem.NumGC,
+		Goroutines:     int32(runtime.NumGoroutine()),
+	}
+
+	return connect.NewResponse(report), nil
+}
+
+// CompileProto implements the CompileProto RPC handler
+func (s *CatalogServer) CompileProto(
+	ctx context.Context,
+	req *connect.Request[catalogv1.CompileProtoRequest],
+) (*connect.Response[catalogv1.CompileProtoResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if req.Msg.Content == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("content is required"),
+		)
+	}
+
+	filename := req.Msg.Filename
+	if filename == "" {
+		filename = defaultCompileFilename
+	}
+
+	result, err := loader.CompileProto(filename, req.Msg.Content, req.Msg.AdditionalFiles)
+	if err != nil {
+		resp := connect.NewResponse(&catalogv1.CompileProtoResponse{
+			Success: false,
+			Error:   fmt.Sprintf("failed to compile: %v", err),
+		})
+		resp.Header().Set("X-Session-ID", newSessionID)
+		return resp, nil
+	}
+
+	compileErrors := make([]*catalogv1.CompileError, len(result.Errors))
+	for i, compileErr := range result.Errors {
+		compileErrors[i] = &catalogv1.CompileError{
+			Filename: compileErr.Filename,
+			Line:     int32(compileErr.Line),
+			Column:   int32(compileErr.Column),
+			Message:  compileErr.Message,
+		}
+	}
+
+	msg := &catalogv1.CompileProtoResponse{
+		Success:  len(result.Errors) == 0,
+		Errors:   compileErrors,
+		Services: result.Info.Services,
+		Messages: result.Info.Messages,
+		Enums:    result.Info.Enums,
+	}
+
+	// Only touch the session registry when explicitly asked to
+	if msg.Success && req.Msg.Register {
+		if err := checkWritable(state); err != nil {
+			return nil, err
+		}
+
+		state.LoadMu.Lock()
+		err := state.Registry.Register(result.Descriptors)
+		state.LoadMu.Unlock()
+
+		if err != nil {
+			msg.Error = fmt.Sprintf("failed to register descriptors: %v", err)
+		} else {
+			msg.Registered = true
+		}
+	}
+
+	resp := connect.NewResponse(msg)
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// CompactSession implements the CompactSession RPC handler
+func (s *CatalogServer) CompactSession(
+	ctx context.Context,
+	req *connect.Request[catalogv1.CompactSessionRequest],
+) (*connect.Response[catalogv1.CompactSessionResponse], error) {
+	sessionID := req.Header().Get("X-Session-ID")
+	if sessionID == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("X-Session-ID header is required"),
+		)
+	}
+
+	// Get would mark the session used and restore the registry about to
+	// be spilled
+	if state := s.sessionManager.Lookup(sessionID); state != nil {
+		if err := checkWritable(state); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &catalogv1.CompactSessionResponse{}
+	spilled, err := s.sessionManager.Compact(sessionID)
+	switch {
+	case errors.Is(err, session.ErrCompactionDisabled), errors.Is(err, session.ErrSessionShared):
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	case errors.Is(err, session.ErrSessionNotFound):
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	case err != nil:
+		resp.Error = err.Error()
+	default:
+		resp.SpilledBytes = spilled
+	}
+
+	response := connect.NewResponse(resp)
+	response.Header().Set("X-Session-ID", sessionID)
+	return response, nil
+}
+
+// SetEndpointDefaults implements the SetEndpointDefaults RPC handler
+func (s *CatalogServer) SetEndpointDefaults(
+	ctx context.Context,
+	req *connect.Request[catalogv1.SetEndpointDefaultsRequest],
+) (*connect.Response[catalogv1.SetEndpointDefaultsResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if err := checkWritable(state); err != nil {
+		return nil, err
+	}
+
+	if req.Msg.Endpoint == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("endpoint is required"),
+		)
+	}
+
+	state.SetEndpointDefaults(req.Msg.Endpoint, req.Msg.Metadata)
+
+	resp := connect.NewResponse(&catalogv1.SetEndpointDefaultsResponse{
+		Endpoints: state.DefaultedEndpoints(),
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// GetPackageStats implements the GetPackageStats RPC handler
+func (s *CatalogServer) GetPackageStats(
+	ctx context.Context,
+	req *connect.Request[catalogv1.GetPackageStatsRequest],
+) (*connect.Response[catalogv1.GetPackageStatsResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	packageStats := state.Registry.GetPackageStats()
+	packages := make([]*catalogv1.PackageStats, 0, len(packageStats))
+	for pkg, stats := range packageStats {
+		packages = append(packages, &catalogv1.PackageStats{
+			Package:      pkg,
+			FileCount:    int32(stats.FileCount),
+			ServiceCount: int32(stats.ServiceCount),
+			MessageCount: int32(stats.MessageCount),
+			EnumCount:    int32(stats.EnumCount),
+		})
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Package < packages[j].Package
+	})
+
+	resp := connect.NewResponse(&catalogv1.GetPackageStatsResponse{
+		Packages: packages,
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// SetCredentialProvider implements the SetCredentialProvider RPC handler
+func (s *CatalogServer) SetCredentialProvider(
+	ctx context.Context,
+	req *connect.Request[catalogv1.SetCredentialProviderRequest],
+) (*connect.Response[catalogv1.SetCredentialProviderResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if err := checkWritable(state); err != nil {
+		return nil, err
+	}
+
+	if req.Msg.Name == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("name is required"),
+		)
+	}
+
+	var provider invoker.CredentialProvider
+	switch p := req.Msg.Provider.(type) {
+	case *catalogv1.SetCredentialProviderRequest_BearerToken:
+		if p.BearerToken.GetToken() == "" {
+			return nil, connect.NewError(
+				connect.CodeInvalidArgument,
+				fmt.Errorf("token is required"),
+			)
+		}
+		provider = invoker.BearerTokenCredentials{Token: p.BearerToken.GetToken()}
+	case *catalogv1.SetCredentialProviderRequest_Mtls:
+		mtls, err := invoker.NewMTLSCredentials([]byte(p.Mtls.GetCertPem()), []byte(p.Mtls.GetKeyPem()))
+		if err != nil {
+			resp := connect.NewResponse(&catalogv1.SetCredentialProviderResponse{
+				Providers: state.Invoker.CredentialProviders(),
+				Error:     err.Error(),
+			})
+			resp.Header().Set("X-Session-ID", newSessionID)
+			return resp, nil
+		}
+		provider = mtls
+	}
+
+	state.Invoker.SetCredentialProvider(req.Msg.Name, provider)
+
+	resp := connect.NewResponse(&catalogv1.SetCredentialProviderResponse{
+		Providers: state.Invoker.CredentialProviders(),
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// SetOAuthCredentials implements the SetOAuthCredentials RPC handler
+func (s *CatalogServer) SetOAuthCredentials(
+	ctx context.Context,
+	req *connect.Request[catalogv1.SetOAuthCredentialsRequest],
+) (*connect.Response[catalogv1.SetOAuthCredentialsResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if err := checkWritable(state); err != nil {
+		return nil, err
+	}
+
+	if req.Msg.Name == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("name is required"),
+		)
+	}
+	if req.Msg.ClientId == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("client_id is required"),
+		)
+	}
+	tokenURL, err := url.Parse(req.Msg.TokenUrl)
+	if err != nil || (tokenURL.Scheme != "https" && tokenURL.Scheme != "http") || tokenURL.Host == "" {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("token_url must be an http or https URL"),
+		)
+	}
+
+	state.Invoker.SetCredentialProvider(req.Msg.Name, &invoker.OAuth2ClientCredentials{
+		TokenURL:     req.Msg.TokenUrl,
+		ClientID:     req.Msg.ClientId,
+		ClientSecret: req.Msg.ClientSecret,
+		Scopes:       req.Msg.Scopes,
+	})
+
+	resp := connect.NewResponse(&catalogv1.SetOAuthCredentialsResponse{
+		Providers: state.Invoker.CredentialProviders(),
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// RefreshReflection implements the RefreshReflection RPC handler
+func (s *CatalogServer) RefreshReflection(
+	ctx context.Context,
+	req *connect.Request[catalogv1.RefreshReflectionRequest],
+) (*connect.Response[catalogv1.RefreshReflectionResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	if err := checkWritable(state); err != nil {
+		return nil, err
+	}
+
+	if req.Msg.MaxAgeSeconds < 0 {
+		return nil, connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("max_age_seconds must not be negative"),
+		)
+	}
+	maxAge := time.Duration(req.Msg.MaxAgeSeconds) * time.Second
+
+	// Refreshes replace registrations, so they queue with loads
+	state.LoadMu.Lock()
+	defer state.LoadMu.Unlock()
+
+	sources := state.ReflectionSources()
+	var results []*catalogv1.ReflectionRefreshResult
+	for i, source := range sources {
+		if req.Msg.Endpoint != "" && !containsString(source.Endpoints, req.Msg.Endpoint) {
+			continue
+		}
+		if maxAge > 0 && time.Since(source.LoadedAt) < maxAge {
+			results = append(results, &catalogv1.ReflectionRefreshResult{Endpoints: source.Endpoints})
+			continue
+		}
+		others := append(append([]session.ReflectionSource(nil), sources[:i]...), sources[i+1:]...)
+		results = append(results, refreshSource(state, source, others))
+	}
+
+	respMsg := &catalogv1.RefreshReflectionResponse{Results: results}
+	if len(results) == 0 {
+		if req.Msg.Endpoint != "" {
+			respMsg.Error = fmt.Sprintf("no reflection source loaded from %s", req.Msg.Endpoint)
+		} else {
+			respMsg.Error = "no reflection sources loaded in this session"
+		}
+	}
+
+	resp := connect.NewResponse(respMsg)
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// refreshSource re-queries a reflection source, bypassing the reflection
+// cache, and swaps its descriptors into the session registry. On failure the
+// previous descriptors are kept. The caller must hold state.LoadMu.
+func refreshSource(state *session.State, source session.ReflectionSource, others []session.ReflectionSource) *catalogv1.ReflectionRefreshResult {
+	result := &catalogv1.ReflectionRefreshResult{Endpoints: source.Endpoints}
+
+	for _, endpoint := range source.Endpoints {
+		loader.InvalidateReflectionCache(endpoint)
+	}
+
+	var fds *descriptorpb.FileDescriptorSet
+	var services []string
+	var origins map[string][]string
+	if len(source.Endpoints) == 1 {
+		loaded, err := loader.LoadFromReflectionWithWarnings(source.Endpoints[0], source.Options)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to load from reflection: %v", err)
+			return result
+		}
+		fds = loaded.Descriptors
+		services = loaded.Services
+		result.Warnings = loaded.Warnings
+		origins = make(map[string][]string, len(services))
+		for _, svc := range services {
+			origins[svc] = source.Endpoints
+		}
+	} else {
+		loaded, err := loader.LoadFromReflectionEndpoints(source.Endpoints, source.Options)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to load from reflection: %v", err)
+			return result
+		}
+		// A partial view would drop the services of unreachable endpoints
+		if len(loaded.Failures) > 0 {
+			failed := toProtoEndpointErrors(loaded.Failures)[0]
+			result.Error = fmt.Sprintf("failed to load from %s: %s", failed.Endpoint, failed.Error)
+			return result
+		}
+		fds = loaded.Descriptors
+		services = sortedKeys(loaded.ServiceOrigins)
+		result.Warnings = loaded.Warnings
+		origins = loaded.ServiceOrigins
+	}
+	if source.StripSourceInfo {
+		registry.StripSourceInfo(fds)
+	}
+
+	oldHashes := make(map[string]string, len(source.Services))
+	for _, svc := range source.Services {
+		if hash, err := state.Registry.ServiceSchemaHash(svc); err == nil {
+			oldHashes[svc] = hash
+		}
+	}
+
+	// Files another reflection source also registered stay until that
+	// source is refreshed. RemoveFiles also keeps files that any other
+	// registered file, however it was loaded, still imports.
+	var stale []string
+	for _, file := range source.Files {
+		shared := false
+		for _, other := range others {
+			if containsString(other.Files, file) {
+				shared = true
+				break
+			}
+		}
+		if !shared {
+			stale = append(stale, file)
+		}
+	}
+
+	// Build the refreshed registry aside so readers never see it half done
+	updated := state.Registry.Clone()
+	updated.RemoveFiles(stale...)
+	fileErrs := updated.RegisterBestEffort(fds)
+	if len(fileErrs) > 0 && len(fileErrs) == len(fds.GetFile()) {
+		result.Error = fmt.Sprintf("failed to register descriptors: %v", fileErrs[0])
+		return result
+	}
+	if err := state.Registry.Restore(updated.Snapshot()); err != nil {
+		result.Error = fmt.Sprintf("failed to update registry: %v", err)
+		return result
+	}
+	if len(fileErrs) > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("loaded %d of %d files", len(fds.File)-len(fileErrs), len(fds.File)))
+	}
+
+	for _, svc := range services {
+		oldHash, existed := oldHashes[svc]
+		if !containsString(source.Services, svc) {
+			result.AddedServices = append(result.AddedServices, svc)
+		} else if hash, err := state.Registry.ServiceSchemaHash(svc); err == nil && existed && hash != oldHash {
+			result.ChangedServices = append(result.ChangedServices, svc)
+		}
+	}
+	for _, svc := range source.Services {
+		if !containsString(services, svc) {
+			result.RemovedServices = append(result.RemovedServices, svc)
+		}
+	}
+
+	// The source's endpoints are recorded afresh for what they serve now;
+	// services whose files were removed lose every origin
+	state.RemoveServiceOrigins(source.Services, source.Endpoints)
+	var unregistered []string
+	for _, svc := range source.Services {
+		if !state.Registry.HasService(svc) {
+			unregistered = append(unregistered, svc)
+		}
+	}
+	state.DeleteServiceOrigins(unregistered...)
+	state.AddServiceOrigins(origins)
+
+	source.Files = fileNames(withoutFailedFiles(fds, fileErrs))
+	source.Services = services
+	source.LoadedAt = time.Now()
+	state.SetReflectionSource(source)
+
+	result.Refreshed = true
+	return result
+}
+
+// InvokeReflective implements the InvokeReflective RPC handler
+func (s *CatalogServer) InvokeReflective(
+	ctx context.Context,
+	req *connect.Request[catalogv1.InvokeGRPCRequest],
+) (*connect.Response[catalogv1.InvokeGRPCResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	// Validate required fields
+	service, method, err := validateInvokeGRPCRequest(req.Msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Methods the session already knows, or the request carries descriptors
+	// for, are invoked without reflecting, so only the first call to an
+	// endpoint pays for discovery
+	_, err = state.Registry.GetMethodDescriptor(service, method)
+	if err != nil && len(req.Msg.FileDescriptorSet) == 0 {
+		if err := checkWritable(state); err != nil {
+			return nil, err
+		}
+		if loadErr := s.loadReflective(state, newSessionID, req.Msg, service); loadErr != "" {
+			errorKind := catalogv1.ErrorKind_ERROR_KIND_CONNECTION
+			if invoker.IsTLSError(errors.New(loadErr)) {
+				errorKind = catalogv1.ErrorKind_ERROR_KIND_TLS
+			}
+			resp := connect.NewResponse(&catalogv1.InvokeGRPCResponse{
+				Success:   false,
+				Error:     loadErr,
+				ErrorKind: errorKind,
+			})
+			resp.Header().Set("X-Session-ID", newSessionID)
+			return resp, nil
+		}
+	}
+
+	invokeReq := connect.NewRequest(req.Msg)
+	invokeReq.Header().Set("X-Session-ID", newSessionID)
+	return s.InvokeGRPC(ctx, invokeReq)
+}
+
+// loadReflective loads every service of an invocation's endpoint into the
+// session via reflection, using the invocation's TLS settings, and checks
+// that it includes service. It returns a description of the failure, or ""
+// on success.
+func (s *CatalogServer) loadReflective(state *session.State, sessionID string, msg *catalogv1.InvokeGRPCRequest, service string) string {
+	loadMsg := &catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_ReflectionEndpoint{ReflectionEndpoint: msg.Endpoint},
+		ReflectionOptions: &catalogv1.ReflectionOptions{
+			UseTls:         msg.UseTls,
+			ServerName:     msg.ServerName,
+			Authority:      msg.Authority,
+			TimeoutSeconds: msg.TimeoutSeconds,
+		},
+	}
+	// Infrastructure services are skipped by default, so calling one loads
+	// just that service
+	if containsString(loader.DefaultExcludedServices, service) {
+		loadMsg.ReflectionOptions.IncludeServices = []string{service}
+	}
+
+	// Concurrent first calls to an endpoint share one load
+	key, err := loadKey(sessionID, loadMsg)
+	if err != nil {
+		return err.Error()
+	}
+	result, _, _ := s.loads.Do(key, func() (any, error) {
+		state.LoadMu.Lock()
+		defer state.LoadMu.Unlock()
+		return s.loadSource(state, loadMsg, nil), nil
+	})
+
+	loaded := result.(*catalogv1.LoadProtosResponse)
+	if !loaded.Success {
+		return fmt.Sprintf("failed to resolve %s via reflection: %s", service, loaded.Error)
+	}
+	if !state.Registry.HasService(service) {
+		return fmt.Sprintf("service %s not found via reflection on %s", service, msg.Endpoint)
+	}
+	return ""
+}
+
+// GetMethodStats implements the GetMethodStats RPC handler
+func (s *CatalogServer) GetMethodStats(
+	ctx context.Context,
+	req *connect.Request[catalogv1.GetMethodStatsRequest],
+) (*connect.Response[catalogv1.GetMethodStatsResponse], error) {
+	// Get or create session
+	sessionID := req.Header().Get("X-Session-ID")
+	state, newSessionID, err := s.sessionManager.Acquire(sessionID)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	defer state.Release()
+
+	methodStats := state.MethodStats()
+	methods := make(map[string]*catalogv1.MethodInvocationStats, len(methodStats))
+	var total int64
+	for method, stats := range methodStats {
+		methods[method] = &catalogv1.MethodInvocationStats{
+			Invocations:       stats.Invocations,
+			Failures:          stats.Failures,
+			LastSuccess:       stats.LastSuccess,
+			LastStatusCode:    stats.LastStatusCode,
+			LastError:         stats.LastError,
+			LastInvokedUnixMs: stats.LastInvokedAt.UnixMilli(),
+		}
+		total += stats.Invocations
+	}
+
+	resp := connect.NewResponse(&catalogv1.GetMethodStatsResponse{
+		Methods:          methods,
+		TotalInvocations: total,
+	})
+	resp.Header().Set("X-Session-ID", newSessionID)
+	return resp, nil
+}
+
+// validateInvokeGRPCRequest checks the fields required to target a method
+// and returns the service and method named. A full method path in Method is
+// split into the two when Service is empty; msg is left unchanged.
+func validateInvokeGRPCRequest(msg *catalogv1.InvokeGRPCRequest) (string, string, error) {
+	if msg.Endpoint == "" {
+		return "", "", connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("endpoint is required"),
+		)
+	}
+	service, method := msg.Service, msg.Method
+	if service == "" && strings.Contains(method, "/") {
+		var err error
+		service, method, err = registry.SplitMethodPath(method)
+		if err != nil {
+			return "", "", connect.NewError(connect.CodeInvalidArgument, err)
+		}
+	}
+	if service == "" {
+		return "", "", connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("service is required"),
+		)
+	}
+	if method == "" {
+		return "", "", connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("method is required"),
+		)
+	}
+	if err := validateTLSSettings(msg.UseTls, msg.ServerName); err != nil {
+		return "", "", err
+	}
+	return service, method, nil
+}
+
+// methodRegistry returns the registry an invocation's method is resolved
+// against: the session's, or one built from the request's inline descriptor
+// set for this call alone
+func (s *CatalogServer) methodRegistry(state *session.State, msg *catalogv1.InvokeGRPCRequest) (*registry.Registry, error) {
+	if len(msg.FileDescriptorSet) == 0 {
+		return state.Registry, nil
+	}
+
+	fds, err := loader.DecodeDescriptorSet(msg.FileDescriptorSet, s.config.MaxDescriptorSetSize)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid file_descriptor_set: %w", err))
+	}
+	reg := registry.New()
+	if err := reg.Register(fds); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid file_descriptor_set: %w", err))
+	}
+	return reg, nil
+}
+
+// validateTLSSettings rejects TLS-only settings on a plaintext connection,
+// where they would be silently ignored. TLS is never enabled implicitly.
+func validateTLSSettings(useTLS bool, serverName string) error {
+	if !useTLS && serverName != "" {
+		return connect.NewError(
+			connect.CodeInvalidArgument,
+			fmt.Errorf("server_name %q requires use_tls", serverName),
+		)
+	}
+	return nil
+}
+
+// newInvokeRequest builds an invoker request from the RPC message, applying
+// defaults. methodDesc must come from reg.
+func (s *CatalogServer) newInvokeRequest(reg *registry.Registry, msg *catalogv1.InvokeGRPCRequest, methodDesc *desc.MethodDescriptor) invoker.InvokeRequest {
+	// Set default timeout if not specified
+	timeoutSeconds := msg.TimeoutSeconds
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = 30
+	}
+
+	// Large responses may exceed gRPC's default 4MB receive limit
+	var maxRecvMsgSize int
+	output, err := reg.MessageComplexity(methodDesc.GetOutputType().GetFullyQualifiedName())
+	if err == nil && output.Large {
+		maxRecvMsgSize = largeResponseMaxRecvMsgSize
+	}
+
+	// Requests without their own Connect protocol version use the server's
+	connectVersion := msg.ConnectProtocolVersion
+	if connectVersion == nil {
+		version := s.config.ConnectProtocolVersion
+		connectVersion = &version
+	}
+
+	return invoker.InvokeRequest{
+		Endpoint:       msg.Endpoint,
+		ServiceName:    methodDesc.GetService().GetFullyQualifiedName(),
+		MethodName:     methodDesc.GetName(),
+		RequestJSON:    invoker.NormalizeRequestJSON(json.RawMessage(msg.RequestJson)),
+		UseTLS:         msg.UseTls,
+		ServerName:     msg.ServerName,
+		TimeoutSeconds: timeoutSeconds,
+		Metadata:       msg.Metadata,
+		MethodDesc:     methodDesc,
+		Transport:      msg.Transport,
+		Authority:      msg.Authority,
+		UseGET:         msg.UseGet,
+		MaxRecvMsgSize: maxRecvMsgSize,
+		Credentials:    msg.Credentials,
+		ConnectVersion: connectVersion,
+		Encoding:       msg.ConnectEncoding,
+		ForceHTTP1:     msg.ForceHttp1,
+		EnumsAsInts:    msg.EnumsAsInts,
+	}
+}
+
+// Close releases all resources held by the server
 func (s *CatalogServer) Close() error {
 	if s.sessionManager != nil {
 		s.sessionManager.Close()
@@ -344,6 +2361,23 @@ func (s *CatalogServer) Close() error {
 	return nil
 }
 
+// SealSharedSession makes the shared session read-only once it has been
+// loaded, so clients can browse and invoke it but not change it. It does
+// nothing without a shared session.
+func (s *CatalogServer) SealSharedSession() {
+	if s.config.SharedSessionID == "" {
+		return
+	}
+	if state := s.sessionManager.Get(s.config.SharedSessionID); state != nil {
+		state.SetReadOnly(true)
+	}
+}
+
+// GetConfig returns the effective server configuration
+func (s *CatalogServer) GetConfig() Config {
+	return s.config
+}
+
 // GetSessionManager returns the session manager (for testing/inspection)
 func (s *CatalogServer) GetSessionManager() *session.Manager {
 	return s.sessionManager
@@ -352,19 +2386,42 @@ func (s *CatalogServer) GetSessionManager() *session.Manager {
 // Stats returns server statistics
 type Stats struct {
 	SessionStats session.Stats
+	// Invocations currently in flight across all sessions
+	InFlightInvocations int
+	// Configured limit on in-flight invocations
+	MaxConcurrentInvocations int
 }
 
 // GetStats returns current server statistics
 func (s *CatalogServer) GetStats() Stats {
 	return Stats{
-		SessionStats: s.sessionManager.GetStats(),
+		SessionStats:             s.sessionManager.GetStats(),
+		InFlightInvocations:      len(s.invocations),
+		MaxConcurrentInvocations: cap(s.invocations),
 	}
 }
 
-// ValidateSetup checks if the server is properly configured
-func (s *CatalogServer) ValidateSetup() error {
+// bufInstallationCheck verifies buf is available; replaced in tests
+var bufInstallationCheck = loader.ValidateBufInstallation
+
+// ValidateSetup checks if the server is properly configured. Problems that
+// only limit some features, such as a missing buf binary, are returned as
+// warnings rather than errors.
+func (s *CatalogServer) ValidateSetup() ([]string, error) {
 	if s.sessionManager == nil {
-		return fmt.Errorf("session manager is nil")
+		return nil, fmt.Errorf("session manager is nil")
 	}
-	return nil
+
+	var warnings []string
+	if s.config.CheckBuf {
+		if err := bufInstallationCheck(); err != nil {
+			warnings = append(warnings, fmt.Sprintf(
+				"%v; loading from proto_path, proto_repo and buf_module will fail. "+
+					"Install buf (https://buf.build/docs/installation) or load descriptors "+
+					"without it via reflection_endpoint, descriptor_set_path, descriptor_set_url or CompileProto",
+				err,
+			))
+		}
+	}
+	return warnings, nil
 }
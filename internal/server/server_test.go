@@ -2,10 +2,23 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"connectrpc.com/connect"
 	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 // TestLoadProtos tests loading proto files from a local path
@@ -79,6 +92,289 @@ func TestLoadProtos_InvalidPath(t *testing.T) {
 	}
 }
 
+// TestLoadProtos_Concurrent tests concurrent loads of one source into the same session
+func TestLoadProtos_Concurrent(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	path := writeTestDescriptorSet(t)
+
+	_, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	const loads = 10
+	var wg sync.WaitGroup
+	errs := make(chan string, loads)
+	for i := 0; i < loads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			req := connect.NewRequest(descriptorSetSource(path))
+			req.Header().Set("X-Session-ID", sessionID)
+
+			resp, err := server.LoadProtos(context.Background(), req)
+			if err != nil {
+				errs <- err.Error()
+				return
+			}
+			if !resp.Msg.Success || resp.Msg.ServiceCount != 1 {
+				errs <- fmt.Sprintf("unexpected response: %v", resp.Msg)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for msg := range errs {
+		t.Error(msg)
+	}
+
+	state := server.sessionManager.Get(sessionID)
+	if state == nil {
+		t.Fatal("Session disappeared")
+	}
+	if stats := state.Registry.GetStats(); stats.ServiceCount != 1 || stats.FileCount != 1 {
+		t.Errorf("Expected one file and service, got %+v", stats)
+	}
+}
+
+// TestLoadProtos_PartialFailure tests that files with unresolved imports are skipped and reported
+func TestLoadProtos_PartialFailure(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	fds := createTestFileDescriptorSet()
+	fds.File = append(fds.File, &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("broken.proto"),
+		Package:    proto.String("broken.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"missing.proto"},
+	})
+
+	resp, err := server.LoadProtos(context.Background(), connect.NewRequest(descriptorSetSource(writeDescriptorSet(t, fds))))
+	if err != nil {
+		t.Fatalf("LoadProtos failed: %v", err)
+	}
+
+	if !resp.Msg.Success {
+		t.Fatalf("Expected partial success, got error: %s", resp.Msg.Error)
+	}
+	if resp.Msg.FileCount != 1 || resp.Msg.ServiceCount != 1 {
+		t.Errorf("Expected 1 file and 1 service, got %d and %d", resp.Msg.FileCount, resp.Msg.ServiceCount)
+	}
+	if len(resp.Msg.FileErrors) != 1 || resp.Msg.FileErrors[0].File != "broken.proto" {
+		t.Errorf("Expected broken.proto to be reported, got %v", resp.Msg.FileErrors)
+	}
+	if len(resp.Msg.Warnings) != 1 || resp.Msg.Warnings[0] != "loaded 1 of 2 files" {
+		t.Errorf("Unexpected warnings: %v", resp.Msg.Warnings)
+	}
+}
+
+// TestLoadProtos_DescriptorSetJSON tests loading a protojson descriptor set
+func TestLoadProtos_DescriptorSetJSON(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	data, err := protojson.Marshal(createTestFileDescriptorSet())
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+
+	resp, err := server.LoadProtos(context.Background(), connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_DescriptorSetJson{DescriptorSetJson: string(data)},
+	}))
+	if err != nil {
+		t.Fatalf("LoadProtos failed: %v", err)
+	}
+	if !resp.Msg.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Msg.Error)
+	}
+	if resp.Msg.ServiceCount != 1 {
+		t.Errorf("Expected 1 service, got %d", resp.Msg.ServiceCount)
+	}
+
+	resp, err = server.LoadProtos(context.Background(), connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_DescriptorSetJson{DescriptorSetJson: `{"file": [`},
+	}))
+	if err != nil {
+		t.Fatalf("LoadProtos failed: %v", err)
+	}
+	if resp.Msg.Success || !strings.Contains(resp.Msg.Error, "failed to decode descriptor set JSON") {
+		t.Errorf("Expected decode error, got success=%v error=%q", resp.Msg.Success, resp.Msg.Error)
+	}
+}
+
+// TestLoadProtos_DescriptorSetDir tests loading a directory of descriptor sets
+func TestLoadProtos_DescriptorSetDir(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	data, err := proto.Marshal(createTestFileDescriptorSet())
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.bin", "b.pb"} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatalf("Failed to write descriptor set: %v", err)
+		}
+	}
+
+	resp, err := server.LoadProtos(context.Background(), connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_DescriptorSetDir{DescriptorSetDir: dir},
+	}))
+	if err != nil {
+		t.Fatalf("LoadProtos failed: %v", err)
+	}
+	if !resp.Msg.Success {
+		t.Fatalf("Expected success, got error: %s", resp.Msg.Error)
+	}
+	if resp.Msg.FileCount != 1 || resp.Msg.ServiceCount != 1 {
+		t.Errorf("Expected 1 file and 1 service, got %d and %d", resp.Msg.FileCount, resp.Msg.ServiceCount)
+	}
+}
+
+// TestLoadProtos_StripSourceInfo tests that source info is dropped only on request
+func TestLoadProtos_StripSourceInfo(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	fds := createTestFileDescriptorSet()
+	fds.File[0].SourceCodeInfo = &descriptorpb.SourceCodeInfo{
+		Location: []*descriptorpb.SourceCodeInfo_Location{{
+			Path:            []int32{6, 0}, // first service
+			Span:            []int32{0, 0, 0},
+			LeadingComments: proto.String(" Test service.\n"),
+		}},
+	}
+	data, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+
+	for _, strip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("strip=%v", strip), func(t *testing.T) {
+			resp, err := server.LoadProtos(context.Background(), connect.NewRequest(&catalogv1.LoadProtosRequest{
+				Source:          &catalogv1.LoadProtosRequest_DescriptorSet{DescriptorSet: data},
+				StripSourceInfo: strip,
+			}))
+			if err != nil {
+				t.Fatalf("LoadProtos failed: %v", err)
+			}
+			if !resp.Msg.Success {
+				t.Fatalf("Expected success, got error: %s", resp.Msg.Error)
+			}
+
+			schemaReq := connect.NewRequest(&catalogv1.GetServiceSchemaRequest{ServiceName: "test.v1.TestService"})
+			schemaReq.Header().Set("X-Session-ID", resp.Header().Get("X-Session-ID"))
+			schemaResp, err := server.GetServiceSchema(context.Background(), schemaReq)
+			if err != nil {
+				t.Fatalf("GetServiceSchema failed: %v", err)
+			}
+
+			wantDoc := " Test service.\n"
+			if strip {
+				wantDoc = ""
+			}
+			if got := schemaResp.Msg.Service.GetDocumentation(); got != wantDoc {
+				t.Errorf("Expected documentation %q, got %q", wantDoc, got)
+			}
+		})
+	}
+}
+
+// TestLoadProtos_ServiceFilter tests that only the filtered services and the
+// files they need are registered
+func TestLoadProtos_ServiceFilter(t *testing.T) {
+	// other.proto declares a second service using test.proto's messages
+	fds := createTestFileDescriptorSet()
+	fds.File = append(fds.File, &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("other.proto"),
+		Package:    proto.String("other.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"test.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("OtherService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Ping"),
+				InputType:  proto.String(".test.v1.TestRequest"),
+				OutputType: proto.String(".test.v1.TestResponse"),
+			}},
+		}},
+	})
+	data, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+
+	tests := []struct {
+		filter   string
+		files    int32
+		services []string
+	}{
+		{"test.v1.TestService", 1, []string{"test.v1.TestService"}},
+		{"other.v1.OtherService", 2, []string{"other.v1.OtherService"}},
+	}
+	for _, tt := range tests {
+		server := New()
+		defer server.Close()
+
+		req := connect.NewRequest(&catalogv1.LoadProtosRequest{
+			Source:        &catalogv1.LoadProtosRequest_DescriptorSet{DescriptorSet: data},
+			ServiceFilter: []string{tt.filter},
+		})
+		resp, err := server.LoadProtos(context.Background(), req)
+		if err != nil {
+			t.Fatalf("LoadProtos failed: %v", err)
+		}
+		if !resp.Msg.Success {
+			t.Fatalf("%s: expected success, got error: %s", tt.filter, resp.Msg.Error)
+		}
+		if resp.Msg.FileCount != tt.files || resp.Msg.ServiceCount != 1 {
+			t.Errorf("%s: expected %d files and 1 service, got %d and %d", tt.filter, tt.files, resp.Msg.FileCount, resp.Msg.ServiceCount)
+		}
+
+		state, _, err := server.sessionManager.GetOrCreate(resp.Header().Get("X-Session-ID"))
+		if err != nil {
+			t.Fatalf("Failed to get session: %v", err)
+		}
+		var names []string
+		for _, svc := range state.Registry.ListServices() {
+			names = append(names, svc.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.services, ",") {
+			t.Errorf("%s: expected services %v, got %v", tt.filter, tt.services, names)
+		}
+	}
+
+	server := New()
+	defer server.Close()
+	resp, err := server.LoadProtos(context.Background(), connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source:        &catalogv1.LoadProtosRequest_DescriptorSet{DescriptorSet: data},
+		ServiceFilter: []string{"missing.v1.Service"},
+	}))
+	if err != nil {
+		t.Fatalf("LoadProtos failed: %v", err)
+	}
+	if resp.Msg.Success || !strings.Contains(resp.Msg.Error, "missing.v1.Service") {
+		t.Errorf("Expected an error naming the missing service, got success=%v error=%q", resp.Msg.Success, resp.Msg.Error)
+	}
+}
+
+// TestLoadProtos_NoSource tests that a request without a source is rejected
+func TestLoadProtos_NoSource(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	_, err := server.LoadProtos(context.Background(), connect.NewRequest(&catalogv1.LoadProtosRequest{}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+	}
+}
+
 // TestListServices tests listing services after loading protos
 func TestListServices(t *testing.T) {
 	server := New()
@@ -133,6 +429,9 @@ func TestListServices(t *testing.T) {
 					if method.OutputType != "test.v1.TestResponse" {
 						t.Errorf("Expected output type 'test.v1.TestResponse', got '%s'", method.OutputType)
 					}
+					if method.StreamingKind != catalogv1.StreamingKind_STREAMING_KIND_UNARY {
+						t.Errorf("Expected a unary streaming kind, got %v", method.StreamingKind)
+					}
 				}
 			}
 
@@ -168,6 +467,73 @@ func TestListServices_Empty(t *testing.T) {
 	}
 }
 
+// TestListServices_IncludeFields tests that method fields are listed with
+// their structure only when requested
+func TestListServices_IncludeFields(t *testing.T) {
+	server := New()
+	defer server.Close()
+	ctx := context.Background()
+
+	compileResp, err := server.CompileProto(ctx, connect.NewRequest(&catalogv1.CompileProtoRequest{
+		Content: `syntax = "proto3";
+package shop.v1;
+message Line { string sku = 1; }
+message OrderRequest {
+  repeated Line lines = 1;
+  map<string, int32> counts = 2;
+  oneof payment { string card = 3; string voucher = 4; }
+  optional string note = 5;
+}
+message OrderResponse { string id = 1; }
+service OrderService { rpc Order(OrderRequest) returns (OrderResponse); }
+`,
+		Register: true,
+	}))
+	if err != nil || !compileResp.Msg.Registered {
+		t.Fatalf("CompileProto failed: %v %v", err, compileResp.Msg.GetError())
+	}
+	sessionID := compileResp.Header().Get("X-Session-ID")
+
+	listMethod := func(includeFields bool) *catalogv1.MethodInfo {
+		t.Helper()
+		req := connect.NewRequest(&catalogv1.ListServicesRequest{IncludeFields: includeFields})
+		req.Header().Set("X-Session-ID", sessionID)
+		resp, err := server.ListServices(ctx, req)
+		if err != nil {
+			t.Fatalf("ListServices failed: %v", err)
+		}
+		if len(resp.Msg.Services) != 1 || len(resp.Msg.Services[0].Methods) != 1 {
+			t.Fatalf("Expected one service with one method, got %v", resp.Msg.Services)
+		}
+		return resp.Msg.Services[0].Methods[0]
+	}
+
+	if method := listMethod(false); len(method.InputFields) != 0 || len(method.OutputFields) != 0 {
+		t.Errorf("Expected no fields by default, got %v", method)
+	}
+
+	method := listMethod(true)
+	if len(method.InputFields) != 5 {
+		t.Fatalf("Expected 5 input fields, got %v", method.InputFields)
+	}
+	lines, counts, card, note := method.InputFields[0], method.InputFields[1], method.InputFields[2], method.InputFields[4]
+	if !lines.Repeated || lines.TypeName != "shop.v1.Line" || lines.Number != 1 {
+		t.Errorf("Expected lines as repeated shop.v1.Line, got %v", lines)
+	}
+	if !counts.Map || counts.Repeated || counts.MapKeyType != "string" || counts.Type != "int32" {
+		t.Errorf("Expected counts as a string to int32 map, got %v", counts)
+	}
+	if card.Oneof != "payment" {
+		t.Errorf("Expected card in the payment oneof, got %v", card)
+	}
+	if !note.Optional || note.Oneof != "" {
+		t.Errorf("Expected note as optional outside any oneof, got %v", note)
+	}
+	if len(method.OutputFields) != 1 || method.OutputFields[0].JsonName != "id" {
+		t.Errorf("Expected the output id field, got %v", method.OutputFields)
+	}
+}
+
 // TestGetServiceSchema tests retrieving service schema
 func TestGetServiceSchema(t *testing.T) {
 	server := New()
@@ -209,6 +575,17 @@ func TestGetServiceSchema(t *testing.T) {
 		t.Errorf("Expected service name 'test.v1.TestService', got '%s'", schemaResp.Msg.Service.Name)
 	}
 
+	// Verify method complexity estimates are returned
+	if len(schemaResp.Msg.Service.Methods) != 1 {
+		t.Fatalf("Expected 1 method, got %d", len(schemaResp.Msg.Service.Methods))
+	}
+	if c := schemaResp.Msg.Service.Methods[0].InputComplexity; c.GetMaxDepth() != 1 || c.GetLarge() {
+		t.Errorf("Unexpected input complexity: %v", c)
+	}
+	if kind := schemaResp.Msg.Service.Methods[0].StreamingKind; kind != catalogv1.StreamingKind_STREAMING_KIND_UNARY {
+		t.Errorf("Expected a unary streaming kind, got %v", kind)
+	}
+
 	// Verify message schemas are returned
 	if len(schemaResp.Msg.MessageSchemas) == 0 {
 		t.Error("Expected message schemas, got zero")
@@ -227,6 +604,131 @@ func TestGetServiceSchema(t *testing.T) {
 	}
 }
 
+// TestStreamingKind tests classification of methods by their streaming flags
+func TestStreamingKind(t *testing.T) {
+	tests := []struct {
+		clientStreaming bool
+		serverStreaming bool
+		want            catalogv1.StreamingKind
+	}{
+		{false, false, catalogv1.StreamingKind_STREAMING_KIND_UNARY},
+		{true, false, catalogv1.StreamingKind_STREAMING_KIND_CLIENT_STREAM},
+		{false, true, catalogv1.StreamingKind_STREAMING_KIND_SERVER_STREAM},
+		{true, true, catalogv1.StreamingKind_STREAMING_KIND_BIDI_STREAM},
+	}
+	for _, tt := range tests {
+		if got := streamingKind(tt.clientStreaming, tt.serverStreaming); got != tt.want {
+			t.Errorf("streamingKind(%v, %v) = %v, want %v", tt.clientStreaming, tt.serverStreaming, got, tt.want)
+		}
+	}
+}
+
+// TestIncludeEnumValues tests that enum mappings are returned with the service
+// schema and method examples only on request
+func TestIncludeEnumValues(t *testing.T) {
+	server := New()
+	defer server.Close()
+	ctx := context.Background()
+
+	compileResp, err := server.CompileProto(ctx, connect.NewRequest(&catalogv1.CompileProtoRequest{
+		Content: `syntax = "proto3";
+package level.v1;
+enum Level { LEVEL_UNSPECIFIED = 0; LEVEL_HIGH = 2; }
+enum Outcome { OUTCOME_UNSPECIFIED = 0; OUTCOME_DONE = 1; }
+message SetRequest { Level level = 1; }
+message SetResponse { Outcome outcome = 1; }
+service LevelService { rpc Set(SetRequest) returns (SetResponse); }
+`,
+		Register: true,
+	}))
+	if err != nil || !compileResp.Msg.Registered {
+		t.Fatalf("CompileProto failed: %v %v", err, compileResp.Msg.GetError())
+	}
+	sessionID := compileResp.Header().Get("X-Session-ID")
+
+	for _, include := range []bool{false, true} {
+		schemaReq := connect.NewRequest(&catalogv1.GetServiceSchemaRequest{ServiceName: "level.v1.LevelService", IncludeEnumValues: include})
+		schemaReq.Header().Set("X-Session-ID", sessionID)
+		schemaResp, err := server.GetServiceSchema(ctx, schemaReq)
+		if err != nil {
+			t.Fatalf("GetServiceSchema failed: %v", err)
+		}
+		describeReq := connect.NewRequest(&catalogv1.DescribeServiceRequest{ServiceName: "level.v1.LevelService", IncludeEnumValues: include})
+		describeReq.Header().Set("X-Session-ID", sessionID)
+		describeResp, err := server.DescribeService(ctx, describeReq)
+		if err != nil {
+			t.Fatalf("DescribeService failed: %v", err)
+		}
+		exampleEnums := describeResp.Msg.Methods[0].EnumValues
+
+		if !include {
+			if len(schemaResp.Msg.EnumValues) != 0 || len(exampleEnums) != 0 {
+				t.Errorf("Expected no enum values by default, got %v and %v", schemaResp.Msg.EnumValues, exampleEnums)
+			}
+			continue
+		}
+		if len(schemaResp.Msg.EnumValues) != 2 || schemaResp.Msg.EnumValues["level.v1.Outcome"].GetNumbers()["OUTCOME_DONE"] != 1 {
+			t.Errorf("Expected Level and Outcome in the schema, got %v", schemaResp.Msg.EnumValues)
+		}
+		// Examples cover the request message only
+		if len(exampleEnums) != 1 || exampleEnums["level.v1.Level"].GetNames()[2] != "LEVEL_HIGH" {
+			t.Errorf("Expected Level alone in the example, got %v", exampleEnums)
+		}
+	}
+}
+
+// TestGetServiceSchema_IncludeMessageSchemas tests that message schemas are
+// included by default and omitted on request
+func TestGetServiceSchema_IncludeMessageSchemas(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		include     *bool
+		wantSchemas bool
+	}{
+		{name: "default", include: nil, wantSchemas: true},
+		{name: "included", include: proto.Bool(true), wantSchemas: true},
+		{name: "excluded", include: proto.Bool(false), wantSchemas: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := connect.NewRequest(&catalogv1.GetServiceSchemaRequest{
+				ServiceName:           "test.v1.TestService",
+				IncludeMessageSchemas: tt.include,
+			})
+			req.Header().Set("X-Session-ID", sessionID)
+
+			resp, err := server.GetServiceSchema(context.Background(), req)
+			if err != nil {
+				t.Fatalf("GetServiceSchema failed: %v", err)
+			}
+			if resp.Msg.Error != "" {
+				t.Fatalf("Expected no error, got: %s", resp.Msg.Error)
+			}
+			if len(resp.Msg.Service.GetMethods()) != 1 {
+				t.Errorf("Expected method metadata in every mode, got %d methods", len(resp.Msg.Service.GetMethods()))
+			}
+			if got := len(resp.Msg.MessageSchemas) > 0; got != tt.wantSchemas {
+				t.Errorf("Expected message schemas=%v, got %d schemas", tt.wantSchemas, len(resp.Msg.MessageSchemas))
+			}
+			if resp.Msg.Hash == "" {
+				t.Error("Expected schema hash in every mode")
+			}
+		})
+	}
+}
+
 // TestGetServiceSchema_NotFound tests error handling for unknown service
 func TestGetServiceSchema_NotFound(t *testing.T) {
 	server := New()
@@ -283,6 +785,85 @@ func TestGetServiceSchema_EmptyName(t *testing.T) {
 	}
 }
 
+// TestListMethods tests the ListMethods handler
+func TestListMethods(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	listReq := connect.NewRequest(&catalogv1.ListMethodsRequest{
+		ServiceName: "test.v1.TestService",
+	})
+	listReq.Header().Set("X-Session-ID", sessionID)
+
+	resp, err := server.ListMethods(ctx, listReq)
+	if err != nil {
+		t.Fatalf("ListMethods failed: %v", err)
+	}
+	if len(resp.Msg.Methods) != 1 || resp.Msg.Methods[0].Name != "TestMethod" {
+		t.Errorf("Unexpected methods: %v", resp.Msg.Methods)
+	}
+
+	notFoundReq := connect.NewRequest(&catalogv1.ListMethodsRequest{
+		ServiceName: "nonexistent.Service",
+	})
+	notFoundReq.Header().Set("X-Session-ID", sessionID)
+
+	resp, err = server.ListMethods(ctx, notFoundReq)
+	if err != nil {
+		t.Fatalf("ListMethods failed: %v", err)
+	}
+	if resp.Msg.Error == "" {
+		t.Error("Expected error for non-existent service")
+	}
+
+	_, err = server.ListMethods(ctx, connect.NewRequest(&catalogv1.ListMethodsRequest{}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+	}
+}
+
+// TestListAllMethods tests the flat method listing of a session
+func TestListAllMethods(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	req := connect.NewRequest(&catalogv1.ListAllMethodsRequest{})
+	req.Header().Set("X-Session-ID", sessionID)
+	resp, err := server.ListAllMethods(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ListAllMethods failed: %v", err)
+	}
+
+	if len(resp.Msg.Methods) != 1 {
+		t.Fatalf("Expected 1 method, got %v", resp.Msg.Methods)
+	}
+	method := resp.Msg.Methods[0]
+	if method.Service != "test.v1.TestService" || method.Method != "TestMethod" {
+		t.Errorf("Unexpected method: %v", method)
+	}
+	if method.StreamingKind != catalogv1.StreamingKind_STREAMING_KIND_UNARY {
+		t.Errorf("Expected a unary streaming kind, got %v", method.StreamingKind)
+	}
+}
+
 // TestInvokeGRPC tests the InvokeGRPC handler validation
 // Note: This test only validates request validation, not actual invocation
 // since we don't have a running gRPC server to invoke in unit tests
@@ -401,71 +982,1730 @@ func TestInvokeGRPC_MissingMethod(t *testing.T) {
 	}
 }
 
-// TestServerValidation tests the ValidateSetup method
-func TestServerValidation(t *testing.T) {
-	server := New()
-	defer server.Close()
-
-	if err := server.ValidateSetup(); err != nil {
-		t.Errorf("ValidateSetup failed: %v", err)
-	}
-}
-
-// TestServerStats tests the GetStats method
-func TestServerStats(t *testing.T) {
-	server := New()
-	defer server.Close()
-
-	stats := server.GetStats()
-
-	// Verify stats structure is populated
-	if stats.SessionStats.ActiveSessions < 0 {
-		t.Error("Expected non-negative active sessions")
-	}
-}
-
-// TestSessionIsolation tests that sessions are isolated from each other
-func TestSessionIsolation(t *testing.T) {
+// TestDescribeInvocation tests that the handler describes a request without sending it
+func TestDescribeInvocation(t *testing.T) {
 	server := New()
 	defer server.Close()
 
 	ctx := context.Background()
 
-	// Create first session and register test descriptors
-	state1, sessionID1, err := server.sessionManager.GetOrCreate("")
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	describeReq := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:    "localhost:9999",
+		Service:     "test.v1.TestService",
+		Method:      "TestMethod",
+		RequestJson: `{"name": "test"}`,
+		Metadata:    map[string]string{"x-request-id": "abc"},
+	})
+	describeReq.Header().Set("X-Session-ID", sessionID)
+
+	resp, err := server.DescribeInvocation(ctx, describeReq)
+	if err != nil {
+		t.Fatalf("DescribeInvocation failed: %v", err)
+	}
+	if resp.Msg.Error != "" {
+		t.Fatalf("Unexpected error: %s", resp.Msg.Error)
+	}
+
+	if resp.Msg.Url != "http://localhost:9999/test.v1.TestService/TestMethod" {
+		t.Errorf("Unexpected URL: %s", resp.Msg.Url)
+	}
+	if resp.Msg.Headers["X-Request-Id"] != "abc" {
+		t.Errorf("Expected metadata in headers, got: %v", resp.Msg.Headers)
+	}
+	if resp.Msg.RequestJson != `{"name":"test"}` {
+		t.Errorf("Unexpected normalized JSON: %s", resp.Msg.RequestJson)
+	}
+}
+
+// TestInvokeGRPC_DryRun tests that dry runs build the request without sending it
+func TestInvokeGRPC_DryRun(t *testing.T) {
+	server := New(WithDefaultInvokeMetadata(map[string]string{"x-service-token": "secret"}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	// Nothing listens on the endpoint, so only a dry run can succeed
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	endpoint := lis.Addr().String()
+	lis.Close()
+
+	invoke := func(t *testing.T, msg *catalogv1.InvokeGRPCRequest) *catalogv1.InvokeGRPCResponse {
+		t.Helper()
+		msg.Endpoint = endpoint
+		msg.Service = "test.v1.TestService"
+		msg.Method = "TestMethod"
+		msg.DryRun = true
+		req := connect.NewRequest(msg)
+		req.Header().Set("X-Session-ID", sessionID)
+		resp, err := server.InvokeGRPC(ctx, req)
+		if err != nil {
+			t.Fatalf("InvokeGRPC failed: %v", err)
+		}
+		if !resp.Msg.DryRun {
+			t.Error("Expected response to be marked as a dry run")
+		}
+		return resp.Msg
+	}
+
+	t.Run("connect", func(t *testing.T) {
+		resp := invoke(t, &catalogv1.InvokeGRPCRequest{
+			RequestJson: `{"name": "test"}`,
+			Metadata:    map[string]string{"x-request-id": "abc"},
+		})
+		if !resp.Success {
+			t.Fatalf("Expected success, got error: %s", resp.Error)
+		}
+		sent := resp.DryRunRequest
+		if sent.GetUrl() != "http://"+endpoint+"/test.v1.TestService/TestMethod" {
+			t.Errorf("Unexpected URL: %s", sent.GetUrl())
+		}
+		if sent.GetHeaders()["X-Request-Id"] != "abc" {
+			t.Errorf("Expected request metadata in headers, got %v", sent.GetHeaders())
+		}
+		for k := range sent.GetHeaders() {
+			if strings.EqualFold(k, "x-service-token") {
+				t.Error("Expected server default metadata to be omitted")
+			}
+		}
+		if resp.RequestBytes != int64(len(`{"name": "test"}`)) || sent.GetRequestBytes() != resp.RequestBytes {
+			t.Errorf("Unexpected request bytes: %d, %d", resp.RequestBytes, sent.GetRequestBytes())
+		}
+	})
+
+	t.Run("grpc", func(t *testing.T) {
+		resp := invoke(t, &catalogv1.InvokeGRPCRequest{
+			RequestJson: `{"name": "test"}`,
+			Transport:   catalogv1.Transport_TRANSPORT_GRPC,
+		})
+		if !resp.Success {
+			t.Fatalf("Expected success, got error: %s", resp.Error)
+		}
+		// One tag byte, one length byte and the four-byte string
+		if resp.RequestBytes != 6 {
+			t.Errorf("Expected 6 request bytes, got %d", resp.RequestBytes)
+		}
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		resp := invoke(t, &catalogv1.InvokeGRPCRequest{RequestJson: `{"unknown": 1}`})
+		if resp.Success || resp.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST {
+			t.Errorf("Expected INVALID_REQUEST, got success=%v kind=%v", resp.Success, resp.ErrorKind)
+		}
+	})
+
+	t.Run("json array", func(t *testing.T) {
+		resp := invoke(t, &catalogv1.InvokeGRPCRequest{RequestJson: `[{"name": "a"}, {"name": "b"}]`})
+		if !resp.Success || len(resp.Results) != 2 {
+			t.Fatalf("Expected 2 successful results, got success=%v results=%d (%s)", resp.Success, len(resp.Results), resp.Error)
+		}
+		if !resp.Results[0].DryRun || resp.Results[1].DryRunRequest == nil {
+			t.Error("Expected every result to describe a dry run")
+		}
+		if resp.ResponseJson != "[null,null]" {
+			t.Errorf("Unexpected aggregated response: %s", resp.ResponseJson)
+		}
+	})
+}
+
+// TestInvokeGRPC_SchemaMismatch tests that with validate_schema, request JSON
+// not matching the input message is rejected with the offending field before
+// sending
+func TestInvokeGRPC_SchemaMismatch(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	for _, dryRun := range []bool{false, true} {
+		req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+			Endpoint:       "localhost:9999",
+			Service:        "test.v1.TestService",
+			Method:         "TestMethod",
+			RequestJson:    `{"name": "test", "nmae": "typo"}`,
+			DryRun:         dryRun,
+			ValidateSchema: true,
+		})
+		req.Header().Set("X-Session-ID", sessionID)
+
+		resp, err := server.InvokeGRPC(context.Background(), req)
+		if err != nil {
+			t.Fatalf("InvokeGRPC failed: %v", err)
+		}
+		if resp.Msg.Success {
+			t.Fatalf("dry run %v: expected the request to be rejected", dryRun)
+		}
+		if resp.Msg.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_INVALID_REQUEST {
+			t.Errorf("dry run %v: expected ERROR_KIND_INVALID_REQUEST, got %v", dryRun, resp.Msg.ErrorKind)
+		}
+		if resp.Msg.ErrorFieldPath != "nmae" || !strings.Contains(resp.Msg.Error, "unknown field") {
+			t.Errorf("dry run %v: expected the unknown field to be named, got %q (%s)", dryRun, resp.Msg.ErrorFieldPath, resp.Msg.Error)
+		}
+		if resp.Msg.DryRun != dryRun {
+			t.Errorf("Expected dry_run %v, got %v", dryRun, resp.Msg.DryRun)
+		}
+	}
+
+	// Nothing was sent, so nothing was recorded
+	if stats := state.MethodStats(); len(stats) != 0 {
+		t.Errorf("Expected no recorded invocations, got %v", stats)
+	}
+
+	// Without validate_schema the request is not checked against the schema
+	req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:    "localhost:9999",
+		Service:     "test.v1.TestService",
+		Method:      "TestMethod",
+		RequestJson: `{"name": "test", "nmae": "typo"}`,
+		DryRun:      true,
+	})
+	req.Header().Set("X-Session-ID", sessionID)
+	resp, err := server.InvokeGRPC(context.Background(), req)
+	if err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+	if resp.Msg.ErrorFieldPath != "" {
+		t.Errorf("Expected no schema check by default, got field %q (%s)", resp.Msg.ErrorFieldPath, resp.Msg.Error)
+	}
+}
+
+// TestDescribeInvocation_MissingEndpoint tests validation for missing endpoint
+func TestDescribeInvocation_MissingEndpoint(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	_, err := server.DescribeInvocation(context.Background(), connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Service: "test.v1.TestService",
+		Method:  "TestMethod",
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+	}
+}
+
+// TestWarmEndpoints tests that reachable endpoints are pooled and unreachable ones reported
+func TestWarmEndpoints(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	server := New()
+	defer server.Close()
+
+	warmReq := connect.NewRequest(&catalogv1.WarmEndpointsRequest{
+		Endpoints: []*catalogv1.EndpointConfig{
+			{Endpoint: lis.Addr().String()},
+			{Endpoint: "localhost:1"},
+		},
+		TimeoutSeconds: 3,
+	})
+
+	resp, err := server.WarmEndpoints(context.Background(), warmReq)
+	if err != nil {
+		t.Fatalf("WarmEndpoints failed: %v", err)
+	}
+
+	results := resp.Msg.Results
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+	if !results[0].Reachable {
+		t.Errorf("Expected %s to be reachable, got error: %s", results[0].Endpoint, results[0].Error)
+	}
+	if results[1].Reachable || results[1].Error == "" {
+		t.Errorf("Expected %s to be unreachable with an error", results[1].Endpoint)
+	}
+
+	// The warmed connection should be in the session's pool
+	state := server.sessionManager.Get(resp.Header().Get("X-Session-ID"))
+	if state == nil {
+		t.Fatal("Expected session to exist")
+	}
+	if stats := state.Invoker.GetConnectionStats(); stats.TotalConnections != 1 {
+		t.Errorf("Expected 1 pooled connection, got %d", stats.TotalConnections)
+	}
+}
+
+// TestWarmEndpoints_MissingEndpoint tests validation for empty endpoint configs
+func TestWarmEndpoints_MissingEndpoint(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	_, err := server.WarmEndpoints(context.Background(), connect.NewRequest(&catalogv1.WarmEndpointsRequest{
+		Endpoints: []*catalogv1.EndpointConfig{{}},
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+	}
+}
+
+// TestWarmEndpoints_TooMany tests that a request over the endpoint limit is
+// rejected before any endpoint is dialed
+func TestWarmEndpoints_TooMany(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	endpoints := make([]*catalogv1.EndpointConfig, maxWarmEndpoints+1)
+	for i := range endpoints {
+		endpoints[i] = &catalogv1.EndpointConfig{Endpoint: "localhost:1"}
+	}
+	_, err := server.WarmEndpoints(context.Background(), connect.NewRequest(&catalogv1.WarmEndpointsRequest{
+		Endpoints: endpoints,
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+	}
+}
+
+// TestTLSSettings_ServerNameWithoutTLS tests that a server name on a
+// plaintext connection is rejected instead of ignored
+func TestTLSSettings_ServerNameWithoutTLS(t *testing.T) {
+	server := New()
+	defer server.Close()
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	for _, useTLS := range []bool{false, true} {
+		req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+			Endpoint:    "localhost:9999",
+			Service:     "test.v1.TestService",
+			Method:      "TestMethod",
+			RequestJson: `{"name": "test"}`,
+			UseTls:      useTLS,
+			ServerName:  "api.internal",
+			DryRun:      true,
+		})
+		req.Header().Set("X-Session-ID", sessionID)
+		_, err := server.InvokeGRPC(ctx, req)
+		if useTLS && err != nil {
+			t.Errorf("Expected server_name with use_tls to be accepted, got %v", err)
+		}
+		if !useTLS && (connect.CodeOf(err) != connect.CodeInvalidArgument || !strings.Contains(err.Error(), "requires use_tls")) {
+			t.Errorf("Expected InvalidArgument naming use_tls, got %v", err)
+		}
+	}
+
+	_, err = server.WarmEndpoints(ctx, connect.NewRequest(&catalogv1.WarmEndpointsRequest{
+		Endpoints: []*catalogv1.EndpointConfig{{Endpoint: "localhost:9999", ServerName: "api.internal"}},
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument || !strings.Contains(err.Error(), "endpoints[0]: server_name") {
+		t.Errorf("WarmEndpoints: expected InvalidArgument naming the endpoint, got %v", err)
+	}
+
+	_, err = server.LoadProtos(ctx, connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source:            &catalogv1.LoadProtosRequest_ReflectionEndpoint{ReflectionEndpoint: "localhost:9999"},
+		ReflectionOptions: &catalogv1.ReflectionOptions{ServerName: "api.internal"},
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("LoadProtos: expected InvalidArgument, got %v", err)
+	}
+
+	_, err = server.ProbeTransports(ctx, connect.NewRequest(&catalogv1.ProbeTransportsRequest{
+		Endpoint:   "localhost:9999",
+		ServerName: "api.internal",
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("ProbeTransports: expected InvalidArgument, got %v", err)
+	}
+
+	_, err = server.CheckEndpoint(ctx, connect.NewRequest(&catalogv1.CheckEndpointRequest{
+		Endpoint:   "localhost:9999",
+		ServerName: "api.internal",
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("CheckEndpoint: expected InvalidArgument, got %v", err)
+	}
+}
+
+// TestCheckEndpoint tests that a refused connection is reported as a TCP
+// failure after a successful DNS stage
+func TestCheckEndpoint(t *testing.T) {
+	server := New()
+	defer server.Close()
+	ctx := context.Background()
+
+	if _, err := server.CheckEndpoint(ctx, connect.NewRequest(&catalogv1.CheckEndpointRequest{})); connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument for a missing endpoint, got %v", err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	endpoint := lis.Addr().String()
+	lis.Close()
+
+	resp, err := server.CheckEndpoint(ctx, connect.NewRequest(&catalogv1.CheckEndpointRequest{Endpoint: endpoint}))
+	if err != nil {
+		t.Fatalf("CheckEndpoint failed: %v", err)
+	}
+	if resp.Msg.Reachable || resp.Msg.FailedStage != catalogv1.ConnectivityStage_CONNECTIVITY_STAGE_TCP {
+		t.Errorf("Expected a TCP failure, got %v", resp.Msg)
+	}
+	if len(resp.Msg.Stages) != 2 || !resp.Msg.Stages[0].Ok || resp.Msg.Stages[1].Detail == "" {
+		t.Errorf("Expected a passed DNS stage and a failed TCP stage with detail, got %v", resp.Msg.Stages)
+	}
+}
+
+// TestInspectTLS_MissingEndpoint tests validation for missing endpoint
+func TestInspectTLS_MissingEndpoint(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	_, err := server.InspectTLS(context.Background(), connect.NewRequest(&catalogv1.InspectTLSRequest{}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+	}
+}
+
+// TestProbeTransports tests transport detection against a gRPC-only server
+func TestProbeTransports(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	server := New()
+	defer server.Close()
+
+	resp, err := server.ProbeTransports(context.Background(), connect.NewRequest(&catalogv1.ProbeTransportsRequest{
+		Endpoint:       lis.Addr().String(),
+		TimeoutSeconds: 3,
+	}))
+	if err != nil {
+		t.Fatalf("ProbeTransports failed: %v", err)
+	}
+
+	if len(resp.Msg.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(resp.Msg.Results))
+	}
+	if resp.Msg.Recommended != catalogv1.Transport_TRANSPORT_GRPC {
+		t.Errorf("Expected gRPC to be recommended, got %v (%v)", resp.Msg.Recommended, resp.Msg.Results)
+	}
+
+	_, err = server.ProbeTransports(context.Background(), connect.NewRequest(&catalogv1.ProbeTransportsRequest{}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+	}
+}
+
+// TestCompileProto tests that compiled descriptors are only registered on request
+func TestCompileProto(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+	source := `syntax = "proto3";
+package playground.v1;
+service EchoService { rpc Echo(EchoMessage) returns (EchoMessage); }
+message EchoMessage { string text = 1; }
+`
+
+	resp, err := server.CompileProto(ctx, connect.NewRequest(&catalogv1.CompileProtoRequest{
+		Content: source,
+	}))
+	if err != nil {
+		t.Fatalf("CompileProto failed: %v", err)
+	}
+	if !resp.Msg.Success || resp.Msg.Registered {
+		t.Fatalf("Expected success without registration, got %+v", resp.Msg)
+	}
+	if len(resp.Msg.Services) != 1 || resp.Msg.Services[0] != "playground.v1.EchoService" {
+		t.Errorf("Unexpected services: %v", resp.Msg.Services)
+	}
+
+	sessionID := resp.Header().Get("X-Session-ID")
+	if server.sessionManager.Get(sessionID).Registry.HasService("playground.v1.EchoService") {
+		t.Error("Service should not be registered without the register flag")
+	}
+
+	registerReq := connect.NewRequest(&catalogv1.CompileProtoRequest{
+		Content:  source,
+		Register: true,
+	})
+	registerReq.Header().Set("X-Session-ID", sessionID)
+
+	resp, err = server.CompileProto(ctx, registerReq)
+	if err != nil {
+		t.Fatalf("CompileProto failed: %v", err)
+	}
+	if !resp.Msg.Registered {
+		t.Fatalf("Expected registration, got error: %s", resp.Msg.Error)
+	}
+	if !server.sessionManager.Get(sessionID).Registry.HasService("playground.v1.EchoService") {
+		t.Error("Expected service to be registered")
+	}
+}
+
+// TestCompileProto_Errors tests that parse errors are returned as structured errors
+func TestCompileProto_Errors(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	resp, err := server.CompileProto(context.Background(), connect.NewRequest(&catalogv1.CompileProtoRequest{
+		Content:  "syntax = \"proto3\";\nmessage {}\n",
+		Register: true,
+	}))
+	if err != nil {
+		t.Fatalf("CompileProto failed: %v", err)
+	}
+	if resp.Msg.Success || resp.Msg.Registered {
+		t.Error("Expected failure without registration")
+	}
+	if len(resp.Msg.Errors) == 0 || resp.Msg.Errors[0].Line != 2 {
+		t.Errorf("Expected error on line 2, got %v", resp.Msg.Errors)
+	}
+}
+
+// TestServerValidation tests the ValidateSetup method
+func TestServerValidation(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	if _, err := server.ValidateSetup(); err != nil {
+		t.Errorf("ValidateSetup failed: %v", err)
+	}
+}
+
+// TestServerValidation_BufMissing tests that a missing buf is reported as a warning
+func TestServerValidation_BufMissing(t *testing.T) {
+	original := bufInstallationCheck
+	defer func() { bufInstallationCheck = original }()
+	bufInstallationCheck = func() error {
+		return fmt.Errorf("buf not installed or not in PATH")
+	}
+
+	server := New()
+	defer server.Close()
+
+	warnings, err := server.ValidateSetup()
+	if err != nil {
+		t.Fatalf("Expected missing buf to be non-fatal, got %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "Install buf") {
+		t.Errorf("Expected buf installation guidance, got %v", warnings)
+	}
+
+	disabled := New(WithBufCheck(false))
+	defer disabled.Close()
+
+	warnings, err = disabled.ValidateSetup()
+	if err != nil || len(warnings) != 0 {
+		t.Errorf("Expected no warnings with buf check disabled, got %v, %v", warnings, err)
+	}
+}
+
+// TestServerStats tests the GetStats method
+func TestServerStats(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	stats := server.GetStats()
+
+	// Verify stats structure is populated
+	if stats.SessionStats.ActiveSessions < 0 {
+		t.Error("Expected non-negative active sessions")
+	}
+}
+
+// TestSessionIsolation tests that sessions are isolated from each other
+func TestSessionIsolation(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	// Create first session and register test descriptors
+	state1, sessionID1, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	fds := createTestFileDescriptorSet()
+	if err := state1.Registry.Register(fds); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	// Verify services are loaded in session 1
+	listReq1 := connect.NewRequest(&catalogv1.ListServicesRequest{})
+	listReq1.Header().Set("X-Session-ID", sessionID1)
+	listResp1, err := server.ListServices(ctx, listReq1)
+	if err != nil {
+		t.Fatalf("ListServices failed: %v", err)
+	}
+	if len(listResp1.Msg.Services) == 0 {
+		t.Fatal("Expected services to be loaded in session 1")
+	}
+
+	// Create second session (should have no services)
+	listReq2 := connect.NewRequest(&catalogv1.ListServicesRequest{})
+	listResp2, err := server.ListServices(ctx, listReq2)
+	if err != nil {
+		t.Fatalf("ListServices failed: %v", err)
+	}
+
+	sessionID2 := listResp2.Header().Get("X-Session-ID")
+	if sessionID2 == sessionID1 {
+		t.Fatal("Expected different session ID")
+	}
+
+	if len(listResp2.Msg.Services) != 0 {
+		t.Errorf("Expected zero services in new session, got %d", len(listResp2.Msg.Services))
+	}
+}
+
+// TestGetServiceSchemaHash tests hashing a loaded service schema
+func TestGetServiceSchemaHash(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	hashReq := connect.NewRequest(&catalogv1.GetServiceSchemaHashRequest{
+		ServiceName: "test.v1.TestService",
+	})
+	hashReq.Header().Set("X-Session-ID", sessionID)
+
+	resp, err := server.GetServiceSchemaHash(ctx, hashReq)
+	if err != nil {
+		t.Fatalf("GetServiceSchemaHash failed: %v", err)
+	}
+	if resp.Msg.Error != "" || len(resp.Msg.Hash) != 64 {
+		t.Errorf("Unexpected response: hash=%q error=%q", resp.Msg.Hash, resp.Msg.Error)
+	}
+
+	notFoundReq := connect.NewRequest(&catalogv1.GetServiceSchemaHashRequest{
+		ServiceName: "nonexistent.Service",
+	})
+	notFoundReq.Header().Set("X-Session-ID", sessionID)
+
+	resp, err = server.GetServiceSchemaHash(ctx, notFoundReq)
+	if err != nil {
+		t.Fatalf("GetServiceSchemaHash failed: %v", err)
+	}
+	if resp.Msg.Error == "" {
+		t.Error("Expected error for non-existent service")
+	}
+
+	_, err = server.GetServiceSchemaHash(ctx, connect.NewRequest(&catalogv1.GetServiceSchemaHashRequest{}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+	}
+}
+
+// TestGetServiceSchema_IfNoneMatch tests conditional schema responses
+func TestGetServiceSchema_IfNoneMatch(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	getSchema := func(ifNoneMatch string) *catalogv1.GetServiceSchemaResponse {
+		req := connect.NewRequest(&catalogv1.GetServiceSchemaRequest{
+			ServiceName: "test.v1.TestService",
+			IfNoneMatch: ifNoneMatch,
+		})
+		req.Header().Set("X-Session-ID", sessionID)
+
+		resp, err := server.GetServiceSchema(ctx, req)
+		if err != nil {
+			t.Fatalf("GetServiceSchema failed: %v", err)
+		}
+		return resp.Msg
+	}
+
+	full := getSchema("")
+	if full.NotModified || full.Service == nil || full.Hash == "" {
+		t.Fatalf("Expected full schema with hash, got %v", full)
+	}
+
+	cached := getSchema(full.Hash)
+	if !cached.NotModified {
+		t.Error("Expected not_modified for matching hash")
+	}
+	if cached.Service != nil || len(cached.MessageSchemas) != 0 {
+		t.Error("Expected schema to be omitted when not modified")
+	}
+	if cached.Hash != full.Hash {
+		t.Errorf("Expected hash %s, got %s", full.Hash, cached.Hash)
+	}
+
+	stale := getSchema("stale")
+	if stale.NotModified || stale.Service == nil {
+		t.Error("Expected full schema for stale hash")
+	}
+}
+
+// TestDescribeService tests per-method examples and commands
+func TestDescribeService(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	req := connect.NewRequest(&catalogv1.DescribeServiceRequest{
+		ServiceName: "test.v1.TestService",
+		Endpoint:    "api.example.com",
+		UseTls:      true,
+	})
+	req.Header().Set("X-Session-ID", sessionID)
+
+	resp, err := server.DescribeService(ctx, req)
+	if err != nil {
+		t.Fatalf("DescribeService failed: %v", err)
+	}
+	if resp.Msg.Error != "" {
+		t.Fatalf("Unexpected error: %s", resp.Msg.Error)
+	}
+	if len(resp.Msg.Methods) != 1 {
+		t.Fatalf("Expected 1 method, got %d", len(resp.Msg.Methods))
+	}
+
+	method := resp.Msg.Methods[0]
+	if method.FullMethod != "/test.v1.TestService/TestMethod" {
+		t.Errorf("Unexpected full method: %s", method.FullMethod)
+	}
+	if !strings.Contains(method.ExampleRequestJson, `"name": ""`) {
+		t.Errorf("Unexpected example request: %s", method.ExampleRequestJson)
+	}
+	if !strings.Contains(method.CurlCommand, "'https://api.example.com/test.v1.TestService/TestMethod'") {
+		t.Errorf("Unexpected curl command: %s", method.CurlCommand)
+	}
+	if !strings.HasSuffix(method.GrpcurlCommand, "'api.example.com' test.v1.TestService/TestMethod") {
+		t.Errorf("Unexpected grpcurl command: %s", method.GrpcurlCommand)
+	}
+
+	notFoundReq := connect.NewRequest(&catalogv1.DescribeServiceRequest{
+		ServiceName: "nonexistent.Service",
+	})
+	notFoundReq.Header().Set("X-Session-ID", sessionID)
+
+	resp, err = server.DescribeService(ctx, notFoundReq)
+	if err != nil {
+		t.Fatalf("DescribeService failed: %v", err)
+	}
+	if resp.Msg.Error == "" {
+		t.Error("Expected error for non-existent service")
+	}
+
+	_, err = server.DescribeService(ctx, connect.NewRequest(&catalogv1.DescribeServiceRequest{}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument error code, got %v", connect.CodeOf(err))
+	}
+}
+
+// TestGenerateClientCode tests the GenerateClientCode RPC
+func TestGenerateClientCode(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	req := connect.NewRequest(&catalogv1.GenerateClientCodeRequest{
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		Language:    catalogv1.CodeLanguage_CODE_LANGUAGE_GO,
+	})
+	req.Header().Set("X-Session-ID", sessionID)
+
+	resp, err := server.GenerateClientCode(ctx, req)
+	if err != nil {
+		t.Fatalf("GenerateClientCode failed: %v", err)
+	}
+	if resp.Msg.Error != "" {
+		t.Fatalf("Unexpected error: %s", resp.Msg.Error)
+	}
+	if !strings.Contains(resp.Msg.Code, `"http://localhost:8080/test.v1.TestService/TestMethod"`) {
+		t.Errorf("Unexpected code:\n%s", resp.Msg.Code)
+	}
+
+	notFoundReq := connect.NewRequest(&catalogv1.GenerateClientCodeRequest{
+		ServiceName: "test.v1.TestService",
+		MethodName:  "Missing",
+	})
+	notFoundReq.Header().Set("X-Session-ID", sessionID)
+
+	resp, err = server.GenerateClientCode(ctx, notFoundReq)
+	if err != nil {
+		t.Fatalf("GenerateClientCode failed: %v", err)
+	}
+	if resp.Msg.Error == "" {
+		t.Error("Expected error for non-existent method")
+	}
+
+	_, err = server.GenerateClientCode(ctx, connect.NewRequest(&catalogv1.GenerateClientCodeRequest{}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+
+	_, err = server.GenerateClientCode(ctx, connect.NewRequest(&catalogv1.GenerateClientCodeRequest{
+		ServiceName: "test.v1.TestService",
+		MethodName:  "TestMethod",
+		Language:    catalogv1.CodeLanguage(99),
+	}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument for unsupported language, got %v", err)
+	}
+}
+
+// TestExportDescriptorsJSON tests the ExportDescriptorsJSON RPC
+func TestExportDescriptorsJSON(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	req := connect.NewRequest(&catalogv1.ExportDescriptorsJSONRequest{})
+	req.Header().Set("X-Session-ID", sessionID)
+
+	resp, err := server.ExportDescriptorsJSON(ctx, req)
+	if err != nil {
+		t.Fatalf("ExportDescriptorsJSON failed: %v", err)
+	}
+	if resp.Msg.Error != "" {
+		t.Fatalf("Unexpected error: %s", resp.Msg.Error)
+	}
+	if !strings.Contains(resp.Msg.DescriptorSetJson, `"test.proto"`) {
+		t.Errorf("Unexpected export: %s", resp.Msg.DescriptorSetJson)
+	}
+	if resp.Header().Get("X-Session-ID") != sessionID {
+		t.Error("Expected session ID to be preserved")
+	}
+}
+
+// TestGetProtoSource tests rendering a service's file and its imports
+func TestGetProtoSource(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
 	if err != nil {
 		t.Fatalf("Failed to create session: %v", err)
 	}
-
 	fds := createTestFileDescriptorSet()
-	if err := state1.Registry.Register(fds); err != nil {
+	fds.File = append(fds.File, &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("other.proto"),
+		Package:    proto.String("other.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"test.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("OtherService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Ping"),
+				InputType:  proto.String(".test.v1.TestRequest"),
+				OutputType: proto.String(".test.v1.TestResponse"),
+			}},
+		}},
+	})
+	if err := state.Registry.Register(fds); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	getSource := func(msg *catalogv1.GetProtoSourceRequest) *catalogv1.GetProtoSourceResponse {
+		t.Helper()
+		req := connect.NewRequest(msg)
+		req.Header().Set("X-Session-ID", sessionID)
+		resp, err := server.GetProtoSource(ctx, req)
+		if err != nil {
+			t.Fatalf("GetProtoSource failed: %v", err)
+		}
+		return resp.Msg
+	}
+
+	resp := getSource(&catalogv1.GetProtoSourceRequest{Service: "other.v1.OtherService"})
+	if resp.Error != "" {
+		t.Fatalf("Unexpected error: %s", resp.Error)
+	}
+	if len(resp.Files) != 1 || resp.Files[0].Name != "other.proto" {
+		t.Fatalf("Expected only other.proto, got %v", resp.Files)
+	}
+	if !strings.Contains(resp.Files[0].Source, "rpc Ping ( test.v1.TestRequest ) returns ( test.v1.TestResponse );") {
+		t.Errorf("Unexpected source:\n%s", resp.Files[0].Source)
+	}
+
+	resp = getSource(&catalogv1.GetProtoSourceRequest{Service: "other.v1.OtherService", IncludeImports: true})
+	if len(resp.Files) != 2 || resp.Files[1].Name != "test.proto" || !strings.Contains(resp.Files[1].Source, "message TestRequest") {
+		t.Errorf("Expected other.proto followed by test.proto, got %v", resp.Files)
+	}
+
+	if resp := getSource(&catalogv1.GetProtoSourceRequest{Service: "missing.v1.Service"}); !strings.Contains(resp.Error, "service not found") {
+		t.Errorf("Expected a not found error, got %q", resp.Error)
+	}
+
+	if _, err := server.GetProtoSource(ctx, connect.NewRequest(&catalogv1.GetProtoSourceRequest{})); connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument without a service, got %v", err)
+	}
+}
+
+// TestDescribeMessage tests the DescribeMessage RPC
+func TestDescribeMessage(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	req := connect.NewRequest(&catalogv1.DescribeMessageRequest{MessageName: "test.v1.TestRequest"})
+	req.Header().Set("X-Session-ID", sessionID)
+
+	resp, err := server.DescribeMessage(ctx, req)
+	if err != nil {
+		t.Fatalf("DescribeMessage failed: %v", err)
+	}
+	if resp.Msg.Error != "" {
+		t.Fatalf("Unexpected error: %s", resp.Msg.Error)
+	}
+	if resp.Msg.Schema.FullName != "test.v1.TestRequest" {
+		t.Errorf("Unexpected message: %s", resp.Msg.Schema.FullName)
+	}
+	if len(resp.Msg.Schema.Fields) != 1 || resp.Msg.Schema.Fields[0].Name != "name" || resp.Msg.Schema.Fields[0].Type != "string" {
+		t.Errorf("Unexpected fields: %v", resp.Msg.Schema.Fields)
+	}
+
+	notFoundReq := connect.NewRequest(&catalogv1.DescribeMessageRequest{MessageName: "test.v1.Missing"})
+	notFoundReq.Header().Set("X-Session-ID", sessionID)
+
+	resp, err = server.DescribeMessage(ctx, notFoundReq)
+	if err != nil {
+		t.Fatalf("DescribeMessage failed: %v", err)
+	}
+	if resp.Msg.Error == "" {
+		t.Error("Expected error for non-existent message")
+	}
+
+	_, err = server.DescribeMessage(ctx, connect.NewRequest(&catalogv1.DescribeMessageRequest{}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+// TestGetResourceReport tests aggregating resources across sessions
+func TestGetResourceReport(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		state, _, err := server.sessionManager.GetOrCreate("")
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+			t.Fatalf("Failed to register test descriptors: %v", err)
+		}
+	}
+
+	resp, err := server.GetResourceReport(context.Background(), connect.NewRequest(&catalogv1.GetResourceReportRequest{}))
+	if err != nil {
+		t.Fatalf("GetResourceReport failed: %v", err)
+	}
+
+	report := resp.Msg
+	if report.SessionCount != 2 {
+		t.Errorf("Expected 2 sessions, got %d", report.SessionCount)
+	}
+	if report.FileCount != 2 || report.ServiceCount != 2 {
+		t.Errorf("Expected 2 files and 2 services, got %d and %d", report.FileCount, report.ServiceCount)
+	}
+
+	data, err := proto.Marshal(createTestFileDescriptorSet())
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+	if report.RegistryBytes != int64(2*len(data)) {
+		t.Errorf("Expected %d registry bytes, got %d", 2*len(data), report.RegistryBytes)
+	}
+	if report.Memory.GetHeapAllocBytes() == 0 || report.Memory.GetGoroutines() == 0 {
+		t.Errorf("Expected runtime memory statistics, got %v", report.Memory)
+	}
+}
+
+// TestCompactSession tests spilling a session registry and restoring it on next use
+func TestCompactSession(t *testing.T) {
+	server := New(WithSessionCompaction(t.TempDir(), 0))
+	defer server.Close()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
+	}
+
+	req := connect.NewRequest(&catalogv1.CompactSessionRequest{})
+	req.Header().Set("X-Session-ID", sessionID)
+	resp, err := server.CompactSession(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CompactSession failed: %v", err)
+	}
+	if resp.Msg.Error != "" || resp.Msg.SpilledBytes == 0 {
+		t.Fatalf("Expected spilled bytes, got %d (error %q)", resp.Msg.SpilledBytes, resp.Msg.Error)
+	}
+
+	// Compacting again leaves the spilled registry on disk
+	resp, err = server.CompactSession(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CompactSession failed: %v", err)
+	}
+	if resp.Msg.SpilledBytes != 0 || !state.Compacted() {
+		t.Errorf("Expected an already compacted session to stay compacted, got %d bytes", resp.Msg.SpilledBytes)
+	}
+
+	listReq := connect.NewRequest(&catalogv1.ListServicesRequest{})
+	listReq.Header().Set("X-Session-ID", sessionID)
+	listResp, err := server.ListServices(context.Background(), listReq)
+	if err != nil {
+		t.Fatalf("ListServices failed: %v", err)
+	}
+	if len(listResp.Msg.Services) != 1 {
+		t.Errorf("Expected restored service, got %d services", len(listResp.Msg.Services))
+	}
+
+	missing := connect.NewRequest(&catalogv1.CompactSessionRequest{})
+	missing.Header().Set("X-Session-ID", "missing")
+	if _, err := server.CompactSession(context.Background(), missing); connect.CodeOf(err) != connect.CodeNotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+
+	disabled := New()
+	defer disabled.Close()
+	_, disabledID, _ := disabled.sessionManager.GetOrCreate("")
+	req = connect.NewRequest(&catalogv1.CompactSessionRequest{})
+	req.Header().Set("X-Session-ID", disabledID)
+	if _, err := disabled.CompactSession(context.Background(), req); connect.CodeOf(err) != connect.CodeFailedPrecondition {
+		t.Errorf("Expected FailedPrecondition, got %v", err)
+	}
+}
+
+// TestGetPackageStats tests per-package counts for the session registry
+func TestGetPackageStats(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
 		t.Fatalf("Failed to register test descriptors: %v", err)
 	}
 
-	// Verify services are loaded in session 1
-	listReq1 := connect.NewRequest(&catalogv1.ListServicesRequest{})
-	listReq1.Header().Set("X-Session-ID", sessionID1)
-	listResp1, err := server.ListServices(ctx, listReq1)
+	req := connect.NewRequest(&catalogv1.GetPackageStatsRequest{})
+	req.Header().Set("X-Session-ID", sessionID)
+	resp, err := server.GetPackageStats(context.Background(), req)
 	if err != nil {
-		t.Fatalf("ListServices failed: %v", err)
+		t.Fatalf("GetPackageStats failed: %v", err)
 	}
-	if len(listResp1.Msg.Services) == 0 {
-		t.Fatal("Expected services to be loaded in session 1")
+
+	if len(resp.Msg.Packages) != 1 {
+		t.Fatalf("Expected 1 package, got %d", len(resp.Msg.Packages))
 	}
+	pkg := resp.Msg.Packages[0]
+	if pkg.Package != "test.v1" || pkg.FileCount != 1 || pkg.ServiceCount != 1 {
+		t.Errorf("Unexpected package stats: %v", pkg)
+	}
+}
 
-	// Create second session (should have no services)
-	listReq2 := connect.NewRequest(&catalogv1.ListServicesRequest{})
-	listResp2, err := server.ListServices(ctx, listReq2)
+// TestInvokeGRPC_MethodPath tests targeting a method by its full method path
+func TestInvokeGRPC_MethodPath(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	state, sessionID, err := server.sessionManager.GetOrCreate("")
 	if err != nil {
-		t.Fatalf("ListServices failed: %v", err)
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if err := state.Registry.Register(createTestFileDescriptorSet()); err != nil {
+		t.Fatalf("Failed to register test descriptors: %v", err)
 	}
 
-	sessionID2 := listResp2.Header().Get("X-Session-ID")
-	if sessionID2 == sessionID1 {
-		t.Fatal("Expected different session ID")
+	invoke := func(service, method string) (*catalogv1.InvokeGRPCResponse, error) {
+		req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+			Endpoint:    "localhost:1",
+			Service:     service,
+			Method:      method,
+			RequestJson: `{"name": "test"}`,
+			DryRun:      true,
+		})
+		req.Header().Set("X-Session-ID", sessionID)
+		resp, err := server.InvokeGRPC(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
 	}
 
-	if len(listResp2.Msg.Services) != 0 {
-		t.Errorf("Expected zero services in new session, got %d", len(listResp2.Msg.Services))
+	for _, method := range []string{"test.v1.TestService/TestMethod", "/test.v1.TestService/TestMethod"} {
+		resp, err := invoke("", method)
+		if err != nil {
+			t.Fatalf("InvokeGRPC(%q) failed: %v", method, err)
+		}
+		if !resp.Success {
+			t.Errorf("InvokeGRPC(%q): expected success, got error: %s", method, resp.Error)
+		}
+	}
+
+	// Splitting the path leaves the request as sent
+	msg := &catalogv1.InvokeGRPCRequest{Endpoint: "localhost:1", Method: "/test.v1.TestService/TestMethod"}
+	service, method, err := validateInvokeGRPCRequest(msg)
+	if err != nil || service != "test.v1.TestService" || method != "TestMethod" {
+		t.Errorf("Expected test.v1.TestService and TestMethod, got %q, %q (%v)", service, method, err)
+	}
+	if msg.Service != "" || msg.Method != "/test.v1.TestService/TestMethod" {
+		t.Errorf("Expected the request unchanged, got service %q and method %q", msg.Service, msg.Method)
+	}
+
+	// A path that splits but doesn't resolve reports the missing method
+	resp, err := invoke("", "/test.v1.TestService/Missing")
+	if err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+	if resp.Success || !strings.Contains(resp.Error, "method not found") {
+		t.Errorf("Expected method not found error, got: %s", resp.Error)
+	}
+
+	// Malformed paths and bare method names without a service are rejected
+	for _, method := range []string{"/TestMethod", "TestMethod"} {
+		_, err := invoke("", method)
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("InvokeGRPC(%q): expected InvalidArgument, got %v", method, err)
+		}
+	}
+}
+
+// TestInvokeGRPC_InlineDescriptors tests invoking a method resolved from a
+// descriptor set in the request, which never reaches the session's catalog
+func TestInvokeGRPC_InlineDescriptors(t *testing.T) {
+	server := New(WithMaxConcurrentInvocations(1))
+	defer server.Close()
+	ctx := context.Background()
+
+	descriptors, err := proto.Marshal(createTestFileDescriptorSet())
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptors: %v", err)
+	}
+
+	invoke := func(method string, fds []byte) (*connect.Response[catalogv1.InvokeGRPCResponse], error) {
+		return server.InvokeGRPC(ctx, connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+			Endpoint:          "localhost:1",
+			Service:           "test.v1.TestService",
+			Method:            method,
+			RequestJson:       `{"name": "test"}`,
+			DryRun:            true,
+			FileDescriptorSet: fds,
+		}))
+	}
+
+	resp, err := invoke("TestMethod", descriptors)
+	if err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+	if !resp.Msg.Success {
+		t.Errorf("Expected the inline method to resolve, got error: %s", resp.Msg.Error)
+	}
+	sessionID := resp.Header().Get("X-Session-ID")
+	if server.sessionManager.Get(sessionID).Registry.HasService("test.v1.TestService") {
+		t.Error("Expected inline descriptors not to be registered into the session")
+	}
+
+	resp, err = invoke("Missing", descriptors)
+	if err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+	if resp.Msg.Success || !strings.Contains(resp.Msg.Error, "method not found") {
+		t.Errorf("Expected method not found for a method outside the set, got: %s", resp.Msg.Error)
+	}
+
+	if _, err := invoke("TestMethod", []byte("not a descriptor set")); connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Errorf("Expected InvalidArgument for a malformed set, got %v", err)
+	}
+
+	// Calls through inline descriptors are not recorded in the session's stats
+	req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:          "localhost:1",
+		Service:           "test.v1.TestService",
+		Method:            "TestMethod",
+		RequestJson:       `{"name": "test"}`,
+		FileDescriptorSet: descriptors,
+	})
+	req.Header().Set("X-Session-ID", sessionID)
+	if _, err := server.InvokeGRPC(ctx, req); err != nil {
+		t.Fatalf("InvokeGRPC failed: %v", err)
+	}
+	if stats := server.sessionManager.Get(sessionID).MethodStats(); len(stats) != 0 {
+		t.Errorf("Expected no method stats, got %v", stats)
+	}
+
+	// The set is only decoded once the invocation slot is held
+	release, err := server.acquireInvocation()
+	if err != nil {
+		t.Fatalf("Failed to acquire a slot: %v", err)
+	}
+	defer release()
+	if _, err := invoke("TestMethod", []byte("not a descriptor set")); connect.CodeOf(err) != connect.CodeResourceExhausted {
+		t.Errorf("Expected ResourceExhausted with no free slot, got %v", err)
+	}
+}
+
+// TestSetCredentialProvider tests registering and removing session credential providers
+func TestSetCredentialProvider(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	_, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	set := func(msg *catalogv1.SetCredentialProviderRequest) (*catalogv1.SetCredentialProviderResponse, error) {
+		req := connect.NewRequest(msg)
+		req.Header().Set("X-Session-ID", sessionID)
+		resp, err := server.SetCredentialProvider(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	resp, err := set(&catalogv1.SetCredentialProviderRequest{
+		Name: "token",
+		Provider: &catalogv1.SetCredentialProviderRequest_BearerToken{
+			BearerToken: &catalogv1.BearerTokenCredentials{Token: "secret"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetCredentialProvider failed: %v", err)
+	}
+	if len(resp.Providers) != 1 || resp.Providers[0] != "token" {
+		t.Errorf("Expected providers [token], got %v", resp.Providers)
+	}
+
+	// Invalid certificates are reported without registering the provider
+	resp, err = set(&catalogv1.SetCredentialProviderRequest{
+		Name: "client",
+		Provider: &catalogv1.SetCredentialProviderRequest_Mtls{
+			Mtls: &catalogv1.MTLSCredentials{CertPem: "invalid", KeyPem: "invalid"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SetCredentialProvider failed: %v", err)
+	}
+	if resp.Error == "" || len(resp.Providers) != 1 {
+		t.Errorf("Expected certificate error and unchanged providers, got %q %v", resp.Error, resp.Providers)
+	}
+
+	// Omitting the provider removes it
+	resp, err = set(&catalogv1.SetCredentialProviderRequest{Name: "token"})
+	if err != nil {
+		t.Fatalf("SetCredentialProvider failed: %v", err)
+	}
+	if len(resp.Providers) != 0 {
+		t.Errorf("Expected no providers, got %v", resp.Providers)
+	}
+
+	for _, msg := range []*catalogv1.SetCredentialProviderRequest{
+		{},
+		{Name: "token", Provider: &catalogv1.SetCredentialProviderRequest_BearerToken{BearerToken: &catalogv1.BearerTokenCredentials{}}},
+	} {
+		if _, err := set(msg); connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", msg, err)
+		}
+	}
+}
+
+// TestSetOAuthCredentials tests registering OAuth2 client credentials
+func TestSetOAuthCredentials(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	_, sessionID, err := server.sessionManager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	set := func(msg *catalogv1.SetOAuthCredentialsRequest) (*catalogv1.SetOAuthCredentialsResponse, error) {
+		req := connect.NewRequest(msg)
+		req.Header().Set("X-Session-ID", sessionID)
+		resp, err := server.SetOAuthCredentials(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	resp, err := set(&catalogv1.SetOAuthCredentialsRequest{
+		Name:         "gateway",
+		TokenUrl:     "https://auth.example.com/oauth2/token",
+		ClientId:     "client",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"read"},
+	})
+	if err != nil {
+		t.Fatalf("SetOAuthCredentials failed: %v", err)
+	}
+	if len(resp.Providers) != 1 || resp.Providers[0] != "gateway" {
+		t.Errorf("Expected providers [gateway], got %v", resp.Providers)
+	}
+
+	for _, msg := range []*catalogv1.SetOAuthCredentialsRequest{
+		{TokenUrl: "https://auth.example.com/token", ClientId: "client"},
+		{Name: "gateway", TokenUrl: "https://auth.example.com/token"},
+		{Name: "gateway", TokenUrl: "auth.example.com/token", ClientId: "client"},
+	} {
+		if _, err := set(msg); connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Errorf("Expected InvalidArgument for %v, got %v", msg, err)
+		}
+	}
+}
+
+// TestLoadProtos_ReflectionEndpoints tests that failed reflection endpoints are reported
+func TestLoadProtos_ReflectionEndpoints(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	unreachable := lis.Addr().String()
+	lis.Close()
+
+	req := connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_ReflectionEndpoints{
+			ReflectionEndpoints: &catalogv1.ReflectionEndpoints{Endpoints: []string{unreachable}},
+		},
+		ReflectionOptions: &catalogv1.ReflectionOptions{TimeoutSeconds: 1},
+	})
+	resp, err := server.LoadProtos(context.Background(), req)
+	if err != nil {
+		t.Fatalf("LoadProtos failed: %v", err)
+	}
+	if resp.Msg.Success || !strings.Contains(resp.Msg.Error, unreachable) {
+		t.Errorf("Expected failure naming %s, got %q", unreachable, resp.Msg.Error)
+	}
+}
+
+// serveReflection serves reflection, and the health service if withHealth is
+// set, on addr until the returned function is called
+func serveReflection(t *testing.T, addr string, withHealth bool) func() {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	grpcServer := grpc.NewServer()
+	if withHealth {
+		healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	}
+	reflection.Register(grpcServer)
+	go grpcServer.Serve(lis)
+	return grpcServer.Stop
+}
+
+// TestRefreshReflection tests that refreshing a reflection source picks up
+// services added and removed since it was loaded
+func TestRefreshReflection(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	endpoint := lis.Addr().String()
+	lis.Close()
+
+	stop := serveReflection(t, endpoint, true)
+	defer func() { stop() }()
+
+	ctx := context.Background()
+	loadReq := connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_ReflectionEndpoint{ReflectionEndpoint: endpoint},
+		ReflectionOptions: &catalogv1.ReflectionOptions{
+			TimeoutSeconds:         5,
+			DisableDefaultExcludes: true,
+		},
+	})
+	loadResp, err := server.LoadProtos(ctx, loadReq)
+	if err != nil || !loadResp.Msg.Success {
+		t.Fatalf("LoadProtos failed: %v %v", err, loadResp.Msg.GetError())
+	}
+	sessionID := loadResp.Header().Get("X-Session-ID")
+
+	refresh := func(msg *catalogv1.RefreshReflectionRequest) *catalogv1.RefreshReflectionResponse {
+		t.Helper()
+		req := connect.NewRequest(msg)
+		req.Header().Set("X-Session-ID", sessionID)
+		resp, err := server.RefreshReflection(ctx, req)
+		if err != nil {
+			t.Fatalf("RefreshReflection failed: %v", err)
+		}
+		return resp.Msg
+	}
+	state := server.sessionManager.Get(sessionID)
+
+	// A fresh source is skipped
+	resp := refresh(&catalogv1.RefreshReflectionRequest{MaxAgeSeconds: 3600})
+	if len(resp.Results) != 1 || resp.Results[0].Refreshed {
+		t.Fatalf("Expected the source to be skipped as fresh, got %v", resp)
+	}
+
+	// Restart the server without the health service
+	stop()
+	stop = serveReflection(t, endpoint, false)
+
+	resp = refresh(&catalogv1.RefreshReflectionRequest{Endpoint: endpoint})
+	if len(resp.Results) != 1 || !resp.Results[0].Refreshed {
+		t.Fatalf("Expected the source to be refreshed, got %v", resp)
+	}
+	result := resp.Results[0]
+	if len(result.RemovedServices) != 1 || result.RemovedServices[0] != "grpc.health.v1.Health" {
+		t.Errorf("Expected grpc.health.v1.Health to be removed, got %v", result.RemovedServices)
+	}
+	if len(result.AddedServices) != 0 || len(result.ChangedServices) != 0 {
+		t.Errorf("Expected no added or changed services, got %v and %v", result.AddedServices, result.ChangedServices)
+	}
+	if state.Registry.HasService("grpc.health.v1.Health") {
+		t.Error("Expected the removed service to leave the registry")
+	}
+	if state.ServiceOrigins("grpc.health.v1.Health") != nil {
+		t.Error("Expected the removed service to lose its origin")
+	}
+
+	// Restart the server with the health service again
+	stop()
+	stop = serveReflection(t, endpoint, true)
+
+	result = refresh(&catalogv1.RefreshReflectionRequest{}).Results[0]
+	if len(result.AddedServices) != 1 || result.AddedServices[0] != "grpc.health.v1.Health" {
+		t.Errorf("Expected grpc.health.v1.Health to be added, got %v", result.AddedServices)
+	}
+	if !state.Registry.HasService("grpc.health.v1.Health") {
+		t.Error("Expected the added service in the registry")
+	}
+
+	// A failed refresh keeps the previous descriptors
+	stop()
+	result = refresh(&catalogv1.RefreshReflectionRequest{}).Results[0]
+	if result.Refreshed || result.Error == "" {
+		t.Errorf("Expected refresh of a stopped server to fail, got %v", result)
+	}
+	if !state.Registry.HasService("grpc.health.v1.Health") {
+		t.Error("Expected a failed refresh to keep the registry")
+	}
+
+	if resp := refresh(&catalogv1.RefreshReflectionRequest{Endpoint: "other:443"}); resp.Error == "" {
+		t.Error("Expected an error for an endpoint with no reflection source")
+	}
+}
+
+// TestRefreshReflection_ImportedFile tests that a refresh keeps a file the
+// source no longer serves while a file loaded from disk still imports it
+func TestRefreshReflection_ImportedFile(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	endpoint := lis.Addr().String()
+	lis.Close()
+
+	stop := serveReflection(t, endpoint, true)
+	defer func() { stop() }()
+
+	ctx := context.Background()
+	loadResp, err := server.LoadProtos(ctx, connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_ReflectionEndpoint{ReflectionEndpoint: endpoint},
+		ReflectionOptions: &catalogv1.ReflectionOptions{
+			TimeoutSeconds:         5,
+			DisableDefaultExcludes: true,
+		},
+	}))
+	if err != nil || !loadResp.Msg.Success {
+		t.Fatalf("LoadProtos failed: %v %v", err, loadResp.Msg.GetError())
+	}
+	sessionID := loadResp.Header().Get("X-Session-ID")
+
+	// A descriptor set on disk whose file imports the reflected health.proto
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:       proto.String("monitor/v1/monitor.proto"),
+		Package:    proto.String("monitor.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"grpc/health/v1/health.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("MonitorService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Probe"),
+				InputType:  proto.String(".grpc.health.v1.HealthCheckRequest"),
+				OutputType: proto.String(".grpc.health.v1.HealthCheckResponse"),
+			}},
+		}},
+	}}}
+	data, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "monitor.binpb")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Failed to write descriptor set: %v", err)
+	}
+	pathReq := connect.NewRequest(&catalogv1.LoadProtosRequest{
+		Source: &catalogv1.LoadProtosRequest_DescriptorSetPath{DescriptorSetPath: path},
+	})
+	pathReq.Header().Set("X-Session-ID", sessionID)
+	if resp, err := server.LoadProtos(ctx, pathReq); err != nil || !resp.Msg.Success {
+		t.Fatalf("LoadProtos failed: %v %v", err, resp.Msg.GetError())
+	}
+
+	// Restart the server without the health service
+	stop()
+	stop = serveReflection(t, endpoint, false)
+
+	refreshReq := connect.NewRequest(&catalogv1.RefreshReflectionRequest{Endpoint: endpoint})
+	refreshReq.Header().Set("X-Session-ID", sessionID)
+	resp, err := server.RefreshReflection(ctx, refreshReq)
+	if err != nil {
+		t.Fatalf("RefreshReflection failed: %v", err)
+	}
+	if len(resp.Msg.Results) != 1 || !resp.Msg.Results[0].Refreshed {
+		t.Fatalf("Expected the source to be refreshed, got %v", resp.Msg)
+	}
+
+	state := server.sessionManager.Get(sessionID)
+	if _, err := state.Registry.GetMessageDescriptor("grpc.health.v1.HealthCheckRequest"); err != nil {
+		t.Errorf("Expected the imported file to stay registered: %v", err)
+	}
+	if !state.Registry.HasService("monitor.v1.MonitorService") {
+		t.Error("Expected the importing service to stay registered")
+	}
+}
+
+// TestInvokeReflective tests invoking a method the session has not loaded
+func TestInvokeReflective(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	endpoint := lis.Addr().String()
+	lis.Close()
+	stop := serveReflection(t, endpoint, true)
+
+	ctx := context.Background()
+	req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:       endpoint,
+		Method:         "/grpc.health.v1.Health/Check",
+		RequestJson:    "{}",
+		TimeoutSeconds: 5,
+		Transport:      catalogv1.Transport_TRANSPORT_GRPC,
+	})
+	resp, err := server.InvokeReflective(ctx, req)
+	if err != nil {
+		t.Fatalf("InvokeReflective failed: %v", err)
+	}
+	if !resp.Msg.Success || !strings.Contains(resp.Msg.ResponseJson, "SERVING") {
+		t.Fatalf("Expected a SERVING response, got %v", resp.Msg)
+	}
+	sessionID := resp.Header().Get("X-Session-ID")
+	state := server.sessionManager.Get(sessionID)
+	if got := state.ServiceOrigins("grpc.health.v1.Health"); len(got) != 1 || got[0] != endpoint {
+		t.Errorf("Expected the service to originate from %s, got %v", endpoint, got)
+	}
+
+	// Later calls resolve from the session without reflecting again
+	stop()
+	req = connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:    endpoint,
+		Service:     "grpc.health.v1.Health",
+		Method:      "Check",
+		RequestJson: "{}",
+		Transport:   catalogv1.Transport_TRANSPORT_GRPC,
+		DryRun:      true,
+	})
+	req.Header().Set("X-Session-ID", sessionID)
+	resp, err = server.InvokeReflective(ctx, req)
+	if err != nil {
+		t.Fatalf("InvokeReflective failed: %v", err)
+	}
+	if !resp.Msg.Success {
+		t.Errorf("Expected the cached descriptor to be used, got error: %s", resp.Msg.Error)
+	}
+
+	// An unknown service on an unreachable endpoint reports the reflection failure
+	req = connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+		Endpoint:       endpoint,
+		Service:        "test.v1.MissingService",
+		Method:         "Get",
+		RequestJson:    "{}",
+		TimeoutSeconds: 1,
+	})
+	req.Header().Set("X-Session-ID", sessionID)
+	resp, err = server.InvokeReflective(ctx, req)
+	if err != nil {
+		t.Fatalf("InvokeReflective failed: %v", err)
+	}
+	if resp.Msg.Success || !strings.Contains(resp.Msg.Error, "via reflection") {
+		t.Errorf("Expected a reflection failure, got %v", resp.Msg)
+	}
+	if resp.Msg.ErrorKind != catalogv1.ErrorKind_ERROR_KIND_CONNECTION {
+		t.Errorf("Expected ERROR_KIND_CONNECTION, got %v", resp.Msg.ErrorKind)
+	}
+}
+
+// TestGetMethodStats tests that invocations are counted per method
+func TestGetMethodStats(t *testing.T) {
+	server := New()
+	defer server.Close()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	endpoint := lis.Addr().String()
+	lis.Close()
+	stop := serveReflection(t, endpoint, true)
+	defer stop()
+
+	ctx := context.Background()
+	var sessionID string
+	invoke := func(body string, dryRun bool) {
+		t.Helper()
+		req := connect.NewRequest(&catalogv1.InvokeGRPCRequest{
+			Endpoint:       endpoint,
+			Method:         "/grpc.health.v1.Health/Check",
+			RequestJson:    body,
+			TimeoutSeconds: 5,
+			Transport:      catalogv1.Transport_TRANSPORT_GRPC,
+			DryRun:         dryRun,
+		})
+		req.Header().Set("X-Session-ID", sessionID)
+		resp, err := server.InvokeReflective(ctx, req)
+		if err != nil {
+			t.Fatalf("InvokeReflective failed: %v", err)
+		}
+		sessionID = resp.Header().Get("X-Session-ID")
+	}
+
+	invoke(`{}`, false)
+	invoke(`[{}, {"service": "unknown"}]`, false)
+	invoke(`{}`, true)
+
+	req := connect.NewRequest(&catalogv1.GetMethodStatsRequest{})
+	req.Header().Set("X-Session-ID", sessionID)
+	resp, err := server.GetMethodStats(ctx, req)
+	if err != nil {
+		t.Fatalf("GetMethodStats failed: %v", err)
+	}
+
+	stats := resp.Msg.Methods["/grpc.health.v1.Health/Check"]
+	if stats == nil {
+		t.Fatalf("Expected stats for Health/Check, got %v", resp.Msg.Methods)
+	}
+	if stats.Invocations != 3 || stats.Failures != 1 {
+		t.Errorf("Expected 3 invocations and 1 failure, got %v", stats)
+	}
+	if stats.LastSuccess || stats.LastStatusCode == 0 || stats.LastInvokedUnixMs == 0 {
+		t.Errorf("Expected the failed element as the latest outcome, got %v", stats)
+	}
+	if resp.Msg.TotalInvocations != 3 {
+		t.Errorf("Expected 3 total invocations, got %d", resp.Msg.TotalInvocations)
 	}
 }
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"errors"
+
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"github.com/opentdf/connectrpc-catalog/internal/loader"
+	"github.com/opentdf/connectrpc-catalog/internal/registry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// validateDescriptorSetUpload decodes uploaded descriptor set bytes and checks
+// them before anything is registered. Size and decoding failures stop
+// validation; otherwise every structural and unresolved-symbol problem is
+// reported.
+func validateDescriptorSetUpload(data []byte, maxSize int) (*descriptorpb.FileDescriptorSet, []*catalogv1.DescriptorSetProblem) {
+	fds, err := loader.DecodeDescriptorSet(data, maxSize)
+	if err != nil {
+		kind := catalogv1.DescriptorSetProblemKind_DESCRIPTOR_SET_PROBLEM_KIND_MALFORMED
+		if errors.Is(err, loader.ErrDescriptorSetTooLarge) {
+			kind = catalogv1.DescriptorSetProblemKind_DESCRIPTOR_SET_PROBLEM_KIND_TOO_LARGE
+		}
+		return nil, []*catalogv1.DescriptorSetProblem{{Kind: kind, Message: err.Error()}}
+	}
+
+	invalid := catalogv1.DescriptorSetProblemKind_DESCRIPTOR_SET_PROBLEM_KIND_INVALID
+	if err := registry.ValidateDescriptors(fds); err != nil {
+		return nil, []*catalogv1.DescriptorSetProblem{{Kind: invalid, Message: err.Error()}}
+	}
+
+	var problems []*catalogv1.DescriptorSetProblem
+	for _, fileErr := range registry.CheckDescriptors(fds) {
+		problems = append(problems, &catalogv1.DescriptorSetProblem{
+			Kind:    invalid,
+			File:    fileErr.File,
+			Message: fileErr.Error(),
+		})
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	return fds, nil
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"connectrpc.com/connect"
+	catalogv1 "github.com/opentdf/connectrpc-catalog/gen/catalog/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestLoadProtos_DescriptorSetUpload tests validation of uploaded descriptor sets
+func TestLoadProtos_DescriptorSetUpload(t *testing.T) {
+	valid, err := proto.Marshal(createTestFileDescriptorSet())
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+
+	unresolved := createTestFileDescriptorSet()
+	unresolved.File[0].Service[0].Method[0].InputType = proto.String(".test.v1.Missing")
+	unresolvedBytes, err := proto.Marshal(unresolved)
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+
+	duplicate := createTestFileDescriptorSet()
+	duplicate.File = append(duplicate.File, duplicate.File[0])
+	duplicateBytes, err := proto.Marshal(duplicate)
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantKind catalogv1.DescriptorSetProblemKind
+		wantFile string
+	}{
+		{name: "valid", data: valid},
+		{name: "too large", data: append(valid, make([]byte, 1024)...), wantKind: catalogv1.DescriptorSetProblemKind_DESCRIPTOR_SET_PROBLEM_KIND_TOO_LARGE},
+		{name: "truncated", data: valid[:len(valid)-3], wantKind: catalogv1.DescriptorSetProblemKind_DESCRIPTOR_SET_PROBLEM_KIND_MALFORMED},
+		{name: "empty", data: nil, wantKind: catalogv1.DescriptorSetProblemKind_DESCRIPTOR_SET_PROBLEM_KIND_INVALID},
+		{name: "duplicate file", data: duplicateBytes, wantKind: catalogv1.DescriptorSetProblemKind_DESCRIPTOR_SET_PROBLEM_KIND_INVALID},
+		{name: "unresolved symbol", data: unresolvedBytes, wantKind: catalogv1.DescriptorSetProblemKind_DESCRIPTOR_SET_PROBLEM_KIND_INVALID, wantFile: "test.proto"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(WithMaxDescriptorSetSize(len(valid) + 512))
+			defer server.Close()
+
+			resp, err := server.LoadProtos(context.Background(), connect.NewRequest(&catalogv1.LoadProtosRequest{
+				Source: &catalogv1.LoadProtosRequest_DescriptorSet{DescriptorSet: tt.data},
+			}))
+			if err != nil {
+				t.Fatalf("LoadProtos failed: %v", err)
+			}
+
+			if tt.wantKind == catalogv1.DescriptorSetProblemKind_DESCRIPTOR_SET_PROBLEM_KIND_UNSPECIFIED {
+				if !resp.Msg.Success || resp.Msg.ServiceCount != 1 {
+					t.Errorf("Expected successful load, got %v", resp.Msg)
+				}
+				return
+			}
+
+			if resp.Msg.Success || resp.Msg.Error == "" {
+				t.Errorf("Expected failure, got %v", resp.Msg)
+			}
+			if len(resp.Msg.Problems) == 0 {
+				t.Fatal("Expected problems to be reported")
+			}
+			problem := resp.Msg.Problems[0]
+			if problem.Kind != tt.wantKind {
+				t.Errorf("Expected kind %v, got %v (%s)", tt.wantKind, problem.Kind, problem.Message)
+			}
+			if problem.File != tt.wantFile {
+				t.Errorf("Expected file %q, got %q", tt.wantFile, problem.File)
+			}
+		})
+	}
+}
+
+// TestValidateDescriptorSetUpload_ReportsAllFiles tests that every unresolved file is listed
+func TestValidateDescriptorSetUpload_ReportsAllFiles(t *testing.T) {
+	fds := createTestFileDescriptorSet()
+	for _, name := range []string{"a.proto", "b.proto"} {
+		fds.File = append(fds.File, &descriptorpb.FileDescriptorProto{
+			Name:       proto.String(name),
+			Syntax:     proto.String("proto3"),
+			Dependency: []string{"missing.proto"},
+		})
+	}
+	data, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatalf("Failed to marshal descriptor set: %v", err)
+	}
+
+	_, problems := validateDescriptorSetUpload(data, 0)
+	if len(problems) != 2 || problems[0].File != "a.proto" || problems[1].File != "b.proto" {
+		t.Errorf("Expected problems for a.proto and b.proto, got %v", problems)
+	}
+}
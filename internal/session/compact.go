@@ -0,0 +1,169 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	// ErrCompactionDisabled is returned when no compaction directory is configured
+	ErrCompactionDisabled = errors.New("session compaction is not configured")
+	// ErrSessionNotFound is returned when a session ID does not exist
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionShared is returned when compacting the shared session, which
+	// stays resident
+	ErrSessionShared = errors.New("the shared session cannot be compacted")
+	// ErrSessionBusy is returned when a session is in use by a load or
+	// another request and cannot be compacted
+	ErrSessionBusy = errors.New("session is in use")
+)
+
+// SetCompaction enables spilling idle session registries to dir. Sessions
+// unused for idleAfter are compacted by the cleanup loop; a non-positive
+// idleAfter leaves only explicit Compact calls. An empty dir disables
+// compaction.
+func (m *Manager) SetCompaction(dir string, idleAfter time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.compactDir = dir
+	m.compactAfter = idleAfter
+}
+
+// Compact serializes a session's registry to the compaction directory and
+// drops the in-memory descriptors. They are restored on the session's next
+// access through GetOrCreate or Get. It returns the number of bytes spilled,
+// which is zero if the session is empty or already compacted. The shared
+// session is never compacted.
+func (m *Manager) Compact(sessionID string) (int64, error) {
+	m.mu.RLock()
+	dir := m.compactDir
+	state, exists := m.sessions[sessionID]
+	m.mu.RUnlock()
+
+	if dir == "" {
+		return 0, ErrCompactionDisabled
+	}
+	if !exists {
+		return 0, ErrSessionNotFound
+	}
+	if state.Shared() {
+		return 0, ErrSessionShared
+	}
+	return state.compact(dir, sessionID)
+}
+
+// compactIdle compacts sessions unused for the configured idle period.
+// Sessions that are busy or fail to compact are left resident.
+func (m *Manager) compactIdle() {
+	m.mu.RLock()
+	dir, idleAfter := m.compactDir, m.compactAfter
+	idle := make(map[string]*State)
+	if dir != "" && idleAfter > 0 {
+		now := m.now()
+		for id, state := range m.sessions {
+			if now.Sub(state.LastUsed) > idleAfter && !state.Shared() {
+				idle[id] = state
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	for id, state := range idle {
+		_, _ = state.compact(dir, id)
+	}
+}
+
+// Release ends a request's use of the session, see Manager.Acquire
+func (s *State) Release() {
+	s.inUse.Add(-1)
+}
+
+// Compacted reports whether the session's registry is currently spilled to disk
+func (s *State) Compacted() bool {
+	return s.compacted.Load()
+}
+
+// compact spills the registry to a file in dir and clears it
+func (s *State) compact(dir, sessionID string) (int64, error) {
+	// Never drop descriptors out from under an in-progress load
+	if !s.LoadMu.TryLock() {
+		return 0, ErrSessionBusy
+	}
+	defer s.LoadMu.Unlock()
+
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
+
+	// Requests acquiring the session from here on wait in hydrate until the
+	// registry is spilled, then restore it
+	if s.inUse.Load() > 0 {
+		return 0, ErrSessionBusy
+	}
+	if s.compactedPath != "" || s.Registry.EncodedSize() == 0 {
+		return 0, nil
+	}
+
+	data, err := s.Registry.MarshalBinary()
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize registry: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "session-"+sessionID+"-*.pb")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create compaction file: %w", err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return 0, fmt.Errorf("failed to write compaction file: %w", err)
+	}
+
+	s.Registry.Clear()
+	s.compactedPath = f.Name()
+	s.compacted.Store(true)
+	return int64(len(data)), nil
+}
+
+// hydrate restores a compacted registry from disk. The file is kept if
+// restoring fails so a later access can retry. It always takes compactMu, so
+// a caller never reads the registry while a compaction is clearing it.
+func (s *State) hydrate() error {
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
+
+	if s.compactedPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.compactedPath)
+	if err != nil {
+		return fmt.Errorf("failed to read compacted session: %w", err)
+	}
+	if err := s.Registry.UnmarshalBinary(data); err != nil {
+		s.Registry.Clear()
+		return fmt.Errorf("failed to restore compacted session: %w", err)
+	}
+
+	os.Remove(s.compactedPath)
+	s.compactedPath = ""
+	s.compacted.Store(false)
+	return nil
+}
+
+// discardCompacted removes the session's compaction file, if any
+func (s *State) discardCompacted() {
+	s.compactMu.Lock()
+	defer s.compactMu.Unlock()
+
+	if s.compactedPath != "" {
+		os.Remove(s.compactedPath)
+		s.compactedPath = ""
+		s.compacted.Store(false)
+	}
+}
@@ -0,0 +1,65 @@
+package session
+
+import (
+	"sort"
+	"strings"
+)
+
+// SetEndpointDefaults replaces the metadata added to invocations of endpoint.
+// Empty metadata removes the endpoint's defaults.
+func (s *State) SetEndpointDefaults(endpoint string, md map[string]string) {
+	s.defaultsMu.Lock()
+	defer s.defaultsMu.Unlock()
+
+	key := endpointKey(endpoint)
+	if len(md) == 0 {
+		delete(s.endpointDefaults, key)
+		return
+	}
+
+	defaults := make(map[string]string, len(md))
+	for k, v := range md {
+		defaults[k] = v
+	}
+	if s.endpointDefaults == nil {
+		s.endpointDefaults = make(map[string]map[string]string)
+	}
+	s.endpointDefaults[key] = defaults
+}
+
+// EndpointDefaults returns a copy of the default metadata for endpoint, or
+// nil if none is set
+func (s *State) EndpointDefaults(endpoint string) map[string]string {
+	s.defaultsMu.RLock()
+	defer s.defaultsMu.RUnlock()
+
+	defaults, ok := s.endpointDefaults[endpointKey(endpoint)]
+	if !ok {
+		return nil
+	}
+
+	md := make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		md[k] = v
+	}
+	return md
+}
+
+// DefaultedEndpoints returns the endpoints with default metadata, sorted
+func (s *State) DefaultedEndpoints() []string {
+	s.defaultsMu.RLock()
+	defer s.defaultsMu.RUnlock()
+
+	endpoints := make([]string, 0, len(s.endpointDefaults))
+	for endpoint := range s.endpointDefaults {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+// endpointKey normalizes an endpoint for matching; host names are
+// case-insensitive
+func endpointKey(endpoint string) string {
+	return strings.ToLower(strings.TrimSpace(endpoint))
+}
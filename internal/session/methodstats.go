@@ -0,0 +1,53 @@
+package session
+
+import "time"
+
+// MethodStats counts the invocations of one method in a session
+type MethodStats struct {
+	Invocations int64
+	Failures    int64
+
+	// The outcome of the latest invocation
+	LastSuccess    bool
+	LastStatusCode int32
+	LastError      string
+	LastInvokedAt  time.Time
+}
+
+// RecordInvocation counts an invocation of method, given as a full method
+// path, and records its outcome as the method's latest
+func (s *State) RecordInvocation(method string, success bool, statusCode int32, errMsg string) {
+	s.methodStatsMu.Lock()
+	defer s.methodStatsMu.Unlock()
+
+	if s.methodStats == nil {
+		s.methodStats = make(map[string]*MethodStats)
+	}
+	stats, ok := s.methodStats[method]
+	if !ok {
+		stats = &MethodStats{}
+		s.methodStats[method] = stats
+	}
+
+	stats.Invocations++
+	if !success {
+		stats.Failures++
+	}
+	stats.LastSuccess = success
+	stats.LastStatusCode = statusCode
+	stats.LastError = errMsg
+	stats.LastInvokedAt = time.Now()
+}
+
+// MethodStats returns a copy of the invocation stats of every method invoked
+// in the session, keyed by full method path
+func (s *State) MethodStats() map[string]MethodStats {
+	s.methodStatsMu.Lock()
+	defer s.methodStatsMu.Unlock()
+
+	stats := make(map[string]MethodStats, len(s.methodStats))
+	for method, methodStats := range s.methodStats {
+		stats[method] = *methodStats
+	}
+	return stats
+}
@@ -0,0 +1,128 @@
+package session
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opentdf/connectrpc-catalog/internal/loader"
+)
+
+// ReflectionSource records a reflection load so it can be refreshed
+type ReflectionSource struct {
+	// Endpoints queried by the load, as given in the request
+	Endpoints []string
+	Options   loader.ReflectionOptions
+	// StripSourceInfo reports whether comments were dropped before registering
+	StripSourceInfo bool
+
+	// Files and Services are what the last load registered
+	Files    []string
+	Services []string
+	LoadedAt time.Time
+}
+
+// reflectionSourceKey identifies a source by its endpoints
+func reflectionSourceKey(endpoints []string) string {
+	sorted := append([]string(nil), endpoints...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// AddServiceOrigins records the endpoints services were discovered from.
+// Endpoints already recorded for a service are not repeated.
+func (s *State) AddServiceOrigins(origins map[string][]string) {
+	s.originsMu.Lock()
+	defer s.originsMu.Unlock()
+
+	if s.serviceOrigins == nil {
+		s.serviceOrigins = make(map[string][]string)
+	}
+	for service, endpoints := range origins {
+		for _, endpoint := range endpoints {
+			if !containsString(s.serviceOrigins[service], endpoint) {
+				s.serviceOrigins[service] = append(s.serviceOrigins[service], endpoint)
+			}
+		}
+	}
+}
+
+// RemoveServiceOrigins forgets that services were discovered from endpoints.
+// Services left with no origin are no longer reported as reflected.
+func (s *State) RemoveServiceOrigins(services, endpoints []string) {
+	s.originsMu.Lock()
+	defer s.originsMu.Unlock()
+
+	for _, service := range services {
+		var kept []string
+		for _, endpoint := range s.serviceOrigins[service] {
+			if !containsString(endpoints, endpoint) {
+				kept = append(kept, endpoint)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.serviceOrigins, service)
+		} else {
+			s.serviceOrigins[service] = kept
+		}
+	}
+}
+
+// DeleteServiceOrigins forgets every endpoint recorded for services, such
+// as those whose files were removed from the registry
+func (s *State) DeleteServiceOrigins(services ...string) {
+	s.originsMu.Lock()
+	defer s.originsMu.Unlock()
+
+	for _, service := range services {
+		delete(s.serviceOrigins, service)
+	}
+}
+
+// ServiceOrigins returns a copy of the endpoints a service was discovered
+// from, or nil if it was not loaded via reflection
+func (s *State) ServiceOrigins(service string) []string {
+	s.originsMu.RLock()
+	defer s.originsMu.RUnlock()
+
+	origins := s.serviceOrigins[service]
+	if len(origins) == 0 {
+		return nil
+	}
+	return append([]string(nil), origins...)
+}
+
+// SetReflectionSource records a reflection load, replacing an earlier load of
+// the same endpoints
+func (s *State) SetReflectionSource(source ReflectionSource) {
+	s.originsMu.Lock()
+	defer s.originsMu.Unlock()
+
+	key := reflectionSourceKey(source.Endpoints)
+	for i, existing := range s.reflectionSources {
+		if reflectionSourceKey(existing.Endpoints) == key {
+			s.reflectionSources[i] = source
+			return
+		}
+	}
+	s.reflectionSources = append(s.reflectionSources, source)
+}
+
+// ReflectionSources returns the session's reflection loads in the order they
+// were first made
+func (s *State) ReflectionSources() []ReflectionSource {
+	s.originsMu.RLock()
+	defer s.originsMu.RUnlock()
+
+	return append([]ReflectionSource(nil), s.reflectionSources...)
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
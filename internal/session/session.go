@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/opentdf/connectrpc-catalog/internal/invoker"
@@ -13,7 +14,8 @@ import (
 const (
 	// DefaultSessionTTL is the default time-to-live for sessions
 	DefaultSessionTTL = 1 * time.Hour
-	// CleanupInterval is how often to check for expired sessions
+	// CleanupInterval is the default for how often to check for expired
+	// sessions, see WithCleanupInterval
 	CleanupInterval = 5 * time.Minute
 	// SessionIDLength is the length of session IDs in bytes (will be hex encoded)
 	SessionIDLength = 16
@@ -25,26 +27,118 @@ type State struct {
 	Invoker   *invoker.Invoker
 	CreatedAt time.Time
 	LastUsed  time.Time
+
+	// LoadMu serializes descriptor loads into this session's registry
+	LoadMu sync.Mutex
+
+	// compactMu guards compactedPath, the file holding the spilled registry
+	// while the session is compacted
+	compactMu     sync.Mutex
+	compactedPath string
+	compacted     atomic.Bool
+
+	// inUse counts the requests served from the session, see Acquire;
+	// sessions in use aren't compacted
+	inUse atomic.Int32
+
+	// defaultsMu guards endpointDefaults, the metadata added to invocations
+	// by endpoint
+	defaultsMu       sync.RWMutex
+	endpointDefaults map[string]map[string]string
+
+	// originsMu guards serviceOrigins, the reflection endpoints each service
+	// was discovered from, and reflectionSources, the reflection loads that
+	// can be refreshed
+	originsMu         sync.RWMutex
+	serviceOrigins    map[string][]string
+	reflectionSources []ReflectionSource
+
+	// methodStatsMu guards methodStats, the invocation counts by full
+	// method path
+	methodStatsMu sync.Mutex
+	methodStats   map[string]*MethodStats
+
+	// shared marks the manager's shared session, see CreateShared; readOnly
+	// is set with SetReadOnly
+	shared   atomic.Bool
+	readOnly atomic.Bool
 }
 
+// InvokerFactory creates the invoker for a new session
+type InvokerFactory func() *invoker.Invoker
+
 // Manager handles session lifecycle
 type Manager struct {
-	sessions map[string]*State
-	mu       sync.RWMutex
-	ttl      time.Duration
-	stopCh   chan struct{}
+	sessions   map[string]*State
+	mu         sync.RWMutex
+	ttl        time.Duration
+	stopCh     chan struct{}
+	newInvoker InvokerFactory
+
+	// How often the cleanup loop runs, and the clock it and session
+	// timestamps read
+	cleanupInterval time.Duration
+	now             func() time.Time
+
+	// Idle session compaction, see SetCompaction
+	compactDir   string
+	compactAfter time.Duration
+
+	// sharedID is the session given to clients without a session ID, see
+	// CreateShared
+	sharedID string
+}
+
+// ManagerOption configures a Manager
+type ManagerOption func(*Manager)
+
+// WithCleanupInterval sets how often expired sessions are removed and idle
+// ones compacted. Non-positive values keep CleanupInterval.
+func WithCleanupInterval(interval time.Duration) ManagerOption {
+	return func(m *Manager) {
+		if interval > 0 {
+			m.cleanupInterval = interval
+		}
+	}
+}
+
+// WithClock sets the clock used for session timestamps, expiry and idle
+// compaction, letting tests advance time instead of waiting. A nil clock
+// keeps time.Now.
+func WithClock(now func() time.Time) ManagerOption {
+	return func(m *Manager) {
+		if now != nil {
+			m.now = now
+		}
+	}
 }
 
 // NewManager creates a new session manager
-func NewManager(ttl time.Duration) *Manager {
+func NewManager(ttl time.Duration, opts ...ManagerOption) *Manager {
+	return NewManagerWithInvokerFactory(ttl, invoker.New, opts...)
+}
+
+// NewManagerWithInvokerFactory creates a new session manager whose sessions
+// get their invoker from the given factory
+func NewManagerWithInvokerFactory(ttl time.Duration, newInvoker InvokerFactory, opts ...ManagerOption) *Manager {
 	if ttl <= 0 {
 		ttl = DefaultSessionTTL
 	}
+	if newInvoker == nil {
+		newInvoker = invoker.New
+	}
 
 	m := &Manager{
-		sessions: make(map[string]*State),
-		ttl:      ttl,
-		stopCh:   make(chan struct{}),
+		sessions:   make(map[string]*State),
+		ttl:        ttl,
+		stopCh:     make(chan struct{}),
+		newInvoker: newInvoker,
+
+		cleanupInterval: CleanupInterval,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
 
 	// Start cleanup goroutine
@@ -62,8 +156,25 @@ func GenerateID() (string, error) {
 	return hex.EncodeToString(bytes), nil
 }
 
-// GetOrCreate returns an existing session or creates a new one
+// GetOrCreate returns an existing session or creates a new one. Without a
+// session ID it returns the shared session, if there is one.
 func (m *Manager) GetOrCreate(sessionID string) (*State, string, error) {
+	return m.getOrCreate(sessionID, false)
+}
+
+// Acquire is GetOrCreate for serving a request: the session is marked in use
+// and isn't compacted until the caller calls Release
+func (m *Manager) Acquire(sessionID string) (*State, string, error) {
+	return m.getOrCreate(sessionID, true)
+}
+
+// getOrCreate implements GetOrCreate, marking the session in use if acquire
+// is set
+func (m *Manager) getOrCreate(sessionID string, acquire bool) (*State, string, error) {
+	if sessionID == "" {
+		sessionID = m.SharedID()
+	}
+
 	// Try to get existing session
 	if sessionID != "" {
 		m.mu.RLock()
@@ -72,8 +183,17 @@ func (m *Manager) GetOrCreate(sessionID string) (*State, string, error) {
 
 		if exists {
 			m.mu.Lock()
-			state.LastUsed = time.Now()
+			state.LastUsed = m.now()
+			if acquire {
+				state.inUse.Add(1)
+			}
 			m.mu.Unlock()
+			if err := state.hydrate(); err != nil {
+				if acquire {
+					state.Release()
+				}
+				return nil, "", err
+			}
 			return state, sessionID, nil
 		}
 	}
@@ -84,11 +204,15 @@ func (m *Manager) GetOrCreate(sessionID string) (*State, string, error) {
 		return nil, "", err
 	}
 
+	now := m.now()
 	state := &State{
 		Registry:  registry.New(),
-		Invoker:   invoker.New(),
-		CreatedAt: time.Now(),
-		LastUsed:  time.Now(),
+		Invoker:   m.newInvoker(),
+		CreatedAt: now,
+		LastUsed:  now,
+	}
+	if acquire {
+		state.inUse.Add(1)
 	}
 
 	m.mu.Lock()
@@ -98,60 +222,97 @@ func (m *Manager) GetOrCreate(sessionID string) (*State, string, error) {
 	return state, newID, nil
 }
 
-// Get returns a session by ID, or nil if not found
+// Get returns a session by ID, or nil if not found. A compacted session is
+// restored on a best-effort basis; use GetOrCreate to observe restore errors.
 func (m *Manager) Get(sessionID string) *State {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
-
 	state, exists := m.sessions[sessionID]
 	if !exists {
+		m.mu.RUnlock()
 		return nil
 	}
 
 	// Update last used time
-	state.LastUsed = time.Now()
+	state.LastUsed = m.now()
+	m.mu.RUnlock()
+
+	_ = state.hydrate()
 	return state
 }
 
+// Lookup returns the session with the given ID, or nil if there is none.
+// Unlike Get, it neither marks the session used nor restores a compacted
+// registry.
+func (m *Manager) Lookup(sessionID string) *State {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sessions[sessionID]
+}
+
 // Delete removes a session
 func (m *Manager) Delete(sessionID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if state, exists := m.sessions[sessionID]; exists {
-		if state.Invoker != nil {
-			state.Invoker.Close()
-		}
+		state.release()
 		delete(m.sessions, sessionID)
 	}
 }
 
-// cleanupLoop periodically removes expired sessions
+// release closes the session's connections and drops its compaction file,
+// endpoint defaults, reflection provenance and method stats
+func (s *State) release() {
+	if s.Invoker != nil {
+		s.Invoker.Close()
+	}
+	s.discardCompacted()
+
+	s.defaultsMu.Lock()
+	s.endpointDefaults = nil
+	s.defaultsMu.Unlock()
+
+	s.originsMu.Lock()
+	s.serviceOrigins = nil
+	s.reflectionSources = nil
+	s.originsMu.Unlock()
+
+	s.methodStatsMu.Lock()
+	s.methodStats = nil
+	s.methodStatsMu.Unlock()
+}
+
+// cleanupLoop periodically removes expired sessions and compacts idle ones
 func (m *Manager) cleanupLoop() {
-	ticker := time.NewTicker(CleanupInterval)
+	ticker := time.NewTicker(m.cleanupInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			m.cleanup()
+			m.Sweep()
 		case <-m.stopCh:
 			return
 		}
 	}
 }
 
+// Sweep removes expired sessions and compacts idle ones, as the cleanup
+// loop does every cleanup interval
+func (m *Manager) Sweep() {
+	m.cleanup()
+	m.compactIdle()
+}
+
 // cleanup removes expired sessions
 func (m *Manager) cleanup() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	now := time.Now()
+	now := m.now()
 	for id, state := range m.sessions {
-		if now.Sub(state.LastUsed) > m.ttl {
-			if state.Invoker != nil {
-				state.Invoker.Close()
-			}
+		if now.Sub(state.LastUsed) > m.ttl && !state.Shared() {
+			state.release()
 			delete(m.sessions, id)
 		}
 	}
@@ -165,18 +326,35 @@ func (m *Manager) Close() {
 	defer m.mu.Unlock()
 
 	for id, state := range m.sessions {
-		if state.Invoker != nil {
-			state.Invoker.Close()
-		}
+		state.release()
 		delete(m.sessions, id)
 	}
 }
 
+// States returns the current sessions in no particular order
+func (m *Manager) States() []*State {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	states := make([]*State, 0, len(m.sessions))
+	for _, state := range m.sessions {
+		states = append(states, state)
+	}
+	return states
+}
+
+// TTL returns the session time-to-live
+func (m *Manager) TTL() time.Duration {
+	return m.ttl
+}
+
 // Stats returns session statistics
 type Stats struct {
 	ActiveSessions int
 	OldestSession  time.Duration
 	NewestSession  time.Duration
+	// Sessions whose registry is spilled to disk
+	CompactedSessions int
 }
 
 // GetStats returns current session statistics
@@ -188,7 +366,7 @@ func (m *Manager) GetStats() Stats {
 		ActiveSessions: len(m.sessions),
 	}
 
-	now := time.Now()
+	now := m.now()
 	for _, state := range m.sessions {
 		age := now.Sub(state.CreatedAt)
 		if stats.OldestSession == 0 || age > stats.OldestSession {
@@ -197,6 +375,9 @@ func (m *Manager) GetStats() Stats {
 		if stats.NewestSession == 0 || age < stats.NewestSession {
 			stats.NewestSession = age
 		}
+		if state.Compacted() {
+			stats.CompactedSessions++
+		}
 	}
 
 	return stats
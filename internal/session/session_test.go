@@ -1,8 +1,16 @@
 package session
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/opentdf/connectrpc-catalog/internal/invoker"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 func TestGenerateID(t *testing.T) {
@@ -130,14 +138,35 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+// fakeClock is a manually advanced clock for WithClock
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
 func TestCleanup(t *testing.T) {
-	// Use short TTL for testing
-	shortTTL := 100 * time.Millisecond
-	manager := NewManager(shortTTL)
+	clock := newFakeClock()
+	manager := NewManager(time.Hour, WithClock(clock.Now), WithCleanupInterval(time.Hour))
 	defer manager.Close()
 
 	// Create a session
-	state, id, err := manager.GetOrCreate("")
+	_, id, err := manager.GetOrCreate("")
 	if err != nil {
 		t.Fatalf("GetOrCreate failed: %v", err)
 	}
@@ -147,15 +176,19 @@ func TestCleanup(t *testing.T) {
 		t.Fatal("Session should exist")
 	}
 
-	// Set LastUsed to past
-	manager.mu.Lock()
-	state.LastUsed = time.Now().Add(-2 * shortTTL)
-	manager.mu.Unlock()
-
-	// Run cleanup
-	manager.cleanup()
+	// Not yet expired
+	clock.Advance(30 * time.Minute)
+	manager.Sweep()
+	if manager.GetStats().ActiveSessions != 1 {
+		t.Fatal("Session should survive until its TTL")
+	}
 
-	// Verify it's gone
+	// A sweep removes it once the TTL has passed
+	clock.Advance(2 * time.Hour)
+	manager.Sweep()
+	if manager.GetStats().ActiveSessions != 0 {
+		t.Fatal("Expired session should be cleaned up")
+	}
 	if manager.Get(id) != nil {
 		t.Error("Expired session should be cleaned up")
 	}
@@ -202,6 +235,316 @@ func TestGetStats(t *testing.T) {
 	}
 }
 
+func TestStates(t *testing.T) {
+	manager := NewManager(DefaultSessionTTL)
+	defer manager.Close()
+
+	if states := manager.States(); len(states) != 0 {
+		t.Errorf("Expected no sessions, got %d", len(states))
+	}
+
+	state, id, err := manager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("GetOrCreate failed: %v", err)
+	}
+
+	states := manager.States()
+	if len(states) != 1 || states[0] != state {
+		t.Errorf("Expected the created session, got %v", states)
+	}
+
+	manager.Delete(id)
+	if states := manager.States(); len(states) != 0 {
+		t.Errorf("Expected no sessions after delete, got %d", len(states))
+	}
+}
+
+func testDescriptorSet() *descriptorpb.FileDescriptorSet {
+	return &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("test.proto"),
+			Package: proto.String("test.v1"),
+			Syntax:  proto.String("proto3"),
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("Empty"),
+			}},
+			Service: []*descriptorpb.ServiceDescriptorProto{{
+				Name: proto.String("TestService"),
+				Method: []*descriptorpb.MethodDescriptorProto{{
+					Name:       proto.String("Test"),
+					InputType:  proto.String(".test.v1.Empty"),
+					OutputType: proto.String(".test.v1.Empty"),
+				}},
+			}},
+		}},
+	}
+}
+
+func TestCompact(t *testing.T) {
+	manager := NewManager(DefaultSessionTTL)
+	defer manager.Close()
+
+	state, id, err := manager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("GetOrCreate failed: %v", err)
+	}
+	if err := state.Registry.Register(testDescriptorSet()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	if _, err := manager.Compact(id); !errors.Is(err, ErrCompactionDisabled) {
+		t.Fatalf("Expected ErrCompactionDisabled, got %v", err)
+	}
+
+	dir := t.TempDir()
+	manager.SetCompaction(dir, 0)
+
+	if _, err := manager.Compact("missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+
+	spilled, err := manager.Compact(id)
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if spilled == 0 {
+		t.Error("Expected spilled bytes")
+	}
+	if !state.Compacted() || state.Registry.HasService("test.v1.TestService") {
+		t.Error("Expected registry to be dropped from memory")
+	}
+	if stats := manager.GetStats(); stats.CompactedSessions != 1 {
+		t.Errorf("Expected 1 compacted session, got %d", stats.CompactedSessions)
+	}
+
+	// Compacting again is a no-op
+	if spilled, err := manager.Compact(id); err != nil || spilled != 0 {
+		t.Errorf("Expected no-op, got %d, %v", spilled, err)
+	}
+
+	restored, _, err := manager.GetOrCreate(id)
+	if err != nil {
+		t.Fatalf("GetOrCreate failed: %v", err)
+	}
+	if restored.Compacted() || !restored.Registry.HasService("test.v1.TestService") {
+		t.Error("Expected registry to be restored on access")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("Expected compaction file to be removed, got %v", files)
+	}
+}
+
+func TestCompactInUse(t *testing.T) {
+	clock := newFakeClock()
+	manager := NewManager(DefaultSessionTTL, WithClock(clock.Now))
+	defer manager.Close()
+	manager.SetCompaction(t.TempDir(), time.Minute)
+
+	state, id, err := manager.Acquire("")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	if err := state.Registry.Register(testDescriptorSet()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	// A request is still being served from the session
+	if _, err := manager.Compact(id); !errors.Is(err, ErrSessionBusy) {
+		t.Fatalf("Expected ErrSessionBusy, got %v", err)
+	}
+	clock.Advance(2 * time.Minute)
+	manager.compactIdle()
+	if state.Compacted() || !state.Registry.HasService("test.v1.TestService") {
+		t.Fatal("Expected a session in use to stay resident")
+	}
+
+	state.Release()
+	manager.compactIdle()
+	if !state.Compacted() {
+		t.Fatal("Expected the released session to be compacted")
+	}
+
+	// Acquiring restores it before the request reads the registry
+	restored, _, err := manager.Acquire(id)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer restored.Release()
+	if restored.Compacted() || !restored.Registry.HasService("test.v1.TestService") {
+		t.Error("Expected the registry to be restored on Acquire")
+	}
+}
+
+func TestCompactIdle(t *testing.T) {
+	clock := newFakeClock()
+	manager := NewManager(DefaultSessionTTL, WithClock(clock.Now))
+	defer manager.Close()
+
+	dir := t.TempDir()
+	manager.SetCompaction(dir, time.Minute)
+
+	idle, idleID, _ := manager.GetOrCreate("")
+	clock.Advance(2 * time.Minute)
+	active, _, _ := manager.GetOrCreate("")
+	for _, state := range []*State{idle, active} {
+		if err := state.Registry.Register(testDescriptorSet()); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	manager.compactIdle()
+
+	if !idle.Compacted() {
+		t.Error("Expected idle session to be compacted")
+	}
+	if active.Compacted() {
+		t.Error("Expected active session to stay resident")
+	}
+
+	// Deleting a compacted session removes its file
+	manager.Delete(idleID)
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("Expected empty compaction dir, got %v, %v", entries, err)
+	}
+}
+
+func TestEndpointDefaults(t *testing.T) {
+	manager := NewManager(DefaultSessionTTL)
+	defer manager.Close()
+
+	state, id, err := manager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("GetOrCreate failed: %v", err)
+	}
+
+	md := map[string]string{"authorization": "Bearer token"}
+	state.SetEndpointDefaults("API.example.com:443", md)
+	md["authorization"] = "changed"
+
+	got := state.EndpointDefaults("api.example.com:443")
+	if got["authorization"] != "Bearer token" {
+		t.Errorf("Expected stored defaults, got %v", got)
+	}
+	if state.EndpointDefaults("other:443") != nil {
+		t.Error("Expected no defaults for another endpoint")
+	}
+
+	manager.Delete(id)
+	if state.EndpointDefaults("api.example.com:443") != nil {
+		t.Error("Expected defaults to be cleared with the session")
+	}
+}
+
+func TestServiceOrigins(t *testing.T) {
+	manager := NewManager(DefaultSessionTTL)
+	defer manager.Close()
+
+	state, id, err := manager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("GetOrCreate failed: %v", err)
+	}
+
+	state.AddServiceOrigins(map[string][]string{"test.v1.TestService": {"a:443"}})
+	state.AddServiceOrigins(map[string][]string{"test.v1.TestService": {"b:443", "a:443"}})
+
+	got := state.ServiceOrigins("test.v1.TestService")
+	if len(got) != 2 || got[0] != "a:443" || got[1] != "b:443" {
+		t.Errorf("Expected origins [a:443 b:443], got %v", got)
+	}
+	if state.ServiceOrigins("other.v1.Service") != nil {
+		t.Error("Expected no origins for an unknown service")
+	}
+
+	state.RemoveServiceOrigins([]string{"test.v1.TestService"}, []string{"a:443"})
+	if got := state.ServiceOrigins("test.v1.TestService"); len(got) != 1 || got[0] != "b:443" {
+		t.Errorf("Expected origins [b:443], got %v", got)
+	}
+	state.DeleteServiceOrigins("test.v1.TestService")
+	if state.ServiceOrigins("test.v1.TestService") != nil {
+		t.Error("Expected deleted origins to be gone")
+	}
+
+	state.AddServiceOrigins(map[string][]string{"test.v1.TestService": {"a:443"}})
+	manager.Delete(id)
+	if state.ServiceOrigins("test.v1.TestService") != nil {
+		t.Error("Expected origins to be cleared with the session")
+	}
+}
+
+func TestMethodStats(t *testing.T) {
+	manager := NewManager(DefaultSessionTTL)
+	defer manager.Close()
+
+	state, id, err := manager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("GetOrCreate failed: %v", err)
+	}
+
+	state.RecordInvocation("/test.v1.TestService/TestMethod", true, 0, "")
+	state.RecordInvocation("/test.v1.TestService/TestMethod", false, 14, "unavailable")
+
+	stats := state.MethodStats()["/test.v1.TestService/TestMethod"]
+	if stats.Invocations != 2 || stats.Failures != 1 {
+		t.Errorf("Expected 2 invocations and 1 failure, got %+v", stats)
+	}
+	if stats.LastSuccess || stats.LastStatusCode != 14 || stats.LastError != "unavailable" {
+		t.Errorf("Expected the failure as the latest outcome, got %+v", stats)
+	}
+	if stats.LastInvokedAt.IsZero() {
+		t.Error("Expected the latest invocation time")
+	}
+
+	manager.Delete(id)
+	if len(state.MethodStats()) != 0 {
+		t.Error("Expected stats to be cleared with the session")
+	}
+}
+
+func TestSharedSession(t *testing.T) {
+	clock := newFakeClock()
+	manager := NewManager(DefaultSessionTTL, WithClock(clock.Now))
+	defer manager.Close()
+	manager.SetCompaction(t.TempDir(), time.Minute)
+
+	shared := manager.CreateShared("shared")
+	if !shared.Shared() || shared.ReadOnly() {
+		t.Fatalf("Expected a writable shared session, got shared=%v read-only=%v", shared.Shared(), shared.ReadOnly())
+	}
+	if err := shared.Registry.Register(testDescriptorSet()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	shared.SetReadOnly(true)
+
+	// Clients without a session ID attach to it
+	state, id, err := manager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("GetOrCreate failed: %v", err)
+	}
+	if state != shared || id != "shared" || !state.ReadOnly() {
+		t.Errorf("Expected the shared session, got %q", id)
+	}
+
+	// Others still get their own
+	other, otherID, _ := manager.GetOrCreate("unknown")
+	if other == shared || otherID == "shared" {
+		t.Error("Expected an unknown session ID to get a new session")
+	}
+
+	// It outlives the TTL and is never compacted
+	clock.Advance(2 * DefaultSessionTTL)
+	manager.Sweep()
+	if manager.Get("shared") != shared || shared.Compacted() {
+		t.Error("Expected the shared session to stay resident")
+	}
+	if _, err := manager.Compact("shared"); !errors.Is(err, ErrSessionShared) {
+		t.Errorf("Expected ErrSessionShared from an explicit compaction, got %v", err)
+	}
+
+	if again := manager.CreateShared("shared"); again != shared {
+		t.Error("Expected CreateShared to return the existing session")
+	}
+}
+
 func TestClose(t *testing.T) {
 	manager := NewManager(DefaultSessionTTL)
 
@@ -224,6 +567,27 @@ func TestClose(t *testing.T) {
 	}
 }
 
+func TestNewManagerWithInvokerFactory(t *testing.T) {
+	calls := 0
+	manager := NewManagerWithInvokerFactory(time.Hour, func() *invoker.Invoker {
+		calls++
+		return invoker.NewWithLimits(3, time.Minute)
+	})
+	defer manager.Close()
+
+	state, _, err := manager.GetOrCreate("")
+	if err != nil {
+		t.Fatalf("GetOrCreate failed: %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected factory to be called once, got %d", calls)
+	}
+	if stats := state.Invoker.GetConnectionStats(); stats.MaxConnections != 3 {
+		t.Errorf("Expected MaxConnections 3, got %d", stats.MaxConnections)
+	}
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	manager := NewManager(DefaultSessionTTL)
 	defer manager.Close()
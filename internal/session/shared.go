@@ -0,0 +1,50 @@
+package session
+
+import "github.com/opentdf/connectrpc-catalog/internal/registry"
+
+// CreateShared creates the session with the given ID, or returns it if it
+// already exists, and makes it the shared session: it never expires or
+// compacts, and GetOrCreate returns it to clients that send no session ID.
+func (m *Manager) CreateShared(sessionID string) *State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, exists := m.sessions[sessionID]
+	if !exists {
+		now := m.now()
+		state = &State{
+			Registry:  registry.New(),
+			Invoker:   m.newInvoker(),
+			CreatedAt: now,
+			LastUsed:  now,
+		}
+		m.sessions[sessionID] = state
+	}
+	state.shared.Store(true)
+	m.sharedID = sessionID
+	return state
+}
+
+// SharedID returns the ID of the shared session, or "" if there is none
+func (m *Manager) SharedID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sharedID
+}
+
+// Shared reports whether this is the manager's shared session
+func (s *State) Shared() bool {
+	return s.shared.Load()
+}
+
+// SetReadOnly marks the session as read-only, or writable again. The session
+// itself doesn't enforce it; handlers that change what a session holds
+// check ReadOnly first.
+func (s *State) SetReadOnly(readOnly bool) {
+	s.readOnly.Store(readOnly)
+}
+
+// ReadOnly reports whether clients may change what the session holds
+func (s *State) ReadOnly() bool {
+	return s.readOnly.Load()
+}
@@ -31,12 +31,25 @@ const sessionInterceptor: Interceptor = (next) => async (req) => {
   return res;
 };
 
+// CSRF token the server embeds in the page when run with -csrf. The matching
+// cookie is sent automatically; the header proves the call came from this page.
+const csrfToken = document
+  .querySelector<HTMLMetaElement>('meta[name="csrf-token"]')
+  ?.getAttribute('content');
+
+const csrfInterceptor: Interceptor = (next) => async (req) => {
+  if (csrfToken) {
+    req.header.set('X-CSRF-Token', csrfToken);
+  }
+  return next(req);
+};
+
 // Get base URL from environment variable or default to localhost
 const baseUrl = import.meta.env.VITE_API_BASE_URL || 'http://localhost:8080';
 
 const transport = createConnectTransport({
   baseUrl,
-  interceptors: [sessionInterceptor],
+  interceptors: [sessionInterceptor, csrfInterceptor],
 });
 
 export const catalogClient = createPromiseClient(CatalogService, transport);